@@ -0,0 +1,169 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// GetOrSet 与 sync.Map.LoadOrStore 类似：key 存在且未过期时返回当前的值，loaded 为 true；
+// 否则写入 value 并返回 value，loaded 为 false。读取和写入是原子的。
+func (c *Cache[K, V]) GetOrSet(ctx context.Context, key K, value V, opts ...ItemOption) (actual V, loaded bool, err error) {
+	defer c.opEnd(ctx, "getorset", key, c.opStart())
+	defer c.wrapKeyError("getorset", key, &err)
+	_, err = c.compute(ctx, key, func(cur *Item[V]) *Item[V] {
+		if cur != nil {
+			actual, loaded = cur.value, true
+			c.recordHit(cur)
+			return nil
+		}
+		actual = value
+		return c.newItem(value, opts...)
+	})
+	if err != nil {
+		var zero V
+		return zero, false, err
+	}
+	if loaded {
+		c.stats.hits.Add(1)
+		actual = c.clone(actual)
+	} else {
+		c.stats.misses.Add(1)
+	}
+	return actual, loaded, nil
+}
+
+// CompareAndSwapFunc 在 key 存在、未过期且当前值与 old 相等（由 equal 判断）时将其替换为 new，返回是否替换。
+// 替换只修改值，元素的过期时间和附加信息保持不变。V 是可比较类型时可以使用 CompareAndSwap。
+func (c *Cache[K, V]) CompareAndSwapFunc(ctx context.Context, key K, old, new V, equal func(a, b V) bool) (bool, error) {
+	defer c.opEnd(ctx, "cas", key, c.opStart())
+	return c.compute(ctx, key, func(cur *Item[V]) *Item[V] {
+		if cur == nil || !equal(cur.value, old) {
+			return nil
+		}
+		return cur.withValue(new)
+	})
+}
+
+// CompareAndSwap 是使用 == 比较值的 CompareAndSwapFunc，可以在缓存之上实现乐观并发控制而不需要额外的锁。
+func CompareAndSwap[K comparable, V comparable](ctx context.Context, c *Cache[K, V], key K, old, new V) (bool, error) {
+	return c.CompareAndSwapFunc(ctx, key, old, new, func(a, b V) bool { return a == b })
+}
+
+// Incr 原子地将 key 的值加上 delta 并返回新值，适用于计数和限流。key 不存在或已过期时以 0 为初始值，
+// 新元素使用 opts 创建；key 已经存在时只修改值，过期时间保持不变，opts 不生效。
+func Incr[K comparable, V types.Number](ctx context.Context, c *Cache[K, V], key K, delta V, opts ...ItemOption) (V, error) {
+	return add(ctx, c, key, func(v V) V { return v + delta }, opts)
+}
+
+// Decr 原子地将 key 的值减去 delta 并返回新值，语义与 Incr 相同。无符号类型的结果小于 0 时按 Go 的规则回绕。
+func Decr[K comparable, V types.Number](ctx context.Context, c *Cache[K, V], key K, delta V, opts ...ItemOption) (V, error) {
+	return add(ctx, c, key, func(v V) V { return v - delta }, opts)
+}
+
+func add[K comparable, V types.Number](ctx context.Context, c *Cache[K, V], key K, op func(V) V, opts []ItemOption) (result V, err error) {
+	defer c.opEnd(ctx, "incr", key, c.opStart())
+	defer c.wrapKeyError("incr", key, &err)
+	_, err = c.compute(ctx, key, func(cur *Item[V]) *Item[V] {
+		if cur == nil {
+			result = op(0)
+			return c.newItem(result, opts...)
+		}
+		result = op(cur.value)
+		return cur.withValue(result)
+	})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return result, nil
+}
+
+// compute 原子地读取 key 当前未过期的元素（不存在时为 nil）并以它调用 fn，fn 返回要写入的元素，返回 nil 时不写入。
+// 写穿模式下先将新值写入 store，store 写入失败时缓存不会被修改。fn 在持有锁时被调用，不能调用 Cache 的方法。
+func (c *Cache[K, V]) compute(ctx context.Context, key K, fn func(cur *Item[V]) *Item[V]) (written bool, err error) {
+	if c.writeThrough() {
+		// storeMu 保证读取到写入之间没有其他写操作，期间的加载结果与 store 一致，不会改变 fn 的判断
+		c.storeMu.Lock()
+		defer c.storeMu.Unlock()
+		if err := c.checkClosed(); err != nil {
+			return false, err
+		}
+		c.readLock()
+		cur, err := c.current(ctx, key)
+		var next *Item[V]
+		if err == nil {
+			next = fn(cur)
+		}
+		c.readUnlock()
+		if err != nil || next == nil {
+			return false, err
+		}
+		if err = c.opts.store.Save(ctx, key, next.value); err != nil {
+			return false, err
+		}
+		c.mutex.Lock()
+		defer c.unlock()
+		return true, c.setItem(ctx, key, next)
+	}
+
+	c.mutex.Lock()
+	defer c.unlock()
+	if err := c.checkClosed(); err != nil {
+		return false, err
+	}
+	cur, err := c.current(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	next := fn(cur)
+	if next == nil {
+		return false, nil
+	}
+	return true, c.setItem(ctx, key, next)
+}
+
+// current 返回 key 当前未过期的元素，不存在或已过期时返回 nil，调用方需要持有锁。
+func (c *Cache[K, V]) current(ctx context.Context, key K) (*Item[V], error) {
+	item, err := c.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, cacheError.ErrNoKey) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if item.Expired() {
+		return nil, nil
+	}
+	return item, nil
+}
+
+// setItem 写入 item 并维护统计信息、进行中的加载和回写队列，调用方需要持有写锁。
+func (c *Cache[K, V]) setItem(ctx context.Context, key K, item *Item[V]) error {
+	if err := c.cache.Set(ctx, key, item); err != nil {
+		return err
+	}
+	c.track(key, item)
+	c.stats.sets.Add(1)
+	c.invalidate(key)
+	if c.wb != nil {
+		c.wb.add(key, pendingWrite[V]{value: item.value, ttl: item.ttl})
+	}
+	return nil
+}
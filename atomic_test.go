@@ -0,0 +1,353 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetOrSet(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) (*Cache[int, int], *memStore)
+		value int
+
+		wantActual int
+		wantLoaded bool
+		wantErr    error
+		wantStore  map[int]int
+	}{
+		{
+			name: "missing key",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				return NewSimpleCache[int, int](ctx, 0, time.Minute), nil
+			},
+			value:      2,
+			wantActual: 2,
+		},
+		{
+			name: "existing key",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 1))
+				return c, nil
+			},
+			value:      2,
+			wantActual: 1,
+			wantLoaded: true,
+		},
+		{
+			name: "expired key is replaced",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 1, WithExpiration(-time.Minute)))
+				return c, nil
+			},
+			value:      2,
+			wantActual: 2,
+		},
+		{
+			name: "write-through saves the new value",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				s := newMemStore()
+				return NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough)), s
+			},
+			value:      2,
+			wantActual: 2,
+			wantStore:  map[int]int{1: 2},
+		},
+		{
+			name: "write-through store error",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				s := newMemStore()
+				s.setFail(true)
+				return NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough)), s
+			},
+			value:     2,
+			wantErr:   errStore,
+			wantStore: map[int]int{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, s := tc.cache(t)
+			actual, loaded, err := c.GetOrSet(ctx, 1, tc.value)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantActual, actual)
+			assert.Equal(t, tc.wantLoaded, loaded)
+			if s != nil {
+				data, _ := s.snapshot()
+				assert.Equal(t, tc.wantStore, data)
+			}
+			if err == nil {
+				got, err := c.Get(ctx, 1)
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantActual, got)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrSet_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 10, time.Minute)
+	var (
+		wg     sync.WaitGroup
+		stored atomic.Int32
+	)
+	actuals := make([]int, 50)
+	for i := range actuals {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			actual, loaded, err := c.GetOrSet(ctx, "k", i)
+			assert.NoError(t, err)
+			if !loaded {
+				stored.Add(1)
+			}
+			actuals[i] = actual
+		}(i)
+	}
+	wg.Wait()
+	// 只有一个协程写入成功，其他协程都得到它写入的值
+	assert.Equal(t, int32(1), stored.Load())
+	for _, actual := range actuals {
+		assert.Equal(t, actuals[0], actual)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) (*Cache[int, int], *memStore)
+		old   int
+		new   int
+
+		wantSwapped bool
+		wantErr     error
+		wantValue   int
+		wantStore   map[int]int
+	}{
+		{
+			name: "missing key",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				return NewSimpleCache[int, int](ctx, 0, time.Minute), nil
+			},
+			old: 0,
+			new: 1,
+		},
+		{
+			name: "expired key",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 1, WithExpiration(-time.Minute)))
+				return c, nil
+			},
+			old: 1,
+			new: 2,
+		},
+		{
+			name: "value changed",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 3))
+				return c, nil
+			},
+			old:       1,
+			new:       2,
+			wantValue: 3,
+		},
+		{
+			name: "swapped",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 1))
+				return c, nil
+			},
+			old:         1,
+			new:         2,
+			wantSwapped: true,
+			wantValue:   2,
+		},
+		{
+			name: "write-through store error",
+			cache: func(t *testing.T) (*Cache[int, int], *memStore) {
+				s := newMemStore()
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough))
+				require.NoError(t, c.Set(ctx, 1, 1))
+				s.setFail(true)
+				return c, s
+			},
+			old:       1,
+			new:       2,
+			wantErr:   errStore,
+			wantValue: 1,
+			wantStore: map[int]int{1: 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, s := tc.cache(t)
+			swapped, err := CompareAndSwap(ctx, c, 1, tc.old, tc.new)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantSwapped, swapped)
+			values, err := c.MGet(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantValue, values[1])
+			if s != nil {
+				data, _ := s.snapshot()
+				assert.Equal(t, tc.wantStore, data)
+			}
+		})
+	}
+}
+
+func TestCache_CompareAndSwapFunc(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, []int](ctx, 0, time.Minute)
+	require.NoError(t, c.Set(ctx, "k", []int{1}, WithExpiration(time.Minute), WithMeta(map[string]string{"a": "b"})))
+	before, err := c.EntryInfo(ctx, "k")
+	require.NoError(t, err)
+
+	equal := func(a, b []int) bool { return len(a) == len(b) && (len(a) == 0 || a[0] == b[0]) }
+	swapped, err := c.CompareAndSwapFunc(ctx, "k", []int{1}, []int{2}, equal)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	got, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, got)
+	// 过期时间和附加信息保持不变
+	after, err := c.EntryInfo(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestCompareAndSwap_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 10, time.Minute)
+	require.NoError(t, c.Set(ctx, "counter", 0))
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// 乐观并发：读取后尝试替换，失败则重试
+				for {
+					v, err := c.Get(ctx, "counter")
+					if !assert.NoError(t, err) {
+						return
+					}
+					swapped, err := CompareAndSwap(ctx, c, "counter", v, v+1)
+					if !assert.NoError(t, err) || swapped {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	got, err := c.Get(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, 800, got)
+}
+
+func TestIncr(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int64](ctx, 0, time.Minute)
+
+	// 不存在的键以 0 为初始值，并使用 opts 创建
+	got, err := Incr(ctx, c, "k", 5, WithExpiration(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), got)
+	before, err := c.EntryInfo(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, before.Expiration.IsZero())
+
+	// 已经存在的键保留原有的过期时间
+	got, err = Incr(ctx, c, "k", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), got)
+	got, err = Decr(ctx, c, "k", 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-3), got)
+	after, err := c.EntryInfo(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// 过期的键重新从 0 开始
+	_, err = c.ExpireMulti(ctx, []string{"k"}, -time.Second)
+	require.NoError(t, err)
+	got, err = Incr(ctx, c, "k", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+
+	f := NewSimpleCache[string, float64](ctx, 0, time.Minute)
+	fv, err := Incr(ctx, f, "k", 0.5)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, fv)
+
+	u := NewSimpleCache[string, uint8](ctx, 0, time.Minute)
+	uv, err := Decr(ctx, u, "k", 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(255), uv)
+}
+
+func TestIncr_writeThroughError(t *testing.T) {
+	ctx := context.Background()
+	s := newMemStore()
+	c := NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough))
+	got, err := Incr(ctx, c, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+
+	s.setFail(true)
+	_, err = Incr(ctx, c, 1, 1)
+	assert.Equal(t, errStore, err)
+	data, _ := s.snapshot()
+	assert.Equal(t, map[int]int{1: 1}, data)
+	got, err = c.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+}
+
+func TestIncr_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 10, time.Minute)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, err := Incr(ctx, c, "counter", 1)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	got, err := c.Get(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, 800, got)
+}
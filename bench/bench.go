@@ -0,0 +1,147 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench 提供可以对任意 types.ICache 运行的标准基准测试和工作负载回放，
+// 用于比较不同淘汰策略的命中率和开销。
+package bench
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// DefaultCapacity 为 Run 创建缓存时默认使用的容量。
+const DefaultCapacity = 1000
+
+// Factory 创建一个容量为 capacity 的空缓存。
+type Factory func(capacity int) types.ICache[uint64, uint64]
+
+// Result 是一次模拟的结果。
+type Result struct {
+	// Accesses 为访问的次数
+	Accesses uint64
+	// Hits 为命中的次数
+	Hits uint64
+}
+
+// HitRatio 返回命中率，没有任何访问时返回 0。
+func (r Result) HitRatio() float64 {
+	if r.Accesses == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Accesses)
+}
+
+// Simulate 按 w 生成的顺序访问 c 中的 n 个键，未命中时写入该键，与读穿透的缓存相同。
+// 相同的 seed 得到相同的访问序列，缓存已满且拒绝写入（cacheError.ErrCacheFull）不算作错误。
+func Simulate(ctx context.Context, c types.ICache[uint64, uint64], w Workload, n int, seed int64) (Result, error) {
+	next := w.keys(seed)
+	var r Result
+	for i := 0; i < n; i++ {
+		hit, err := access(ctx, c, next())
+		if err != nil {
+			return r, err
+		}
+		r.Accesses++
+		if hit {
+			r.Hits++
+		}
+	}
+	return r, nil
+}
+
+func access(ctx context.Context, c types.ICache[uint64, uint64], key uint64) (hit bool, err error) {
+	if _, err := c.Get(ctx, key); err == nil {
+		return true, nil
+	} else if !errors.Is(err, cacheError.ErrNoKey) {
+		return false, err
+	}
+	if err := c.Set(ctx, key, key); err != nil && !errors.Is(err, cacheError.ErrCacheFull) {
+		return false, err
+	}
+	return false, nil
+}
+
+// Option 配置 Run。
+type Option func(*config)
+
+type config struct {
+	capacity  int
+	seed      int64
+	workloads []Workload
+}
+
+// WithCapacity 设置缓存的容量，默认为 DefaultCapacity，Standard 工作负载的键空间随容量缩放。
+func WithCapacity(n int) Option {
+	return func(c *config) {
+		c.capacity = n
+	}
+}
+
+// WithSeed 设置生成访问序列的随机种子，默认为 1。
+func WithSeed(seed int64) Option {
+	return func(c *config) {
+		c.seed = seed
+	}
+}
+
+// WithWorkloads 替换默认的 Standard 工作负载，例如使用 Trace 回放线上采集的访问序列。
+func WithWorkloads(workloads ...Workload) Option {
+	return func(c *config) {
+		c.workloads = workloads
+	}
+}
+
+// Run 对 factory 创建的缓存依次运行每个工作负载，每个工作负载是一个子基准测试。
+// 每次迭代是一次读穿透的访问，除了耗时以外还以 hit% 报告命中率。例如：
+//
+//	func BenchmarkLRU(b *testing.B) {
+//		bench.Run(b, func(n int) types.ICache[uint64, uint64] { return lru.NewCache[uint64, uint64](n) })
+//	}
+//
+// 内置的后端不是并发安全的，Run 只在单个协程中访问缓存。
+func Run(b *testing.B, factory Factory, opts ...Option) {
+	cfg := config{capacity: DefaultCapacity, seed: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	workloads := cfg.workloads
+	if workloads == nil {
+		workloads = Standard(cfg.capacity)
+	}
+	ctx := context.Background()
+	for _, w := range workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			c := factory(cfg.capacity)
+			next := w.keys(cfg.seed)
+			b.ReportAllocs()
+			b.ResetTimer()
+			var hits int
+			for i := 0; i < b.N; i++ {
+				hit, err := access(ctx, c, next())
+				if err != nil {
+					b.Fatal(err)
+				}
+				if hit {
+					hits++
+				}
+			}
+			b.ReportMetric(float64(hits)/float64(b.N)*100, "hit%")
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chenmingyong0423/go-generics-cache/fifo"
+	"github.com/chenmingyong0423/go-generics-cache/lru"
+	"github.com/chenmingyong0423/go-generics-cache/random"
+	"github.com/chenmingyong0423/go-generics-cache/slru"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var backends = []struct {
+	name    string
+	factory Factory
+}{
+	{"lru", func(n int) types.ICache[uint64, uint64] { return lru.NewCache[uint64, uint64](n) }},
+	{"fifo", func(n int) types.ICache[uint64, uint64] { return fifo.NewCache[uint64, uint64](n) }},
+	{"slru", func(n int) types.ICache[uint64, uint64] {
+		return slru.NewCache[uint64, uint64](n, slru.DefaultProtectedRatio)
+	}},
+	{"random", func(n int) types.ICache[uint64, uint64] { return random.NewCache[uint64, uint64](n) }},
+}
+
+func backend(name string) Factory {
+	for _, b := range backends {
+		if b.name == name {
+			return b.factory
+		}
+	}
+	panic("unknown backend " + name)
+}
+
+func BenchmarkBackends(b *testing.B) {
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			Run(b, backend.factory)
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	ctx := context.Background()
+	const capacity, n = 100, 20000
+	testCases := []struct {
+		name     string
+		backend  string
+		workload Workload
+
+		wantMin float64
+		wantMax float64
+	}{
+		{
+			name:     "scan never hits",
+			backend:  "lru",
+			workload: Scan(),
+			wantMax:  0,
+		},
+		{
+			name:     "loop defeats lru",
+			backend:  "lru",
+			workload: Loop(150),
+			wantMax:  0,
+		},
+		{
+			name:     "loop defeats fifo",
+			backend:  "fifo",
+			workload: Loop(150),
+			wantMax:  0,
+		},
+		{
+			name:     "loop fits in cache",
+			backend:  "lru",
+			workload: Loop(100),
+			wantMin:  0.99,
+			wantMax:  1,
+		},
+		{
+			name:     "zipf hot keys hit",
+			backend:  "lru",
+			workload: Zipf(1.1, 1000),
+			wantMin:  0.5,
+			wantMax:  1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Simulate(ctx, backend(tc.backend)(capacity), tc.workload, n, 1)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(n), r.Accesses)
+			assert.GreaterOrEqual(t, r.HitRatio(), tc.wantMin)
+			assert.LessOrEqual(t, r.HitRatio(), tc.wantMax)
+		})
+	}
+}
+
+func TestSimulate_scanResistance(t *testing.T) {
+	// SLRU 的受保护段不会被扫描冲掉，混入扫描时命中率应当高于 LRU
+	ctx := context.Background()
+	w := Mix("zipf+scan", Zipf(1.1, 1000), Scan(), 0.5)
+	lruResult, err := Simulate(ctx, backend("lru")(100), w, 50000, 1)
+	require.NoError(t, err)
+	slruResult, err := Simulate(ctx, backend("slru")(100), w, 50000, 1)
+	require.NoError(t, err)
+	assert.Greater(t, slruResult.HitRatio(), lruResult.HitRatio())
+}
+
+func TestWorkload_deterministic(t *testing.T) {
+	for _, w := range Standard(10) {
+		t.Run(w.Name, func(t *testing.T) {
+			a, b := w.keys(42), w.keys(42)
+			for i := 0; i < 100; i++ {
+				require.Equal(t, a(), b(), "access %d", i)
+			}
+		})
+	}
+}
+
+func TestReadTrace(t *testing.T) {
+	keys, err := ReadTrace(strings.NewReader("# header\n1\n\n user:1 \n2\nuser:1\n"))
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+	assert.Equal(t, uint64(1), keys[0])
+	assert.Equal(t, uint64(2), keys[2])
+	assert.Equal(t, keys[1], keys[3])
+
+	next := Trace("trace", keys).keys(1)
+	var got []uint64
+	for i := 0; i < 6; i++ {
+		got = append(got, next())
+	}
+	assert.Equal(t, append(keys, keys[:2]...), got)
+}
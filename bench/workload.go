@@ -0,0 +1,147 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/chenmingyong0423/go-generics-cache/internal/keyhash"
+)
+
+// Workload 描述一种访问模式。
+type Workload struct {
+	// Name 为子基准测试的名称
+	Name string
+	// New 使用 r 创建一个无限的键序列，相同的随机源得到相同的序列
+	New func(r *rand.Rand) func() uint64
+}
+
+func (w Workload) keys(seed int64) func() uint64 {
+	return w.New(rand.New(rand.NewSource(seed)))
+}
+
+// Standard 返回针对容量为 capacity 的缓存的标准工作负载：
+//   - zipf：键空间为容量的 10 倍、s 为 1.1 的 Zipf 分布，少数热点键占据大部分访问
+//   - scan：键从不重复，任何策略的命中率都为 0，用于衡量未命中路径和淘汰的开销
+//   - loop：循环访问 1.5 倍容量的键，LRU 和 FIFO 的命中率为 0，是它们最坏的情况
+//   - zipf+scan：zipf 中混入 20% 的 scan，用于衡量淘汰策略抵抗扫描的能力
+func Standard(capacity int) []Workload {
+	keys := uint64(max(capacity, 1))
+	zipf := Zipf(1.1, keys*10)
+	return []Workload{
+		zipf,
+		Scan(),
+		Loop(keys + keys/2),
+		Mix("zipf+scan", zipf, Scan(), 0.8),
+	}
+}
+
+// Zipf 返回键服从 Zipf 分布的工作负载，键的取值范围为 [0, keys)，0 最热。s 必须大于 1，越大越集中。
+func Zipf(s float64, keys uint64) Workload {
+	return Workload{
+		Name: "zipf",
+		New: func(r *rand.Rand) func() uint64 {
+			return rand.NewZipf(r, s, 1, max(keys, 1)-1).Uint64
+		},
+	}
+}
+
+// Scan 返回访问递增的键的工作负载，每个键只被访问一次。键从 1<<63 开始，与其他工作负载混合时不会重叠。
+func Scan() Workload {
+	return Workload{
+		Name: "scan",
+		New: func(*rand.Rand) func() uint64 {
+			next := uint64(1) << 63
+			return func() uint64 {
+				next++
+				return next - 1
+			}
+		},
+	}
+}
+
+// Loop 返回循环访问 0, 1, ..., keys-1 的工作负载。
+func Loop(keys uint64) Workload {
+	keys = max(keys, 1)
+	return Workload{
+		Name: "loop",
+		New: func(*rand.Rand) func() uint64 {
+			var i uint64
+			return func() uint64 {
+				k := i % keys
+				i++
+				return k
+			}
+		},
+	}
+}
+
+// Mix 返回以概率 p 从 a 取键、否则从 b 取键的工作负载。
+func Mix(name string, a, b Workload, p float64) Workload {
+	return Workload{
+		Name: name,
+		New: func(r *rand.Rand) func() uint64 {
+			nextA, nextB := a.New(r), b.New(r)
+			return func() uint64 {
+				if r.Float64() < p {
+					return nextA()
+				}
+				return nextB()
+			}
+		},
+	}
+}
+
+// Trace 返回按顺序回放 keys 的工作负载，回放结束后从头开始。keys 不能为空。
+func Trace(name string, keys []uint64) Workload {
+	return Workload{
+		Name: name,
+		New: func(*rand.Rand) func() uint64 {
+			var i int
+			return func() uint64 {
+				k := keys[i%len(keys)]
+				i++
+				return k
+			}
+		},
+	}
+}
+
+// ReadTrace 读取每行一个键的访问记录，用于 Trace。十进制的无符号整数直接作为键，
+// 其他内容使用与进程无关的哈希转换为键，空行和以 # 开头的行会被忽略。
+func ReadTrace(r io.Reader) ([]uint64, error) {
+	var keys []uint64
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, err := strconv.ParseUint(line, 10, 64); err == nil {
+			keys = append(keys, k)
+			continue
+		}
+		keys = append(keys, keyhash.Key(line))
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("bench: read trace: %w", err)
+	}
+	return keys, nil
+}
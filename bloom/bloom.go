@@ -0,0 +1,129 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/chenmingyong0423/go-generics-cache/internal/keyhash"
+)
+
+var (
+	ErrInvalidRate = errors.New("bloom: false positive rate must be in (0, 1)")
+	ErrInvalidData = errors.New("bloom: invalid serialized filter")
+)
+
+// headerSize 序列化格式的头部长度：哈希函数个数 (uint32) + 位数组长度 (uint64)，均为大端序。
+const headerSize = 4 + 8
+
+// maxHashes 哈希函数个数的上限，误判率为 1e-9 时最优的个数约为 30，更大的值只会拖慢 Add 和 Test。
+// 反序列化的数据可能来自其他服务，超过上限的数据被视为无效。
+const maxHashes = 64
+
+// Filter 是一个布隆过滤器，Test 返回 false 时元素一定不存在，返回 true 时元素可能存在。
+type Filter struct {
+	k    uint32
+	m    uint64
+	bits []uint64
+}
+
+// New 根据预期元素个数 n 和期望误判率 fpRate 创建一个布隆过滤器。
+func New(n int, fpRate float64) (*Filter, error) {
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, ErrInvalidRate
+	}
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = min(max(k, 1), maxHashes)
+	return &Filter{
+		k:    k,
+		m:    m,
+		bits: make([]uint64, (m+63)/64),
+	}, nil
+}
+
+// Add 将 data 加入过滤器。
+func (f *Filter) Add(data []byte) {
+	h1, h2 := hash(data)
+	for i := uint32(0); i < f.k; i++ {
+		idx := (h1 + uint64(i)*h2) % f.m
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// Test 判断 data 是否可能存在于过滤器中。
+func (f *Filter) Test(data []byte) bool {
+	h1, h2 := hash(data)
+	for i := uint32(0); i < f.k; i++ {
+		idx := (h1 + uint64(i)*h2) % f.m
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary 将过滤器序列化，格式为头部加上大端序的位数组。
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	data := make([]byte, headerSize+8*len(f.bits))
+	binary.BigEndian.PutUint32(data, f.k)
+	binary.BigEndian.PutUint64(data[4:], f.m)
+	for i, word := range f.bits {
+		binary.BigEndian.PutUint64(data[headerSize+8*i:], word)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary 从 MarshalBinary 的输出中恢复过滤器。data 可能来自不可信的来源，
+// 头部与位数组的长度不一致或者哈希函数个数超过上限时返回 ErrInvalidData。
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < headerSize || (len(data)-headerSize)%8 != 0 {
+		return ErrInvalidData
+	}
+	k := binary.BigEndian.Uint32(data)
+	m := binary.BigEndian.Uint64(data[4:])
+	// 不使用 (m+63)/64，避免 m 接近 2^64 时溢出
+	words := m / 64
+	if m%64 != 0 {
+		words++
+	}
+	if k == 0 || k > maxHashes || m == 0 || words != uint64(len(data)-headerSize)/8 {
+		return ErrInvalidData
+	}
+	bits := make([]uint64, words)
+	for i := range bits {
+		bits[i] = binary.BigEndian.Uint64(data[headerSize+8*i:])
+	}
+	f.k, f.m, f.bits = k, m, bits
+	return nil
+}
+
+// Key 返回 key 在过滤器中使用的字节表示：字符串使用其原始字节，其他类型使用 fmt 的 %v 格式。
+// 生成过滤器和查询过滤器的双方必须使用相同的表示。
+func Key[K comparable](key K) []byte {
+	return keyhash.Bytes(key)
+}
+
+// hash 使用 FNV-1a 生成两个基础哈希值，第 i 个哈希函数为 h1 + i*h2（Kirsch-Mitzenmacher）。
+func hash(data []byte) (uint64, uint64) {
+	h1 := keyhash.Sum64(data)
+	h2 := h1>>33 | h1<<31
+	return h1, h2 | 1
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name    string
+		n       int
+		fpRate  float64
+		wantErr error
+	}{
+		{
+			name:   "valid rate",
+			n:      100,
+			fpRate: 0.01,
+		},
+		{
+			// 哈希函数个数不超过上限
+			name:   "tiny rate",
+			n:      100,
+			fpRate: 1e-30,
+		},
+		{
+			name:    "zero rate",
+			n:       100,
+			fpRate:  0,
+			wantErr: ErrInvalidRate,
+		},
+		{
+			name:    "rate of one",
+			n:       100,
+			fpRate:  1,
+			wantErr: ErrInvalidRate,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := New(tc.n, tc.fpRate)
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.NotZero(t, f.k)
+				assert.LessOrEqual(t, f.k, uint32(maxHashes))
+				assert.NotZero(t, f.m)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f, err := New(1000, 0.01)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		f.Add(Key(i))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.Test(Key(i)))
+	}
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if f.Test(Key(i)) {
+			falsePositives++
+		}
+	}
+	// 期望误判率为 1%，留出足够余量避免测试抖动
+	assert.Less(t, falsePositives, 300)
+}
+
+func TestFilter_MarshalBinary(t *testing.T) {
+	f, err := New(10, 0.01)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		f.Add([]byte(strconv.Itoa(i)))
+	}
+	data, err := f.MarshalBinary()
+	require.NoError(t, err)
+
+	var got Filter
+	require.NoError(t, got.UnmarshalBinary(data))
+	assert.Equal(t, f, &got)
+
+	assert.Equal(t, ErrInvalidData, got.UnmarshalBinary(data[:3]))
+	assert.Equal(t, ErrInvalidData, got.UnmarshalBinary(data[:len(data)-1]))
+}
+
+func TestFilter_UnmarshalBinary_invalid(t *testing.T) {
+	header := func(k uint32, m uint64, words int) []byte {
+		data := make([]byte, headerSize+8*words)
+		binary.BigEndian.PutUint32(data, k)
+		binary.BigEndian.PutUint64(data[4:], m)
+		return data
+	}
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "zero hashes", data: header(0, 64, 1)},
+		{name: "too many hashes", data: header(math.MaxUint32, 64, 1)},
+		{name: "zero bits", data: header(3, 0, 0)},
+		// (m+63)/64 溢出为 0
+		{name: "overflowing bits", data: header(3, math.MaxUint64, 0)},
+		{name: "bits longer than data", data: header(3, 129, 2)},
+		{name: "bits shorter than data", data: header(3, 64, 2)},
+		{name: "partial word", data: append(header(3, 64, 1), 0)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var f Filter
+			assert.Equal(t, ErrInvalidData, f.UnmarshalBinary(tc.data))
+			assert.Zero(t, f)
+		})
+	}
+}
+
+func FuzzFilter_UnmarshalBinary(f *testing.F) {
+	valid, err := New(10, 0.01)
+	require.NoError(f, err)
+	data, err := valid.MarshalBinary()
+	require.NoError(f, err)
+	f.Add(data)
+	f.Add([]byte{})
+	f.Add(make([]byte, headerSize))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var got Filter
+		if got.UnmarshalBinary(data) != nil {
+			return
+		}
+		// 成功恢复的过滤器可以安全地使用，并且可以原样序列化
+		got.Add([]byte("a"))
+		assert.True(t, got.Test([]byte("a")))
+		out, err := got.MarshalBinary()
+		require.NoError(t, err)
+		assert.Len(t, out, len(data))
+	})
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, []byte("a"), Key("a"))
+	assert.Equal(t, []byte("42"), Key(42))
+}
@@ -17,154 +17,1174 @@ package cache
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/chenmingyong0423/go-generics-cache/bloom"
+	"github.com/chenmingyong0423/go-generics-cache/fifo"
+	"github.com/chenmingyong0423/go-generics-cache/generational"
 	"github.com/chenmingyong0423/go-generics-cache/lru"
+	"github.com/chenmingyong0423/go-generics-cache/random"
 
 	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
 	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/chenmingyong0423/go-generics-cache/slru"
+	"github.com/chenmingyong0423/go-generics-cache/types"
 )
 
 var (
 	_ ICache[int, any] = (*simple.Cache[int, any])(nil)
+	_ ICache[int, any] = (*simple.ConcurrentCache[int, any])(nil)
 	_ ICache[int, any] = (*lru.Cache[int, any])(nil)
-)
+	_ ICache[int, any] = (*fifo.Cache[int, any])(nil)
+	_ ICache[int, any] = (*slru.Cache[int, any])(nil)
+	_ ICache[int, any] = (*random.Cache[int, any])(nil)
+	_ ICache[int, any] = (*generational.Cache[int, any])(nil)
 
-// ICache defines an interface for a key-value cache.
-type ICache[K comparable, V any] interface {
+	_ types.EvictionNotifier[int, any] = (*lru.Cache[int, any])(nil)
+	_ types.EvictionNotifier[int, any] = (*fifo.Cache[int, any])(nil)
+	_ types.EvictionNotifier[int, any] = (*slru.Cache[int, any])(nil)
+	_ types.EvictionNotifier[int, any] = (*random.Cache[int, any])(nil)
+
+	_ types.Evicter[int] = (*lru.Cache[int, any])(nil)
+	_ types.Evicter[int] = (*fifo.Cache[int, any])(nil)
+	_ types.Evicter[int] = (*slru.Cache[int, any])(nil)
+	_ types.Evicter[int] = (*random.Cache[int, any])(nil)
+	_ types.Pinner[int]  = (*lru.Cache[int, any])(nil)
+	_ types.Pinner[int]  = (*fifo.Cache[int, any])(nil)
+	_ types.Resizer      = (*lru.Cache[int, any])(nil)
+	_ types.Resizer      = (*fifo.Cache[int, any])(nil)
+	_ types.FullRejecter = (*lru.Cache[int, any])(nil)
+	_ types.FullRejecter = (*fifo.Cache[int, any])(nil)
+
+	_ types.Sampler[int] = (*random.Cache[int, any])(nil)
+	_ types.RandSetter   = (*random.Cache[int, any])(nil)
 
-	// Set stores the given key-value pair in the cache.
-	Set(ctx context.Context, key K, value V) error
+	_ types.KeyOrderer[int] = (*lru.Cache[int, any])(nil)
+	_ types.KeyOrderer[int] = (*fifo.Cache[int, any])(nil)
 
-	// Get retrieves the value associated with the given key from the cache.
-	Get(ctx context.Context, key K) (V, error)
+	_ types.ExtendedICache[int, any] = (*simple.Cache[int, any])(nil)
+	_ types.ExtendedICache[int, any] = (*simple.ConcurrentCache[int, any])(nil)
+	_ types.ExtendedICache[int, any] = (*lru.Cache[int, any])(nil)
+	_ types.ExtendedICache[int, any] = (*fifo.Cache[int, any])(nil)
+	_ types.ExtendedICache[int, any] = (*slru.Cache[int, any])(nil)
+	_ types.ExtendedICache[int, any] = (*random.Cache[int, any])(nil)
+	_ types.ExtendedICache[int, any] = (*generational.Cache[int, any])(nil)
 
-	// Delete removes the value associated with the given key from the cache.
-	Delete(ctx context.Context, key K) error
+	_ types.Inspector[int, any] = (*simple.Cache[int, any])(nil)
+	_ types.Inspector[int, any] = (*simple.ConcurrentCache[int, any])(nil)
+	_ types.Inspector[int, any] = (*lru.Cache[int, any])(nil)
+	_ types.Inspector[int, any] = (*fifo.Cache[int, any])(nil)
+	_ types.Inspector[int, any] = (*slru.Cache[int, any])(nil)
+	_ types.Inspector[int, any] = (*random.Cache[int, any])(nil)
+	_ types.Inspector[int, any] = (*generational.Cache[int, any])(nil)
+)
 
-	Keys() []K
+// ICache defines an interface for a key-value cache.
+// It is kept for compatibility, new code should use types.ICache.
+type ICache[K comparable, V any] interface {
+	types.ICache[K, V]
 }
 
+// Cache 在 ICache 的基础上提供过期时间和定期清理能力。
+// Cache 触发的用户回调总是在释放内部锁之后执行，回调中可以安全地再次调用 Cache 的方法。
+// Get、Set、Delete 等操作在加锁之前检查 ctx，ctx 已经结束时直接返回 ctx.Err()；
+// MGet、DeleteExpired 等需要遍历的操作在持有锁期间也会定期检查，ctx 结束后尽快释放锁。
 type Cache[K comparable, V any] struct {
-	cache ICache[K, *Item[V]]
+	cache types.ICache[K, *Item[V]]
 	mutex sync.RWMutex
+	opts  options[K, V]
+	// pending 保存持有写锁期间登记的回调，释放锁之后再执行
+	pending []func()
+	stats   stats
+	// sharedReads 为 true 时后端的 Get 没有副作用，Get 只需要持有读锁
+	sharedReads bool
+	// calls 保存正在进行的加载，持有写锁时读写
+	calls map[K]*call[V]
+	// storeMu 在写穿模式下串行化写操作，wb 为回写模式下的待写入队列
+	storeMu sync.Mutex
+	wb      *writeBack[K, V]
+	// refresher 在元素即将过期时通过加载器提前刷新
+	refresher *refresher[K]
+	// ghost 记录最近被淘汰的键，用于估算扩容的收益
+	ghost *ghostList[K]
+	// expiry 按过期时间记录设置了过期时间的键，持有写锁时读写
+	expiry expiryQueue[K]
+	// cost 在设置了 WithMaxCost 或 WithMaxBytes 时记录每个键的成本，超出限制时通过 evicter 淘汰元素
+	cost    *costTracker[K]
+	evicter types.Evicter[K]
+	// pinner 在后端实现了 types.Pinner 时用于固定元素，否则为 nil
+	pinner types.Pinner[K]
+	// backendName 为后端的类型名称，记录在 *cacheError.KeyError 中
+	backendName string
 
 	janitor *janitor
+	// cancel 结束所有后台协程，closed 在 Close 之后为 true
+	cancel context.CancelFunc
+	closed atomic.Bool
+	// snapshotMu 保证同一时刻只有一次 WithSnapshot 的保存
+	snapshotMu sync.Mutex
+	// wal 为 WithWAL 正在写入的日志，未配置时为 nil
+	wal *walLog
 }
 
 // NewSimpleCache - 创建一个新的简单缓存。
 // interval time.Duration - 清理过期缓存项的时间间隔。在这个间隔内，缓存将自动检查并清理过期项。
-func NewSimpleCache[K comparable, V any](ctx context.Context, size int, interval time.Duration) *Cache[K, V] {
-	cache := &Cache[K, V]{
-		cache:   simple.NewCache[K, *Item[V]](size),
-		janitor: newJanitor(ctx, interval),
-	}
-	cache.janitor.run(cache.DeleteExpired)
-	return cache
+func NewSimpleCache[K comparable, V any](ctx context.Context, size int, interval time.Duration, opts ...Option[K, V]) *Cache[K, V] {
+	return New[K, V](ctx, simple.NewCache[K, *Item[V]](size), interval, opts...)
 }
 
 // NewLruCache - 创建一个新的LRU缓存。
 // interval time.Duration - 清理过期缓存项的时间间隔。在这个间隔内，缓存将自动检查并清理过期项。
-func NewLruCache[K comparable, V any](ctx context.Context, cap int, interval time.Duration) *Cache[K, V] {
-	cache := &Cache[K, V]{
-		cache:   lru.NewCache[K, *Item[V]](cap),
-		janitor: newJanitor(ctx, interval),
+func NewLruCache[K comparable, V any](ctx context.Context, cap int, interval time.Duration, opts ...Option[K, V]) *Cache[K, V] {
+	return New[K, V](ctx, lru.NewCache[K, *Item[V]](cap), interval, opts...)
+}
+
+// NewFifoCache - 创建一个新的FIFO缓存。
+// interval time.Duration - 清理过期缓存项的时间间隔。在这个间隔内，缓存将自动检查并清理过期项。
+func NewFifoCache[K comparable, V any](ctx context.Context, cap int, interval time.Duration, opts ...Option[K, V]) *Cache[K, V] {
+	return New[K, V](ctx, fifo.NewCache[K, *Item[V]](cap), interval, opts...)
+}
+
+// New - 使用任意实现了 types.ICache 的淘汰策略创建缓存，并为其提供过期时间和定期清理能力。
+// interval time.Duration - 清理过期缓存项的时间间隔。在这个间隔内，缓存将自动检查并清理过期项；小于等于 0 时不启动清理协程。
+// 配置无效时 New 会 panic，需要以错误的形式处理时请使用 NewWithConfig。
+func New[K comparable, V any](ctx context.Context, backend types.ICache[K, *Item[V]], interval time.Duration, opts ...Option[K, V]) *Cache[K, V] {
+	cache, err := NewWithConfig[K, V](ctx, Config[K, V]{
+		Backend:  backend,
+		Interval: interval,
+		Options:  opts,
+	})
+	if err != nil {
+		panic(err)
 	}
-	cache.janitor.run(cache.DeleteExpired)
 	return cache
 }
 
+// NewWithConfig 校验 cfg 并创建缓存，配置无效时返回 Config.Validate 的错误。
+func NewWithConfig[K comparable, V any](ctx context.Context, cfg Config[K, V]) (*Cache[K, V], error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	cache := &Cache[K, V]{
+		cache:   cfg.Backend,
+		opts:    cfg.options(),
+		janitor: newJanitor(ctx, cfg.Interval),
+		cancel:  cancel,
+
+		backendName: typeName(cfg.Backend),
+	}
+	if n, ok := cfg.Backend.(types.EvictionNotifier[K, *Item[V]]); ok {
+		n.SetOnEvicted(cache.evicted)
+	}
+	if cache.opts.rejectWhenFull {
+		cfg.Backend.(types.FullRejecter).SetRejectWhenFull(true)
+	}
+	if p, ok := cfg.Backend.(types.Pinner[K]); ok {
+		cache.pinner = p
+	}
+	if r, ok := cfg.Backend.(types.RandSetter); ok && cache.opts.rand != nil {
+		r.SetRandIntn(cache.opts.rand.Intn)
+	}
+	if r, ok := cfg.Backend.(types.ReadOnlyGetter); ok {
+		cache.sharedReads = r.ReadOnlyGet()
+	}
+	if cc := cache.opts.cleanup; cc != nil {
+		cache.janitor.delay = cc.startDelay(time.Now(), cfg.Interval, cache.randInt63n)
+	}
+	if limit := max(cache.opts.maxCost, cache.opts.maxBytes); limit > 0 {
+		cache.cost = newCostTracker[K](limit)
+		cache.evicter = cfg.Backend.(types.Evicter[K])
+	}
+	if cache.opts.ghostSize > 0 {
+		cache.ghost = newGhostList[K](cache.opts.ghostSize)
+	}
+	if cache.opts.snapshot != nil {
+		if err := cache.loadSnapshot(); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	if cache.opts.wal != nil {
+		if err := cache.openWAL(); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	if cache.opts.expvarName != "" {
+		cache.publishExpvar()
+	}
+	if cache.opts.store != nil && cache.opts.storeMode == WriteBack {
+		cache.wb = newWriteBack(cache.opts.store, cache.opts.storeCfg)
+		cache.wb.run(ctx, cache.safeCall)
+	}
+	if cache.opts.refreshRatio > 0 {
+		cache.refresher = newRefresher[K](cache.opts.refreshWorkers)
+		cache.refresher.run(ctx, cache.refresh)
+	}
+	if cache.opts.snapshot != nil {
+		cache.runSnapshots(ctx)
+	}
+	if cache.opts.wal != nil {
+		cache.runWAL(ctx)
+	}
+	cache.janitor.run(func(ctx context.Context) {
+		// ctx 结束后仍然执行最后一次清理
+		cache.safeCall(func() { cache.DeleteExpired(context.WithoutCancel(ctx)) })
+	})
+	if cache.opts.name != "" {
+		register(cache)
+		context.AfterFunc(ctx, func() { unregister(cache) })
+	}
+	return cache, nil
+}
+
+// enqueue 登记一个用户回调，调用方必须持有写锁。
+// 回调不会在锁内执行，而是在 unlock 释放锁之后依次执行，因此回调中可以安全地再次调用 Cache 的任意方法。
+func (c *Cache[K, V]) enqueue(fn func()) {
+	c.pending = append(c.pending, fn)
+}
+
+// unlock 释放写锁，然后执行持锁期间登记的回调。
+func (c *Cache[K, V]) unlock() {
+	pending := c.pending
+	c.pending = nil
+	c.mutex.Unlock()
+	for _, fn := range pending {
+		c.safeCall(fn)
+	}
+}
+
+// evicted 在后端淘汰元素时被调用，此时调用方持有写锁。
+func (c *Cache[K, V]) evicted(key K, item *Item[V]) {
+	c.stats.evictions.Add(1)
+	c.untrack(key)
+	c.logDelete(key)
+	if c.ghost != nil {
+		c.ghost.add(key)
+	}
+	if c.opts.log != nil {
+		c.enqueue(func() { c.logEviction(key, item.meta) })
+	}
+	if c.opts.onEvicted != nil {
+		c.enqueue(func() { c.opts.onEvicted(key, item.value) })
+	}
+}
+
 type ItemOption func(*itemOptions)
 
 type itemOptions struct {
 	expiration time.Time
+	ttl        time.Duration
+	// hasExpiration 表示显式设置了过期时间，此时不使用缓存的默认过期时间
+	hasExpiration bool
+	delta         time.Duration
+	sliding       bool
+	meta          map[string]string
+	cost          int64
+	pinned        bool
+	maxIdle       time.Duration
 }
 
 func WithExpiration(exp time.Duration) ItemOption {
 	return func(o *itemOptions) {
 		o.expiration = time.Now().Add(exp)
+		o.ttl = exp
+		o.hasExpiration = true
+	}
+}
+
+// WithoutExpiration 使元素永不过期，即使缓存通过 WithDefaultExpiration 设置了默认过期时间。
+func WithoutExpiration() ItemOption {
+	return func(o *itemOptions) {
+		o.expiration = time.Time{}
+		o.ttl = 0
+		o.sliding = false
+		o.maxIdle = 0
+		o.hasExpiration = true
+	}
+}
+
+// WithSlidingExpiration 设置滑动过期时间：元素在 d 之后过期，且每次通过 Get 命中都会将过期时间重新推迟到 d 之后，
+// 适用于会话等需要空闲超时的场景。
+func WithSlidingExpiration(d time.Duration) ItemOption {
+	return func(o *itemOptions) {
+		WithExpiration(d)(o)
+		o.sliding = true
+	}
+}
+
+// WithMaxIdle 设置最大空闲时间：元素在 d 之内没有被 Get 命中时过期，每次命中都会将过期时间重新推迟到 d 之后，
+// 但不会超过 WithExpiration 或 WithDefaultExpiration 设置的绝对过期时间，适用于会话、连接元数据等需要按空闲时间淘汰的场景。
+// 只需要空闲超时而不需要绝对过期时间时可以使用 WithSlidingExpiration。d <= 0 时忽略。
+func WithMaxIdle(d time.Duration) ItemOption {
+	return func(o *itemOptions) {
+		if d > 0 {
+			o.maxIdle = d
+		}
+	}
+}
+
+// WithMeta 为元素附加少量自定义元数据，例如追踪 ID 或数据来源，便于排查数据的出处。
+// 元数据会被复制保存，可以通过 EntryInfo 读取，并会出现在淘汰日志中。
+func WithMeta(meta map[string]string) ItemOption {
+	return func(o *itemOptions) {
+		o.meta = maps.Clone(meta)
 	}
 }
 
 type Item[V any] struct {
 	value      V
 	expiration time.Time
+	// ttl 为设置过期时间时使用的时长，提前刷新据此判断元素的剩余寿命
+	ttl time.Duration
+	// delta 为重新计算该值的耗时，用于概率提前过期
+	delta time.Duration
+	// sliding 为 true 时每次命中都会按 ttl 推迟过期时间
+	sliding bool
+	// deadline 为 WithMaxIdle 的元素的绝对过期时间，滑动推迟过期时间时不会超过它，零值表示没有限制
+	deadline time.Time
+	meta     map[string]string
+	// created 为元素写入缓存的时间
+	created time.Time
+	// cost 为 WithCost 设置的成本，0 表示未设置
+	cost int64
+	// pinned 为 true 时写入之后固定该元素，见 WithPinned
+	pinned bool
+	// hits 和 lastAccess 为启用 WithEntryStats 时的命中次数和最近一次命中的时间（Unix 纳秒），
+	// 共享读锁下也会被修改，因此使用原子操作
+	hits       atomic.Uint64
+	lastAccess atomic.Int64
 }
 
 func newItem[V any](value V, opts ...ItemOption) *Item[V] {
+	return newItemWithDefault(value, 0, opts...)
+}
+
+// newItemWithDefault 创建元素，opts 没有设置过期时间且 defaultTTL 大于 0 时元素在 defaultTTL 之后过期。
+func newItemWithDefault[V any](value V, defaultTTL time.Duration, opts ...ItemOption) *Item[V] {
 	var item = &itemOptions{}
 	for _, opt := range opts {
 		opt(item)
 	}
-	return &Item[V]{
+	if !item.hasExpiration && defaultTTL > 0 {
+		WithExpiration(defaultTTL)(item)
+	}
+	i := &Item[V]{
 		value:      value,
 		expiration: item.expiration,
+		ttl:        item.ttl,
+		delta:      item.delta,
+		sliding:    item.sliding,
+		meta:       item.meta,
+		created:    time.Now(),
+		cost:       item.cost,
+		pinned:     item.pinned,
 	}
+	if item.maxIdle > 0 {
+		// 空闲过期按滑动过期处理，原来的过期时间作为上限
+		i.deadline = item.expiration
+		i.ttl = item.maxIdle
+		i.sliding = true
+		i.touch()
+	}
+	return i
+}
+
+// withValue 返回值替换为 v 的副本，过期时间、附加信息和访问统计保持不变。
+func (i *Item[V]) withValue(v V) *Item[V] {
+	next := &Item[V]{
+		value:      v,
+		expiration: i.expiration,
+		ttl:        i.ttl,
+		delta:      i.delta,
+		sliding:    i.sliding,
+		deadline:   i.deadline,
+		meta:       i.meta,
+		created:    i.created,
+		cost:       i.cost,
+	}
+	next.hits.Store(i.hits.Load())
+	next.lastAccess.Store(i.lastAccess.Load())
+	return next
+}
+
+// newItem 创建元素，未显式设置过期时间时使用 WithDefaultExpiration 设置的默认过期时间。
+func (c *Cache[K, V]) newItem(value V, opts ...ItemOption) *Item[V] {
+	return newItemWithDefault(value, c.opts.defaultExpiration, opts...)
+}
+
+// EntryInfo 描述缓存中某个元素的附加信息。
+type EntryInfo struct {
+	// Expiration 为过期时间，零值表示永不过期
+	Expiration time.Time
+	// Meta 为通过 WithMeta 附加的元数据副本，没有元数据时为 nil
+	Meta map[string]string
 }
 
 func (i *Item[V]) Expired() bool {
 	return !i.expiration.IsZero() && i.expiration.Before(time.Now())
 }
 
+// touch 将过期时间推迟到 ttl 之后，但不会超过 deadline，调用方需持有写锁。
+func (i *Item[V]) touch() {
+	i.expiration = time.Now().Add(i.ttl)
+	if !i.deadline.IsZero() && i.deadline.Before(i.expiration) {
+		i.expiration = i.deadline
+	}
+}
+
+// slide 在写锁内推迟滑动过期元素的过期时间，元素在读锁释放后被替换或删除时不做任何处理。
+func (c *Cache[K, V]) slide(ctx context.Context, key K, item *Item[V]) {
+	c.mutex.Lock()
+	defer c.unlock()
+	if cur, err := c.cache.Get(ctx, key); err == nil && cur == item && !item.Expired() {
+		item.touch()
+	}
+}
+
+// Get 返回 key 对应的值，key 不存在时返回 cacheError.ErrNoKey，key 存在但已过期时返回包装了 ErrNoKey 的 cacheError.ErrKeyExpired。
+// 如果通过 WithLoader 设置了加载器，未命中时会通过加载器加载并写入缓存，语义与 GetOrLoad 相同。
+// 对内置后端而言，命中时 Get 不会产生堆内存分配，TestCache_Get_Allocs 保证了这一点。
 func (c *Cache[K, V]) Get(ctx context.Context, key K) (v V, err error) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	defer c.opEnd(ctx, "get", key, c.opStart())
+	defer c.wrapKeyError("get", key, &err)
+	v, early, err := c.get(ctx, key)
+	if early {
+		return c.expireEarly(ctx, key, v, c.loaderFunc(), c.opts.loaderItemOpts...)
+	}
+	if err != nil && c.opts.loader != nil && errors.Is(err, cacheError.ErrNoKey) {
+		return c.loadAndStore(ctx, key, c.opts.loader.Load, c.opts.loaderItemOpts...)
+	}
+	return v, err
+}
+
+// clone 在设置了 WithCloner 时返回 v 的副本，否则返回 v 本身。
+func (c *Cache[K, V]) clone(v V) V {
+	if c.opts.cloner == nil {
+		return v
+	}
+	return c.opts.cloner(v)
+}
+
+// get 从后端读取 key。early 为 true 时元素尚未过期，但被 WithEarlyExpiration 提前判定为过期，v 为当前缓存的值。
+func (c *Cache[K, V]) get(ctx context.Context, key K) (v V, early bool, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	c.readLock()
 	item, err := c.cache.Get(ctx, key)
 	if err != nil {
+		c.readUnlock()
+		c.stats.misses.Add(1)
+		if c.ghost != nil && c.ghost.remove(key) {
+			c.stats.ghostHits.Add(1)
+		}
 		return
 	}
+	// 过期时间可能被其他写操作修改，需要在锁内检查
 	if item.Expired() {
-		return v, cacheError.ErrNoKey
+		c.readUnlock()
+		c.stats.misses.Add(1)
+		return v, false, cacheError.ErrKeyExpired
+	}
+	v = item.value
+	early = c.opts.beta > 0 && c.expiresEarly(item)
+	refresh := !early && c.refresher != nil && c.refreshDue(item)
+	slide := !early && item.sliding && item.ttl > 0
+	if slide && !c.sharedReads {
+		// 已持有写锁，可以直接修改
+		item.touch()
+		slide = false
+	}
+	c.readUnlock()
+	v = c.clone(v)
+	if early {
+		c.stats.misses.Add(1)
+		return v, true, nil
+	}
+	c.stats.hits.Add(1)
+	c.recordHit(item)
+	if slide {
+		c.slide(ctx, key, item)
+	}
+	if refresh {
+		c.refresher.trigger(key)
+	}
+	return v, false, nil
+}
+
+// Contains 报告 key 是否存在且未过期。与 Get 不同，Contains 不返回值、不计入命中统计、不触发加载器，
+// 后端实现了 types.Peeker 时也不会改变元素的访问顺序，适用于健康检查和去重等不希望影响淘汰的场景。
+func (c *Cache[K, V]) Contains(ctx context.Context, key K) bool {
+	c.readLock()
+	defer c.readUnlock()
+	item, err := c.peek(ctx, key)
+	return err == nil && !item.Expired()
+}
+
+// Peek 返回 key 对应的值，key 不存在或已过期时返回 cacheError.ErrNoKey。
+// 与 Get 不同，Peek 只观察缓存而不改变它：不计入命中统计、不触发加载器、提前刷新和滑动过期，
+// 后端实现了 types.Peeker 时也不会改变元素的访问顺序，适用于监控和调试工具。
+func (c *Cache[K, V]) Peek(ctx context.Context, key K) (v V, err error) {
+	defer c.wrapKeyError("peek", key, &err)
+	c.readLock()
+	item, err := c.peek(ctx, key)
+	if err == nil && item.Expired() {
+		err = cacheError.ErrNoKey
+	}
+	if err == nil {
+		v = item.value
+	}
+	c.readUnlock()
+	if err != nil {
+		return v, err
+	}
+	return c.clone(v), nil
+}
+
+// peek 读取 key 对应的元素，后端实现了 types.Peeker 时不会改变元素的访问顺序，调用方需要持有锁。
+func (c *Cache[K, V]) peek(ctx context.Context, key K) (*Item[V], error) {
+	if p, ok := c.cache.(types.Peeker[K, *Item[V]]); ok {
+		return p.Peek(ctx, key)
+	}
+	return c.cache.Get(ctx, key)
+}
+
+// readLock 为读操作加锁：后端的 Get 没有副作用时使用读锁，否则（如 LRU 需要调整访问顺序）使用写锁。
+func (c *Cache[K, V]) readLock() {
+	if c.sharedReads {
+		c.mutex.RLock()
+		return
+	}
+	c.mutex.Lock()
+}
+
+func (c *Cache[K, V]) readUnlock() {
+	if c.sharedReads {
+		c.mutex.RUnlock()
+		return
+	}
+	c.mutex.Unlock()
+}
+
+// wrapKeyError 在设置了 WithKeyErrors 时将 *err 中可以匹配 cacheError.ErrNoKey 或 cacheError.ErrCacheFull 的错误
+// 包装为 *cacheError.KeyError，附加操作、键、缓存名称和后端。对单个键的操作通过 defer 调用。
+func (c *Cache[K, V]) wrapKeyError(op string, key K, err *error) {
+	if !c.opts.keyErrors || *err == nil || !(errors.Is(*err, cacheError.ErrNoKey) || errors.Is(*err, cacheError.ErrCacheFull)) {
+		return
 	}
-	return item.value, nil
+	var ke *cacheError.KeyError
+	if errors.As(*err, &ke) {
+		return
+	}
+	*err = &cacheError.KeyError{Op: op, Key: key, Cache: c.opts.name, Backend: c.backendName, Err: *err}
+}
+
+// typeName 返回 v 的类型名称，省略泛型参数，如 "*lru.Cache"。
+func typeName(v any) string {
+	name := fmt.Sprintf("%T", v)
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return name
 }
 
+// Set 写入 key 对应的值。配置了 WithStore 时，写穿模式下 store 写入失败会直接返回错误且不更新缓存。
 func (c *Cache[K, V]) Set(ctx context.Context, key K, value V, opts ...ItemOption) (err error) {
+	defer c.opEnd(ctx, "set", key, c.opStart())
+	defer c.wrapKeyError("set", key, &err)
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if c.writeThrough() {
+		c.storeMu.Lock()
+		defer c.storeMu.Unlock()
+		if err = c.checkClosed(); err != nil {
+			return err
+		}
+		if err = c.opts.store.Save(ctx, key, value); err != nil {
+			return err
+		}
+	}
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	item := newItem[V](value, opts...)
-	return c.cache.Set(ctx, key, item)
+	defer c.unlock()
+	if err = c.checkClosed(); err != nil {
+		return err
+	}
+	item := c.newItem(value, opts...)
+	if err = c.cache.Set(ctx, key, item); err == nil {
+		c.track(key, item)
+		c.stats.sets.Add(1)
+		c.invalidate(key)
+		if c.wb != nil {
+			c.wb.add(key, pendingWrite[V]{value: value, ttl: item.ttl})
+		}
+	}
+	return err
 }
 
 func (c *Cache[K, V]) SetNX(ctx context.Context, key K, value V, opts ...ItemOption) (b bool, err error) {
+	defer c.opEnd(ctx, "setnx", key, c.opStart())
+	defer c.wrapKeyError("setnx", key, &err)
+	if err = ctx.Err(); err != nil {
+		return false, err
+	}
+	if c.writeThrough() {
+		return c.setNXThrough(ctx, key, value, opts...)
+	}
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
+	if err = c.checkClosed(); err != nil {
+		return false, err
+	}
 	_, err = c.cache.Get(ctx, key)
 	if err != nil {
 		if errors.Is(err, cacheError.ErrNoKey) {
-			item := newItem[V](value, opts...)
-			return true, c.cache.Set(ctx, key, item)
+			item := c.newItem(value, opts...)
+			if err = c.cache.Set(ctx, key, item); err != nil {
+				return false, err
+			}
+			c.track(key, item)
+			c.stats.sets.Add(1)
+			c.invalidate(key)
+			if c.wb != nil {
+				c.wb.add(key, pendingWrite[V]{value: value, ttl: item.ttl})
+			}
+			return true, nil
 		}
 		return false, err
 	}
 	return false, nil
 }
 
+// Entry 是批量写入中的一个元素，Opts 只对该元素生效。
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+	Opts  []ItemOption
+}
+
+// SetMulti 在一次加锁内依次写入 entries，每个元素可以通过 Opts 设置各自的过期时间等选项，
+// 其他协程不会观察到只写入了一部分的中间状态。
+// 配置了写穿模式的 WithStore 时先写入 store，store 写入失败时缓存不会被修改。
+func (c *Cache[K, V]) SetMulti(ctx context.Context, entries []Entry[K, V]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.writeThrough() {
+		c.storeMu.Lock()
+		defer c.storeMu.Unlock()
+		if err := c.checkClosed(); err != nil {
+			return err
+		}
+		if err := c.saveMulti(ctx, entries); err != nil {
+			return err
+		}
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	if err := c.checkClosed(); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		item := c.newItem(e.Value, e.Opts...)
+		if err := c.cache.Set(ctx, e.Key, item); err != nil {
+			return err
+		}
+		c.track(e.Key, item)
+		c.stats.sets.Add(1)
+		c.invalidate(e.Key)
+		if c.wb != nil {
+			c.wb.add(e.Key, pendingWrite[V]{value: e.Value, ttl: item.ttl})
+		}
+	}
+	return nil
+}
+
+// MSet 在一次加锁内写入 entries 中的所有键值对，opts 作用于每一个元素，适用于批量预热。
+// 需要为每个元素设置不同的过期时间等选项时使用 SetMulti。
+func (c *Cache[K, V]) MSet(ctx context.Context, entries map[K]V, opts ...ItemOption) error {
+	return c.SetMulti(ctx, toEntries(entries, opts))
+}
+
+func toEntries[K comparable, V any](m map[K]V, opts []ItemOption) []Entry[K, V] {
+	entries := make([]Entry[K, V], 0, len(m))
+	for key, value := range m {
+		entries = append(entries, Entry[K, V]{Key: key, Value: value, Opts: opts})
+	}
+	return entries
+}
+
+// MGet 在一次加锁内读取 keys，返回其中存在且未过期的键和值，不存在的键不会出现在结果中。
+// MGet 只读取缓存：不会调用 WithLoader 设置的加载器，也不会触发提前过期或提前刷新。
+// 读取期间 ctx 结束时放弃已经读取的结果并返回 ctx.Err()。
+func (c *Cache[K, V]) MGet(ctx context.Context, keys ...K) (map[K]V, error) {
+	values, err := c.mget(ctx, keys)
+	if err != nil || c.opts.cloner == nil {
+		return values, err
+	}
+	// 在释放锁之后复制，见 WithCloner
+	for key, v := range values {
+		values[key] = c.opts.cloner(v)
+	}
+	return values, nil
+}
+
+func (c *Cache[K, V]) mget(ctx context.Context, keys []K) (map[K]V, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	values := make(map[K]V, len(keys))
+	c.readLock()
+	defer c.readUnlock()
+	for i, key := range keys {
+		if err := checkCtx(ctx, i); err != nil {
+			return nil, err
+		}
+		item, err := c.cache.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, cacheError.ErrNoKey) {
+				c.stats.misses.Add(1)
+				continue
+			}
+			return nil, err
+		}
+		if item.Expired() {
+			c.stats.misses.Add(1)
+			continue
+		}
+		c.stats.hits.Add(1)
+		c.recordHit(item)
+		values[key] = item.value
+	}
+	return values, nil
+}
+
+// setNXThrough 是写穿模式下的 SetNX：store 写入期间不持有缓存锁，避免阻塞读操作。
+// storeMu 保证期间没有其他写操作；期间写入的加载结果早于本次写入，因此 store 写入成功后直接覆盖。
+func (c *Cache[K, V]) setNXThrough(ctx context.Context, key K, value V, opts ...ItemOption) (bool, error) {
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	if err := c.checkClosed(); err != nil {
+		return false, err
+	}
+	c.readLock()
+	_, err := c.cache.Get(ctx, key)
+	c.readUnlock()
+	if !errors.Is(err, cacheError.ErrNoKey) {
+		return false, err
+	}
+	if err = c.opts.store.Save(ctx, key, value); err != nil {
+		return false, err
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	item := c.newItem(value, opts...)
+	if err = c.cache.Set(ctx, key, item); err != nil {
+		return false, err
+	}
+	c.track(key, item)
+	c.stats.sets.Add(1)
+	c.invalidate(key)
+	return true, nil
+}
+
+// Expire 将 key 的过期时间设置为 ttl 之后，值和其他属性保持不变，可用于延长会话等场景。
+// ttl 小于等于 0 时 key 会立即过期，key 不存在或已过期时返回 cacheError.ErrNoKey。
+func (c *Cache[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) (err error) {
+	defer c.wrapKeyError("expire", key, &err)
+	n, err := c.ExpireMulti(ctx, []K{key}, ttl)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return cacheError.ErrNoKey
+	}
+	return nil
+}
+
+// Touch 将 key 的过期时间推迟到最近一次设置的过期时长之后，永不过期的元素保持不变。
+// key 不存在或已过期时返回 cacheError.ErrNoKey。
+func (c *Cache[K, V]) Touch(ctx context.Context, key K) (err error) {
+	defer c.wrapKeyError("touch", key, &err)
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	item, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if item.Expired() {
+		return cacheError.ErrNoKey
+	}
+	if item.ttl > 0 {
+		item.touch()
+		c.logExpire(key, item)
+	}
+	return nil
+}
+
+// Persist 与 Redis 的同名命令一致，清除 key 的过期时间使其永不过期，返回 key 原来是否设置了过期时间。
+// key 不存在或已过期时返回 cacheError.ErrNoKey。
+func (c *Cache[K, V]) Persist(ctx context.Context, key K) (_ bool, err error) {
+	defer c.wrapKeyError("persist", key, &err)
+	if err = ctx.Err(); err != nil {
+		return false, err
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	item, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if item.Expired() {
+		return false, cacheError.ErrNoKey
+	}
+	if item.expiration.IsZero() {
+		return false, nil
+	}
+	item.expiration = time.Time{}
+	item.ttl = 0
+	item.deadline = time.Time{}
+	c.logExpire(key, item)
+	return true, nil
+}
+
+// ExpireMulti 在一次加锁内将 keys 中所有存在且未过期的键的过期时间设置为 ttl 之后，返回被更新的键的数量。
+// ttl 小于等于 0 时这些键会立即过期。遍历期间 ctx 结束时返回已经更新的键的数量和 ctx.Err()。
+func (c *Cache[K, V]) ExpireMulti(ctx context.Context, keys []K, ttl time.Duration) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	expiration := time.Now().Add(ttl)
+	n := 0
+	for i, key := range keys {
+		if err := checkCtx(ctx, i); err != nil {
+			return n, err
+		}
+		item, err := c.cache.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, cacheError.ErrNoKey) {
+				continue
+			}
+			return n, err
+		}
+		if item.Expired() {
+			continue
+		}
+		item.expiration = expiration
+		item.ttl = ttl
+		item.deadline = time.Time{}
+		c.expiry.push(key, expiration)
+		c.logExpire(key, item)
+		n++
+	}
+	return n, nil
+}
+
+// Delete 删除 key，key 不存在时返回 cacheError.ErrNoKey。
+// 配置了 WithStore 时 key 同样会从 store 中删除；由于 store 中的键不一定被缓存，此时只返回 store 的错误。
 func (c *Cache[K, V]) Delete(ctx context.Context, key K) (err error) {
+	defer c.opEnd(ctx, "delete", key, c.opStart())
+	defer c.wrapKeyError("delete", key, &err)
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if c.writeThrough() {
+		c.storeMu.Lock()
+		defer c.storeMu.Unlock()
+		if err = c.checkClosed(); err != nil {
+			return err
+		}
+		if err = c.opts.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	return c.cache.Delete(ctx, key)
+	defer c.unlock()
+	if err = c.checkClosed(); err != nil {
+		return err
+	}
+	// 即使键不存在也要使正在进行的加载失效，避免加载结果在删除之后写回
+	c.invalidate(key)
+	if c.wb != nil {
+		c.wb.add(key, pendingWrite[V]{deleted: true})
+	}
+	if err = c.cache.Delete(ctx, key); err == nil {
+		c.deleted(key)
+	}
+	if c.opts.store != nil && errors.Is(err, cacheError.ErrNoKey) {
+		return nil
+	}
+	return err
+}
+
+// MDelete 在一次加锁内删除 keys，返回其中实际存在的键的数量，其他写操作不会观察到只删除了一部分的中间状态。
+// 配置了写穿模式的 WithStore 时先从 store 中删除，store 删除失败时缓存不会被修改。
+func (c *Cache[K, V]) MDelete(ctx context.Context, keys ...K) (deleted int, err error) {
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+	if c.writeThrough() {
+		c.storeMu.Lock()
+		defer c.storeMu.Unlock()
+		if err = c.checkClosed(); err != nil {
+			return 0, err
+		}
+		if err = c.deleteMulti(ctx, keys); err != nil {
+			return 0, err
+		}
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	if err = c.checkClosed(); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		c.invalidate(key)
+		if c.wb != nil {
+			c.wb.add(key, pendingWrite[V]{deleted: true})
+		}
+		err = c.cache.Delete(ctx, key)
+		if errors.Is(err, cacheError.ErrNoKey) {
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		c.deleted(key)
+		deleted++
+	}
+	return deleted, nil
+}
+
+// deleted 在 key 被 Delete 或 MDelete 删除之后清理记录的信息并登记 WithOnDeleted 的回调，调用方需要持有写锁。
+func (c *Cache[K, V]) deleted(key K) {
+	c.untrack(key)
+	c.logDelete(key)
+	c.stats.deletes.Add(1)
+	if c.opts.onDeleted != nil {
+		c.enqueue(func() { c.opts.onDeleted(key) })
+	}
+}
+
+// Clear 在一次加锁内删除缓存中的所有元素并返回删除的数量，不会触发淘汰和过期回调。
+// 与 MDelete 不同，Clear 只清空缓存，不会从 WithStore 设置的 store 中删除。后端实现了 types.Clearer 时一次性清空，
+// 否则逐个删除。
+func (c *Cache[K, V]) Clear(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	if err := c.checkClosed(); err != nil {
+		return 0, err
+	}
+	keys := c.cache.Keys()
+	if cl, ok := c.cache.(types.Clearer); ok {
+		cl.Clear()
+	} else {
+		for i, key := range keys {
+			if err := c.cache.Delete(ctx, key); err != nil && !errors.Is(err, cacheError.ErrNoKey) {
+				c.untrackAll(keys[:i])
+				return i, err
+			}
+		}
+	}
+	c.untrackAll(keys)
+	return len(keys), nil
+}
+
+// untrackAll 在 keys 被 Clear 删除之后清理记录的信息，调用方需要持有写锁。
+func (c *Cache[K, V]) untrackAll(keys []K) {
+	for _, key := range keys {
+		c.invalidate(key)
+		c.untrack(key)
+		c.logDelete(key)
+	}
+	c.stats.deletes.Add(uint64(len(keys)))
 }
 
 func (c *Cache[K, V]) Keys() []K {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return c.cache.Keys()
 }
 
-func (c *Cache[K, V]) DeleteExpired(ctx context.Context) {
+// Len 返回缓存中元素的数量，包括已过期但尚未清理的元素，与 len(Keys()) 相同。
+// 后端实现了 types.Lener 时不会分配内存，内置的后端均已实现。
+func (c *Cache[K, V]) Len() int {
 	c.mutex.RLock()
-	keys := c.Keys()
-	c.mutex.RUnlock()
-	i := 0
-	for _, key := range keys {
-		if i > 10000 {
-			return
+	defer c.mutex.RUnlock()
+	return c.len()
+}
+
+// len 返回后端中元素的数量，调用方需要持有锁。
+func (c *Cache[K, V]) len() int {
+	if l, ok := c.cache.(types.Lener); ok {
+		return l.Len()
+	}
+	return len(c.cache.Keys())
+}
+
+// CountValid 返回未过期的元素的数量，即 Len 减去已过期但尚未清理的元素。
+// 过期时间记录在过期堆中，CountValid 只检查已经到期的键，不需要遍历所有元素。
+func (c *Cache[K, V]) CountValid(ctx context.Context) int {
+	c.readLock()
+	defer c.readUnlock()
+	expired := 0
+	c.expiry.walkDue(time.Now(), func(key K) {
+		if item, err := c.peek(ctx, key); err == nil && item.Expired() {
+			expired++
 		}
-		c.mutex.Lock()
-		if item, err := c.cache.Get(ctx, key); err == nil && item.Expired() {
-			_ = c.cache.Delete(ctx, key)
+	})
+	return c.len() - expired
+}
+
+// KeysIn 按 order 返回所有的键，后端不支持该顺序时返回 cacheError.ErrUnsupportedOrder。
+// 任何后端都支持 types.OrderAny；lru 支持 types.OrderRecency，fifo 支持 types.OrderInsertion。
+// 与 Keys 相同，返回的键可能包含已过期但尚未清理的键。
+func (c *Cache[K, V]) KeysIn(order types.Order) ([]K, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if o, ok := c.cache.(types.KeyOrderer[K]); ok {
+		return o.KeysIn(order)
+	}
+	if order == types.OrderAny {
+		return c.cache.Keys(), nil
+	}
+	return nil, cacheError.ErrUnsupportedOrder
+}
+
+// DeleteExpired 删除已经过期的元素并返回删除的数量。过期时间记录在小顶堆中，每次只处理已经到期的键，
+// 耗时与到期键的数量而不是缓存中元素的总数相关；每次的工作量受 WithCleanup 的限制。
+// ctx 结束时停止清理并返回已经删除的数量，剩余的过期元素留给下一次清理。
+func (c *Cache[K, V]) DeleteExpired(ctx context.Context) (removed int) {
+	start := time.Now()
+	scanned := 0
+	defer func() { c.logCleanup(ctx, scanned, removed, time.Since(start)) }()
+	cfg := c.opts.cleanup
+	if cfg == nil {
+		cfg = &defaultCleanupConfig
+	}
+	if ctx.Err() != nil {
+		return 0
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	for !cfg.done(start, scanned, removed) {
+		if checkCtx(ctx, scanned) != nil {
+			break
+		}
+		key, ok := c.expiry.popDue(start)
+		if !ok {
+			break
+		}
+		scanned++
+		if c.deleteIfExpired(ctx, key) {
+			removed++
 		}
-		c.mutex.Unlock()
-		i++
 	}
+	return removed
+}
+
+// ctxCheckInterval 为持有锁遍历时检查 ctx 的间隔，避免每个元素都检查带来的开销。
+const ctxCheckInterval = 64
+
+// checkCtx 在 i 为 ctxCheckInterval 的整数倍时返回 ctx.Err()，用于在遍历中定期检查 ctx 是否结束。
+func checkCtx(ctx context.Context, i int) error {
+	if i%ctxCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// deleteIfExpired 删除已过期的 key，调用方需要持有写锁。key 的过期时间被延长时重新放回过期堆。
+// 通过 peek 读取元素，清理不会改变元素的访问顺序。
+func (c *Cache[K, V]) deleteIfExpired(ctx context.Context, key K) bool {
+	item, err := c.peek(ctx, key)
+	if err != nil || item.expiration.IsZero() {
+		return false
+	}
+	if !item.Expired() {
+		c.expiry.push(key, item.expiration)
+		return false
+	}
+	if c.cache.Delete(ctx, key) != nil {
+		return false
+	}
+	c.untrack(key)
+	c.stats.expired.Add(1)
+	if c.opts.onExpired != nil {
+		c.enqueue(func() { c.opts.onExpired(key, item.value) })
+	}
+	return true
+}
+
+// NoExpiration 是 TTL 对永不过期的元素返回的剩余时间。
+const NoExpiration time.Duration = -1
+
+// TTL 返回 key 的剩余存活时间，永不过期时返回 NoExpiration，key 不存在或已过期时返回 cacheError.ErrNoKey。
+// 与 Peek 相同，TTL 不会改变元素的访问顺序。
+func (c *Cache[K, V]) TTL(ctx context.Context, key K) (_ time.Duration, err error) {
+	defer c.wrapKeyError("ttl", key, &err)
+	if err = ctx.Err(); err != nil {
+		return 0, err
+	}
+	c.readLock()
+	defer c.readUnlock()
+	item, err := c.peek(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if item.Expired() {
+		return 0, cacheError.ErrNoKey
+	}
+	if item.expiration.IsZero() {
+		return NoExpiration, nil
+	}
+	return max(time.Until(item.expiration), 0), nil
+}
+
+// EntryInfo 返回 key 对应元素的附加信息，key 不存在或已过期时返回 cacheError.ErrNoKey，不会改变元素的访问顺序。
+func (c *Cache[K, V]) EntryInfo(ctx context.Context, key K) (_ EntryInfo, err error) {
+	defer c.wrapKeyError("entryinfo", key, &err)
+	if err = ctx.Err(); err != nil {
+		return EntryInfo{}, err
+	}
+	c.readLock()
+	defer c.readUnlock()
+	item, err := c.peek(ctx, key)
+	if err != nil {
+		return EntryInfo{}, err
+	}
+	if item.Expired() {
+		return EntryInfo{}, cacheError.ErrNoKey
+	}
+	return EntryInfo{Expiration: item.expiration, Meta: maps.Clone(item.meta)}, nil
+}
+
+// BloomOfKeys 返回由当前所有键构建并序列化的布隆过滤器，fpRate 为期望误判率。
+// 下游可以通过 bloom.Filter.UnmarshalBinary 还原过滤器，并用 bloom.Key 生成查询用的字节表示，
+// 以较低的成本判断某个键是否可能缓存在本实例中。
+func (c *Cache[K, V]) BloomOfKeys(fpRate float64) ([]byte, error) {
+	keys := c.Keys()
+	f, err := bloom.New(len(keys), fpRate)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		f.Add(bloom.Key(key))
+	}
+	return f.MarshalBinary()
 }
@@ -15,13 +15,22 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/chenmingyong0423/go-generics-cache/bloom"
 	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/generational"
+	"github.com/chenmingyong0423/go-generics-cache/lru"
+	"github.com/chenmingyong0423/go-generics-cache/random"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/chenmingyong0423/go-generics-cache/slru"
+	"github.com/chenmingyong0423/go-generics-cache/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSimpleCache(t *testing.T) {
@@ -51,10 +60,22 @@ func Test_newItem(t *testing.T) {
 				value: 1,
 			},
 		},
+		{
+			name:  "Creates an item with meta",
+			value: 1,
+			opts:  []ItemOption{WithMeta(map[string]string{"source": "db"})},
+			want: &Item[int]{
+				value: 1,
+				meta:  map[string]string{"source": "db"},
+			},
+		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.want, newItem(tt.value, tt.opts...))
+			got := newItem(tt.value, tt.opts...)
+			assert.WithinDuration(t, time.Now(), got.created, time.Second)
+			got.created = time.Time{}
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
@@ -114,6 +135,19 @@ func TestCache_Get(t *testing.T) {
 			ctx:       context.Background(),
 			key:       1,
 			wantValue: 0,
+			wantErr:   cacheError.ErrKeyExpired,
+		},
+		{
+			name: "Lookup the key after the expired key is deleted",
+			cache: func(t *testing.T) *Cache[int, int] {
+				cache := NewSimpleCache[int, int](context.Background(), 0, time.Minute)
+				assert.NoError(t, cache.Set(context.Background(), 1, 1, WithExpiration(-time.Second)))
+				assert.Equal(t, 1, cache.DeleteExpired(context.Background()))
+				return cache
+			},
+			ctx:       context.Background(),
+			key:       1,
+			wantValue: 0,
 			wantErr:   cacheError.ErrNoKey,
 		},
 	}
@@ -275,9 +309,10 @@ func TestCache_Delete(t *testing.T) {
 			cache: func(t *testing.T) *Cache[int, int] {
 				return NewSimpleCache[int, int](context.Background(), 0, time.Minute)
 			},
+			ctx:      context.Background(),
 			keys:     1,
 			wantKeys: []int{},
-			wantErr:  nil,
+			wantErr:  cacheError.ErrNoKey,
 		},
 		{
 			name: "Delete non-existent key from the empty cache",
@@ -286,9 +321,10 @@ func TestCache_Delete(t *testing.T) {
 				assert.NoError(t, cache.Set(context.Background(), 1, 1))
 				return cache
 			},
+			ctx:      context.Background(),
 			keys:     2,
 			wantKeys: []int{1},
-			wantErr:  nil,
+			wantErr:  cacheError.ErrNoKey,
 		},
 		{
 			name: "Delete existing keys from the cache",
@@ -298,6 +334,7 @@ func TestCache_Delete(t *testing.T) {
 				assert.NoError(t, cache.Set(context.Background(), 2, 2))
 				return cache
 			},
+			ctx:      context.Background(),
 			keys:     1,
 			wantKeys: []int{2},
 			wantErr:  nil,
@@ -318,3 +355,1222 @@ func TestNewLruCache(t *testing.T) {
 	cache := NewLruCache[int, int](context.Background(), 0, 3*time.Second)
 	assert.NotNil(t, cache)
 }
+
+func TestNewFifoCache(t *testing.T) {
+	cache := NewFifoCache[int, int](context.Background(), 2, time.Minute)
+	assert.NotNil(t, cache)
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, cache.Set(context.Background(), i, i))
+	}
+	assert.Equal(t, []int{2, 3}, cache.Keys())
+}
+
+func TestNew(t *testing.T) {
+	cache := New[int, int](context.Background(), random.NewCache[int, *Item[int]](2), time.Minute)
+	assert.NotNil(t, cache)
+	assert.NoError(t, cache.Set(context.Background(), 1, 1, WithExpiration(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+	_, err := cache.Get(context.Background(), 1)
+	assert.Equal(t, cacheError.ErrKeyExpired, err)
+	assert.ErrorIs(t, err, cacheError.ErrNoKey)
+}
+
+func TestCache_BloomOfKeys(t *testing.T) {
+	cache := NewSimpleCache[int, int](context.Background(), 0, time.Minute)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, cache.Set(context.Background(), i, i))
+	}
+
+	data, err := cache.BloomOfKeys(0.01)
+	require.NoError(t, err)
+	var f bloom.Filter
+	require.NoError(t, f.UnmarshalBinary(data))
+	for i := 0; i < 100; i++ {
+		assert.True(t, f.Test(bloom.Key(i)))
+	}
+
+	_, err = cache.BloomOfKeys(0)
+	assert.Equal(t, bloom.ErrInvalidRate, err)
+}
+
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 0, time.Minute)
+	require.NoError(t, c.Set(ctx, "forever", 1))
+	require.NoError(t, c.Set(ctx, "minute", 1, WithExpiration(time.Minute)))
+	require.NoError(t, c.Set(ctx, "expired", 1, WithExpiration(-time.Minute)))
+
+	testCases := []struct {
+		name string
+		key  string
+
+		want    time.Duration
+		wantErr error
+	}{
+		{name: "no expiration", key: "forever", want: NoExpiration},
+		{name: "with expiration", key: "minute", want: time.Minute},
+		{name: "expired", key: "expired", wantErr: cacheError.ErrNoKey},
+		{name: "missing", key: "missing", wantErr: cacheError.ErrNoKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := c.TTL(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.InDelta(t, tc.want, got, float64(time.Second))
+		})
+	}
+}
+
+func TestCache_EntryInfo(t *testing.T) {
+	ctx := context.Background()
+	cache := NewSimpleCache[int, int](ctx, 0, time.Minute)
+	meta := map[string]string{"trace_id": "abc"}
+	require.NoError(t, cache.Set(ctx, 1, 1, WithMeta(meta)))
+	require.NoError(t, cache.Set(ctx, 2, 2, WithExpiration(time.Hour)))
+	require.NoError(t, cache.Set(ctx, 3, 3, WithExpiration(-time.Second)))
+	// 修改传入的 map 不影响已缓存的元数据
+	meta["trace_id"] = "changed"
+
+	testCases := []struct {
+		name string
+		key  int
+
+		wantMeta       map[string]string
+		wantExpiration bool
+		wantErr        error
+	}{
+		{
+			name:     "with meta",
+			key:      1,
+			wantMeta: map[string]string{"trace_id": "abc"},
+		},
+		{
+			name:           "with expiration",
+			key:            2,
+			wantExpiration: true,
+		},
+		{
+			name:    "expired",
+			key:     3,
+			wantErr: cacheError.ErrNoKey,
+		},
+		{
+			name:    "missing",
+			key:     4,
+			wantErr: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := cache.EntryInfo(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantMeta, info.Meta)
+			assert.Equal(t, tc.wantExpiration, !info.Expiration.IsZero())
+		})
+	}
+}
+
+func TestCache_unlock(t *testing.T) {
+	cache := NewSimpleCache[int, int](context.Background(), 0, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.mutex.Lock()
+		// 回调在释放锁之后执行，再次调用 Set 不会死锁
+		cache.enqueue(func() {
+			assert.NoError(t, cache.Set(context.Background(), 1, 1))
+		})
+		cache.unlock()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deadlock")
+	}
+	got, err := cache.Get(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.Nil(t, cache.pending)
+}
+
+func TestWithOnEvicted(t *testing.T) {
+	evicted := make(map[int]int)
+	var cache *Cache[int, int]
+	cache = NewLruCache[int, int](context.Background(), 2, time.Minute, WithOnEvicted[int, int](func(key int, value int) {
+		evicted[key] = value
+		// 回调在锁外执行，可以再次访问缓存
+		_, err := cache.Get(context.Background(), key)
+		assert.Equal(t, cacheError.ErrNoKey, err)
+	}))
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, cache.Set(context.Background(), i, i*10))
+	}
+	assert.NoError(t, cache.Delete(context.Background(), 4))
+	assert.Equal(t, map[int]int{1: 10, 2: 20}, evicted)
+}
+
+func allocBackends() map[string]*Cache[int, int] {
+	ctx := context.Background()
+	return map[string]*Cache[int, int]{
+		"simple": NewSimpleCache[int, int](ctx, 0, time.Minute),
+		"lru":    NewLruCache[int, int](ctx, 10, time.Minute),
+		"fifo":   NewFifoCache[int, int](ctx, 10, time.Minute),
+		"slru":   New[int, int](ctx, slru.NewCache[int, *Item[int]](10, 0.5), time.Minute),
+		"random": New[int, int](ctx, random.NewCache[int, *Item[int]](10), time.Minute),
+	}
+}
+
+func TestCache_KeysIn(t *testing.T) {
+	ctx := context.Background()
+	backends := allocBackends()
+	backends["concurrent"] = New[int, int](ctx, simple.NewConcurrentCache[int, *Item[int]](), time.Minute)
+	backends["generational"] = New[int, int](ctx, generational.NewCache[int, *Item[int]](time.Hour), time.Minute)
+
+	// 写入 1、2、3，读取 1，再覆盖写入 2
+	want := map[types.Order]map[string][]int{
+		types.OrderInsertion: {"fifo": {1, 3, 2}},
+		types.OrderRecency:   {"lru": {3, 1, 2}},
+	}
+	for name, c := range backends {
+		t.Run(name, func(t *testing.T) {
+			for i := 1; i <= 3; i++ {
+				require.NoError(t, c.Set(ctx, i, i))
+			}
+			_, err := c.Get(ctx, 1)
+			require.NoError(t, err)
+			require.NoError(t, c.Set(ctx, 2, 2))
+
+			keys, err := c.KeysIn(types.OrderAny)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []int{1, 2, 3}, keys)
+			for _, order := range []types.Order{types.OrderInsertion, types.OrderRecency} {
+				keys, err := c.KeysIn(order)
+				wantKeys, ok := want[order][name]
+				if !ok {
+					assert.ErrorIs(t, err, cacheError.ErrUnsupportedOrder, "order %d", order)
+					assert.Nil(t, keys)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, wantKeys, keys, "order %d", order)
+			}
+		})
+	}
+}
+
+func TestCache_Get_Allocs(t *testing.T) {
+	ctx := context.Background()
+	for name, cache := range allocBackends() {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, cache.Set(ctx, 1, 1, WithExpiration(time.Hour)))
+			require.NoError(t, cache.Set(ctx, 2, 2))
+			assert.Zero(t, testing.AllocsPerRun(100, func() {
+				_, _ = cache.Get(ctx, 1)
+			}), "hit with expiration")
+			assert.Zero(t, testing.AllocsPerRun(100, func() {
+				_, _ = cache.Get(ctx, 2)
+			}), "hit without expiration")
+			assert.Zero(t, testing.AllocsPerRun(100, func() {
+				_, _ = cache.Get(ctx, 3)
+			}), "miss")
+			for i := 0; i < 10; i++ {
+				require.NoError(t, cache.Set(ctx, i, i))
+				_, _ = cache.Get(ctx, i)
+			}
+			i := 0
+			assert.Zero(t, testing.AllocsPerRun(100, func() {
+				_, _ = cache.Get(ctx, i%10)
+				i++
+			}), "hits across keys")
+		})
+	}
+}
+
+func BenchmarkCache_Get(b *testing.B) {
+	ctx := context.Background()
+	for name, cache := range allocBackends() {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < 10; i++ {
+				_ = cache.Set(ctx, i, i, WithExpiration(time.Hour))
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = cache.Get(ctx, i%10)
+			}
+		})
+	}
+}
+
+func TestWithOnExpired(t *testing.T) {
+	expired := make(map[int]int)
+	cache := NewSimpleCache[int, int](context.Background(), 0, time.Minute, WithOnExpired[int, int](func(key int, value int) {
+		expired[key] = value
+	}))
+	assert.NoError(t, cache.Set(context.Background(), 1, 10, WithExpiration(time.Millisecond)))
+	assert.NoError(t, cache.Set(context.Background(), 2, 20))
+	time.Sleep(5 * time.Millisecond)
+
+	cache.DeleteExpired(context.Background())
+	assert.Equal(t, map[int]int{1: 10}, expired)
+	assert.Equal(t, []int{2}, cache.Keys())
+}
+
+func TestWithOnDeleted(t *testing.T) {
+	ctx := context.Background()
+	var deleted []int
+	cache := NewLruCache[int, int](ctx, 3, time.Minute, WithOnDeleted[int, int](func(key int) {
+		deleted = append(deleted, key)
+	}))
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, cache.Set(ctx, i, i))
+	}
+	require.NoError(t, cache.Delete(ctx, 3))
+	assert.ErrorIs(t, cache.Delete(ctx, 3), cacheError.ErrNoKey)
+	_, err := cache.MDelete(ctx, 4, 6)
+	require.NoError(t, err)
+	// 淘汰的 1、2 和 Clear 删除的 5 不会触发回调
+	_, err = cache.Clear(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, deleted)
+}
+
+func TestCache_SetMulti(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name    string
+		cache   func(t *testing.T) *Cache[int, int]
+		entries []Entry[int, int]
+
+		wantValues     map[int]int
+		wantExpiration map[int]bool
+		wantErr        error
+	}{
+		{
+			name: "empty",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return NewSimpleCache[int, int](ctx, 0, time.Minute)
+			},
+			wantValues:     map[int]int{},
+			wantExpiration: map[int]bool{},
+		},
+		{
+			name: "per-entry options",
+			cache: func(t *testing.T) *Cache[int, int] {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 0, WithExpiration(time.Minute)))
+				return c
+			},
+			entries: []Entry[int, int]{
+				{Key: 1, Value: 1},
+				{Key: 2, Value: 2, Opts: []ItemOption{WithExpiration(time.Minute)}},
+				{Key: 3, Value: 3, Opts: []ItemOption{WithMeta(map[string]string{"a": "b"})}},
+			},
+			wantValues:     map[int]int{1: 1, 2: 2, 3: 3},
+			wantExpiration: map[int]bool{1: false, 2: true, 3: false},
+		},
+		{
+			name: "duplicate keys keep the last entry",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return NewSimpleCache[int, int](ctx, 0, time.Minute)
+			},
+			entries: []Entry[int, int]{
+				{Key: 1, Value: 1},
+				{Key: 1, Value: 2},
+			},
+			wantValues:     map[int]int{1: 2},
+			wantExpiration: map[int]bool{1: false},
+		},
+		{
+			name: "write-through store error",
+			cache: func(t *testing.T) *Cache[int, int] {
+				s := newMemStore()
+				s.setFail(true)
+				return NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough))
+			},
+			entries: []Entry[int, int]{
+				{Key: 1, Value: 1},
+			},
+			wantValues:     map[int]int{},
+			wantExpiration: map[int]bool{},
+			wantErr:        errStore,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache(t)
+			err := c.SetMulti(ctx, tc.entries)
+			assert.Equal(t, tc.wantErr, err)
+			values := make(map[int]int)
+			expiration := make(map[int]bool)
+			for _, key := range c.Keys() {
+				v, err := c.Get(ctx, key)
+				require.NoError(t, err)
+				values[key] = v
+				info, err := c.EntryInfo(ctx, key)
+				require.NoError(t, err)
+				expiration[key] = !info.Expiration.IsZero()
+			}
+			assert.Equal(t, tc.wantValues, values)
+			assert.Equal(t, tc.wantExpiration, expiration)
+		})
+	}
+}
+
+func TestCache_MSet(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name    string
+		cache   func(t *testing.T) *Cache[int, int]
+		entries map[int]int
+		opts    []ItemOption
+
+		wantValues     map[int]int
+		wantExpiration bool
+		wantErr        error
+	}{
+		{
+			name: "without options",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return NewSimpleCache[int, int](ctx, 0, time.Minute)
+			},
+			entries:    map[int]int{1: 1, 2: 2},
+			wantValues: map[int]int{1: 1, 2: 2},
+		},
+		{
+			name: "shared expiration overwrites existing entries",
+			cache: func(t *testing.T) *Cache[int, int] {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 0))
+				return c
+			},
+			entries:        map[int]int{1: 1, 2: 2},
+			opts:           []ItemOption{WithExpiration(time.Minute)},
+			wantValues:     map[int]int{1: 1, 2: 2},
+			wantExpiration: true,
+		},
+		{
+			name: "write-through store error",
+			cache: func(t *testing.T) *Cache[int, int] {
+				s := newMemStore()
+				s.setFail(true)
+				return NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough))
+			},
+			entries:    map[int]int{1: 1},
+			wantValues: map[int]int{},
+			wantErr:    errStore,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache(t)
+			assert.Equal(t, tc.wantErr, c.MSet(ctx, tc.entries, tc.opts...))
+			values, err := c.MGet(ctx, c.Keys()...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantValues, values)
+			for key := range values {
+				info, err := c.EntryInfo(ctx, key)
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantExpiration, !info.Expiration.IsZero())
+			}
+		})
+	}
+}
+
+func TestCache_MDelete(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) *Cache[int, int]
+		keys  []int
+
+		wantDeleted int
+		wantErr     error
+		wantKeys    []int
+	}{
+		{
+			name: "existing and missing keys",
+			cache: func(t *testing.T) *Cache[int, int] {
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute)
+				require.NoError(t, c.MSet(ctx, map[int]int{1: 1, 2: 2, 3: 3}))
+				return c
+			},
+			keys:        []int{1, 2, 4, 1},
+			wantDeleted: 2,
+			wantKeys:    []int{3},
+		},
+		{
+			name: "write-through batch store",
+			cache: func(t *testing.T) *Cache[int, int] {
+				s := &memBatchStore{memStore: newMemStore()}
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough))
+				require.NoError(t, c.MSet(ctx, map[int]int{1: 1, 2: 2}))
+				return c
+			},
+			keys:        []int{1, 3},
+			wantDeleted: 1,
+			wantKeys:    []int{2},
+		},
+		{
+			name: "write-through store error",
+			cache: func(t *testing.T) *Cache[int, int] {
+				s := newMemStore()
+				c := NewSimpleCache[int, int](ctx, 0, time.Minute, WithStore[int, int](s, WriteThrough))
+				require.NoError(t, c.Set(ctx, 1, 1))
+				s.setFail(true)
+				return c
+			},
+			keys:     []int{1},
+			wantErr:  errStore,
+			wantKeys: []int{1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache(t)
+			deleted, err := c.MDelete(ctx, tc.keys...)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantDeleted, deleted)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+			assert.Equal(t, uint64(tc.wantDeleted), c.Stats().Deletes)
+		})
+	}
+}
+
+func TestCache_MGet(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) *Cache[int, int]
+		keys  []int
+
+		want      map[int]int
+		wantStats Stats
+	}{
+		{
+			name: "no keys",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return NewSimpleCache[int, int](ctx, 0, time.Minute)
+			},
+			want: map[int]int{},
+		},
+		{
+			name: "hits, misses and expired keys",
+			cache: func(t *testing.T) *Cache[int, int] {
+				c := NewLruCache[int, int](ctx, 10, time.Minute)
+				require.NoError(t, c.Set(ctx, 1, 1))
+				require.NoError(t, c.Set(ctx, 2, 2, WithExpiration(time.Minute)))
+				require.NoError(t, c.Set(ctx, 3, 3, WithExpiration(-time.Minute)))
+				return c
+			},
+			keys:      []int{1, 2, 3, 4, 1},
+			want:      map[int]int{1: 1, 2: 2},
+			wantStats: Stats{Hits: 3, Misses: 2, Sets: 3},
+		},
+		{
+			name: "loader is not called",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return NewSimpleCache[int, int](ctx, 0, time.Minute, WithLoader[int, int](LoaderFunc[int, int](func(ctx context.Context, key int) (int, error) {
+					t.Fatal("unexpected load")
+					return 0, nil
+				}), 0))
+			},
+			keys:      []int{1},
+			want:      map[int]int{},
+			wantStats: Stats{Misses: 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache(t)
+			got, err := c.MGet(ctx, tc.keys...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantStats, c.Stats())
+		})
+	}
+}
+
+func TestCache_Expire(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		key  string
+		ttl  time.Duration
+
+		wantErr error
+		wantTTL time.Duration
+	}{
+		{name: "extend", key: "k", ttl: time.Hour, wantTTL: time.Hour},
+		{name: "add expiration", key: "forever", ttl: time.Minute, wantTTL: time.Minute},
+		{name: "expire immediately", key: "k", ttl: 0},
+		{name: "missing key", key: "missing", ttl: time.Minute, wantErr: cacheError.ErrNoKey},
+		{name: "expired key", key: "expired", ttl: time.Minute, wantErr: cacheError.ErrNoKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSimpleCache[string, int](ctx, 0, time.Minute)
+			require.NoError(t, c.Set(ctx, "k", 1, WithExpiration(time.Second)))
+			require.NoError(t, c.Set(ctx, "forever", 2))
+			require.NoError(t, c.Set(ctx, "expired", 3, WithExpiration(-time.Second)))
+
+			assert.Equal(t, tc.wantErr, c.Expire(ctx, tc.key, tc.ttl))
+			if tc.wantErr != nil {
+				return
+			}
+			ttl, err := c.TTL(ctx, tc.key)
+			if tc.wantTTL == 0 {
+				assert.Equal(t, cacheError.ErrNoKey, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tc.wantTTL, ttl, float64(time.Second))
+		})
+	}
+}
+
+func TestCache_Touch(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 0, time.Minute)
+	require.NoError(t, c.Set(ctx, "k", 1, WithExpiration(time.Hour)))
+	require.NoError(t, c.Expire(ctx, "k", time.Minute))
+	require.NoError(t, c.Set(ctx, "forever", 2))
+	require.NoError(t, c.Set(ctx, "expired", 3, WithExpiration(-time.Second)))
+
+	testCases := []struct {
+		name string
+		key  string
+
+		wantTTL time.Duration
+		wantErr error
+	}{
+		// 按最近一次设置的时长推迟
+		{name: "touch", key: "k", wantTTL: time.Minute},
+		{name: "never expired", key: "forever", wantTTL: NoExpiration},
+		{name: "expired key", key: "expired", wantErr: cacheError.ErrNoKey},
+		{name: "missing key", key: "missing", wantErr: cacheError.ErrNoKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := c.Touch(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			ttl, err := c.TTL(ctx, tc.key)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.wantTTL, ttl, float64(time.Second))
+		})
+	}
+}
+
+func TestCache_SlidingExpiration(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache *Cache[string, int]
+	}{
+		{name: "shared reads", cache: NewSimpleCache[string, int](ctx, 0, time.Minute)},
+		{name: "exclusive reads", cache: NewLruCache[string, int](ctx, 10, time.Minute)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache
+			require.NoError(t, c.Set(ctx, "session", 1, WithSlidingExpiration(100*time.Millisecond)))
+			require.NoError(t, c.Set(ctx, "fixed", 2, WithExpiration(100*time.Millisecond)))
+			// 持续访问时 session 的存活时间超过了最初的过期时间
+			for i := 0; i < 4; i++ {
+				time.Sleep(40 * time.Millisecond)
+				got, err := c.Get(ctx, "session")
+				require.NoError(t, err)
+				assert.Equal(t, 1, got)
+			}
+			_, err := c.Get(ctx, "fixed")
+			assert.Equal(t, cacheError.ErrKeyExpired, err)
+
+			// 空闲超过滑动窗口后过期
+			time.Sleep(150 * time.Millisecond)
+			_, err = c.Get(ctx, "session")
+			assert.Equal(t, cacheError.ErrKeyExpired, err)
+		})
+	}
+}
+
+func TestWithMaxIdle(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache *Cache[string, int]
+		opts  []ItemOption
+
+		wantTTL      time.Duration
+		wantDeadline time.Duration
+	}{
+		{
+			name:    "idle only",
+			cache:   NewSimpleCache[string, int](ctx, 0, 0),
+			opts:    []ItemOption{WithMaxIdle(time.Minute)},
+			wantTTL: time.Minute,
+		},
+		{
+			name:         "capped by expiration",
+			cache:        NewSimpleCache[string, int](ctx, 0, 0),
+			opts:         []ItemOption{WithMaxIdle(time.Minute), WithExpiration(30 * time.Second)},
+			wantTTL:      30 * time.Second,
+			wantDeadline: 30 * time.Second,
+		},
+		{
+			name:         "default expiration as deadline",
+			cache:        NewSimpleCache[string, int](ctx, 0, 0, WithDefaultExpiration[string, int](time.Hour)),
+			opts:         []ItemOption{WithMaxIdle(time.Minute)},
+			wantTTL:      time.Minute,
+			wantDeadline: time.Hour,
+		},
+		{
+			name:    "without expiration",
+			cache:   NewSimpleCache[string, int](ctx, 0, 0, WithDefaultExpiration[string, int](time.Hour)),
+			opts:    []ItemOption{WithMaxIdle(time.Minute), WithoutExpiration()},
+			wantTTL: NoExpiration,
+		},
+		{
+			name:    "non-positive idle is ignored",
+			cache:   NewSimpleCache[string, int](ctx, 0, 0),
+			opts:    []ItemOption{WithMaxIdle(0)},
+			wantTTL: NoExpiration,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.cache.Set(ctx, "session", 1, tc.opts...))
+			ttl, err := tc.cache.TTL(ctx, "session")
+			require.NoError(t, err)
+			assert.InDelta(t, tc.wantTTL, ttl, float64(time.Second))
+			deadline := tc.cache.Items(ctx)[0].Deadline
+			if tc.wantDeadline == 0 {
+				assert.True(t, deadline.IsZero())
+			} else {
+				assert.WithinDuration(t, time.Now().Add(tc.wantDeadline), deadline, time.Second)
+			}
+		})
+	}
+}
+
+func TestWithMaxIdle_expire(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 10, 0)
+	require.NoError(t, c.Set(ctx, "active", 1, WithMaxIdle(100*time.Millisecond), WithExpiration(250*time.Millisecond)))
+	require.NoError(t, c.Set(ctx, "idle", 2, WithMaxIdle(100*time.Millisecond)))
+	// 持续访问时 active 的存活时间超过了空闲时间
+	for i := 0; i < 4; i++ {
+		time.Sleep(40 * time.Millisecond)
+		_, err := c.Get(ctx, "active")
+		require.NoError(t, err)
+	}
+	_, err := c.Get(ctx, "idle")
+	assert.Equal(t, cacheError.ErrKeyExpired, err)
+
+	// 持续访问也不会超过绝对过期时间
+	for i := 0; i < 3; i++ {
+		time.Sleep(40 * time.Millisecond)
+		_, err = c.Get(ctx, "active")
+	}
+	assert.Equal(t, cacheError.ErrKeyExpired, err)
+}
+
+func TestWithMaxIdle_SaveTo(t *testing.T) {
+	ctx := context.Background()
+	src := NewSimpleCache[string, int](ctx, 0, 0)
+	require.NoError(t, src.Set(ctx, "session", 1, WithMaxIdle(time.Minute), WithExpiration(time.Hour)))
+	var buf bytes.Buffer
+	require.NoError(t, src.SaveTo(&buf))
+
+	dst := NewSimpleCache[string, int](ctx, 0, 0)
+	require.NoError(t, dst.LoadFrom(&buf))
+	item := dst.Items(ctx)[0]
+	assert.True(t, item.Sliding)
+	assert.Equal(t, time.Minute, item.TTL)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), item.Deadline, time.Second)
+}
+
+func TestCache_Contains(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 3, 0)
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.Set(ctx, "b", 2))
+	require.NoError(t, c.Set(ctx, "expired", 3, WithExpiration(-time.Second)))
+
+	testCases := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{name: "present", key: "a", want: true},
+		{name: "expired", key: "expired"},
+		{name: "missing", key: "missing"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, c.Contains(ctx, tc.key))
+		})
+	}
+	assert.Equal(t, Stats{Sets: 3}, c.Stats())
+	// Contains 没有改变访问顺序，a 仍然最先被淘汰
+	require.NoError(t, c.Delete(ctx, "expired"))
+	require.NoError(t, c.Set(ctx, "c", 3))
+	require.NoError(t, c.Set(ctx, "d", 4))
+	assert.False(t, c.Contains(ctx, "a"))
+}
+
+func TestCache_Peek(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 3, 0, WithLoader[string, int](LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		return 0, errors.New("unexpected load")
+	}), 0))
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.Set(ctx, "b", 2, WithSlidingExpiration(time.Hour)))
+	require.NoError(t, c.Set(ctx, "expired", 3, WithExpiration(-time.Second)))
+	require.NoError(t, c.Expire(ctx, "b", time.Minute))
+
+	testCases := []struct {
+		name string
+		key  string
+
+		want    int
+		wantErr error
+	}{
+		{name: "present", key: "a", want: 1},
+		{name: "sliding", key: "b", want: 2},
+		{name: "expired", key: "expired", wantErr: cacheError.ErrNoKey},
+		{name: "missing without loading", key: "missing", wantErr: cacheError.ErrNoKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := c.Peek(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	assert.Equal(t, Stats{Sets: 3}, c.Stats())
+	// Peek 没有延长滑动过期时间
+	ttl, err := c.TTL(ctx, "b")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	// Peek 没有改变访问顺序，a 仍然最先被淘汰
+	require.NoError(t, c.Delete(ctx, "expired"))
+	require.NoError(t, c.Set(ctx, "c", 3))
+	require.NoError(t, c.Set(ctx, "d", 4))
+	assert.False(t, c.Contains(ctx, "a"))
+}
+
+func TestCache_Len(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache *Cache[int, int]
+	}{
+		{name: "simple", cache: NewSimpleCache[int, int](ctx, 0, 0)},
+		{name: "lru", cache: NewLruCache[int, int](ctx, 10, 0)},
+		// 后端没有实现 types.Lener 时通过 Keys 计数
+		{name: "without lener", cache: New[int, int](ctx, panicCache[int, *Item[int]]{Cache: simple.NewCache[int, *Item[int]](0)}, 0)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache
+			for i := 0; i < 5; i++ {
+				require.NoError(t, c.Set(ctx, i, i))
+			}
+			require.NoError(t, c.Set(ctx, 5, 5, WithExpiration(-time.Second)))
+			require.NoError(t, c.Set(ctx, 6, 6, WithExpiration(time.Hour)))
+			assert.Equal(t, 7, c.Len())
+			assert.Equal(t, len(c.Keys()), c.Len())
+		})
+	}
+}
+
+func TestCache_CountValid(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[int, int](ctx, 10, 0)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.Set(ctx, i, i))
+	}
+	require.NoError(t, c.Set(ctx, 3, 3, WithExpiration(time.Millisecond)))
+	require.NoError(t, c.Set(ctx, 4, 4, WithExpiration(time.Millisecond)))
+	require.NoError(t, c.Set(ctx, 5, 5, WithExpiration(time.Millisecond)))
+	require.NoError(t, c.Set(ctx, 6, 6, WithExpiration(time.Hour)))
+	// 5 仍在过期堆中，但已经不会过期
+	_, err := c.Persist(ctx, 5)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, 7, c.Len())
+	assert.Equal(t, 5, c.CountValid(ctx))
+	c.DeleteExpired(ctx)
+	assert.Equal(t, 5, c.Len())
+	assert.Equal(t, 5, c.CountValid(ctx))
+}
+
+// 清理和查询元素信息的操作不会改变淘汰顺序
+func TestCache_inspectKeepsEvictionOrder(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache *Cache[string, int]
+		// key 为被查询的键，也是最先被淘汰的键
+		key string
+	}{
+		{name: "lru", cache: NewLruCache[string, int](ctx, 3, 0), key: "x"},
+		// 查询试用段的元素不会将其晋升到受保护段
+		{name: "slru", cache: New[string, int](ctx, slru.NewCache[string, *Item[int]](3, 0.34), 0), key: "a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache
+			require.NoError(t, c.Set(ctx, "x", 0, WithExpiration(time.Millisecond)))
+			// x 仍在过期堆中，但已经不会过期
+			_, err := c.Persist(ctx, "x")
+			require.NoError(t, err)
+			require.NoError(t, c.Set(ctx, "a", 1))
+			require.NoError(t, c.Set(ctx, "b", 2))
+			time.Sleep(5 * time.Millisecond)
+
+			assert.Equal(t, 3, c.CountValid(ctx))
+			assert.Zero(t, c.DeleteExpired(ctx))
+			_, err = c.TTL(ctx, tc.key)
+			require.NoError(t, err)
+			_, err = c.EntryInfo(ctx, tc.key)
+			require.NoError(t, err)
+
+			require.NoError(t, c.Set(ctx, "c", 3))
+			assert.False(t, c.Contains(ctx, tc.key))
+			assert.Equal(t, 3, c.Len())
+		})
+	}
+}
+
+func TestCache_Persist(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 0, time.Minute)
+	require.NoError(t, c.Set(ctx, "k", 1, WithExpiration(time.Minute)))
+	require.NoError(t, c.Set(ctx, "forever", 2))
+	require.NoError(t, c.Set(ctx, "expired", 3, WithExpiration(-time.Second)))
+
+	testCases := []struct {
+		name string
+		key  string
+
+		want    bool
+		wantErr error
+	}{
+		{name: "remove expiration", key: "k", want: true},
+		{name: "already persistent", key: "k"},
+		{name: "never expired", key: "forever"},
+		{name: "expired key", key: "expired", wantErr: cacheError.ErrNoKey},
+		{name: "missing key", key: "missing", wantErr: cacheError.ErrNoKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := c.Persist(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	ttl, err := c.TTL(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, NoExpiration, ttl)
+}
+
+func TestCache_ExpireMulti(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) *Cache[int, int]
+		keys  []int
+		ttl   time.Duration
+
+		wantN    int
+		wantErr  error
+		wantKeys []int
+	}{
+		{
+			name: "shorten ttl of existing keys",
+			cache: func(t *testing.T) *Cache[int, int] {
+				cache := NewSimpleCache[int, int](context.Background(), 0, time.Minute)
+				assert.NoError(t, cache.Set(context.Background(), 1, 1))
+				assert.NoError(t, cache.Set(context.Background(), 2, 2, WithExpiration(time.Hour)))
+				assert.NoError(t, cache.Set(context.Background(), 3, 3))
+				return cache
+			},
+			keys:     []int{1, 2, 4},
+			ttl:      time.Millisecond,
+			wantN:    2,
+			wantKeys: []int{3},
+		},
+		{
+			name: "expired keys are skipped",
+			cache: func(t *testing.T) *Cache[int, int] {
+				cache := NewSimpleCache[int, int](context.Background(), 0, time.Minute)
+				assert.NoError(t, cache.Set(context.Background(), 1, 1, WithExpiration(-time.Second)))
+				return cache
+			},
+			keys:     []int{1},
+			ttl:      time.Hour,
+			wantN:    0,
+			wantKeys: []int{},
+		},
+		{
+			name: "backend error",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return &Cache[int, int]{cache: &errorCache[int, *Item[int]]{}}
+			},
+			keys:    []int{1},
+			ttl:     time.Hour,
+			wantErr: errors.New("get error"),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := tt.cache(t)
+			n, err := cache.ExpireMulti(context.Background(), tt.keys, tt.ttl)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantN, n)
+			if err != nil {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+			cache.DeleteExpired(context.Background())
+			assert.ElementsMatch(t, tt.wantKeys, cache.Keys())
+		})
+	}
+}
+
+func TestCache_sharedReads(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, NewSimpleCache[int, int](ctx, 0, time.Minute).sharedReads)
+	assert.True(t, NewFifoCache[int, int](ctx, 1, time.Minute).sharedReads)
+	// LRU 的 Get 会调整访问顺序，必须持有写锁
+	assert.False(t, NewLruCache[int, int](ctx, 1, time.Minute).sharedReads)
+}
+
+// errAfterCtx 在 Err 被调用 n 次之后返回 context.Canceled，用于模拟遍历期间 ctx 结束
+type errAfterCtx struct {
+	context.Context
+	n int
+}
+
+func (c *errAfterCtx) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestCache_ContextCanceled(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	testCases := []struct {
+		name string
+		// op 使用已经结束的 ctx 调用缓存的方法，缓存中预先写入了 1、2，以及已经过期的 3
+		op func(c *Cache[int, int]) error
+
+		wantLen int
+	}{
+		{
+			name: "get",
+			op: func(c *Cache[int, int]) error {
+				_, err := c.Get(canceled, 1)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "set",
+			op: func(c *Cache[int, int]) error {
+				return c.Set(canceled, 4, 4)
+			},
+			wantLen: 3,
+		},
+		{
+			name: "setnx",
+			op: func(c *Cache[int, int]) error {
+				_, err := c.SetNX(canceled, 4, 4)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "delete",
+			op: func(c *Cache[int, int]) error {
+				return c.Delete(canceled, 1)
+			},
+			wantLen: 3,
+		},
+		{
+			name: "mget",
+			op: func(c *Cache[int, int]) error {
+				values, err := c.MGet(canceled, 1, 2)
+				assert.Nil(t, values)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "mget canceled while reading",
+			op: func(c *Cache[int, int]) error {
+				keys := make([]int, 2*ctxCheckInterval)
+				// 加锁前和第一次检查通过，第二次检查时 ctx 已经结束
+				values, err := c.MGet(&errAfterCtx{Context: context.Background(), n: 2}, keys...)
+				assert.Nil(t, values)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "set multi",
+			op: func(c *Cache[int, int]) error {
+				return c.SetMulti(canceled, []Entry[int, int]{{Key: 4, Value: 4}})
+			},
+			wantLen: 3,
+		},
+		{
+			name: "mdelete",
+			op: func(c *Cache[int, int]) error {
+				n, err := c.MDelete(canceled, 1, 2)
+				assert.Zero(t, n)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "expire",
+			op: func(c *Cache[int, int]) error {
+				err := c.Expire(canceled, 1, -time.Second)
+				// key 的过期时间没有被修改
+				ttl, ttlErr := c.TTL(context.Background(), 1)
+				assert.NoError(t, ttlErr)
+				assert.Equal(t, NoExpiration, ttl)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "expire multi",
+			op: func(c *Cache[int, int]) error {
+				n, err := c.ExpireMulti(canceled, []int{1, 2}, -time.Second)
+				assert.Zero(t, n)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "expire multi canceled while updating",
+			op: func(c *Cache[int, int]) error {
+				keys := make([]int, 2*ctxCheckInterval)
+				keys[0], keys[ctxCheckInterval] = 1, 2
+				// 加锁前和第一次检查通过，第二次检查时 ctx 已经结束，只更新了 1
+				n, err := c.ExpireMulti(&errAfterCtx{Context: context.Background(), n: 2}, keys, time.Minute)
+				assert.Equal(t, 1, n)
+				ttl, ttlErr := c.TTL(context.Background(), 2)
+				assert.NoError(t, ttlErr)
+				assert.Equal(t, NoExpiration, ttl)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "touch",
+			op: func(c *Cache[int, int]) error {
+				return c.Touch(canceled, 1)
+			},
+			wantLen: 3,
+		},
+		{
+			name: "persist",
+			op: func(c *Cache[int, int]) error {
+				persisted, err := c.Persist(canceled, 1)
+				assert.False(t, persisted)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "ttl",
+			op: func(c *Cache[int, int]) error {
+				ttl, err := c.TTL(canceled, 1)
+				assert.Zero(t, ttl)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "entry info",
+			op: func(c *Cache[int, int]) error {
+				info, err := c.EntryInfo(canceled, 1)
+				assert.Zero(t, info)
+				return err
+			},
+			wantLen: 3,
+		},
+		{
+			name: "delete expired",
+			op: func(c *Cache[int, int]) error {
+				assert.Zero(t, c.DeleteExpired(canceled))
+				return context.Canceled
+			},
+			wantLen: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewSimpleCache[int, int](ctx, 0, 0)
+			require.NoError(t, c.Set(ctx, 1, 1))
+			require.NoError(t, c.Set(ctx, 2, 2))
+			require.NoError(t, c.Set(ctx, 3, 3, WithExpiration(-time.Second)))
+			assert.ErrorIs(t, tc.op(c), context.Canceled)
+			assert.Equal(t, tc.wantLen, c.Len())
+		})
+	}
+}
+
+func TestCache_Clear(t *testing.T) {
+	ctx := context.Background()
+	backends := map[string]func() types.ICache[int, *Item[int]]{
+		"clearer": func() types.ICache[int, *Item[int]] {
+			return lru.NewCache[int, *Item[int]](10)
+		},
+		"keys only": func() types.ICache[int, *Item[int]] {
+			return iCacheOnly[int, *Item[int]]{ICache: simple.NewCache[int, *Item[int]](0)}
+		},
+	}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			var expired []int
+			c := New[int, int](ctx, backend(), time.Hour, WithOnExpired[int, int](func(key int, _ int) {
+				expired = append(expired, key)
+			}))
+			require.NoError(t, c.Set(ctx, 1, 1))
+			require.NoError(t, c.Set(ctx, 2, 2, WithExpiration(time.Minute)))
+			require.NoError(t, c.Set(ctx, 3, 3, WithExpiration(-time.Second)))
+
+			n, err := c.Clear(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 3, n)
+			assert.Zero(t, c.Len())
+			assert.Equal(t, uint64(3), c.Stats().Deletes)
+			// 过期堆同样被清空，不会触发过期回调
+			assert.Zero(t, c.DeleteExpired(ctx))
+			assert.Empty(t, expired)
+
+			require.NoError(t, c.Set(ctx, 1, 1))
+			v, err := c.Get(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, v)
+
+			require.NoError(t, c.Close())
+			_, err = c.Clear(ctx)
+			assert.ErrorIs(t, err, cacheError.ErrClosed)
+		})
+	}
+}
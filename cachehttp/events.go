@@ -0,0 +1,152 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/internal/glob"
+)
+
+// 事件的类型
+const (
+	EventEvicted = "evicted"
+	EventExpired = "expired"
+	EventDeleted = "deleted"
+)
+
+// subscriberBuffer 为每个订阅者缓冲的事件数量，订阅者来不及读取时丢弃多出的事件
+const subscriberBuffer = 64
+
+// Event 是推送给 /{name}/events 订阅者的一个缓存事件。
+type Event struct {
+	Type string    `json:"type"`
+	Key  string    `json:"key"`
+	Time time.Time `json:"time"`
+}
+
+// Events 把缓存的淘汰、过期和删除事件广播给所有订阅者，可以被多个协程并发使用。
+// 发布事件不会阻塞缓存：订阅者来不及读取时，多出的事件会被丢弃。
+type Events struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// NewEvents 创建没有订阅者的 Events。
+func NewEvents() *Events {
+	return &Events{subs: make(map[chan Event]struct{})}
+}
+
+// Publish 把 key 上类型为 typ 的事件发送给所有订阅者。
+func (e *Events) Publish(typ, key string) {
+	ev := Event{Type: typ, Key: key, Time: time.Now()}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// subscribe 登记一个订阅者，返回的 cancel 取消订阅。
+func (e *Events) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	e.mu.Lock()
+	e.subs[ch] = struct{}{}
+	e.mu.Unlock()
+	return ch, func() {
+		e.mu.Lock()
+		delete(e.subs, ch)
+		e.mu.Unlock()
+	}
+}
+
+// EventOptions 返回把缓存的淘汰、过期和删除事件发布到 e 的选项，键通过 fmt.Sprint 转换为字符串。
+// 返回的选项会替换 cache.WithOnEvicted、cache.WithOnExpired 和 cache.WithOnDeleted 设置的回调，
+// 需要同时使用自己的回调时，在回调中调用 e.Publish。
+func EventOptions[K comparable, V any](e *Events) []cache.Option[K, V] {
+	return []cache.Option[K, V]{
+		cache.WithOnEvicted[K, V](func(key K, _ V) { e.Publish(EventEvicted, fmt.Sprint(key)) }),
+		cache.WithOnExpired[K, V](func(key K, _ V) { e.Publish(EventExpired, fmt.Sprint(key)) }),
+		cache.WithOnDeleted[K, V](func(key K) { e.Publish(EventDeleted, fmt.Sprint(key)) }),
+	}
+}
+
+// eventSource 由 WithEvents 返回的 Inspector 实现。
+type eventSource interface {
+	events() *Events
+}
+
+type withEvents struct {
+	Inspector
+	e *Events
+}
+
+func (w *withEvents) events() *Events {
+	return w.e
+}
+
+// WithEvents 返回同时通过 /{name}/events 推送 e 中的事件的 Inspector，e 通常通过 EventOptions 连接到同一个缓存。
+func WithEvents(in Inspector, e *Events) Inspector {
+	return &withEvents{Inspector: in, e: e}
+}
+
+// streamEvents 以 Server-Sent Events 推送事件，直到客户端断开连接。参数 match 为键的 glob 模式，只推送匹配的事件。
+func (h *handler) streamEvents(w http.ResponseWriter, r *http.Request, c Inspector) {
+	src, ok := c.(eventSource)
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("events are not enabled for this cache"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+	pattern := r.URL.Query().Get("match")
+	ch, cancel := src.events().subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-ch:
+			if pattern != "" && !glob.Match(pattern, ev.Key) {
+				continue
+			}
+			b, err := json.Marshal(ev)
+			if err != nil {
+				return
+			}
+			if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
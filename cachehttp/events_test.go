@@ -0,0 +1,100 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachehttp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_events(t *testing.T) {
+	ctx := context.Background()
+	events := NewEvents()
+	sessions := cache.NewLruCache[string, int](ctx, 2, time.Hour, EventOptions[string, int](events)...)
+	defer sessions.Close()
+	plain := cache.NewSimpleCache[string, int](ctx, 10, time.Hour)
+	defer plain.Close()
+	srv := httptest.NewServer(NewHandler(map[string]Inspector{
+		"sessions": WithEvents(InspectString(sessions), events),
+		"plain":    InspectString(plain),
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/plain/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, srv.URL+"/sessions/events?match=user:*", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// 响应头返回之后订阅已经登记
+	require.NoError(t, sessions.Set(ctx, "user:1", 1))
+	require.NoError(t, sessions.Set(ctx, "job:1", 1))
+	require.NoError(t, sessions.Set(ctx, "user:2", 2, cache.WithExpiration(time.Millisecond)))
+	require.NoError(t, sessions.Delete(ctx, "job:1"))
+	time.Sleep(5 * time.Millisecond)
+	sessions.DeleteExpired(ctx)
+	require.NoError(t, sessions.Set(ctx, "user:3", 3))
+	require.NoError(t, sessions.Delete(ctx, "user:3"))
+
+	type received struct {
+		name string
+		ev   Event
+	}
+	var got []received
+	scanner := bufio.NewScanner(resp.Body)
+	var name string
+	for len(got) < 3 && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var ev Event
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev))
+			got = append(got, received{name: name, ev: ev})
+		}
+	}
+	require.Len(t, got, 3)
+	// 写入 user:2 淘汰了 user:1，job:1 的删除事件被 match 过滤
+	want := []received{
+		{name: EventEvicted, ev: Event{Type: EventEvicted, Key: "user:1"}},
+		{name: EventExpired, ev: Event{Type: EventExpired, Key: "user:2"}},
+		{name: EventDeleted, ev: Event{Type: EventDeleted, Key: "user:3"}},
+	}
+	for i := range got {
+		assert.False(t, got[i].ev.Time.IsZero())
+		got[i].ev.Time = time.Time{}
+	}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,344 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cachehttp 提供查看和管理缓存的 HTTP 端点，用于调试和运维。
+package cachehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+const (
+	// DefaultLimit 为列出键时每页的默认数量
+	DefaultLimit = 100
+	// MaxLimit 为列出键时每页的最大数量
+	MaxLimit = 1000
+)
+
+// Inspector 是与键值类型无关的缓存视图，键以字符串表示。
+type Inspector interface {
+	Stats() cache.Stats
+	Len() int
+	// Keys 返回所有的键
+	Keys() []string
+	// Peek 返回 key 对应的值，不会影响元素的淘汰顺序，键不存在时返回 cacheError.ErrNoKey
+	Peek(ctx context.Context, key string) (any, error)
+	Delete(ctx context.Context, key string) error
+	// DeleteExpired 删除所有过期的元素，返回删除的数量
+	DeleteExpired(ctx context.Context) int
+}
+
+var _ Inspector = (*inspector[string, int])(nil)
+
+type inspector[K comparable, V any] struct {
+	c        *cache.Cache[K, V]
+	parseKey func(string) (K, error)
+}
+
+// Inspect 把 c 包装为 Inspector，键通过 fmt.Sprint 转换为字符串，通过 parseKey 从请求中的字符串解析。
+func Inspect[K comparable, V any](c *cache.Cache[K, V], parseKey func(string) (K, error)) Inspector {
+	return &inspector[K, V]{c: c, parseKey: parseKey}
+}
+
+// InspectString 把键为字符串的缓存包装为 Inspector。
+func InspectString[V any](c *cache.Cache[string, V]) Inspector {
+	return Inspect(c, func(s string) (string, error) { return s, nil })
+}
+
+func (i *inspector[K, V]) Stats() cache.Stats {
+	return i.c.Stats()
+}
+
+func (i *inspector[K, V]) Len() int {
+	return i.c.Len()
+}
+
+func (i *inspector[K, V]) Keys() []string {
+	keys := i.c.Keys()
+	out := make([]string, len(keys))
+	for j, k := range keys {
+		out[j] = fmt.Sprint(k)
+	}
+	return out
+}
+
+func (i *inspector[K, V]) Peek(ctx context.Context, key string) (any, error) {
+	k, err := i.parseKey(key)
+	if err != nil {
+		return nil, &keyError{err}
+	}
+	return i.c.Peek(ctx, k)
+}
+
+func (i *inspector[K, V]) Delete(ctx context.Context, key string) error {
+	k, err := i.parseKey(key)
+	if err != nil {
+		return &keyError{err}
+	}
+	return i.c.Delete(ctx, k)
+}
+
+func (i *inspector[K, V]) DeleteExpired(ctx context.Context) int {
+	return i.c.DeleteExpired(ctx)
+}
+
+// keyError 表示请求中的键无法解析，对应 400。
+type keyError struct {
+	err error
+}
+
+func (e *keyError) Error() string {
+	return "invalid key: " + e.err.Error()
+}
+
+func (e *keyError) Unwrap() error {
+	return e.err
+}
+
+// 端点（挂载在 http.StripPrefix 之后，{name} 和 {key} 需要进行路径转义）：
+//
+//	GET    /                     所有缓存的统计信息
+//	GET    /{name}               缓存的统计信息
+//	GET    /{name}/keys          按字典序分页列出键，参数 after 为上一页的最后一个键，limit 为每页的数量
+//	GET    /{name}/keys/{key}    查看键对应的值，不会影响淘汰顺序
+//	DELETE /{name}/keys/{key}    删除键
+//	POST   /{name}/expire        删除所有过期的元素
+//	GET    /{name}/events        以 Server-Sent Events 推送淘汰、过期和删除事件，参数 match 为键的 glob 模式，
+//	                             缓存需要通过 WithEvents 包装
+//
+// 除 events 之外响应均为 JSON，缓存或键不存在时返回 404。
+
+// NewHandler 返回管理 caches 的 http.Handler，caches 的键为缓存在路径中的名称。
+// 处理函数不做任何鉴权，只应当挂载在内部端口上，例如
+// http.Handle("/debug/cache/", http.StripPrefix("/debug/cache", cachehttp.NewHandler(caches)))。
+func NewHandler(caches map[string]Inspector) http.Handler {
+	h := &handler{caches: make(map[string]Inspector, len(caches))}
+	for name, c := range caches {
+		h.caches[name] = c
+	}
+	return h
+}
+
+type handler struct {
+	caches map[string]Inspector
+}
+
+type statsResponse struct {
+	Len       int     `json:"len"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRatio  float64 `json:"hit_ratio"`
+	Sets      uint64  `json:"sets"`
+	Deletes   uint64  `json:"deletes"`
+	Evictions uint64  `json:"evictions"`
+	Expired   uint64  `json:"expired"`
+	GhostHits uint64  `json:"ghost_hits"`
+
+	Loads          uint64 `json:"loads"`
+	CoalescedLoads uint64 `json:"coalesced_loads"`
+	MaxCoalesced   uint64 `json:"max_coalesced"`
+}
+
+type keysResponse struct {
+	Keys []string `json:"keys"`
+	// Next 为下一页的 after 参数，没有下一页时为空
+	Next string `json:"next,omitempty"`
+}
+
+type valueResponse struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+type expireResponse struct {
+	Removed int `json:"removed"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts, err := splitPath(r.URL.EscapedPath())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(parts) == 0 {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		all := make(map[string]statsResponse, len(h.caches))
+		for name, c := range h.caches {
+			all[name] = stats(c)
+		}
+		writeJSON(w, http.StatusOK, all)
+		return
+	}
+	c, ok := h.caches[parts[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("cache %q not found", parts[0]))
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		if allowMethod(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, stats(c))
+		}
+	case len(parts) == 2 && parts[1] == "keys":
+		if allowMethod(w, r, http.MethodGet) {
+			h.listKeys(w, r, c)
+		}
+	case len(parts) == 3 && parts[1] == "keys":
+		h.serveKey(w, r, c, parts[2])
+	case len(parts) == 2 && parts[1] == "expire":
+		if allowMethod(w, r, http.MethodPost) {
+			writeJSON(w, http.StatusOK, expireResponse{Removed: c.DeleteExpired(r.Context())})
+		}
+	case len(parts) == 2 && parts[1] == "events":
+		if allowMethod(w, r, http.MethodGet) {
+			h.streamEvents(w, r, c)
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (h *handler) listKeys(w http.ResponseWriter, r *http.Request, c Inspector) {
+	query := r.URL.Query()
+	limit := DefaultLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", s))
+			return
+		}
+		limit = min(n, MaxLimit)
+	}
+	// 按字典序分页，翻页期间写入或删除的键不会导致其他键被重复或遗漏
+	keys := c.Keys()
+	sort.Strings(keys)
+	if after := query.Get("after"); after != "" {
+		keys = keys[sort.Search(len(keys), func(i int) bool { return keys[i] > after }):]
+	}
+	resp := keysResponse{Keys: keys}
+	if len(keys) > limit {
+		resp.Keys = keys[:limit]
+		resp.Next = keys[limit-1]
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) serveKey(w http.ResponseWriter, r *http.Request, c Inspector, key string) {
+	switch r.Method {
+	case http.MethodGet:
+		v, err := c.Peek(r.Context(), key)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, valueResponse{Key: key, Value: v})
+	case http.MethodDelete:
+		if err := c.Delete(r.Context(), key); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func stats(c Inspector) statsResponse {
+	s := c.Stats()
+	return statsResponse{
+		Len:       c.Len(),
+		Hits:      s.Hits,
+		Misses:    s.Misses,
+		HitRatio:  s.HitRatio(),
+		Sets:      s.Sets,
+		Deletes:   s.Deletes,
+		Evictions: s.Evictions,
+		Expired:   s.Expired,
+		GhostHits: s.GhostHits,
+
+		Loads:          s.Loads,
+		CoalescedLoads: s.CoalescedLoads,
+		MaxCoalesced:   s.MaxCoalesced,
+	}
+}
+
+// splitPath 把转义后的路径拆分为反转义的各段，忽略首尾的斜杠。
+func splitPath(escaped string) ([]string, error) {
+	escaped = strings.Trim(escaped, "/")
+	if escaped == "" {
+		return nil, nil
+	}
+	parts := strings.Split(escaped, "/")
+	for i, p := range parts {
+		s, err := url.PathUnescape(p)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = s
+	}
+	return parts, nil
+}
+
+func statusOf(err error) int {
+	var ke *keyError
+	switch {
+	case errors.As(err, &ke):
+		return http.StatusBadRequest
+	case errors.Is(err, cacheError.ErrNoKey):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(b, '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	b, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(b, '\n'))
+}
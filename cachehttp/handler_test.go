@@ -0,0 +1,199 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachehttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandler(t *testing.T) (http.Handler, *cache.Cache[string, string], *cache.Cache[int, int]) {
+	ctx := context.Background()
+	users := cache.NewSimpleCache[string, string](ctx, 10, time.Hour)
+	require.NoError(t, users.Set(ctx, "a", "alice"))
+	require.NoError(t, users.Set(ctx, "b/c", "bob"))
+	require.NoError(t, users.Set(ctx, "d", "dave"))
+	require.NoError(t, users.Set(ctx, "e", "eve", cache.WithExpiration(time.Millisecond)))
+	_, _ = users.Get(ctx, "a")
+
+	ids := cache.NewSimpleCache[int, int](ctx, 10, time.Hour)
+	require.NoError(t, ids.Set(ctx, 1, 100))
+	t.Cleanup(func() {
+		_ = users.Close()
+		_ = ids.Close()
+	})
+	return NewHandler(map[string]Inspector{
+		"users": InspectString(users),
+		"ids":   Inspect(ids, strconv.Atoi),
+	}), users, ids
+}
+
+func TestHandler(t *testing.T) {
+	h, users, _ := newTestHandler(t)
+	time.Sleep(2 * time.Millisecond)
+
+	testCases := []struct {
+		name   string
+		method string
+		target string
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "all stats",
+			method:   http.MethodGet,
+			target:   "/",
+			wantCode: http.StatusOK,
+			wantBody: `{"ids":{"len":1,"hits":0,"misses":0,"hit_ratio":0,"sets":1,"deletes":0,"evictions":0,"expired":0,"ghost_hits":0,"loads":0,"coalesced_loads":0,"max_coalesced":0},` +
+				`"users":{"len":4,"hits":1,"misses":0,"hit_ratio":1,"sets":4,"deletes":0,"evictions":0,"expired":0,"ghost_hits":0,"loads":0,"coalesced_loads":0,"max_coalesced":0}}`,
+		},
+		{
+			name:     "cache stats",
+			method:   http.MethodGet,
+			target:   "/ids",
+			wantCode: http.StatusOK,
+			wantBody: `{"len":1,"hits":0,"misses":0,"hit_ratio":0,"sets":1,"deletes":0,"evictions":0,"expired":0,"ghost_hits":0,"loads":0,"coalesced_loads":0,"max_coalesced":0}`,
+		},
+		{
+			name:     "unknown cache",
+			method:   http.MethodGet,
+			target:   "/orders",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"cache \"orders\" not found"}`,
+		},
+		{
+			name:     "first page",
+			method:   http.MethodGet,
+			target:   "/users/keys?limit=2",
+			wantCode: http.StatusOK,
+			wantBody: `{"keys":["a","b/c"],"next":"b/c"}`,
+		},
+		{
+			name:     "last page",
+			method:   http.MethodGet,
+			target:   "/users/keys?limit=2&after=b%2Fc",
+			wantCode: http.StatusOK,
+			wantBody: `{"keys":["d","e"]}`,
+		},
+		{
+			name:     "invalid limit",
+			method:   http.MethodGet,
+			target:   "/users/keys?limit=0",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error":"invalid limit \"0\""}`,
+		},
+		{
+			name:     "peek escaped key",
+			method:   http.MethodGet,
+			target:   "/users/keys/b%2Fc",
+			wantCode: http.StatusOK,
+			wantBody: `{"key":"b/c","value":"bob"}`,
+		},
+		{
+			name:     "peek parsed key",
+			method:   http.MethodGet,
+			target:   "/ids/keys/1",
+			wantCode: http.StatusOK,
+			wantBody: `{"key":"1","value":100}`,
+		},
+		{
+			name:     "peek missing key",
+			method:   http.MethodGet,
+			target:   "/users/keys/z",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"cache: no key in cache"}`,
+		},
+		{
+			name:     "peek invalid key",
+			method:   http.MethodGet,
+			target:   "/ids/keys/x",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error":"invalid key: strconv.Atoi: parsing \"x\": invalid syntax"}`,
+		},
+		{
+			name:     "method not allowed",
+			method:   http.MethodPost,
+			target:   "/users/keys/a",
+			wantCode: http.StatusMethodNotAllowed,
+			wantBody: `{"error":"method not allowed"}`,
+		},
+		{
+			name:     "expire requires post",
+			method:   http.MethodGet,
+			target:   "/users/expire",
+			wantCode: http.StatusMethodNotAllowed,
+			wantBody: `{"error":"method not allowed"}`,
+		},
+		{
+			name:     "unknown endpoint",
+			method:   http.MethodGet,
+			target:   "/users/values",
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"not found"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.wantBody, rec.Body.String())
+		})
+	}
+	// 查看不会影响统计信息
+	assert.Equal(t, uint64(1), users.Stats().Hits)
+}
+
+func TestHandler_mutations(t *testing.T) {
+	ctx := context.Background()
+	h, users, ids := newTestHandler(t)
+	time.Sleep(2 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/ids/keys/1", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.False(t, ids.Contains(ctx, 1))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/ids/keys/1", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users/expire", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"removed":1}`, rec.Body.String())
+	assert.ElementsMatch(t, []string{"a", "b/c", "d"}, users.Keys())
+}
+
+func TestHandler_stripPrefix(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	mux := http.NewServeMux()
+	mux.Handle("/debug/cache/", http.StripPrefix("/debug/cache", h))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/cache/users/keys/a", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"key":"a","value":"alice"}`, rec.Body.String())
+}
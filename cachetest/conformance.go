@@ -0,0 +1,404 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cachetest 提供 types.ICache 的一致性测试，第三方后端（Redis、Bolt、自定义淘汰策略等）
+// 只需要一次调用即可验证自己与本包的约定是否兼容。
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// Capacity 是一致性测试最多同时写入的键的数量，factory 创建的缓存至少要能容纳这么多键而不淘汰。
+const Capacity = 64
+
+// Option 配置一致性测试。
+type Option func(*config)
+
+type config struct {
+	concurrent bool
+}
+
+// WithConcurrency 额外验证并发安全性：多个协程同时读写缓存，需要配合 -race 运行。
+// 本仓库的内置后端本身不是并发安全的（由 cache.Cache 加锁），只有自身并发安全的后端才应该启用。
+func WithConcurrency() Option {
+	return func(c *config) {
+		c.concurrent = true
+	}
+}
+
+// RunICacheConformance 验证 factory 创建的缓存是否满足 types.ICache 的约定：
+//   - Get 和 Delete 不存在的键时返回可以被 errors.Is 识别的 cacheError.ErrNoKey，Get 同时返回零值
+//   - Set 已存在的键会覆盖旧值，Keys 中每个键只出现一次
+//   - Keys 返回当前所有的键，修改返回的切片不影响缓存
+//   - ctx 已经取消时，操作要么正常完成，要么返回包装了 ctx.Err() 的错误
+//   - 实现了 types.Evicter 时，EvictOne 删除一个已有的键并通知 SetOnEvicted 设置的回调，缓存为空时返回 false
+//   - 实现了 types.Pinner 时，被固定的键不会被 EvictOne 淘汰，但仍然可以被 Delete 删除
+//   - 实现了 types.Container 时，Contains 与 Get 是否返回 ErrNoKey 一致
+//   - 实现了 types.Clearer 时，Clear 删除所有的键，之后仍然可以正常写入
+//   - 实现了 types.Inspector 时，Stats 的 Len 与 Keys 一致，OldestEntry 返回一个已有的元素且就是 EvictOne 淘汰的元素，
+//     EvictOne 使 Evictions 加一而 Delete 不会
+//
+// 每个子测试都会调用 factory 创建新的缓存。
+func RunICacheConformance(t *testing.T, factory func(t *testing.T) types.ICache[string, string], opts ...Option) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ctx := context.Background()
+
+	t.Run("get missing key", func(t *testing.T) {
+		c := factory(t)
+		v, err := c.Get(ctx, "missing")
+		if !errors.Is(err, cacheError.ErrNoKey) {
+			t.Fatalf("Get(missing) error = %v, want ErrNoKey", err)
+		}
+		if v != "" {
+			t.Fatalf("Get(missing) = %q, want zero value", v)
+		}
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		c := factory(t)
+		for i := 0; i < Capacity; i++ {
+			mustSet(t, c, key(i), value(i))
+		}
+		for i := 0; i < Capacity; i++ {
+			if v := mustGet(t, c, key(i)); v != value(i) {
+				t.Fatalf("Get(%s) = %q, want %q", key(i), v, value(i))
+			}
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		c := factory(t)
+		mustSet(t, c, "k", "old")
+		mustSet(t, c, "k", "new")
+		if v := mustGet(t, c, "k"); v != "new" {
+			t.Fatalf("Get(k) = %q after overwrite, want %q", v, "new")
+		}
+		assertKeys(t, c, "k")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c := factory(t)
+		mustSet(t, c, "a", "1")
+		mustSet(t, c, "b", "2")
+		if err := c.Delete(ctx, "a"); err != nil {
+			t.Fatalf("Delete(a) error = %v", err)
+		}
+		if _, err := c.Get(ctx, "a"); !errors.Is(err, cacheError.ErrNoKey) {
+			t.Fatalf("Get(a) after Delete error = %v, want ErrNoKey", err)
+		}
+		if err := c.Delete(ctx, "a"); !errors.Is(err, cacheError.ErrNoKey) {
+			t.Fatalf("Delete(a) twice error = %v, want ErrNoKey", err)
+		}
+		assertKeys(t, c, "b")
+		mustSet(t, c, "a", "3")
+		if v := mustGet(t, c, "a"); v != "3" {
+			t.Fatalf("Get(a) after re-Set = %q, want %q", v, "3")
+		}
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		c := factory(t)
+		assertKeys(t, c)
+		mustSet(t, c, "a", "1")
+		mustSet(t, c, "b", "2")
+		keys := c.Keys()
+		assertKeys(t, c, "a", "b")
+		// 修改返回的切片不影响缓存
+		for i := range keys {
+			keys[i] = "changed"
+		}
+		assertKeys(t, c, "a", "b")
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		c := factory(t)
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		check := func(op string, err error) {
+			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, cacheError.ErrNoKey) {
+				t.Fatalf("%s with canceled context error = %v, want nil or context.Canceled", op, err)
+			}
+		}
+		check("Set", c.Set(canceled, "k", "v"))
+		_, err := c.Get(canceled, "k")
+		check("Get", err)
+		check("Delete", c.Delete(canceled, "k"))
+	})
+
+	t.Run("evict one", func(t *testing.T) {
+		c := factory(t)
+		e, ok := c.(types.Evicter[string])
+		if !ok {
+			t.Skip("cache does not implement types.Evicter")
+		}
+		if key, ok := e.EvictOne(); ok {
+			t.Fatalf("EvictOne() on empty cache = %q, true, want false", key)
+		}
+		var evicted []string
+		if n, ok := c.(types.EvictionNotifier[string, string]); ok {
+			n.SetOnEvicted(func(key, value string) { evicted = append(evicted, key) })
+		}
+		mustSet(t, c, "a", "1")
+		mustSet(t, c, "b", "2")
+		key, ok := e.EvictOne()
+		if !ok || (key != "a" && key != "b") {
+			t.Fatalf("EvictOne() = %q, %v, want a or b", key, ok)
+		}
+		if _, ok := c.(types.EvictionNotifier[string, string]); ok && (len(evicted) != 1 || evicted[0] != key) {
+			t.Fatalf("evicted keys = %q after EvictOne() = %q", evicted, key)
+		}
+		remaining := "a"
+		if key == "a" {
+			remaining = "b"
+		}
+		assertKeys(t, c, remaining)
+	})
+
+	t.Run("pin", func(t *testing.T) {
+		c := factory(t)
+		p, ok := c.(types.Pinner[string])
+		if !ok {
+			t.Skip("cache does not implement types.Pinner")
+		}
+		if p.Pin("missing") || p.Unpin("missing") {
+			t.Fatal("Pin(missing) or Unpin(missing) = true, want false")
+		}
+		mustSet(t, c, "a", "1")
+		mustSet(t, c, "b", "2")
+		if !p.Pin("a") {
+			t.Fatal("Pin(a) = false, want true")
+		}
+		if e, ok := c.(types.Evicter[string]); ok {
+			if key, ok := e.EvictOne(); !ok || key != "b" {
+				t.Fatalf("EvictOne() = %q, %v, want b", key, ok)
+			}
+			if key, ok := e.EvictOne(); ok {
+				t.Fatalf("EvictOne() with only pinned keys = %q, true, want false", key)
+			}
+			if !p.Unpin("a") {
+				t.Fatal("Unpin(a) = false, want true")
+			}
+			if key, ok := e.EvictOne(); !ok || key != "a" {
+				t.Fatalf("EvictOne() after Unpin = %q, %v, want a", key, ok)
+			}
+			mustSet(t, c, "a", "1")
+			p.Pin("a")
+		}
+		if err := c.Delete(ctx, "a"); err != nil {
+			t.Fatalf("Delete(pinned) error = %v", err)
+		}
+		if _, err := c.Get(ctx, "a"); !errors.Is(err, cacheError.ErrNoKey) {
+			t.Fatalf("Get(a) after Delete error = %v, want ErrNoKey", err)
+		}
+	})
+
+	t.Run("contains", func(t *testing.T) {
+		c := factory(t)
+		ct, ok := c.(types.Container[string])
+		if !ok {
+			t.Skip("cache does not implement types.Container")
+		}
+		if ct.Contains("a") {
+			t.Fatal("Contains(a) on an empty cache = true, want false")
+		}
+		mustSet(t, c, "a", "1")
+		if !ct.Contains("a") {
+			t.Fatal("Contains(a) = false, want true")
+		}
+		if err := c.Delete(ctx, "a"); err != nil {
+			t.Fatalf("Delete(a) error = %v", err)
+		}
+		if ct.Contains("a") {
+			t.Fatal("Contains(a) after Delete = true, want false")
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		c := factory(t)
+		cl, ok := c.(types.Clearer)
+		if !ok {
+			t.Skip("cache does not implement types.Clearer")
+		}
+		cl.Clear()
+		assertKeys(t, c)
+		mustSet(t, c, "a", "1")
+		mustSet(t, c, "b", "2")
+		cl.Clear()
+		assertKeys(t, c)
+		if _, err := c.Get(ctx, "a"); !errors.Is(err, cacheError.ErrNoKey) {
+			t.Fatalf("Get(a) after Clear error = %v, want ErrNoKey", err)
+		}
+		// 清空之后仍然可以正常写入
+		mustSet(t, c, "c", "3")
+		assertKeys(t, c, "c")
+	})
+
+	t.Run("inspector", func(t *testing.T) {
+		c := factory(t)
+		in, ok := c.(types.Inspector[string, string])
+		if !ok {
+			t.Skip("cache does not implement types.Inspector")
+		}
+		if in.Config().Policy == "" {
+			t.Fatal("Config().Policy is empty")
+		}
+		if s := in.Stats(); s.Len != 0 || s.Evictions != 0 {
+			t.Fatalf("Stats() on empty cache = %+v, want zero", s)
+		}
+		if key, _, ok := in.OldestEntry(); ok {
+			t.Fatalf("OldestEntry() on empty cache = %q, true, want false", key)
+		}
+		mustSet(t, c, "a", "1")
+		mustSet(t, c, "b", "2")
+		mustSet(t, c, "c", "3")
+		if err := c.Delete(ctx, "c"); err != nil {
+			t.Fatalf("Delete(c) error = %v", err)
+		}
+		if s := in.Stats(); s.Len != 2 || s.Evictions != 0 {
+			t.Fatalf("Stats() = %+v, want Len 2 and no evictions", s)
+		}
+		oldest, value, hasOldest := in.OldestEntry()
+		if hasOldest && (oldest != "a" || value != "1") && (oldest != "b" || value != "2") {
+			t.Fatalf("OldestEntry() = %q, %q, want an existing entry", oldest, value)
+		}
+		assertKeys(t, c, "a", "b")
+		e, ok := c.(types.Evicter[string])
+		if !ok {
+			return
+		}
+		if key, _ := e.EvictOne(); hasOldest && key != oldest {
+			t.Fatalf("EvictOne() = %q, want %q returned by OldestEntry", key, oldest)
+		}
+		if s := in.Stats(); s.Len != 1 || s.Evictions != 1 {
+			t.Fatalf("Stats() after EvictOne = %+v, want Len 1 and 1 eviction", s)
+		}
+	})
+
+	if cfg.concurrent {
+		t.Run("concurrent access", func(t *testing.T) {
+			c := factory(t)
+			const workers = 8
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < Capacity/workers; i++ {
+						k := key(w*Capacity/workers + i)
+						if err := c.Set(ctx, k, k); err != nil {
+							t.Errorf("Set(%s) error = %v", k, err)
+							return
+						}
+						if v, err := c.Get(ctx, k); err != nil || v != k {
+							t.Errorf("Get(%s) = %q, %v, want %q", k, v, err, k)
+							return
+						}
+						_ = c.Keys()
+						if i%2 == 0 {
+							if err := c.Delete(ctx, k); err != nil {
+								t.Errorf("Delete(%s) error = %v", k, err)
+								return
+							}
+						}
+					}
+				}(w)
+			}
+			wg.Wait()
+			if n := len(c.Keys()); n != Capacity/2 {
+				t.Fatalf("len(Keys()) = %d after concurrent access, want %d", n, Capacity/2)
+			}
+		})
+	}
+}
+
+func key(i int) string {
+	return fmt.Sprintf("key-%d", i)
+}
+
+func value(i int) string {
+	return fmt.Sprintf("value-%d", i)
+}
+
+func mustSet(t *testing.T, c types.ICache[string, string], k, v string) {
+	t.Helper()
+	if err := c.Set(context.Background(), k, v); err != nil {
+		t.Fatalf("Set(%s) error = %v", k, err)
+	}
+}
+
+func mustGet(t *testing.T, c types.ICache[string, string], k string) string {
+	t.Helper()
+	v, err := c.Get(context.Background(), k)
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", k, err)
+	}
+	return v
+}
+
+// assertKeys 断言 Keys 返回的键与 want 相同，不关心顺序；缓存实现了 types.Lener 和 types.Ranger 时同时检查 Len 和 RangeKeys。
+func assertKeys(t *testing.T, c types.ICache[string, string], want ...string) {
+	t.Helper()
+	keys := c.Keys()
+	if len(keys) != len(want) {
+		t.Fatalf("Keys() = %q, want %q in any order", keys, want)
+	}
+	seen := make(map[string]int, len(keys))
+	for _, k := range keys {
+		seen[k]++
+	}
+	for _, k := range want {
+		if seen[k] != 1 {
+			t.Fatalf("Keys() = %q, want %q in any order", keys, want)
+		}
+	}
+	if l, ok := c.(types.Lener); ok && l.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", l.Len(), len(want))
+	}
+	if r, ok := c.(types.Ranger[string]); ok {
+		var ranged []string
+		r.RangeKeys(func(key string) bool {
+			ranged = append(ranged, key)
+			return true
+		})
+		if len(ranged) != len(want) {
+			t.Fatalf("RangeKeys() = %q, want %q in any order", ranged, want)
+		}
+		for _, k := range ranged {
+			if seen[k] != 1 {
+				t.Fatalf("RangeKeys() = %q, want %q in any order", ranged, want)
+			}
+		}
+		if len(want) > 1 {
+			n := 0
+			r.RangeKeys(func(string) bool {
+				n++
+				return false
+			})
+			if n != 1 {
+				t.Fatalf("RangeKeys() called fn %d times after it returned false, want 1", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"time"
+)
+
+// DefaultScanLimit 为未通过 WithScanLimit 设置时 DeleteExpired 每次最多处理的到期键的数量。
+const DefaultScanLimit = 10000
+
+// CleanupOption 配置 WithCleanup 的过期清理行为。
+type CleanupOption func(*cleanupConfig)
+
+type cleanupConfig struct {
+	scanLimit   int
+	deleteLimit int
+	timeBudget  time.Duration
+	// jitter 和 phaseOffset 决定清理协程第一次运行之前的等待时间
+	jitter      time.Duration
+	phaseOffset time.Duration
+	aligned     bool
+}
+
+var defaultCleanupConfig = cleanupConfig{scanLimit: DefaultScanLimit}
+
+// WithCleanup 配置过期清理：限制每次 DeleteExpired 的工作量，超出限制时剩余的到期键留给下一次清理；
+// 以及清理协程的启动时间。默认每次最多处理 DefaultScanLimit 个到期键，不限制删除数量和耗时，创建后立即开始计时。
+func WithCleanup[K comparable, V any](opts ...CleanupOption) Option[K, V] {
+	return func(o *options[K, V]) {
+		cfg := defaultCleanupConfig
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		o.cleanup = &cfg
+	}
+}
+
+// WithScanLimit 设置每次最多处理的到期键的数量，n 为 0 时不限制。
+// 到期键的过期时间可能已被延长，因此处理的数量不一定等于删除的数量。
+func WithScanLimit(n int) CleanupOption {
+	return func(c *cleanupConfig) {
+		c.scanLimit = n
+	}
+}
+
+// WithDeleteLimit 设置每次最多删除的元素数量，n 为 0 时不限制。
+func WithDeleteLimit(n int) CleanupOption {
+	return func(c *cleanupConfig) {
+		c.deleteLimit = n
+	}
+}
+
+// WithTimeBudget 设置每次清理的最长耗时，d 为 0 时不限制。清理在持有写锁时进行，
+// 限制耗时可以避免一次清理大量到期键时长时间阻塞读写。
+func WithTimeBudget(d time.Duration) CleanupOption {
+	return func(c *cleanupConfig) {
+		c.timeBudget = d
+	}
+}
+
+// WithStartJitter 使清理协程在启动前额外等待 [0, max) 之间的随机时长。
+// 同时创建大量缓存时，随机的启动时间可以避免所有清理协程在同一时刻运行造成周期性的延迟尖刺。
+func WithStartJitter(max time.Duration) CleanupOption {
+	return func(c *cleanupConfig) {
+		c.jitter = max
+	}
+}
+
+// WithPhaseOffset 将清理对齐到墙上时钟：清理总是在 interval 整数倍的时刻再加上 offset 时运行，
+// offset 大于 interval 时取余数。为不同的缓存设置不同的 offset 可以让它们的清理错开进行。
+func WithPhaseOffset(offset time.Duration) CleanupOption {
+	return func(c *cleanupConfig) {
+		c.phaseOffset = offset
+		c.aligned = true
+	}
+}
+
+// startDelay 返回清理协程第一次运行之前的等待时间，now 为当前时间，int63n 用于生成随机的启动延迟。
+func (c *cleanupConfig) startDelay(now time.Time, interval time.Duration, int63n func(n int64) int64) time.Duration {
+	var delay time.Duration
+	if c.aligned && interval > 0 {
+		// 等到下一个对齐的时刻再启动计时器，之后每次清理都落在对齐的时刻上
+		next := now.Truncate(interval).Add(c.phaseOffset % interval)
+		if !next.After(now) {
+			next = next.Add(interval)
+		}
+		delay = next.Sub(now)
+	}
+	if c.jitter > 0 {
+		delay += time.Duration(int63n(int64(c.jitter)))
+	}
+	return delay
+}
+
+// done 判断本次清理是否已经用完了配额。
+func (c *cleanupConfig) done(start time.Time, scanned, removed int) bool {
+	return c.scanLimit > 0 && scanned >= c.scanLimit ||
+		c.deleteLimit > 0 && removed >= c.deleteLimit ||
+		c.timeBudget > 0 && time.Since(start) >= c.timeBudget
+}
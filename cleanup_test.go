@@ -0,0 +1,155 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCleanup(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		opts []CleanupOption
+
+		wantRemoved []int
+	}{
+		{
+			name:        "default",
+			wantRemoved: []int{8, 0},
+		},
+		{
+			// 没有过期的键也计入处理数量
+			name:        "scan limit",
+			opts:        []CleanupOption{WithScanLimit(3)},
+			wantRemoved: []int{1, 3, 3, 1, 0},
+		},
+		{
+			name:        "delete limit",
+			opts:        []CleanupOption{WithDeleteLimit(5)},
+			wantRemoved: []int{5, 3, 0},
+		},
+		{
+			name:        "time budget",
+			opts:        []CleanupOption{WithTimeBudget(time.Nanosecond)},
+			wantRemoved: []int{0},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSimpleCache[int, int](ctx, 0, 0, WithCleanup[int, int](tc.opts...))
+			for i := 0; i < 10; i++ {
+				require.NoError(t, c.Set(ctx, i, i, WithExpiration(time.Duration(i+1)*time.Millisecond)))
+			}
+			// 0 和 1 仍留在过期堆中，到期时会被处理但不会被删除
+			for i := 0; i < 2; i++ {
+				_, err := c.Persist(ctx, i)
+				require.NoError(t, err)
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			var removed []int
+			for range tc.wantRemoved {
+				removed = append(removed, c.DeleteExpired(ctx))
+			}
+			assert.Equal(t, tc.wantRemoved, removed)
+		})
+	}
+}
+
+func TestCleanupConfig_startDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 25, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		opts     []CleanupOption
+		interval time.Duration
+
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "default", interval: time.Minute},
+		{
+			name:     "phase offset",
+			opts:     []CleanupOption{WithPhaseOffset(40 * time.Second)},
+			interval: time.Minute,
+			wantMin:  15 * time.Second,
+			wantMax:  15 * time.Second,
+		},
+		{
+			// 对齐的时刻已经过去，等到下一个周期
+			name:     "phase offset passed",
+			opts:     []CleanupOption{WithPhaseOffset(10 * time.Second)},
+			interval: time.Minute,
+			wantMin:  45 * time.Second,
+			wantMax:  45 * time.Second,
+		},
+		{
+			name:     "phase offset larger than interval",
+			opts:     []CleanupOption{WithPhaseOffset(100 * time.Second)},
+			interval: time.Minute,
+			wantMin:  15 * time.Second,
+			wantMax:  15 * time.Second,
+		},
+		{
+			name:     "jitter",
+			opts:     []CleanupOption{WithStartJitter(time.Second)},
+			interval: time.Minute,
+			wantMax:  time.Second - 1,
+		},
+		{
+			name:     "phase offset with jitter",
+			opts:     []CleanupOption{WithPhaseOffset(30 * time.Second), WithStartJitter(time.Second)},
+			interval: time.Minute,
+			wantMin:  5 * time.Second,
+			wantMax:  6*time.Second - 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := defaultCleanupConfig
+			for _, opt := range tc.opts {
+				opt(&cfg)
+			}
+			for i := 0; i < 100; i++ {
+				got := cfg.startDelay(now, tc.interval, rand.Int63n)
+				assert.GreaterOrEqual(t, got, tc.wantMin)
+				assert.LessOrEqual(t, got, tc.wantMax)
+			}
+		})
+	}
+}
+
+func TestWithStartJitter_randSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newCache := func(seed int64) *Cache[int, int] {
+		c := NewSimpleCache[int, int](ctx, 0, time.Hour,
+			WithCleanup[int, int](WithStartJitter(time.Hour)),
+			WithRandSource[int, int](rand.NewSource(seed)))
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+	// 相同种子的随机数源得到相同的启动延迟
+	a, b := newCache(1), newCache(1)
+	assert.Equal(t, a.janitor.delay, b.janitor.delay)
+	assert.Equal(t, time.Duration(rand.New(rand.NewSource(1)).Int63n(int64(time.Hour))), a.janitor.delay)
+	assert.NotEqual(t, a.janitor.delay, newCache(2).janitor.delay)
+}
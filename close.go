@@ -0,0 +1,98 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// WithOnClose 添加一个在 Close 结束时执行的回调，多次设置时按添加的顺序执行。
+func WithOnClose[K comparable, V any](fn func()) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onClose = append(o.onClose, fn)
+	}
+}
+
+// Close 关闭缓存：停止清理协程和其他后台协程，最后清理一次过期元素并把回写模式下待写入的操作写入 store，
+// 配置了 WithSnapshot 或 WithWAL 时保存最后一次快照，然后释放所有元素并执行 WithOnClose 添加的回调。
+// 关闭之后读写操作返回 cacheError.ErrClosed，重复调用 Close 同样返回 cacheError.ErrClosed。
+// 返回的错误为最后一次写入 store 以及保存快照时的错误。
+func (c *Cache[K, V]) Close() error {
+	// 持有 storeMu 时设置 closed，保证写穿模式下关闭之后不会再修改 store
+	c.storeMu.Lock()
+	closed := c.closed.Swap(true)
+	c.storeMu.Unlock()
+	if closed {
+		return cacheError.ErrClosed
+	}
+	c.cancel()
+	// cancel 触发的注销是异步的，这里同步注销以便 Close 返回后不再出现在 Caches 中
+	unregister(c)
+	c.janitor.wait()
+	ctx := context.Background()
+	// 写操作持有写锁时检查 closed，DeleteExpired 获取写锁之后，不会再有写操作进入回写队列
+	c.DeleteExpired(ctx)
+	err := c.Flush(ctx)
+	if c.opts.snapshot != nil {
+		err = errors.Join(err, c.saveSnapshot(true))
+	}
+	if c.wal != nil {
+		err = errors.Join(err, c.compactWAL(true))
+	}
+
+	c.mutex.Lock()
+	c.cache = closedCache[K, *Item[V]]{}
+	c.expiry = expiryQueue[K]{}
+	if c.cost != nil {
+		c.cost = newCostTracker[K](c.cost.max)
+	}
+	for _, fn := range c.opts.onClose {
+		c.enqueue(fn)
+	}
+	c.unlock()
+	return err
+}
+
+// checkClosed 在缓存已关闭时返回 cacheError.ErrClosed。写操作在持有写锁之后调用，
+// 避免在 Close 刷新回写队列之后、替换后端之前写入；写穿模式下还要在持有 storeMu 之后、修改 store 之前调用。
+func (c *Cache[K, V]) checkClosed() error {
+	if c.closed.Load() {
+		return cacheError.ErrClosed
+	}
+	return nil
+}
+
+// closedCache 是缓存关闭之后使用的后端，所有读写都返回 cacheError.ErrClosed。
+type closedCache[K comparable, V any] struct{}
+
+func (closedCache[K, V]) Set(context.Context, K, V) error {
+	return cacheError.ErrClosed
+}
+
+func (closedCache[K, V]) Get(context.Context, K) (V, error) {
+	var zero V
+	return zero, cacheError.ErrClosed
+}
+
+func (closedCache[K, V]) Delete(context.Context, K) error {
+	return cacheError.ErrClosed
+}
+
+func (closedCache[K, V]) Keys() []K {
+	return make([]K, 0)
+}
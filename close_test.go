@@ -0,0 +1,148 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Close(t *testing.T) {
+	ctx := context.Background()
+	var events []string
+	wb := newMemStore()
+	c := NewLruCache[int, int](ctx, 10, time.Hour,
+		WithName[int, int]("close"),
+		WithStore[int, int](wb, WriteBack, WithFlushInterval(time.Hour)),
+		WithOnExpired[int, int](func(key int, value int) { events = append(events, "expired") }),
+		WithOnClose[int, int](func() { events = append(events, "hook 1") }),
+		WithOnClose[int, int](func() { events = append(events, "hook 2") }))
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Set(ctx, 2, 2, WithExpiration(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+
+	require.NoError(t, c.Close())
+	// 最后一次清理在回调之前执行，待写入的操作已经写入 store
+	assert.Equal(t, []string{"expired", "hook 1", "hook 2"}, events)
+	data, _ := wb.snapshot()
+	assert.Equal(t, map[int]int{1: 1, 2: 2}, data)
+	assert.NotContains(t, Caches(), NamedCache(c))
+	assert.Empty(t, c.Keys())
+	assert.Equal(t, cacheError.ErrClosed, c.Close())
+
+	testCases := []struct {
+		name string
+		op   func() error
+	}{
+		{name: "get", op: func() error { _, err := c.Get(ctx, 1); return err }},
+		{name: "set", op: func() error { return c.Set(ctx, 1, 1) }},
+		{name: "setnx", op: func() error { _, err := c.SetNX(ctx, 1, 1); return err }},
+		{name: "mset", op: func() error { return c.MSet(ctx, map[int]int{1: 1}) }},
+		{name: "mget", op: func() error { _, err := c.MGet(ctx, 1); return err }},
+		{name: "delete", op: func() error { return c.Delete(ctx, 1) }},
+		{name: "mdelete", op: func() error { _, err := c.MDelete(ctx, 1); return err }},
+		{name: "getorset", op: func() error { _, _, err := c.GetOrSet(ctx, 1, 1); return err }},
+		{name: "expire", op: func() error { return c.Expire(ctx, 1, time.Minute) }},
+		{name: "ttl", op: func() error { _, err := c.TTL(ctx, 1); return err }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, cacheError.ErrClosed, tc.op())
+		})
+	}
+	// 关闭之后的删除不会进入回写队列
+	require.NoError(t, c.Flush(ctx))
+	_, writes := wb.snapshot()
+	assert.Equal(t, 2, writes)
+}
+
+func TestCache_Close_writeThrough(t *testing.T) {
+	ctx := context.Background()
+	s := newMemStore()
+	c := NewSimpleCache[int, int](ctx, 0, 0, WithStore[int, int](s, WriteThrough))
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Close())
+
+	assert.Equal(t, cacheError.ErrClosed, c.Set(ctx, 2, 2))
+	assert.Equal(t, cacheError.ErrClosed, c.Delete(ctx, 1))
+	_, err := c.SetNX(ctx, 3, 3)
+	assert.Equal(t, cacheError.ErrClosed, err)
+	data, _ := s.snapshot()
+	assert.Equal(t, map[int]int{1: 1}, data)
+}
+
+// closeOps 是 Close 进行期间执行的写操作，缓存中预先写入了 1。
+func closeOps(ctx context.Context, c *Cache[int, int]) map[string]func() error {
+	return map[string]func() error{
+		"set":      func() error { return c.Set(ctx, 2, 2) },
+		"setnx":    func() error { _, err := c.SetNX(ctx, 2, 2); return err },
+		"mset":     func() error { return c.MSet(ctx, map[int]int{2: 2}) },
+		"getorset": func() error { _, _, err := c.GetOrSet(ctx, 2, 2); return err },
+		"cas":      func() error { _, err := CompareAndSwap(ctx, c, 1, 1, 2); return err },
+		"incr":     func() error { _, err := Incr(ctx, c, 1, 1); return err },
+	}
+}
+
+func TestCache_Close_writeBackRace(t *testing.T) {
+	ctx := context.Background()
+	for name := range closeOps(ctx, nil) {
+		t.Run(name, func(t *testing.T) {
+			s := &blockingStore{memStore: newMemStore(), started: make(chan struct{}), release: make(chan struct{})}
+			c := NewSimpleCache[int, int](ctx, 0, 0, WithStore[int, int](s, WriteBack, WithFlushInterval(time.Hour)))
+			require.NoError(t, c.Set(ctx, 1, 1))
+			closed := make(chan error, 1)
+			go func() { closed <- c.Close() }()
+			// Close 正在刷新回写队列，此时的写入不能进入已经不会再被刷新的队列
+			<-s.started
+			assert.Equal(t, cacheError.ErrClosed, closeOps(ctx, c)[name]())
+			close(s.release)
+			require.NoError(t, <-closed)
+			data, _ := s.snapshot()
+			assert.Equal(t, map[int]int{1: 1}, data)
+		})
+	}
+}
+
+func TestCache_Close_writeThroughRace(t *testing.T) {
+	ctx := context.Background()
+	for name := range closeOps(ctx, nil) {
+		t.Run(name, func(t *testing.T) {
+			s := newMemStore()
+			expired := make(chan struct{})
+			release := make(chan struct{})
+			c := NewSimpleCache[int, int](ctx, 0, time.Hour, WithStore[int, int](s, WriteThrough),
+				WithOnExpired[int, int](func(int, int) {
+					close(expired)
+					<-release
+				}))
+			require.NoError(t, c.Set(ctx, 1, 1))
+			require.NoError(t, c.Set(ctx, 3, 3, WithExpiration(-time.Second)))
+			closed := make(chan error, 1)
+			go func() { closed <- c.Close() }()
+			// Close 的最后一次清理正在执行回调，后端尚未被替换
+			<-expired
+			assert.Equal(t, cacheError.ErrClosed, closeOps(ctx, c)[name]())
+			close(release)
+			require.NoError(t, <-closed)
+			data, _ := s.snapshot()
+			assert.Equal(t, map[int]int{1: 1, 3: 3}, data)
+		})
+	}
+}
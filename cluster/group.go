@@ -0,0 +1,125 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// Peer 从集群中的其他节点获取键的值，HTTP 协议的实现见 HTTPPeer。
+type Peer[K comparable, V any] interface {
+	// Get 返回远程节点中 key 的值，远程节点也无法加载时返回 cacheError.ErrNoKey。
+	Get(ctx context.Context, key K) (V, error)
+}
+
+// GroupOption 配置 Group 的行为。
+type GroupOption[K comparable, V any] func(*Group[K, V])
+
+// WithHotCache 设置缓存其他节点拥有的键的本地缓存，热点键不必每次都访问拥有它的节点。
+// 其他节点上的值发生变化后，hot 中的副本直到过期才会更新，应当为其设置较短的过期时间。
+func WithHotCache[K comparable, V any](hot *cache.Cache[K, V]) GroupOption[K, V] {
+	return func(g *Group[K, V]) {
+		g.hot = hot
+	}
+}
+
+// WithPeerErrorHandler 设置访问其他节点失败时的处理函数，失败后 Group 会退化为在本地加载。
+func WithPeerErrorHandler[K comparable, V any](fn func(node NodeID, err error)) GroupOption[K, V] {
+	return func(g *Group[K, V]) {
+		g.onPeerError = fn
+	}
+}
+
+// Group 是多个进程组成的对等缓存组：每个键通过一致性哈希归属于一个节点，只由该节点调用加载器并缓存结果，
+// 其他节点通过 Peer 向它获取，因此同一个键在整个集群中只会被加载一次。
+type Group[K comparable, V any] struct {
+	self        NodeID
+	router      *Router[K]
+	peers       map[NodeID]Peer[K, V]
+	main        *cache.Cache[K, V]
+	hot         *cache.Cache[K, V]
+	loader      cache.Loader[K, V]
+	onPeerError func(node NodeID, err error)
+}
+
+// NewGroup 创建节点 self 上的对等缓存组。main 缓存本节点拥有的键，loader 在 main 未命中时加载，
+// peers 为除 self 以外所有节点的客户端。所有节点必须使用相同的 cfg。
+func NewGroup[K comparable, V any](self NodeID, cfg Config, main *cache.Cache[K, V], loader cache.Loader[K, V],
+	peers map[NodeID]Peer[K, V], opts ...GroupOption[K, V]) (*Group[K, V], error) {
+	router, err := NewRouter[K](cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(cfg.Nodes, self) {
+		return nil, fmt.Errorf("cluster: node %q is not in the config", self)
+	}
+	for _, node := range cfg.Nodes {
+		if node != self && peers[node] == nil {
+			return nil, fmt.Errorf("cluster: no peer for node %q", node)
+		}
+	}
+	g := &Group[K, V]{
+		self:   self,
+		router: router,
+		peers:  peers,
+		main:   main,
+		loader: loader,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, nil
+}
+
+// Get 返回 key 的值：本节点拥有的键从 main 读取或加载，其他键向拥有它的节点获取。
+// 拥有者不可用时退化为在本地加载，结果不会写入 main。
+func (g *Group[K, V]) Get(ctx context.Context, key K) (V, error) {
+	owner := g.router.RouteKey(key)
+	if owner == g.self {
+		return g.GetLocal(ctx, key)
+	}
+	peer := g.peers[owner]
+	fetch := func(ctx context.Context, key K) (V, error) {
+		v, err := peer.Get(ctx, key)
+		if err == nil || errors.Is(err, cacheError.ErrNoKey) {
+			return v, err
+		}
+		if g.onPeerError != nil {
+			g.onPeerError(owner, err)
+		}
+		return g.loader.Load(ctx, key)
+	}
+	if g.hot != nil {
+		return g.hot.GetOrLoad(ctx, key, fetch)
+	}
+	return fetch(ctx, key)
+}
+
+// GetLocal 从 main 读取 key，未命中时调用加载器并缓存结果，不会访问其他节点。
+// 节点的对等协议服务端应当调用 GetLocal，避免各节点配置不一致时请求在节点之间循环转发。
+func (g *Group[K, V]) GetLocal(ctx context.Context, key K) (V, error) {
+	return g.main.GetOrLoad(ctx, key, g.loader.Load)
+}
+
+// Owner 返回拥有 key 的节点。
+func (g *Group[K, V]) Owner(key K) NodeID {
+	return g.router.RouteKey(key)
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func marshalInt(v int) ([]byte, error) { return []byte(strconv.Itoa(v)), nil }
+
+func unmarshalInt(b []byte) (int, error) { return strconv.Atoi(string(b)) }
+
+// startGroups 启动 n 个通过 HTTP 对等协议组成缓存组的节点，loads 记录每个键被加载的次数。
+func startGroups(t *testing.T, n int, loads *sync.Map) []*Group[string, int] {
+	ctx := context.Background()
+	loader := cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		cnt, _ := loads.LoadOrStore(key, new(atomic.Int32))
+		cnt.(*atomic.Int32).Add(1)
+		if key == "missing" {
+			return 0, cacheError.ErrNoKey
+		}
+		return len(key), nil
+	})
+
+	groups := make([]*Group[string, int], n)
+	handlers := make([]http.Handler, n)
+	cfg := Config{}
+	for i := 0; i < n; i++ {
+		i := i
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[i].ServeHTTP(w, r)
+		}))
+		t.Cleanup(s.Close)
+		cfg.Nodes = append(cfg.Nodes, NodeID(s.URL))
+	}
+	for i, self := range cfg.Nodes {
+		peers := make(map[NodeID]Peer[string, int])
+		for _, node := range cfg.Nodes {
+			if node != self {
+				peers[node] = NewHTTPPeer(string(node), unmarshalInt)
+			}
+		}
+		g, err := NewGroup(self, cfg, cache.NewSimpleCache[string, int](ctx, 0, time.Minute), loader, peers,
+			WithHotCache(cache.NewSimpleCache[string, int](ctx, 0, time.Minute)))
+		require.NoError(t, err)
+		groups[i] = g
+		handlers[i] = NewHTTPHandler(g, marshalInt)
+	}
+	return groups
+}
+
+func TestGroup_Get(t *testing.T) {
+	var loads sync.Map
+	groups := startGroups(t, 3, &loads)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for _, g := range groups {
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(g *Group[string, int], key string) {
+				defer wg.Done()
+				got, err := g.Get(ctx, key)
+				assert.NoError(t, err)
+				assert.Equal(t, len(key), got)
+			}(g, "key-"+strconv.Itoa(i))
+		}
+	}
+	wg.Wait()
+
+	// 每个键在整个缓存组中只被它的拥有者加载一次
+	var keys int
+	loads.Range(func(key, cnt any) bool {
+		keys++
+		assert.Equal(t, int32(1), cnt.(*atomic.Int32).Load(), key)
+		return true
+	})
+	assert.Equal(t, 50, keys)
+
+	for _, g := range groups {
+		_, err := g.Get(ctx, "missing")
+		assert.Equal(t, cacheError.ErrNoKey, err)
+	}
+}
+
+func TestGroup_peerUnavailable(t *testing.T) {
+	ctx := context.Background()
+	loader := cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		return len(key), nil
+	})
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	cfg := Config{Nodes: []NodeID{"self", NodeID(down.URL)}}
+
+	var peerErrs []NodeID
+	main := cache.NewSimpleCache[string, int](ctx, 0, time.Minute)
+	g, err := NewGroup(NodeID("self"), cfg, main, loader,
+		map[NodeID]Peer[string, int]{NodeID(down.URL): NewHTTPPeer(down.URL, unmarshalInt)},
+		WithPeerErrorHandler[string, int](func(node NodeID, err error) { peerErrs = append(peerErrs, node) }))
+	require.NoError(t, err)
+
+	var key string
+	for i := 0; ; i++ {
+		if key = strconv.Itoa(i); g.Owner(key) != "self" {
+			break
+		}
+	}
+	got, err := g.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, len(key), got)
+	assert.Equal(t, []NodeID{NodeID(down.URL)}, peerErrs)
+	// 退化为本地加载的结果不写入 main
+	assert.Empty(t, main.Keys())
+}
+
+func TestNewGroup(t *testing.T) {
+	main := cache.NewSimpleCache[string, int](context.Background(), 0, time.Minute)
+	loader := cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) { return 0, nil })
+	testCases := []struct {
+		name    string
+		self    NodeID
+		cfg     Config
+		wantErr string
+	}{
+		{name: "no nodes", self: "a", wantErr: ErrNoNodes.Error()},
+		{name: "self not in config", self: "x", cfg: Config{Nodes: []NodeID{"a"}}, wantErr: `cluster: node "x" is not in the config`},
+		{name: "missing peer", self: "a", cfg: Config{Nodes: []NodeID{"a", "b"}}, wantErr: `cluster: no peer for node "b"`},
+		{name: "single node", self: "a", cfg: Config{Nodes: []NodeID{"a"}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewGroup[string, int](tc.self, tc.cfg, main, loader, nil)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestHTTPPeer_Get(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/a%2Fb":
+			_, _ = w.Write([]byte("42"))
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer s.Close()
+	p := NewHTTPPeer(s.URL+"/", unmarshalInt, WithHTTPClient(s.Client()))
+	ctx := context.Background()
+
+	got, err := p.Get(ctx, "a/b")
+	require.NoError(t, err)
+	assert.Equal(t, 42, got)
+	_, err = p.Get(ctx, "missing")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+	_, err = p.Get(ctx, "other")
+	assert.EqualError(t, err, "cluster: peer "+s.URL+" returned 500 Internal Server Error: boom")
+	assert.False(t, errors.Is(err, cacheError.ErrNoKey))
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+var _ Peer[string, int] = (*HTTPPeer[int])(nil)
+
+// HTTP 对等协议：GET {baseURL}/{转义后的键}，命中时返回 200 和序列化后的值，
+// 拥有者也无法加载时返回 404，其他错误返回 500 和错误信息。
+
+// NewHTTPHandler 返回节点的对等协议服务端，键固定为字符串，值通过 marshal 转换为字节。
+// 服务端只读取或加载本节点的数据，不会再转发给其他节点。应当挂载在 HTTPPeer 的 baseURL 对应的路径上，
+// 例如 http.Handle("/_cache/", http.StripPrefix("/_cache", handler))。
+func NewHTTPHandler[V any](g *Group[string, V], marshal func(V) ([]byte, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		key, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		v, err := g.GetLocal(r.Context(), key)
+		if err != nil {
+			if errors.Is(err, cacheError.ErrNoKey) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		b, err := marshal(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(b)
+	})
+}
+
+// HTTPOption 配置 HTTPPeer 的行为。
+type HTTPOption func(*httpConfig)
+
+type httpConfig struct {
+	client *http.Client
+}
+
+// WithHTTPClient 设置发送请求使用的客户端，默认为 http.DefaultClient。
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(c *httpConfig) {
+		c.client = client
+	}
+}
+
+// HTTPPeer 是 HTTP 对等协议的客户端，实现了 Peer。
+type HTTPPeer[V any] struct {
+	baseURL   string
+	unmarshal func([]byte) (V, error)
+	cfg       httpConfig
+}
+
+// NewHTTPPeer 创建访问 baseURL（例如 http://10.0.0.2:8080/_cache）上 NewHTTPHandler 的客户端。
+func NewHTTPPeer[V any](baseURL string, unmarshal func([]byte) (V, error), opts ...HTTPOption) *HTTPPeer[V] {
+	p := &HTTPPeer[V]{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		unmarshal: unmarshal,
+		cfg:       httpConfig{client: http.DefaultClient},
+	}
+	for _, opt := range opts {
+		opt(&p.cfg)
+	}
+	return p
+}
+
+// Get 实现了 Peer。
+func (p *HTTPPeer[V]) Get(ctx context.Context, key string) (v V, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/"+url.PathEscape(key), nil)
+	if err != nil {
+		return v, err
+	}
+	resp, err := p.cfg.client.Do(req)
+	if err != nil {
+		return v, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return v, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return p.unmarshal(body)
+	case http.StatusNotFound:
+		return v, cacheError.ErrNoKey
+	default:
+		return v, fmt.Errorf("cluster: peer %s returned %s: %s", p.baseURL, resp.Status, strings.TrimSpace(string(body)))
+	}
+}
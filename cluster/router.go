@@ -0,0 +1,89 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/chenmingyong0423/go-generics-cache/internal/keyhash"
+)
+
+// DefaultReplicas 每个节点在哈希环上的默认虚拟节点数。
+const DefaultReplicas = 128
+
+var ErrNoNodes = errors.New("cluster: no nodes configured")
+
+// NodeID 标识集群中的一个缓存实例。
+type NodeID string
+
+// Config 描述集群的分片配置，所有实例和上游代理必须使用相同的配置才能得到一致的路由结果。
+type Config struct {
+	// Nodes 集群中的所有节点
+	Nodes []NodeID
+	// Replicas 每个节点的虚拟节点数，小于等于 0 时使用 DefaultReplicas
+	Replicas int
+}
+
+// Router 基于一致性哈希计算键归属的节点，节点增减时只有少量键会改变归属。
+type Router[K comparable] struct {
+	points []uint64
+	owners map[uint64]NodeID
+}
+
+// NewRouter 根据分片配置创建路由器。
+func NewRouter[K comparable](cfg Config) (*Router[K], error) {
+	if len(cfg.Nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	replicas := cfg.Replicas
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Router[K]{
+		points: make([]uint64, 0, len(cfg.Nodes)*replicas),
+		owners: make(map[uint64]NodeID, len(cfg.Nodes)*replicas),
+	}
+	seen := make(map[NodeID]struct{}, len(cfg.Nodes))
+	for _, node := range cfg.Nodes {
+		if _, ok := seen[node]; ok {
+			return nil, fmt.Errorf("cluster: duplicate node %q", node)
+		}
+		seen[node] = struct{}{}
+		for i := 0; i < replicas; i++ {
+			point := keyhash.Sum64([]byte(strconv.Itoa(i) + "#" + string(node)))
+			if _, ok := r.owners[point]; ok {
+				// 哈希冲突时保留先加入的节点
+				continue
+			}
+			r.owners[point] = node
+			r.points = append(r.points, point)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r, nil
+}
+
+// RouteKey 返回拥有 key 的节点。
+func (r *Router[K]) RouteKey(key K) NodeID {
+	h := keyhash.Key(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
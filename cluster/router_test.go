@@ -0,0 +1,94 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRouter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name:    "no nodes",
+			cfg:     Config{},
+			wantErr: ErrNoNodes.Error(),
+		},
+		{
+			name:    "duplicate nodes",
+			cfg:     Config{Nodes: []NodeID{"a", "a"}},
+			wantErr: `cluster: duplicate node "a"`,
+		},
+		{
+			name: "default replicas",
+			cfg:  Config{Nodes: []NodeID{"a", "b"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewRouter[string](tc.cfg)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, r.points, len(tc.cfg.Nodes)*DefaultReplicas)
+		})
+	}
+}
+
+func TestRouter_RouteKey(t *testing.T) {
+	nodes := []NodeID{"a", "b", "c"}
+	r, err := NewRouter[int](Config{Nodes: nodes})
+	require.NoError(t, err)
+
+	// 相同的配置在不同的路由器上得到相同的结果
+	other, err := NewRouter[int](Config{Nodes: []NodeID{"c", "b", "a"}})
+	require.NoError(t, err)
+
+	counts := make(map[NodeID]int)
+	for i := 0; i < 3000; i++ {
+		node := r.RouteKey(i)
+		assert.Equal(t, node, other.RouteKey(i))
+		counts[node]++
+	}
+	for _, node := range nodes {
+		assert.Greater(t, counts[node], 500, "node %s is underloaded", node)
+	}
+}
+
+func TestRouter_RouteKey_AddNode(t *testing.T) {
+	before, err := NewRouter[int](Config{Nodes: []NodeID{"a", "b", "c"}})
+	require.NoError(t, err)
+	after, err := NewRouter[int](Config{Nodes: []NodeID{"a", "b", "c", "d"}})
+	require.NoError(t, err)
+
+	moved := 0
+	for i := 0; i < 3000; i++ {
+		from, to := before.RouteKey(i), after.RouteKey(i)
+		if from != to {
+			// 新增节点时，键只会迁移到新节点上
+			assert.Equal(t, NodeID("d"), to)
+			moved++
+		}
+	}
+	assert.Less(t, moved, 1500)
+}
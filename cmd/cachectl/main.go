@@ -0,0 +1,253 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cachectl 查看 SaveTo、WithSnapshot 和 WithWAL 保存的文件。
+//
+// 用法：
+//
+//	cachectl [flags] keys   <path>   每行输出一个键
+//	cachectl [flags] ttl    <path>   输出键和剩余存活时间
+//	cachectl [flags] export <path>   以 JSON 数组输出所有元素
+//
+// path 为快照文件，或者 -wal 时为 WithWAL 的目录。文件使用 gob 编码，需要通过 -key-type 和 -value-type
+// 指定与保存时兼容的类型；值为结构体等其他类型时使用默认的 -value-type none，只读取键和过期时间。
+// 输出与以相同的选项创建缓存时恢复的元素一致，已经过期的元素不会输出，cachectl 不会修改任何文件。
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "cachectl:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+type config struct {
+	command   string
+	path      string
+	wal       bool
+	keyType   string
+	valueType string
+	keyFile   string
+}
+
+// entry 是与类型无关的元素，用于输出。
+type entry struct {
+	Key       any               `json:"key"`
+	Value     any               `json:"value,omitempty"`
+	Remaining time.Duration     `json:"-"`
+	TTL       time.Duration     `json:"-"`
+	Sliding   bool              `json:"sliding,omitempty"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	Cost      int64             `json:"cost,omitempty"`
+}
+
+// exported 是 export 输出的元素，时长以毫秒表示，0 表示永不过期或没有设置。
+type exported struct {
+	entry
+	RemainingMS int64 `json:"remaining_ms,omitempty"`
+	TTLMS       int64 `json:"ttl_ms,omitempty"`
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("cachectl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var cfg config
+	fs.BoolVar(&cfg.wal, "wal", false, "path is a WithWAL directory instead of a snapshot file")
+	fs.StringVar(&cfg.keyType, "key-type", "string", "key type: string, int, uint, float or bool")
+	fs.StringVar(&cfg.valueType, "value-type", "none", "value type: none, string, int, uint, float, bool or bytes")
+	fs.StringVar(&cfg.keyFile, "encryption-key-file", "", "file holding the hex-encoded AES key for encrypted snapshots or WALs")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cachectl [flags] keys|ttl|export <path>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected a command and a path")
+	}
+	cfg.command, cfg.path = fs.Arg(0), fs.Arg(1)
+	switch cfg.command {
+	case "keys", "ttl", "export":
+	default:
+		return fmt.Errorf("unknown command %q", cfg.command)
+	}
+	if _, err := os.Stat(cfg.path); err != nil {
+		return err
+	}
+	var key []byte
+	if cfg.keyFile != "" {
+		var err error
+		if key, err = readKey(cfg.keyFile); err != nil {
+			return err
+		}
+	}
+	entries, err := readEntries(cfg, key)
+	if err != nil {
+		return err
+	}
+	return write(stdout, cfg.command, entries)
+}
+
+func readKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("read encryption key: %w", err)
+	}
+	return key, nil
+}
+
+// readEntries 根据 -key-type 选择键的类型。
+func readEntries(cfg config, key []byte) ([]entry, error) {
+	switch cfg.keyType {
+	case "string":
+		return readWithKey[string](cfg, key)
+	case "int":
+		return readWithKey[int64](cfg, key)
+	case "uint":
+		return readWithKey[uint64](cfg, key)
+	case "float":
+		return readWithKey[float64](cfg, key)
+	case "bool":
+		return readWithKey[bool](cfg, key)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", cfg.keyType)
+	}
+}
+
+// readWithKey 根据 -value-type 选择值的类型。gob 对不同位数的整数使用相同的编码，因此 int 和 uint 可以读取任意位数的整数。
+func readWithKey[K comparable](cfg config, key []byte) ([]entry, error) {
+	switch cfg.valueType {
+	case "none":
+		return read[K, cache.Ignored](cfg, key)
+	case "string":
+		return read[K, string](cfg, key)
+	case "int":
+		return read[K, int64](cfg, key)
+	case "uint":
+		return read[K, uint64](cfg, key)
+	case "float":
+		return read[K, float64](cfg, key)
+	case "bool":
+		return read[K, bool](cfg, key)
+	case "bytes":
+		return read[K, []byte](cfg, key)
+	default:
+		return nil, fmt.Errorf("unsupported value type %q", cfg.valueType)
+	}
+}
+
+func read[K comparable, V any](cfg config, key []byte) ([]entry, error) {
+	var (
+		entries []cache.SnapshotEntry[K, V]
+		err     error
+	)
+	if cfg.wal {
+		var opts []cache.WALOption
+		if key != nil {
+			opts = append(opts, cache.WithWALEncryption(key))
+		}
+		entries, err = cache.ReadWAL[K, V](cfg.path, opts...)
+	} else {
+		entries, err = readSnapshot[K, V](cfg.path, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, ignored := any(*new(V)).(cache.Ignored)
+	out := make([]entry, 0, len(entries))
+	for _, e := range entries {
+		o := entry{Key: e.Key, Remaining: e.Remaining, TTL: e.TTL, Sliding: e.Sliding, Meta: e.Meta, Cost: e.Cost}
+		if !ignored {
+			o.Value = e.Value
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func readSnapshot[K comparable, V any](path string, key []byte) ([]cache.SnapshotEntry[K, V], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if key != nil {
+		if r, err = cache.NewDecryptReader(f, key); err != nil {
+			return nil, err
+		}
+	}
+	return cache.ReadSnapshot[K, V](r)
+}
+
+func write(w io.Writer, command string, entries []entry) error {
+	switch command {
+	case "keys":
+		for _, e := range entries {
+			if _, err := fmt.Fprintln(w, e.Key); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "ttl":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tREMAINING\tTTL")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%v\t%s\t%s\n", e.Key, duration(e.Remaining, "never"), duration(e.TTL, "-"))
+		}
+		return tw.Flush()
+	default:
+		out := make([]exported, len(entries))
+		for i, e := range entries {
+			out[i] = exported{entry: e, RemainingMS: e.Remaining.Milliseconds(), TTLMS: e.TTL.Milliseconds()}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+}
+
+// duration 格式化时长，0 时返回 zero，非 0 时精确到秒。
+func duration(d time.Duration, zero string) string {
+	if d == 0 {
+		return zero
+	}
+	if d < time.Second {
+		return d.String()
+	}
+	return d.Round(time.Second).String()
+}
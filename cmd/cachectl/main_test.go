@@ -0,0 +1,165 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name string
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// 值为结构体的快照
+	snapshot := filepath.Join(dir, "users.snapshot")
+	users := cache.NewLruCache[string, user](ctx, 10, time.Hour, cache.WithSnapshot[string, user](snapshot, 0))
+	require.NoError(t, users.Set(ctx, "alice", user{Name: "Alice"}))
+	require.NoError(t, users.Set(ctx, "bob", user{Name: "Bob"}, cache.WithExpiration(2*time.Hour)))
+	require.NoError(t, users.Close())
+
+	// 头部损坏的快照，header 与 SaveTo 写入的头部具有相同的字段
+	type header struct {
+		Version int
+		Count   int
+	}
+	negative := filepath.Join(dir, "negative.snapshot")
+	writeGob(t, negative, header{Version: 1, Count: -1})
+	huge := filepath.Join(dir, "huge.snapshot")
+	writeGob(t, huge, header{Version: 1, Count: math.MaxInt})
+
+	testCases := []struct {
+		name string
+		args []string
+
+		want    string
+		wantErr string
+	}{
+		{
+			name: "keys",
+			args: []string{"keys", snapshot},
+			want: "alice\nbob\n",
+		},
+		{
+			name: "ttl",
+			args: []string{"ttl", snapshot},
+			want: "KEY    REMAINING  TTL\n" +
+				"alice  never      -\n" +
+				"bob    2h0m0s     2h0m0s\n",
+		},
+		{
+			name:    "incompatible value type",
+			args:    []string{"-value-type", "string", "keys", snapshot},
+			wantErr: "cache: read snapshot entry 0",
+		},
+		{
+			name:    "negative entry count",
+			args:    []string{"keys", negative},
+			wantErr: "cache: invalid snapshot entry count -1",
+		},
+		{
+			name:    "huge entry count",
+			args:    []string{"keys", huge},
+			wantErr: "cache: read snapshot entry 0: unexpected EOF",
+		},
+		{
+			name:    "unsupported key type",
+			args:    []string{"-key-type", "complex", "keys", snapshot},
+			wantErr: `unsupported key type "complex"`,
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"dump", snapshot},
+			wantErr: `unknown command "dump"`,
+		},
+		{
+			name:    "missing path",
+			args:    []string{"keys"},
+			wantErr: "expected a command and a path",
+		},
+		{
+			name:    "path does not exist",
+			args:    []string{"keys", filepath.Join(dir, "missing")},
+			wantErr: "no such file or directory",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := run(tc.args, &out, io.Discard)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
+
+func writeGob(t *testing.T, path string, v any) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, gob.NewEncoder(f).Encode(v))
+}
+
+func TestRun_export(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{1}, 16)
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0o600))
+	walDir := filepath.Join(dir, "wal")
+	counters := cache.NewLruCache[int, int](ctx, 10, time.Hour,
+		cache.WithWAL[int, int](walDir, cache.WithCompactionInterval(0), cache.WithWALEncryption(key)))
+	defer counters.Close()
+	require.NoError(t, counters.Set(ctx, 1, 10))
+	require.NoError(t, counters.Set(ctx, 2, 20, cache.WithExpiration(time.Hour), cache.WithMeta(map[string]string{"source": "db"})))
+
+	var out bytes.Buffer
+	err := run([]string{"-wal", "-key-type", "int", "-value-type", "int", "-encryption-key-file", keyFile, "export", walDir}, &out, io.Discard)
+	require.NoError(t, err)
+	var got []map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	require.Len(t, got, 2)
+	assert.Equal(t, map[string]any{"key": 1.0, "value": 10.0}, got[0])
+	assert.InDelta(t, time.Hour.Milliseconds(), got[1]["remaining_ms"], 1000)
+	delete(got[1], "remaining_ms")
+	assert.Equal(t, map[string]any{
+		"key": 2.0, "value": 20.0, "ttl_ms": 3600000.0, "meta": map[string]any{"source": "db"},
+	}, got[1])
+
+	// 缺少密钥时无法读取
+	err = run([]string{"-wal", "-key-type", "int", "keys", walDir}, &out, io.Discard)
+	assert.ErrorContains(t, err, "cache: load wal snapshot")
+}
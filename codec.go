@@ -0,0 +1,150 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec 在值和字节之间转换，见 NewEncoded。Unmarshal 不能持有 data 的引用。
+type Codec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONCodec 使用 encoding/json 编解码值。
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// GobCodec 使用 encoding/gob 编解码值，每个值单独编码，因此会重复写入类型信息，适用于结构较小的值。
+type GobCodec[V any] struct{}
+
+func (GobCodec[V]) Marshal(v V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// EncodedCache 是以编码之后的字节保存值的 Cache：Set 时通过 Codec 编码，Get 时解码。
+// 与 WithCloner 相比，调用方得到的总是新解码的值，写入之后修改传入的值也不会影响缓存；
+// 底层的 Cache 可以使用 WithMaxBytes（sizer 为 nil）按编码之后的实际字节数限制内存，
+// 也可以直接通过 SaveTo、DumpJSON 或 WithWAL 持久化，而不需要 V 能够被 gob 或 json 编码。
+type EncodedCache[K comparable, V any] struct {
+	c     *Cache[K, []byte]
+	codec Codec[V]
+}
+
+// NewEncoded 返回以 codec 编码的值保存在 c 中的视图，容量、过期时间等由 c 的 Option 决定。
+func NewEncoded[K comparable, V any](c *Cache[K, []byte], codec Codec[V]) *EncodedCache[K, V] {
+	return &EncodedCache[K, V]{c: c, codec: codec}
+}
+
+// Unwrap 返回保存编码之后的值的 Cache。
+func (e *EncodedCache[K, V]) Unwrap() *Cache[K, []byte] {
+	return e.c
+}
+
+func (e *EncodedCache[K, V]) encode(v V) ([]byte, error) {
+	data, err := e.codec.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cache: encode value: %w", err)
+	}
+	return data, nil
+}
+
+func (e *EncodedCache[K, V]) decode(data []byte, err error) (V, error) {
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	v, err := e.codec.Unmarshal(data)
+	if err != nil {
+		var zero V
+		return zero, fmt.Errorf("cache: decode value: %w", err)
+	}
+	return v, nil
+}
+
+// Get 返回 key 对应的值，错误与 Cache.Get 相同；值无法解码时返回包装了 Codec 错误的错误。
+func (e *EncodedCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return e.decode(e.c.Get(ctx, key))
+}
+
+func (e *EncodedCache[K, V]) Peek(ctx context.Context, key K) (V, error) {
+	return e.decode(e.c.Peek(ctx, key))
+}
+
+// Set 编码 value 并写入 key，编码失败时不修改缓存。
+func (e *EncodedCache[K, V]) Set(ctx context.Context, key K, value V, opts ...ItemOption) error {
+	data, err := e.encode(value)
+	if err != nil {
+		return err
+	}
+	return e.c.Set(ctx, key, data, opts...)
+}
+
+func (e *EncodedCache[K, V]) SetNX(ctx context.Context, key K, value V, opts ...ItemOption) (bool, error) {
+	data, err := e.encode(value)
+	if err != nil {
+		return false, err
+	}
+	return e.c.SetNX(ctx, key, data, opts...)
+}
+
+// GetOrLoad 与 Cache.GetOrLoad 相同，loader 的结果编码之后写入缓存。
+func (e *EncodedCache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error), opts ...ItemOption) (V, error) {
+	return e.decode(e.c.GetOrLoad(ctx, key, func(ctx context.Context, key K) ([]byte, error) {
+		v, err := loader(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return e.encode(v)
+	}, opts...))
+}
+
+func (e *EncodedCache[K, V]) Delete(ctx context.Context, key K) error {
+	return e.c.Delete(ctx, key)
+}
+
+func (e *EncodedCache[K, V]) Contains(ctx context.Context, key K) bool {
+	return e.c.Contains(ctx, key)
+}
+
+func (e *EncodedCache[K, V]) Keys() []K {
+	return e.c.Keys()
+}
+
+func (e *EncodedCache[K, V]) Len() int {
+	return e.c.Len()
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name string
+	Tags []string
+}
+
+// failingCodec 编码 Name 为空的值时失败，解码总是失败。
+type failingCodec struct{}
+
+var errCodec = errors.New("codec error")
+
+func (failingCodec) Marshal(v user) ([]byte, error) {
+	if v.Name == "" {
+		return nil, errCodec
+	}
+	return []byte(v.Name), nil
+}
+
+func (failingCodec) Unmarshal(data []byte) (user, error) {
+	return user{}, errCodec
+}
+
+func TestEncodedCache(t *testing.T) {
+	codecs := map[string]Codec[user]{
+		"json": JSONCodec[user]{},
+		"gob":  GobCodec[user]{},
+	}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewEncoded[string, user](NewSimpleCache[string, []byte](ctx, 0, 0), codec)
+
+			u := user{Name: "a", Tags: []string{"x"}}
+			require.NoError(t, c.Set(ctx, "1", u))
+			// 写入之后修改传入的值不影响缓存
+			u.Tags[0] = "y"
+			got, err := c.Get(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, user{Name: "a", Tags: []string{"x"}}, got)
+			// 修改读取到的值同样不影响缓存
+			got.Tags[0] = "z"
+			got, err = c.Peek(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, user{Name: "a", Tags: []string{"x"}}, got)
+
+			ok, err := c.SetNX(ctx, "1", user{Name: "b"})
+			require.NoError(t, err)
+			assert.False(t, ok)
+			got, err = c.GetOrLoad(ctx, "2", func(ctx context.Context, key string) (user, error) {
+				return user{Name: key}, nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "2", got.Name)
+
+			assert.True(t, c.Contains(ctx, "2"))
+			assert.ElementsMatch(t, []string{"1", "2"}, c.Keys())
+			assert.Equal(t, 2, c.Len())
+			require.NoError(t, c.Delete(ctx, "2"))
+			_, err = c.Get(ctx, "2")
+			assert.ErrorIs(t, err, cacheError.ErrNoKey)
+		})
+	}
+}
+
+func TestEncodedCache_codecErrors(t *testing.T) {
+	ctx := context.Background()
+	c := NewEncoded[string, user](NewSimpleCache[string, []byte](ctx, 0, 0), failingCodec{})
+
+	assert.ErrorIs(t, c.Set(ctx, "1", user{}), errCodec)
+	_, err := c.SetNX(ctx, "1", user{})
+	assert.ErrorIs(t, err, errCodec)
+	_, err = c.GetOrLoad(ctx, "1", func(ctx context.Context, key string) (user, error) {
+		return user{}, nil
+	})
+	assert.ErrorIs(t, err, errCodec)
+	// 编码失败时不会写入缓存
+	assert.Zero(t, c.Len())
+
+	require.NoError(t, c.Set(ctx, "1", user{Name: "a"}))
+	_, err = c.Get(ctx, "1")
+	assert.ErrorIs(t, err, errCodec)
+	assert.EqualError(t, err, "cache: decode value: codec error")
+}
+
+func TestEncodedCache_maxBytes(t *testing.T) {
+	ctx := context.Background()
+	inner := NewLruCache[string, []byte](ctx, 100, 0, WithMaxBytes[string, []byte](10, nil))
+	c := NewEncoded[string, string](inner, JSONCodec[string]{})
+	// 每个值编码为 5 个字节，如 "aaa"
+	require.NoError(t, c.Set(ctx, "1", "aaa"))
+	require.NoError(t, c.Set(ctx, "2", "bbb"))
+	assert.Equal(t, int64(10), inner.Bytes())
+	require.NoError(t, c.Set(ctx, "3", "ccc"))
+	assert.Equal(t, int64(10), inner.Bytes())
+	assert.Equal(t, []string{"2", "3"}, c.Keys())
+	assert.Same(t, inner, c.Unwrap())
+}
+
+func TestEncodedCache_SaveTo(t *testing.T) {
+	// 值以字节保存，V 不需要能够被 gob 编码
+	ctx := context.Background()
+	c := NewEncoded[string, func()](NewSimpleCache[string, []byte](ctx, 0, 0), funcCodec{})
+	require.NoError(t, c.Set(ctx, "1", func() {}))
+	var buf bytes.Buffer
+	require.NoError(t, c.Unwrap().SaveTo(&buf))
+
+	restored := NewSimpleCache[string, []byte](ctx, 0, 0)
+	require.NoError(t, restored.LoadFrom(&buf))
+	v, err := restored.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("f"), v)
+}
+
+// funcCodec 编解码无法被 gob 编码的函数类型。
+type funcCodec struct{}
+
+func (funcCodec) Marshal(func()) ([]byte, error)        { return []byte("f"), nil }
+func (funcCodec) Unmarshal(data []byte) (func(), error) { return func() {}, nil }
@@ -0,0 +1,199 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// Config 汇总了创建 Cache 所需的全部配置。
+type Config[K comparable, V any] struct {
+	// Backend 存储元素并实现淘汰策略的后端
+	Backend types.ICache[K, *Item[V]]
+	// Interval 清理过期元素的时间间隔，小于等于 0 时不启动清理协程
+	Interval time.Duration
+	// Options 其他可选配置
+	Options []Option[K, V]
+}
+
+func (c Config[K, V]) options() options[K, V] {
+	var o options[K, V]
+	for _, opt := range c.Options {
+		opt(&o)
+	}
+	return o
+}
+
+// Validate 交叉检查各项配置，一次性返回所有问题。
+// 返回的错误可以通过 errors.Is(err, cacheError.ErrInvalidConfig) 识别。
+func (c Config[K, V]) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{cacheError.ErrInvalidConfig}, args...)...))
+	}
+
+	if c.Backend == nil {
+		invalid("backend must not be nil")
+	}
+	o := c.options()
+	if o.onExpired != nil && c.Interval <= 0 {
+		invalid("WithOnExpired requires a positive janitor interval, got %s; expired entries are only reaped by the janitor", c.Interval)
+	}
+	if o.onEvicted != nil && c.Backend != nil {
+		if _, ok := c.Backend.(types.EvictionNotifier[K, *Item[V]]); !ok {
+			invalid("WithOnEvicted requires a backend implementing types.EvictionNotifier, %T does not", c.Backend)
+		}
+	}
+	if o.maxBytes < 0 {
+		invalid("WithMaxBytes must not be negative, got %d", o.maxBytes)
+	}
+	if o.maxBytes > 0 && o.sizer == nil {
+		var zero V
+		invalid("WithMaxBytes requires a Sizer for value type %T", zero)
+	}
+	if o.maxCost < 0 {
+		invalid("WithMaxCost must not be negative, got %d", o.maxCost)
+	}
+	if o.maxCost > 0 && o.maxBytes > 0 {
+		invalid("WithMaxCost and WithMaxBytes are mutually exclusive")
+	}
+	if (o.maxCost > 0 || o.maxBytes > 0) && c.Backend != nil {
+		_, evicter := c.Backend.(types.Evicter[K])
+		_, notifier := c.Backend.(types.EvictionNotifier[K, *Item[V]])
+		if !evicter || !notifier {
+			name := "WithMaxCost"
+			if o.maxBytes > 0 {
+				name = "WithMaxBytes"
+			}
+			invalid("%s requires a backend implementing types.Evicter and types.EvictionNotifier, %T does not", name, c.Backend)
+		}
+	}
+	if o.evictWindow > 1 {
+		if o.maxCost <= 0 && o.maxBytes <= 0 {
+			invalid("WithRecomputeAwareEviction requires WithMaxCost or WithMaxBytes")
+		}
+		if c.Backend != nil {
+			_, inspector := c.Backend.(types.Inspector[K, *Item[V]])
+			_, pinner := c.Backend.(types.Pinner[K])
+			if !inspector || !pinner {
+				invalid("WithRecomputeAwareEviction requires a backend implementing types.Inspector and types.Pinner, %T does not", c.Backend)
+			}
+		}
+	}
+	if o.rejectWhenFull {
+		if o.maxCost > 0 || o.maxBytes > 0 {
+			invalid("WithRejectWhenFull cannot be combined with WithMaxCost or WithMaxBytes")
+		}
+		if _, ok := c.Backend.(types.FullRejecter); !ok && c.Backend != nil {
+			invalid("WithRejectWhenFull requires a backend implementing types.FullRejecter, %T does not", c.Backend)
+		}
+	}
+	if o.ghostSize < 0 {
+		invalid("WithGhostList size must not be negative, got %d", o.ghostSize)
+	}
+	if o.ghostSize > 0 && c.Backend != nil {
+		if _, ok := c.Backend.(types.EvictionNotifier[K, *Item[V]]); !ok {
+			invalid("WithGhostList requires a backend implementing types.EvictionNotifier, %T does not", c.Backend)
+		}
+	}
+	if o.store != nil && o.storeMode != WriteThrough && o.storeMode != WriteBack {
+		invalid("unknown store write mode %d", o.storeMode)
+	}
+	if o.store != nil && o.storeMode == WriteBack && o.storeCfg.flushInterval <= 0 && o.storeCfg.batchSize <= 0 {
+		invalid("write-back store requires a positive flush interval or batch size, otherwise pending writes are only flushed on shutdown")
+	}
+	if o.store != nil && o.storeCfg.opsPerSecond > 0 && o.storeMode != WriteBack {
+		invalid("WithWriteBudget requires the write-back store mode")
+	}
+	if o.store != nil && o.storeCfg.consistency != ReadYourWrites {
+		if o.storeCfg.consistency != Eventual {
+			invalid("unknown consistency mode %d", o.storeCfg.consistency)
+		} else if o.storeMode != WriteBack {
+			invalid("WithConsistency requires the write-back store mode")
+		}
+	}
+	if o.store != nil && o.storeCfg.minTTL < 0 {
+		invalid("WithWriteBudget minimum TTL must not be negative, got %s", o.storeCfg.minTTL)
+	}
+	if o.refreshRatio != 0 {
+		if o.loader == nil {
+			invalid("WithRefreshAhead requires a loader set by WithLoader")
+		}
+		if o.refreshRatio < 0 || o.refreshRatio >= 1 {
+			invalid("WithRefreshAhead ratio must be in (0, 1), got %v", o.refreshRatio)
+		}
+		if o.refreshWorkers <= 0 {
+			invalid("WithRefreshAhead requires at least one worker, got %d", o.refreshWorkers)
+		}
+	}
+	if o.cleanup != nil {
+		if o.cleanup.scanLimit < 0 {
+			invalid("WithScanLimit must not be negative, got %d", o.cleanup.scanLimit)
+		}
+		if o.cleanup.deleteLimit < 0 {
+			invalid("WithDeleteLimit must not be negative, got %d", o.cleanup.deleteLimit)
+		}
+		if o.cleanup.timeBudget < 0 {
+			invalid("WithTimeBudget must not be negative, got %s", o.cleanup.timeBudget)
+		}
+		if o.cleanup.jitter < 0 {
+			invalid("WithStartJitter must not be negative, got %s", o.cleanup.jitter)
+		}
+		if o.cleanup.phaseOffset < 0 {
+			invalid("WithPhaseOffset must not be negative, got %s", o.cleanup.phaseOffset)
+		}
+	}
+	if o.snapshot != nil {
+		if o.snapshot.path == "" {
+			invalid("WithSnapshot requires a file path")
+		}
+		if o.snapshot.interval < 0 {
+			invalid("WithSnapshot interval must not be negative, got %s", o.snapshot.interval)
+		}
+		if o.snapshot.key != nil && !validKeySize(o.snapshot.key) {
+			invalid("WithSnapshotEncryption key must be 16, 24 or 32 bytes, got %d", len(o.snapshot.key))
+		}
+	}
+	if o.wal != nil {
+		if o.wal.dir == "" {
+			invalid("WithWAL requires a directory")
+		}
+		if o.wal.compactionInterval < 0 {
+			invalid("WithCompactionInterval must not be negative, got %s", o.wal.compactionInterval)
+		}
+		if o.wal.key != nil && !validKeySize(o.wal.key) {
+			invalid("WithWALEncryption key must be 16, 24 or 32 bytes, got %d", len(o.wal.key))
+		}
+	}
+	if o.defaultExpiration < 0 {
+		invalid("WithDefaultExpiration must not be negative, got %s", o.defaultExpiration)
+	}
+	if o.beta < 0 {
+		invalid("WithEarlyExpiration beta must not be negative, got %v", o.beta)
+	}
+	if o.expvarName != "" && expvar.Get(o.expvarName) != nil {
+		invalid("WithExpvar name %q is already published", o.expvarName)
+	}
+	if o.log != nil && o.log.logger == nil {
+		invalid("WithLogger requires a non-nil logger")
+	}
+	return errors.Join(errs...)
+}
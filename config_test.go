@@ -0,0 +1,211 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/lru"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	noop := func(int, int) {}
+	testCases := []struct {
+		name    string
+		cfg     Config[int, int]
+		wantErr string
+	}{
+		{
+			name: "valid",
+			cfg: Config[int, int]{
+				Backend:  lru.NewCache[int, *Item[int]](10),
+				Interval: time.Minute,
+				Options:  []Option[int, int]{WithOnExpired(noop), WithOnEvicted(noop)},
+			},
+		},
+		{
+			name: "janitor disabled without callbacks",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+			},
+		},
+		{
+			name:    "nil backend",
+			cfg:     Config[int, int]{Interval: time.Minute},
+			wantErr: "cache: invalid config: backend must not be nil",
+		},
+		{
+			name: "multiple problems are aggregated",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithOnExpired(noop), WithOnEvicted(noop)},
+			},
+			wantErr: "cache: invalid config: WithOnExpired requires a positive janitor interval, got 0s; expired entries are only reaped by the janitor\n" +
+				"cache: invalid config: WithOnEvicted requires a backend implementing types.EvictionNotifier, *simple.Cache[int,*github.com/chenmingyong0423/go-generics-cache.Item[int]] does not",
+		},
+		{
+			name: "unknown store write mode",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithStore[int, int](newMemStore(), WriteMode(2))},
+			},
+			wantErr: "cache: invalid config: unknown store write mode 2",
+		},
+		{
+			name: "write-back store never flushed",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithStore[int, int](newMemStore(), WriteBack, WithFlushInterval(0), WithBatchSize(0))},
+			},
+			wantErr: "cache: invalid config: write-back store requires a positive flush interval or batch size, otherwise pending writes are only flushed on shutdown",
+		},
+		{
+			name: "write budget in write-through mode",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithStore[int, int](newMemStore(), WriteThrough, WithWriteBudget(10, -time.Second))},
+			},
+			wantErr: "cache: invalid config: WithWriteBudget requires the write-back store mode\n" +
+				"cache: invalid config: WithWriteBudget minimum TTL must not be negative, got -1s",
+		},
+		{
+			name: "eventual consistency in write-through mode",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithStore[int, int](newMemStore(), WriteThrough, WithConsistency(Eventual))},
+			},
+			wantErr: "cache: invalid config: WithConsistency requires the write-back store mode",
+		},
+		{
+			name: "unknown consistency mode",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithStore[int, int](newMemStore(), WriteBack, WithConsistency(Consistency(7)))},
+			},
+			wantErr: "cache: invalid config: unknown consistency mode 7",
+		},
+		{
+			name: "refresh ahead without loader",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithRefreshAhead[int, int](1, 0)},
+			},
+			wantErr: "cache: invalid config: WithRefreshAhead requires a loader set by WithLoader\n" +
+				"cache: invalid config: WithRefreshAhead ratio must be in (0, 1), got 1\n" +
+				"cache: invalid config: WithRefreshAhead requires at least one worker, got 0",
+		},
+		{
+			name: "negative early expiration beta",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithEarlyExpiration[int, int](-1)},
+			},
+			wantErr: "cache: invalid config: WithEarlyExpiration beta must not be negative, got -1",
+		},
+		{
+			name: "negative default expiration",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithDefaultExpiration[int, int](-time.Second)},
+			},
+			wantErr: "cache: invalid config: WithDefaultExpiration must not be negative, got -1s",
+		},
+		{
+			name: "negative cleanup limits",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithCleanup[int, int](WithScanLimit(-1), WithDeleteLimit(-1), WithTimeBudget(-time.Second))},
+			},
+			wantErr: "cache: invalid config: WithScanLimit must not be negative, got -1\n" +
+				"cache: invalid config: WithDeleteLimit must not be negative, got -1\n" +
+				"cache: invalid config: WithTimeBudget must not be negative, got -1s",
+		},
+		{
+			name: "negative janitor start options",
+			cfg: Config[int, int]{
+				Backend:  simple.NewCache[int, *Item[int]](0),
+				Interval: time.Minute,
+				Options:  []Option[int, int]{WithCleanup[int, int](WithStartJitter(-time.Second), WithPhaseOffset(-time.Second))},
+			},
+			wantErr: "cache: invalid config: WithStartJitter must not be negative, got -1s\n" +
+				"cache: invalid config: WithPhaseOffset must not be negative, got -1s",
+		},
+		{
+			name: "ghost list without eviction notifier",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithGhostList[int, int](10)},
+			},
+			wantErr: "cache: invalid config: WithGhostList requires a backend implementing types.EvictionNotifier, *simple.Cache[int,*github.com/chenmingyong0423/go-generics-cache.Item[int]] does not",
+		},
+		{
+			name: "reject when full without full rejecter",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithRejectWhenFull[int, int]()},
+			},
+			wantErr: "cache: invalid config: WithRejectWhenFull requires a backend implementing types.FullRejecter, *simple.Cache[int,*github.com/chenmingyong0423/go-generics-cache.Item[int]] does not",
+		},
+		{
+			name: "nil logger",
+			cfg: Config[int, int]{
+				Backend: simple.NewCache[int, *Item[int]](0),
+				Options: []Option[int, int]{WithLogger[int, int](nil)},
+			},
+			wantErr: "cache: invalid config: WithLogger requires a non-nil logger",
+		},
+		{
+			name: "reject when full with max cost",
+			cfg: Config[int, int]{
+				Backend: lru.NewCache[int, *Item[int]](10),
+				Options: []Option[int, int]{WithRejectWhenFull[int, int](), WithMaxCost[int, int](10)},
+			},
+			wantErr: "cache: invalid config: WithRejectWhenFull cannot be combined with WithMaxCost or WithMaxBytes",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.wantErr)
+			assert.True(t, errors.Is(err, cacheError.ErrInvalidConfig))
+		})
+	}
+}
+
+func TestNewWithConfig(t *testing.T) {
+	cache, err := NewWithConfig[int, int](context.Background(), Config[int, int]{})
+	assert.Nil(t, cache)
+	assert.True(t, errors.Is(err, cacheError.ErrInvalidConfig))
+
+	assert.Panics(t, func() {
+		New[int, int](context.Background(), nil, time.Minute)
+	})
+
+	cache, err = NewWithConfig[int, int](context.Background(), Config[int, int]{
+		Backend: simple.NewCache[int, *Item[int]](0),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Set(context.Background(), 1, 1))
+}
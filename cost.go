@@ -0,0 +1,149 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// WithCost 设置元素的成本，配合 WithMaxCost 或 WithMaxBytes 使用，例如让一份报表的成本是一条用户记录的 50 倍。
+// cost <= 0 时忽略。
+func WithCost(cost int64) ItemOption {
+	return func(o *itemOptions) {
+		if cost > 0 {
+			o.cost = cost
+		}
+	}
+}
+
+// WithMaxCost 按元素的总成本限制缓存容量：写入之后总成本超过 max 时，按后端的淘汰策略淘汰元素，直到总成本不超过 max。
+// 元素的成本通过 WithCost 设置，未设置时为 1。单个成本超过 max 的元素写入之后会被立即淘汰。
+//
+// 与 WithMaxBytes 相同，后端需要实现 types.Evicter 和 types.EvictionNotifier，两者不能同时使用。
+func WithMaxCost[K comparable, V any](max int64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxCost = max
+	}
+}
+
+// WithRecomputeAwareEviction 让 WithMaxCost 和 WithMaxBytes 的淘汰考虑元素重新计算的耗时：每次淘汰时查看淘汰策略接下来的
+// window 个候选元素，淘汰其中重新计算耗时最短的一个，耗时相同时淘汰更早的候选。因此加载缓慢的值会比几微秒就能重新加载的值保留得更久，
+// 而耗时相近的元素仍然按淘汰策略的顺序淘汰。
+//
+// 通过加载器写入的元素自动记录加载耗时，手动写入的元素可以通过 WithRecomputeTime 提供，未记录时视为 0。
+// 后端自身因容量不足触发的淘汰不受影响。window <= 1 时只按淘汰策略淘汰。
+// 后端需要实现 types.Inspector 和 types.Pinner，内置的 LRU 和 FIFO 后端都满足。
+func WithRecomputeAwareEviction[K comparable, V any](window int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.evictWindow = window
+	}
+}
+
+// costTracker 记录 WithMaxCost 或 WithMaxBytes 下每个键的成本和总成本，持有写锁时读写。
+type costTracker[K comparable] struct {
+	max   int64
+	total int64
+	costs map[K]int64
+}
+
+func newCostTracker[K comparable](max int64) *costTracker[K] {
+	return &costTracker[K]{max: max, costs: make(map[K]int64)}
+}
+
+func (t *costTracker[K]) set(key K, cost int64) {
+	t.total += cost - t.costs[key]
+	t.costs[key] = cost
+}
+
+func (t *costTracker[K]) remove(key K) {
+	t.total -= t.costs[key]
+	delete(t.costs, key)
+}
+
+// Cost 返回缓存中元素的总成本，未设置 WithMaxCost 或 WithMaxBytes 时返回 0。
+func (c *Cache[K, V]) Cost() int64 {
+	if c.cost == nil {
+		return 0
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.cost.total
+}
+
+// itemCost 返回 item 的成本：优先使用 WithCost 设置的成本，其次是 WithMaxBytes 的 sizer 估算的大小，否则为 1。
+func (c *Cache[K, V]) itemCost(item *Item[V]) int64 {
+	if item.cost > 0 {
+		return item.cost
+	}
+	if c.opts.sizer != nil && c.opts.maxBytes > 0 {
+		return int64(c.opts.sizer(item.value))
+	}
+	return 1
+}
+
+// trackCost 记录 key 的新元素的成本，总成本超过限制时按后端的淘汰策略淘汰元素，调用方需要持有写锁。
+func (c *Cache[K, V]) trackCost(key K, item *Item[V]) {
+	if c.cost == nil {
+		return
+	}
+	c.cost.set(key, c.itemCost(item))
+	for c.cost.total > c.cost.max {
+		// 淘汰的元素通过 evicted 从 cost 中移除
+		if !c.evictCheapest() {
+			return
+		}
+	}
+}
+
+// evictCheapest 在淘汰策略接下来的 WithRecomputeAwareEviction 个候选元素中淘汰重新计算耗时最短的一个，
+// 未设置时直接淘汰策略选出的元素，没有可淘汰的元素时返回 false。调用方需要持有写锁。
+func (c *Cache[K, V]) evictCheapest() bool {
+	if c.opts.evictWindow <= 1 {
+		_, ok := c.evicter.EvictOne()
+		return ok
+	}
+	inspector := c.cache.(types.Inspector[K, *Item[V]])
+	// 依次固定候选元素，使 OldestEntry 返回下一个候选
+	var (
+		keys     []K
+		cheapest int
+		delta    time.Duration
+	)
+	for len(keys) < c.opts.evictWindow {
+		key, item, ok := inspector.OldestEntry()
+		if !ok {
+			break
+		}
+		if len(keys) == 0 || item.delta < delta {
+			cheapest, delta = len(keys), item.delta
+		}
+		c.pinner.Pin(key)
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return false
+	}
+	// 更早的候选仍然被固定，EvictOne 淘汰的就是选中的元素
+	c.pinner.Unpin(keys[cheapest])
+	_, ok := c.evicter.EvictOne()
+	for i, key := range keys {
+		if i != cheapest {
+			c.pinner.Unpin(key)
+		}
+	}
+	return ok
+}
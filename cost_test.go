@@ -0,0 +1,196 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/lru"
+	"github.com/chenmingyong0423/go-generics-cache/slru"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxCost(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		ops  func(t *testing.T, c *Cache[string, int])
+
+		wantKeys []string
+		wantCost int64
+	}{
+		{
+			name: "default cost is one",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"} {
+					require.NoError(t, c.Set(ctx, key, 1))
+				}
+			},
+			wantKeys: []string{"b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+			wantCost: 10,
+		},
+		{
+			name: "heavy entry evicts several light ones",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 1, WithCost(4)))
+				require.NoError(t, c.Set(ctx, "c", 1, WithCost(3)))
+				require.NoError(t, c.Set(ctx, "report", 1, WithCost(6)))
+			},
+			wantKeys: []string{"c", "report"},
+			wantCost: 9,
+		},
+		{
+			name: "incr keeps cost",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "a", 1, WithCost(8)))
+				_, err := Incr(ctx, c, "a", 1)
+				require.NoError(t, err)
+				require.NoError(t, c.Set(ctx, "b", 1, WithCost(2)))
+			},
+			wantKeys: []string{"a", "b"},
+			wantCost: 10,
+		},
+		{
+			name: "non-positive cost is ignored",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "a", 1, WithCost(0)))
+				require.NoError(t, c.Set(ctx, "b", 1, WithCost(-5)))
+			},
+			wantKeys: []string{"a", "b"},
+			wantCost: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewLruCache[string, int](ctx, 100, 0, WithMaxCost[string, int](10))
+			tc.ops(t, c)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+			assert.Equal(t, tc.wantCost, c.Cost())
+			assert.Zero(t, c.Bytes())
+		})
+	}
+}
+
+func TestWithCost_maxBytes(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, string](ctx, 100, 0, WithMaxBytes[string, string](10, nil))
+	// 显式的成本优先于 sizer 估算的大小
+	require.NoError(t, c.Set(ctx, "a", "x", WithCost(9)))
+	require.NoError(t, c.Set(ctx, "b", "xx"))
+	assert.Equal(t, []string{"b"}, c.Keys())
+	assert.Equal(t, int64(2), c.Bytes())
+}
+
+func TestWithCost_SaveTo(t *testing.T) {
+	ctx := context.Background()
+	src := NewLruCache[string, int](ctx, 100, 0)
+	require.NoError(t, src.Set(ctx, "a", 1, WithCost(7)))
+	var buf bytes.Buffer
+	require.NoError(t, src.SaveTo(&buf))
+
+	dst := NewLruCache[string, int](ctx, 100, 0, WithMaxCost[string, int](10))
+	require.NoError(t, dst.LoadFrom(&buf))
+	assert.Equal(t, int64(7), dst.Cost())
+	assert.Equal(t, int64(7), dst.Items(ctx)[0].Cost)
+}
+
+func TestWithRecomputeAwareEviction(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name   string
+		window int
+
+		wantKeys []string
+	}{
+		{
+			// 加载缓慢的 slow 被跳过，其他元素按 LRU 顺序淘汰
+			name:     "slow entry retained",
+			window:   3,
+			wantKeys: []string{"slow", "c", "d"},
+		},
+		{
+			name:     "policy order only",
+			window:   1,
+			wantKeys: []string{"b", "c", "d"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewLruCache[string, int](ctx, 100, 0, WithMaxCost[string, int](3), WithRecomputeAwareEviction[string, int](tc.window))
+			_, err := c.GetOrLoad(ctx, "slow", func(ctx context.Context, key string) (int, error) {
+				time.Sleep(10 * time.Millisecond)
+				return 1, nil
+			})
+			require.NoError(t, err)
+			require.NoError(t, c.Set(ctx, "a", 1))
+			require.NoError(t, c.Set(ctx, "b", 1))
+			require.NoError(t, c.Set(ctx, "c", 1))
+			require.NoError(t, c.Set(ctx, "d", 1))
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+			assert.Equal(t, int64(3), c.Cost())
+			// 没有遗留被固定的候选元素
+			require.NoError(t, c.Delete(ctx, "d"))
+			require.NoError(t, c.SetCapacity(1))
+			assert.Equal(t, 1, c.Len())
+		})
+	}
+}
+
+func TestWithMaxCost_invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		backend types.ICache[string, *Item[string]]
+		opts    []Option[string, string]
+		wantErr string
+	}{
+		{
+			name:    "negative",
+			opts:    []Option[string, string]{WithMaxCost[string, string](-1)},
+			wantErr: "WithMaxCost must not be negative, got -1",
+		},
+		{
+			name:    "with max bytes",
+			opts:    []Option[string, string]{WithMaxCost[string, string](10), WithMaxBytes[string, string](10, nil)},
+			wantErr: "WithMaxCost and WithMaxBytes are mutually exclusive",
+		},
+		{
+			name:    "recompute aware without max cost",
+			opts:    []Option[string, string]{WithRecomputeAwareEviction[string, string](4)},
+			wantErr: "WithRecomputeAwareEviction requires WithMaxCost or WithMaxBytes",
+		},
+		{
+			name:    "recompute aware without pinner",
+			backend: slru.NewCache[string, *Item[string]](10, 0),
+			opts:    []Option[string, string]{WithMaxCost[string, string](10), WithRecomputeAwareEviction[string, string](4)},
+			wantErr: "WithRecomputeAwareEviction requires a backend implementing types.Inspector and types.Pinner, *slru.Cache",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := tc.backend
+			if backend == nil {
+				backend = lru.NewCache[string, *Item[string]](10)
+			}
+			err := Config[string, string]{Backend: backend, Options: tc.opts}.Validate()
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
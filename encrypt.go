@@ -0,0 +1,227 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// 加密流的格式：4 字节的魔数和 1 字节的版本号，12 字节的随机 nonce，之后是若干帧。
+// 每一帧为 4 字节的大端密文长度和 AES-GCM 密文，第 i 帧的 nonce 为随机 nonce 的后 8 字节异或 i，
+// 附加数据为 1 字节的结束标记，流以一个明文为空、结束标记为 1 的帧结尾，用于发现在帧边界处的截断。
+const (
+	encryptMagic    = "GGCE"
+	encryptVersion  = 1
+	encryptMaxChunk = 64 << 10
+)
+
+var (
+	frameData  = []byte{0}
+	frameFinal = []byte{1}
+)
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("cache: encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func validKeySize(key []byte) bool {
+	switch len(key) {
+	case 16, 24, 32:
+		return true
+	}
+	return false
+}
+
+type frameNonce struct {
+	base    []byte
+	buf     []byte
+	counter uint64
+}
+
+func (n *frameNonce) next() []byte {
+	copy(n.buf, n.base)
+	tail := n.buf[len(n.buf)-8:]
+	binary.BigEndian.PutUint64(tail, binary.BigEndian.Uint64(tail)^n.counter)
+	n.counter++
+	return n.buf
+}
+
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	nonce  frameNonce
+	buf    []byte
+	closed bool
+}
+
+// NewEncryptWriter 返回一个使用 AES-GCM 加密写入内容的 io.WriteCloser，key 的长度为 16、24 或 32 字节，
+// 分别对应 AES-128、AES-192 和 AES-256。每次 Write 写入一个或多个加密帧，写入大量小块数据时可以在外面包一层 bufio.Writer。
+// Close 写入结束帧，但不会关闭 w；没有 Close 的流在读取时会报告 io.ErrUnexpectedEOF。
+//
+// 配合 SaveTo 和 LoadFrom 使用，可以把包含敏感数据的缓存加密保存到磁盘。
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(encryptMagic)+1+aead.NonceSize())
+	copy(header, encryptMagic)
+	header[len(encryptMagic)] = encryptVersion
+	base := header[len(encryptMagic)+1:]
+	if _, err := io.ReadFull(rand.Reader, base); err != nil {
+		return nil, fmt.Errorf("cache: generate nonce: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:     w,
+		aead:  aead,
+		nonce: frameNonce{base: base, buf: make([]byte, len(base))},
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("cache: write to closed encrypt writer")
+	}
+	n := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), encryptMaxChunk)]
+		if err := e.writeFrame(chunk, frameData); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.writeFrame(nil, frameFinal)
+}
+
+func (e *encryptWriter) writeFrame(plaintext, flag []byte) error {
+	e.buf = append(e.buf[:0], 0, 0, 0, 0)
+	e.buf = e.aead.Seal(e.buf, e.nonce.next(), plaintext, flag)
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+type decryptReader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	nonce frameNonce
+	buf   []byte
+	plain []byte
+	done  bool
+	err   error
+}
+
+// NewDecryptReader 返回一个读取 NewEncryptWriter 写入的内容并解密的 io.Reader。
+// 密钥错误或数据被篡改时读取返回 cacheError.ErrDecrypt，流在结束帧之前中断时返回 io.ErrUnexpectedEOF。
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(encryptMagic)+1+aead.NonceSize())
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("cache: read encryption header: %w", err)
+	}
+	if string(header[:len(encryptMagic)]) != encryptMagic {
+		return nil, errors.New("cache: not an encrypted stream")
+	}
+	if v := header[len(encryptMagic)]; v != encryptVersion {
+		return nil, fmt.Errorf("cache: unsupported encryption version %d", v)
+	}
+	base := header[len(encryptMagic)+1:]
+	return &decryptReader{
+		r:     r,
+		aead:  aead,
+		nonce: frameNonce{base: base, buf: make([]byte, len(base))},
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.err = d.readFrame()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) readFrame() error {
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < uint32(d.aead.Overhead()) || n > encryptMaxChunk+uint32(d.aead.Overhead()) {
+		return fmt.Errorf("%w: invalid frame size %d", cacheError.ErrDecrypt, n)
+	}
+	if cap(d.buf) < int(n) {
+		d.buf = make([]byte, n)
+	}
+	d.buf = d.buf[:n]
+	if _, err := io.ReadFull(d.r, d.buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	// 只有结束帧的明文为空，数据帧至少包含一个字节
+	final := int(n) == d.aead.Overhead()
+	flag := frameData
+	if final {
+		flag = frameFinal
+	}
+	plain, err := d.aead.Open(d.buf[:0], d.nonce.next(), d.buf, flag)
+	if err != nil {
+		return cacheError.ErrDecrypt
+	}
+	d.plain, d.done = plain, final
+	return nil
+}
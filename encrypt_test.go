@@ -0,0 +1,202 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func encrypt(t *testing.T, key []byte, chunks ...[]byte) []byte {
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, key)
+	require.NoError(t, err)
+	for _, chunk := range chunks {
+		_, err := w.Write(chunk)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestEncrypt(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), encryptMaxChunk/5)
+	testCases := []struct {
+		name   string
+		chunks [][]byte
+		// modify 修改加密后的数据
+		modify func(data []byte) []byte
+		key    []byte
+
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "empty",
+			want: []byte{},
+		},
+		{
+			name:   "multiple writes",
+			chunks: [][]byte{[]byte("hello "), []byte("world")},
+			want:   []byte("hello world"),
+		},
+		{
+			name:   "larger than one frame",
+			chunks: [][]byte{large},
+			want:   large,
+		},
+		{
+			name:    "wrong key",
+			chunks:  [][]byte{[]byte("secret")},
+			key:     bytes.Repeat([]byte{8}, 32),
+			wantErr: cacheError.ErrDecrypt,
+		},
+		{
+			name:   "tampered",
+			chunks: [][]byte{[]byte("secret")},
+			modify: func(data []byte) []byte {
+				data[len(data)-30] ^= 1
+				return data
+			},
+			wantErr: cacheError.ErrDecrypt,
+		},
+		{
+			name:   "truncated at frame boundary",
+			chunks: [][]byte{[]byte("hello "), []byte("world")},
+			modify: func(data []byte) []byte {
+				// 去掉结束帧：4 字节长度和 16 字节认证标签
+				return data[:len(data)-20]
+			},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:   "frames reordered",
+			chunks: [][]byte{[]byte("ab"), []byte("cd")},
+			modify: func(data []byte) []byte {
+				// 头部 17 字节，每个数据帧 4+2+16 字节
+				header, first, second := data[:17], data[17:39], data[39:61]
+				return append(append(append([]byte{}, header...), second...), append(first, data[61:]...)...)
+			},
+			wantErr: cacheError.ErrDecrypt,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := encrypt(t, testKey, tc.chunks...)
+			assert.NotContains(t, string(data), "secret")
+			if tc.modify != nil {
+				data = tc.modify(data)
+			}
+			key := testKey
+			if tc.key != nil {
+				key = tc.key
+			}
+			r, err := NewDecryptReader(bytes.NewReader(data), key)
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestEncrypt_invalid(t *testing.T) {
+	_, err := NewEncryptWriter(io.Discard, []byte("short"))
+	assert.ErrorContains(t, err, "cache: encryption key")
+	_, err = NewDecryptReader(bytes.NewReader(nil), []byte("short"))
+	assert.ErrorContains(t, err, "cache: encryption key")
+	_, err = NewDecryptReader(bytes.NewReader([]byte("not an encrypted stream")), testKey)
+	assert.ErrorContains(t, err, "cache: not an encrypted stream")
+	_, err = NewDecryptReader(bytes.NewReader([]byte("GGCE")), testKey)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestWithSnapshotEncryption(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	c := NewSimpleCache[string, string](ctx, 10, time.Hour,
+		WithSnapshot[string, string](path, 0, WithSnapshotEncryption(testKey)))
+	require.NoError(t, c.Set(ctx, "email", "alice@example.com"))
+	require.NoError(t, c.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "alice@example.com")
+
+	restored := NewSimpleCache[string, string](ctx, 10, time.Hour,
+		WithSnapshot[string, string](path, 0, WithSnapshotEncryption(testKey)))
+	defer restored.Close()
+	assert.Equal(t, map[string]string{"email": "alice@example.com"}, restored.Snapshot(ctx))
+
+	// 密钥错误时恢复失败
+	_, err = NewWithConfig(ctx, Config[string, string]{
+		Backend:  simple.NewCache[string, *Item[string]](10),
+		Interval: time.Hour,
+		Options: []Option[string, string]{
+			WithSnapshot[string, string](path, 0, WithSnapshotEncryption(bytes.Repeat([]byte{8}, 32))),
+		},
+	})
+	assert.ErrorIs(t, err, cacheError.ErrDecrypt)
+}
+
+func TestWithWALEncryption(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	opt := WithWAL[string, string](dir, WithCompactionInterval(0), WithWALEncryption(testKey))
+	c := NewSimpleCache[string, string](ctx, 10, time.Hour, opt)
+	require.NoError(t, c.Set(ctx, "a", "alice@example.com"))
+	require.NoError(t, c.Set(ctx, "b", "bob@example.com"))
+
+	for _, name := range []string{walSnapshotFile, walLogFile} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "example.com", name)
+	}
+
+	// 模拟崩溃时最后一条记录只写入了一部分
+	path := filepath.Join(dir, walLogFile)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-2))
+
+	restored := NewSimpleCache[string, string](ctx, 10, time.Hour, opt)
+	assert.Equal(t, map[string]string{"a": "alice@example.com"}, restored.Snapshot(ctx))
+	require.NoError(t, restored.Close())
+
+	_, err = NewWithConfig(ctx, Config[string, string]{
+		Backend:  simple.NewCache[string, *Item[string]](10),
+		Interval: time.Hour,
+		Options: []Option[string, string]{
+			WithWAL[string, string](dir, WithWALEncryption(bytes.Repeat([]byte{8}, 32))),
+		},
+	})
+	assert.ErrorIs(t, err, cacheError.ErrDecrypt)
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// EntryStats 是单个元素的访问统计。
+type EntryStats struct {
+	// Hits 为元素写入之后被 Get、MGet、GetOrSet 等读操作命中的次数
+	Hits uint64
+	// LastAccess 为最近一次命中的时间，从未被命中时为零值
+	LastAccess time.Time
+}
+
+// WithEntryStats 启用单个元素的访问统计，通过 EntryStats 查询，可用于找出真正的热点键以规划容量。
+// 每次命中会额外读取一次时钟并执行两次原子操作，因此默认关闭；统计随元素保存，覆盖写入时重新计数。
+func WithEntryStats[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.entryStats = true
+	}
+}
+
+// EntryStats 返回 key 的访问统计，查询本身不计入统计。
+// key 不存在或已过期时返回 cacheError.ErrNoKey，没有通过 WithEntryStats 启用时返回 cacheError.ErrEntryStatsDisabled。
+func (c *Cache[K, V]) EntryStats(ctx context.Context, key K) (_ EntryStats, err error) {
+	defer c.wrapKeyError("entrystats", key, &err)
+	if !c.opts.entryStats {
+		return EntryStats{}, cacheError.ErrEntryStatsDisabled
+	}
+	c.readLock()
+	defer c.readUnlock()
+	item, err := c.peek(ctx, key)
+	if err != nil {
+		return EntryStats{}, err
+	}
+	if item.Expired() {
+		return EntryStats{}, cacheError.ErrNoKey
+	}
+	stats := EntryStats{Hits: item.hits.Load()}
+	if ns := item.lastAccess.Load(); ns != 0 {
+		stats.LastAccess = time.Unix(0, ns)
+	}
+	return stats, nil
+}
+
+// recordHit 在启用 WithEntryStats 时记录 item 被命中一次，只需要持有读锁或不持有锁。
+func (c *Cache[K, V]) recordHit(item *Item[V]) {
+	if c.opts.entryStats {
+		item.hits.Add(1)
+		item.lastAccess.Store(time.Now().UnixNano())
+	}
+}
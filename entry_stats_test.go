@@ -0,0 +1,136 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_EntryStats(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		ops  func(t *testing.T, c *Cache[string, int])
+		key  string
+
+		wantHits   uint64
+		wantAccess bool
+		wantErr    error
+	}{
+		{
+			name:     "never read",
+			ops:      func(t *testing.T, c *Cache[string, int]) {},
+			key:      "a",
+			wantHits: 0,
+		},
+		{
+			name: "get, mget and getorset",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				_, err := c.Get(ctx, "a")
+				require.NoError(t, err)
+				_, err = c.MGet(ctx, "a", "missing")
+				require.NoError(t, err)
+				_, _, err = c.GetOrSet(ctx, "a", 2)
+				require.NoError(t, err)
+				// 不计入统计的读操作
+				c.Contains(ctx, "a")
+				_, err = c.Peek(ctx, "a")
+				require.NoError(t, err)
+			},
+			key:        "a",
+			wantHits:   3,
+			wantAccess: true,
+		},
+		{
+			name: "incr keeps stats",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				_, err := c.Get(ctx, "a")
+				require.NoError(t, err)
+				_, err = Incr(ctx, c, "a", 1)
+				require.NoError(t, err)
+			},
+			key:        "a",
+			wantHits:   1,
+			wantAccess: true,
+		},
+		{
+			name: "overwrite resets stats",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				_, err := c.Get(ctx, "a")
+				require.NoError(t, err)
+				require.NoError(t, c.Set(ctx, "a", 2))
+			},
+			key:      "a",
+			wantHits: 0,
+		},
+		{
+			name:    "missing key",
+			ops:     func(t *testing.T, c *Cache[string, int]) {},
+			key:     "missing",
+			wantErr: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewLruCache[string, int](ctx, 10, 0, WithEntryStats[string, int]())
+			require.NoError(t, c.Set(ctx, "a", 1))
+			tc.ops(t, c)
+			stats, err := c.EntryStats(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantHits, stats.Hits)
+			if tc.wantAccess {
+				assert.WithinDuration(t, time.Now(), stats.LastAccess, time.Second)
+			} else {
+				assert.True(t, stats.LastAccess.IsZero())
+			}
+		})
+	}
+}
+
+func TestCache_EntryStats_disabled(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 10, 0)
+	require.NoError(t, c.Set(ctx, "a", 1))
+	_, err := c.EntryStats(ctx, "a")
+	assert.Equal(t, cacheError.ErrEntryStatsDisabled, err)
+}
+
+func TestCache_EntryStats_concurrent(t *testing.T) {
+	ctx := context.Background()
+	// simple 后端的 Get 在共享读锁下执行
+	c := NewSimpleCache[string, int](ctx, 10, 0, WithEntryStats[string, int]())
+	require.NoError(t, c.Set(ctx, "a", 1))
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 100; j++ {
+				_, _ = c.Get(ctx, "a")
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	stats, err := c.EntryStats(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(400), stats.Hits)
+}
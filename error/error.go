@@ -14,8 +14,84 @@
 
 package error
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrNoKey = errors.New("cache: no key in cache")
+	ErrNoKey         = errors.New("cache: no key in cache")
+	ErrCallbackPanic = errors.New("cache: callback panicked")
+	ErrInvalidConfig = errors.New("cache: invalid config")
+	// ErrUnsupportedOrder 表示后端不能按请求的顺序返回键
+	ErrUnsupportedOrder = errors.New("cache: unsupported key order")
+	// ErrClosed 表示缓存已经被关闭
+	ErrClosed = errors.New("cache: cache is closed")
+	// ErrEntryStatsDisabled 表示没有通过 WithEntryStats 启用单个元素的访问统计
+	ErrEntryStatsDisabled = errors.New("cache: entry stats are not enabled")
+	// ErrPinUnsupported 表示后端没有实现 types.Pinner，不能固定元素
+	ErrPinUnsupported = errors.New("cache: backend does not support pinning")
+	// ErrResizeUnsupported 表示后端没有实现 types.Resizer，不能在运行时修改容量
+	ErrResizeUnsupported = errors.New("cache: backend does not support resizing")
+	// ErrCacheFull 表示缓存已满且设置了 WithRejectWhenFull，或者所有元素都被固定，新的键没有被写入
+	ErrCacheFull = errors.New("cache: cache is full")
+	// ErrKeyExpired 表示键存在但已经过期、尚未被清理，调用方可以据此区分"需要刷新"和"从未存在"。
+	// ErrKeyExpired 包装了 ErrNoKey，只关心键是否可用的调用方仍然可以使用 errors.Is(err, ErrNoKey)。
+	ErrKeyExpired error = keyExpiredError{}
+	// ErrDecrypt 表示加密的数据无法解密，通常是密钥错误或数据被篡改
+	ErrDecrypt = errors.New("cache: message authentication failed")
 )
+
+type keyExpiredError struct{}
+
+func (keyExpiredError) Error() string {
+	return "cache: key expired"
+}
+
+func (keyExpiredError) Unwrap() error {
+	return ErrNoKey
+}
+
+// KeyError 是缓存对单个键的操作失败时返回的错误，记录了操作、键、缓存名称和后端，
+// 便于在多个缓存共享的中间件中定位出错的缓存。KeyError 包装了具体的原因，
+// 仍然可以通过 errors.Is(err, ErrNoKey) 等方式匹配，也可以通过 errors.As 取得 KeyError。
+type KeyError struct {
+	// Op 为出错的操作，如 "get"、"delete"
+	Op string
+	// Key 为出错的键
+	Key any
+	// Cache 为缓存的名称，未命名时为空字符串
+	Cache string
+	// Backend 为后端的类型，如 "*lru.Cache"
+	Backend string
+	// Err 为被包装的错误
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	name := "cache"
+	if e.Cache != "" {
+		name = fmt.Sprintf("cache %q", e.Cache)
+	}
+	return fmt.Sprintf("%s (%s): %s %v: %v", name, e.Backend, e.Op, e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError 记录用户回调中被恢复的 panic，可以通过 errors.Is(err, ErrCallbackPanic) 识别。
+type PanicError struct {
+	// Value 为传给 panic 的值
+	Value any
+	// Stack 为发生 panic 时的调用栈
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrCallbackPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrCallbackPanic
+}
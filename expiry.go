@@ -0,0 +1,127 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryQueue 是按过期时间排序的小顶堆，每个键至多一项，DeleteExpired 据此只处理已经到期的键。
+// 堆中记录的过期时间允许早于元素实际的过期时间（例如被 Touch 或滑动过期延长），出堆时以元素为准，
+// 因此只有写入新元素或缩短过期时间时需要调用 push。调用方需要持有写锁。
+type expiryQueue[K comparable] struct {
+	entries []expiryEntry[K]
+	index   map[K]int
+}
+
+type expiryEntry[K comparable] struct {
+	key        K
+	expiration time.Time
+}
+
+func (q *expiryQueue[K]) Len() int { return len(q.entries) }
+
+func (q *expiryQueue[K]) Less(i, j int) bool {
+	return q.entries[i].expiration.Before(q.entries[j].expiration)
+}
+
+func (q *expiryQueue[K]) Swap(i, j int) {
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.index[q.entries[i].key] = i
+	q.index[q.entries[j].key] = j
+}
+
+func (q *expiryQueue[K]) Push(x any) {
+	e := x.(expiryEntry[K])
+	q.index[e.key] = len(q.entries)
+	q.entries = append(q.entries, e)
+}
+
+func (q *expiryQueue[K]) Pop() any {
+	n := len(q.entries) - 1
+	e := q.entries[n]
+	q.entries[n] = expiryEntry[K]{}
+	q.entries = q.entries[:n]
+	delete(q.index, e.key)
+	return e
+}
+
+// push 记录 key 在 expiration 过期，key 已在堆中时更新其过期时间。
+func (q *expiryQueue[K]) push(key K, expiration time.Time) {
+	if i, ok := q.index[key]; ok {
+		q.entries[i].expiration = expiration
+		heap.Fix(q, i)
+		return
+	}
+	if q.index == nil {
+		q.index = make(map[K]int)
+	}
+	heap.Push(q, expiryEntry[K]{key: key, expiration: expiration})
+}
+
+// remove 将 key 移出堆，key 不在堆中时什么也不做。
+func (q *expiryQueue[K]) remove(key K) {
+	if i, ok := q.index[key]; ok {
+		heap.Remove(q, i)
+	}
+}
+
+// popDue 弹出过期时间不晚于 now 的最早一项，没有到期的键时返回 false。
+func (q *expiryQueue[K]) popDue(now time.Time) (K, bool) {
+	if len(q.entries) == 0 || q.entries[0].expiration.After(now) {
+		var zero K
+		return zero, false
+	}
+	return heap.Pop(q).(expiryEntry[K]).key, true
+}
+
+// walkDue 对过期时间不晚于 now 的每个键调用 fn，不修改堆，只访问到期的节点。
+func (q *expiryQueue[K]) walkDue(now time.Time, fn func(key K)) {
+	var walk func(i int)
+	walk = func(i int) {
+		if i >= len(q.entries) || q.entries[i].expiration.After(now) {
+			return
+		}
+		fn(q.entries[i].key)
+		walk(2*i + 1)
+		walk(2*i + 2)
+	}
+	walk(0)
+}
+
+// track 在写入 item 之后维护过期堆、WithPinned 的固定状态和 WithMaxCost、WithMaxBytes 的成本统计，并记录到 WithWAL 的日志，调用方需要持有写锁。
+// 总成本超过限制时 track 会淘汰元素，其中可能包括 key 本身。
+func (c *Cache[K, V]) track(key K, item *Item[V]) {
+	c.logSet(key, item)
+	if item.expiration.IsZero() {
+		c.expiry.remove(key)
+	} else {
+		c.expiry.push(key, item.expiration)
+	}
+	if item.pinned {
+		// 先固定再按成本淘汰，避免刚写入的元素被淘汰
+		c.pin(key)
+	}
+	c.trackCost(key, item)
+}
+
+// untrack 在 key 被删除、淘汰或过期之后清理 track 记录的信息，调用方需要持有写锁。
+func (c *Cache[K, V]) untrack(key K) {
+	c.expiry.remove(key)
+	if c.cost != nil {
+		c.cost.remove(key)
+	}
+}
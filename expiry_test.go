@@ -0,0 +1,94 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryQueue(t *testing.T) {
+	now := time.Now()
+	var q expiryQueue[string]
+	q.push("c", now.Add(3*time.Second))
+	q.push("a", now.Add(time.Second))
+	q.push("b", now.Add(2*time.Second))
+	q.push("d", now.Add(4*time.Second))
+	// 更新已有的键
+	q.push("d", now.Add(-time.Second))
+	q.remove("b")
+	q.remove("missing")
+
+	var got []string
+	for {
+		key, ok := q.popDue(now.Add(3 * time.Second))
+		if !ok {
+			break
+		}
+		got = append(got, key)
+	}
+	assert.Equal(t, []string{"d", "a", "c"}, got)
+	assert.Zero(t, q.Len())
+	assert.Empty(t, q.index)
+}
+
+// countingCache 记录 Get 的调用次数。
+type countingCache[K comparable, V any] struct {
+	*simple.Cache[K, V]
+	gets int
+}
+
+func (c *countingCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	c.gets++
+	return c.Cache.Get(ctx, key)
+}
+
+func TestCache_DeleteExpired_onlyDue(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingCache[int, *Item[int]]{Cache: simple.NewCache[int, *Item[int]](0)}
+	c := New[int, int](ctx, backend, 0)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Set(ctx, i, i))
+	}
+	require.NoError(t, c.Set(ctx, 100, 100, WithExpiration(time.Millisecond)))
+	require.NoError(t, c.Set(ctx, 101, 101, WithSlidingExpiration(time.Millisecond)))
+	require.NoError(t, c.Set(ctx, 102, 102, WithExpiration(time.Millisecond)))
+	require.NoError(t, c.Set(ctx, 103, 103, WithExpiration(time.Millisecond)))
+	require.NoError(t, c.Set(ctx, 104, 104, WithExpiration(time.Hour)))
+	// 延长和取消过期时间不会调整过期堆，到期时以元素为准
+	require.NoError(t, c.Expire(ctx, 101, time.Hour))
+	require.NoError(t, c.Touch(ctx, 101))
+	_, err := c.Persist(ctx, 102)
+	require.NoError(t, err)
+	require.NoError(t, c.Delete(ctx, 103))
+	time.Sleep(5 * time.Millisecond)
+
+	backend.gets = 0
+	c.DeleteExpired(ctx)
+	// 只读取了到期的 100 和 102，被删除的 103 已经移出过期堆
+	assert.Equal(t, 2, backend.gets)
+	assert.Equal(t, uint64(1), c.Stats().Expired)
+	assert.Len(t, c.Keys(), 103)
+	assert.Equal(t, 2, c.expiry.Len())
+
+	backend.gets = 0
+	c.DeleteExpired(ctx)
+	assert.Zero(t, backend.gets)
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import "expvar"
+
+// WithExpvar 将缓存的统计信息以 name 为名发布到 expvar，可以通过 /debug/vars 查看。
+// name 已经被发布时配置无效，New 会 panic，NewWithConfig 返回 cacheError.ErrInvalidConfig；
+// expvar 不支持取消发布，因此该选项适用于与进程生命周期相同的缓存。
+func WithExpvar[K comparable, V any](name string) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.expvarName = name
+	}
+}
+
+func (c *Cache[K, V]) publishExpvar() {
+	expvar.Publish(c.opts.expvarName, expvar.Func(func() any {
+		size := c.Len()
+		s := c.Stats()
+		return map[string]uint64{
+			"size":            uint64(size),
+			"hits":            s.Hits,
+			"misses":          s.Misses,
+			"sets":            s.Sets,
+			"deletes":         s.Deletes,
+			"evictions":       s.Evictions,
+			"expired":         s.Expired,
+			"ghost_hits":      s.GhostHits,
+			"loads":           s.Loads,
+			"coalesced_loads": s.CoalescedLoads,
+			"max_coalesced":   s.MaxCoalesced,
+		}
+	}))
+}
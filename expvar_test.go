@@ -0,0 +1,66 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExpvar(t *testing.T) {
+	ctx := context.Background()
+	// expvar 不支持取消发布，使用唯一的名称保证测试可以重复运行
+	name := fmt.Sprintf("test_with_expvar_%d", time.Now().UnixNano())
+	cache := NewSimpleCache[int, int](ctx, 0, time.Minute, WithExpvar[int, int](name))
+	require.NoError(t, cache.Set(ctx, 1, 1))
+	require.NoError(t, cache.Set(ctx, 2, 2, WithExpiration(-time.Second)))
+	_, _ = cache.Get(ctx, 1)
+	_, _ = cache.Get(ctx, 3)
+
+	v := expvar.Get(name)
+	require.NotNil(t, v)
+	var got map[string]uint64
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &got))
+	assert.Equal(t, map[string]uint64{
+		"size":            2,
+		"hits":            1,
+		"misses":          1,
+		"sets":            2,
+		"deletes":         0,
+		"evictions":       0,
+		"expired":         0,
+		"ghost_hits":      0,
+		"loads":           0,
+		"coalesced_loads": 0,
+		"max_coalesced":   0,
+	}, got)
+
+	// 重复的名称作为无效配置返回，而不是在 expvar.Publish 中 panic
+	_, err := NewWithConfig[int, int](ctx, Config[int, int]{
+		Backend: simple.NewCache[int, *Item[int]](0),
+		Options: []Option[int, int]{WithExpvar[int, int](name)},
+	})
+	assert.ErrorIs(t, err, cacheError.ErrInvalidConfig)
+}
@@ -15,62 +15,183 @@
 package fifo
 
 import (
-	"container/list"
 	"context"
 
 	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/internal/list"
+	"github.com/chenmingyong0423/go-generics-cache/types"
 )
 
 type entry[K comparable, V any] struct {
-	key   K
-	value V
+	key    K
+	value  V
+	pinned bool
+	// tick 为最近一次写入的序号，固定和取消固定时据此保持元素在链表中的相对顺序
+	tick uint64
 }
 
-func NewCache[K comparable, V any](cap int) *Cache[K, V] {
-	return &Cache[K, V]{
+// Option 配置 Cache 的行为。
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithOnEvicted 设置元素因容量不足被淘汰时的回调，显式调用 Delete 删除的元素不会触发回调。
+func WithOnEvicted[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.onEvicted = fn
+	}
+}
+
+// WithRejectWhenFull 使缓存已满时写入新的键返回 cacheError.ErrCacheFull，而不是淘汰已有的元素。
+func WithRejectWhenFull[K comparable, V any]() Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.rejectWhenFull = true
+	}
+}
+
+// SetRejectWhenFull 实现了 types.FullRejecter，效果与 WithRejectWhenFull 相同。
+func (c *Cache[K, V]) SetRejectWhenFull(reject bool) {
+	c.rejectWhenFull = reject
+}
+
+// SetOnEvicted 替换淘汰回调，实现了 types.EvictionNotifier。
+func (c *Cache[K, V]) SetOnEvicted(fn func(key K, value V)) {
+	c.onEvicted = fn
+}
+
+func NewCache[K comparable, V any](cap int, opts ...Option[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{
 		maxEntries:       cap,
-		cache:            make(map[K]*list.Element, cap),
-		linkedDoublyList: list.New(),
+		cache:            make(map[K]*list.Element[entry[K, V]], cap),
+		linkedDoublyList: list.New[entry[K, V]](),
+		pinned:           list.New[entry[K, V]](),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type Cache[K comparable, V any] struct {
-	maxEntries       int
-	cache            map[K]*list.Element
-	linkedDoublyList *list.List
+	maxEntries int
+	cache      map[K]*list.Element[entry[K, V]]
+	// linkedDoublyList 只保存没有被固定的元素，被固定的元素保存在 pinned 中，淘汰时不需要跳过它们。
+	// 两个链表都从头到尾按 tick 从小到大排列
+	linkedDoublyList *list.List[entry[K, V]]
+	pinned           *list.List[entry[K, V]]
+	tick             uint64
+	onEvicted        func(key K, value V)
+	rejectWhenFull   bool
+	evictions        uint64
 }
 
 func (c *Cache[K, V]) Set(_ context.Context, key K, value V) error {
 	if e, ok := c.cache[key]; ok {
 		// 元素存在
-		c.linkedDoublyList.MoveToBack(e)
-		e.Value.(*entry[K, V]).value = value
+		c.tick++
+		e.Value.tick = c.tick
+		c.listOf(e).MoveToBack(e)
+		e.Value.value = value
 		return nil
 	}
 	// 元素不存在
-	if c.linkedDoublyList.Len() >= c.maxEntries {
-		e := c.linkedDoublyList.Front()
-		c.linkedDoublyList.Remove(e)
-		delete(c.cache, e.Value.(*entry[K, V]).key)
+	if len(c.cache) >= c.maxEntries {
+		// 所有元素都被固定时没有可以淘汰的元素，新写入的元素会被立即淘汰，因此拒绝写入
+		if c.rejectWhenFull || (len(c.cache) > 0 && c.linkedDoublyList.Len() == 0) {
+			return cacheError.ErrCacheFull
+		}
 	}
-	e := &entry[K, V]{
+	c.tick++
+	e := entry[K, V]{
 		key:   key,
 		value: value,
+		tick:  c.tick,
 	}
 	c.cache[key] = c.linkedDoublyList.PushBack(e)
+	if len(c.cache) > c.maxEntries {
+		c.EvictOne()
+	}
 	return nil
 }
 
+func (c *Cache[K, V]) listOf(e *list.Element[entry[K, V]]) *list.List[entry[K, V]] {
+	if e.Value.pinned {
+		return c.pinned
+	}
+	return c.linkedDoublyList
+}
+
+// EvictOne 实现了 types.Evicter，淘汰最早写入且没有被固定的元素，所有元素都被固定时返回 false。
+func (c *Cache[K, V]) EvictOne() (key K, ok bool) {
+	e := c.linkedDoublyList.Front()
+	if e == nil {
+		return key, false
+	}
+	c.linkedDoublyList.Remove(e)
+	en := e.Value
+	delete(c.cache, en.key)
+	c.evictions++
+	if c.onEvicted != nil {
+		c.onEvicted(en.key, en.value)
+	}
+	return en.key, true
+}
+
+// SetCapacity 实现了 types.Resizer，缩小容量时立即淘汰多出的元素，被固定的元素不会被淘汰。
+func (c *Cache[K, V]) SetCapacity(n int) {
+	c.maxEntries = n
+	for len(c.cache) > n {
+		if _, ok := c.EvictOne(); !ok {
+			return
+		}
+	}
+}
+
+// Pin 实现了 types.Pinner，被固定的元素不会因容量不足被淘汰，只能通过 Delete 删除。
+// 所有元素都被固定时写入新的键返回 cacheError.ErrCacheFull。
+func (c *Cache[K, V]) Pin(key K) bool {
+	return c.setPinned(key, true)
+}
+
+// Unpin 实现了 types.Pinner，取消固定之后元素可以再次被淘汰。
+func (c *Cache[K, V]) Unpin(key K) bool {
+	return c.setPinned(key, false)
+}
+
+// setPinned 将元素移动到另一个链表中 tick 对应的位置，需要从链表头部查找位置，
+// 被固定和取消固定的通常是较早写入的元素（例如 WithRecomputeAwareEviction 的候选元素）。
+func (c *Cache[K, V]) setPinned(key K, pinned bool) bool {
+	e, ok := c.cache[key]
+	if !ok || e.Value.pinned == pinned {
+		return ok
+	}
+	c.listOf(e).Remove(e)
+	en := e.Value
+	en.pinned = pinned
+	to := c.linkedDoublyList
+	if pinned {
+		to = c.pinned
+	}
+	mark := to.Front()
+	for mark != nil && mark.Value.tick < en.tick {
+		mark = mark.Next()
+	}
+	if mark == nil {
+		c.cache[key] = to.PushBack(en)
+	} else {
+		c.cache[key] = to.InsertBefore(en, mark)
+	}
+	return true
+}
+
 func (c *Cache[K, V]) Get(_ context.Context, key K) (v V, err error) {
 	if e, ok := c.cache[key]; ok {
-		return e.Value.(*entry[K, V]).value, nil
+		return e.Value.value, nil
 	}
 	return v, cacheError.ErrNoKey
 }
 
 func (c *Cache[K, V]) Delete(_ context.Context, key K) error {
 	if e, ok := c.cache[key]; ok {
-		c.linkedDoublyList.Remove(e)
+		c.listOf(e).Remove(e)
 		delete(c.cache, key)
 		return nil
 	}
@@ -79,8 +200,76 @@ func (c *Cache[K, V]) Delete(_ context.Context, key K) error {
 
 func (c *Cache[K, V]) Keys() []K {
 	keys := make([]K, 0)
-	for e := c.linkedDoublyList.Front(); e != nil; e = e.Next() {
-		keys = append(keys, e.Value.(*entry[K, V]).key)
-	}
+	c.RangeKeys(func(key K) bool {
+		keys = append(keys, key)
+		return true
+	})
 	return keys
 }
+
+// RangeKeys 实现了 types.Ranger，按与 Keys 相同的顺序遍历键，即合并两个链表后按写入顺序。
+func (c *Cache[K, V]) RangeKeys(fn func(key K) bool) {
+	a, b := c.linkedDoublyList.Front(), c.pinned.Front()
+	for a != nil || b != nil {
+		e := a
+		if a == nil || (b != nil && b.Value.tick < a.Value.tick) {
+			e, b = b, b.Next()
+		} else {
+			a = a.Next()
+		}
+		if !fn(e.Value.key) {
+			return
+		}
+	}
+}
+
+// Len 返回元素的数量。
+func (c *Cache[K, V]) Len() int {
+	return len(c.cache)
+}
+
+// Contains 实现了 types.Container，报告 key 是否存在，不会改变元素的顺序。
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.cache[key]
+	return ok
+}
+
+// Clear 实现了 types.Clearer，删除所有元素（包括被固定的元素），不会触发淘汰回调。
+func (c *Cache[K, V]) Clear() {
+	clear(c.cache)
+	c.linkedDoublyList.Init()
+	c.pinned.Init()
+}
+
+// ReadOnlyGet 实现了 types.ReadOnlyGetter，Get 不会修改内部状态。
+func (c *Cache[K, V]) ReadOnlyGet() bool {
+	return true
+}
+
+// KeysIn 实现了 types.KeyOrderer，支持 types.OrderAny 和 types.OrderInsertion，两者的结果与 Keys 相同。
+func (c *Cache[K, V]) KeysIn(order types.Order) ([]K, error) {
+	switch order {
+	case types.OrderAny, types.OrderInsertion:
+		return c.Keys(), nil
+	default:
+		return nil, cacheError.ErrUnsupportedOrder
+	}
+}
+
+// Stats 实现了 types.Inspector。
+func (c *Cache[K, V]) Stats() types.BackendStats {
+	return types.BackendStats{Len: len(c.cache), Evictions: c.evictions}
+}
+
+// OldestEntry 实现了 types.Inspector，返回最早写入且没有被固定的元素，即下一个被淘汰的元素。
+func (c *Cache[K, V]) OldestEntry() (key K, value V, ok bool) {
+	if e := c.linkedDoublyList.Front(); e != nil {
+		return e.Value.key, e.Value.value, true
+	}
+	return key, value, false
+}
+
+// Config 实现了 types.Inspector。
+func (c *Cache[K, V]) Config() types.BackendConfig {
+	return types.BackendConfig{Policy: "fifo", Capacity: c.maxEntries, RejectWhenFull: c.rejectWhenFull}
+}
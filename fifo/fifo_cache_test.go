@@ -16,9 +16,12 @@ package fifo
 
 import (
 	"context"
+	"strconv"
 	"testing"
 
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
 	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -378,3 +381,175 @@ func TestCache_Keys(t *testing.T) {
 		})
 	}
 }
+
+func TestWithOnEvicted(t *testing.T) {
+	var evicted []string
+	cache := NewCache[string, int](1, WithOnEvicted(func(key string, value int) {
+		evicted = append(evicted, key)
+		assert.Equal(t, key, strconv.Itoa(value))
+	}))
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, cache.Set(context.Background(), strconv.Itoa(i), i))
+	}
+	assert.NoError(t, cache.Delete(context.Background(), "3"))
+	assert.Equal(t, []string{"1", "2"}, evicted)
+}
+
+func TestCache_Pin(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	cache := NewCache[string, int](3, WithOnEvicted(func(key string, _ int) {
+		evicted = append(evicted, key)
+	}))
+	for i, key := range []string{"a", "b", "c"} {
+		assert.NoError(t, cache.Set(ctx, key, i))
+	}
+	assert.True(t, cache.Pin("a"))
+	assert.True(t, cache.Pin("b"))
+	assert.False(t, cache.Pin("missing"))
+	assert.NoError(t, cache.Set(ctx, "d", 3))
+	assert.Equal(t, []string{"a", "b", "d"}, cache.Keys())
+
+	// 所有元素都被固定时拒绝写入新的键
+	assert.True(t, cache.Pin("d"))
+	assert.Equal(t, cacheError.ErrCacheFull, cache.Set(ctx, "e", 4))
+	assert.Equal(t, []string{"a", "b", "d"}, cache.Keys())
+
+	// 被固定的元素仍然参与排序，取消固定后回到原来的位置
+	assert.NoError(t, cache.Set(ctx, "a", 0))
+	assert.True(t, cache.Unpin("b"))
+	assert.Equal(t, []string{"b", "d", "a"}, cache.Keys())
+	assert.NoError(t, cache.Set(ctx, "e", 4))
+	assert.Equal(t, []string{"d", "a", "e"}, cache.Keys())
+	assert.Equal(t, []string{"c", "b"}, evicted)
+}
+
+func TestCache_Set_zeroCapacity(t *testing.T) {
+	var evicted []string
+	cache := NewCache[string, int](0, WithOnEvicted(func(key string, _ int) {
+		evicted = append(evicted, key)
+	}))
+	assert.NoError(t, cache.Set(context.Background(), "a", 1))
+	assert.Zero(t, cache.Len())
+	assert.Equal(t, []string{"a"}, evicted)
+}
+
+func TestCache_SetCapacity(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	cache := NewCache[string, int](4, WithOnEvicted(func(key string, _ int) {
+		evicted = append(evicted, key)
+	}))
+	for i, key := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, cache.Set(ctx, key, i))
+	}
+	cache.SetCapacity(2)
+	assert.Equal(t, []string{"a", "b"}, evicted)
+	assert.Equal(t, []string{"c", "d"}, cache.Keys())
+
+	cache.SetCapacity(3)
+	assert.NoError(t, cache.Set(ctx, "e", 4))
+	assert.Equal(t, []string{"c", "d", "e"}, cache.Keys())
+	assert.Equal(t, []string{"a", "b"}, evicted)
+
+	// 被固定的元素不会被淘汰
+	assert.True(t, cache.Pin("c"))
+	cache.SetCapacity(1)
+	assert.Equal(t, []string{"c"}, cache.Keys())
+	assert.Equal(t, []string{"a", "b", "d", "e"}, evicted)
+}
+
+func TestWithRejectWhenFull(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	cache := NewCache[string, int](2, WithRejectWhenFull[string, int](), WithOnEvicted(func(key string, _ int) {
+		evicted = append(evicted, key)
+	}))
+	assert.NoError(t, cache.Set(ctx, "a", 1))
+	assert.NoError(t, cache.Set(ctx, "b", 2))
+	assert.Equal(t, cacheError.ErrCacheFull, cache.Set(ctx, "c", 3))
+	// 覆盖已有的键不受影响
+	assert.NoError(t, cache.Set(ctx, "a", 10))
+	assert.NoError(t, cache.Delete(ctx, "b"))
+	assert.NoError(t, cache.Set(ctx, "c", 3))
+	assert.ElementsMatch(t, []string{"a", "c"}, cache.Keys())
+	assert.Empty(t, evicted)
+
+	cache.SetRejectWhenFull(false)
+	assert.NoError(t, cache.Set(ctx, "d", 4))
+	assert.Len(t, evicted, 1)
+}
+
+func TestCache_KeysIn(t *testing.T) {
+	cache := NewCache[string, int](3)
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, cache.Set(context.Background(), strconv.Itoa(i), i))
+	}
+	testCases := []struct {
+		name  string
+		order types.Order
+
+		want    []string
+		wantErr error
+	}{
+		{name: "any", order: types.OrderAny, want: []string{"1", "2", "3"}},
+		{name: "supported", order: types.OrderInsertion, want: []string{"1", "2", "3"}},
+		{name: "unsupported", order: types.OrderRecency, wantErr: cacheError.ErrUnsupportedOrder},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := cache.KeysIn(tc.order)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return NewCache[string, string](cachetest.Capacity)
+	})
+}
+
+func TestCache_Allocs(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache[int, int](100)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, c.Set(ctx, i, i))
+	}
+	testCases := []struct {
+		name string
+		fn   func(i int)
+
+		want float64
+	}{
+		{
+			name: "get",
+			fn:   func(i int) { _, _ = c.Get(ctx, i%100) },
+		},
+		{
+			name: "get missing",
+			fn:   func(i int) { _, _ = c.Get(ctx, -1) },
+		},
+		{
+			name: "overwrite",
+			fn:   func(i int) { _ = c.Set(ctx, i%100, i) },
+		},
+		{
+			// 缓存已满，每次写入淘汰一个元素，只分配新的节点
+			name: "set new key",
+			fn:   func(i int) { _ = c.Set(ctx, 100+i, i) },
+			want: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			i := 0
+			allocs := testing.AllocsPerRun(100, func() {
+				tc.fn(i)
+				i++
+			})
+			assert.Equal(t, tc.want, allocs)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filecache
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"sort"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// File 是以只读方式打开的缓存文件，实现了 types.ICache[string, []byte]。
+// File 不可变，可以被多个协程并发读取；Set 与 Delete 总是返回 ErrReadOnly。
+type File struct {
+	data  []byte
+	count int
+	unmap func() error
+}
+
+// Open 以内存映射的方式打开 path 指向的缓存文件，不支持内存映射的平台会把文件完整读入内存。
+// 使用完毕后需要调用 Close 释放映射。
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, unmap, err := mapFile(f)
+	if err != nil {
+		return nil, err
+	}
+	file, err := newFile(data)
+	if err != nil {
+		_ = unmap()
+		return nil, err
+	}
+	file.unmap = unmap
+	return file, nil
+}
+
+// FromBytes 从内存中的数据创建 File，data 在 File 使用期间不能被修改。
+func FromBytes(data []byte) (*File, error) {
+	return newFile(data)
+}
+
+func newFile(data []byte) (*File, error) {
+	if len(data) < headerSize || string(data[:4]) != magic || binary.BigEndian.Uint32(data[4:8]) != version {
+		return nil, ErrInvalidFile
+	}
+	count := binary.BigEndian.Uint64(data[8:16])
+	if count > uint64(len(data)-headerSize)/indexSize {
+		return nil, ErrInvalidFile
+	}
+	f := &File{data: data, count: int(count)}
+	// 校验所有条目都落在文件范围内，之后的读取无需再做边界检查
+	for i := 0; i < f.count; i++ {
+		off, klen, vlen := f.index(i)
+		if off < uint64(headerSize+indexSize*f.count) || off+klen+vlen > uint64(len(data)) {
+			return nil, ErrInvalidFile
+		}
+	}
+	return f, nil
+}
+
+func (f *File) index(i int) (off, klen, vlen uint64) {
+	e := f.data[headerSize+i*indexSize:]
+	return binary.BigEndian.Uint64(e[0:8]), uint64(binary.BigEndian.Uint32(e[8:12])), uint64(binary.BigEndian.Uint32(e[12:16]))
+}
+
+func (f *File) key(i int) []byte {
+	off, klen, _ := f.index(i)
+	return f.data[off : off+klen]
+}
+
+// Len 返回文件中的条目数。
+func (f *File) Len() int {
+	return f.count
+}
+
+// Get 返回 key 对应的值，key 不存在时返回 cacheError.ErrNoKey。
+// 返回的切片直接指向映射区域，调用方不能修改它，并且在 Close 之后不能再使用。
+func (f *File) Get(_ context.Context, key string) ([]byte, error) {
+	i := sort.Search(f.count, func(i int) bool {
+		return string(f.key(i)) >= key
+	})
+	if i == f.count || string(f.key(i)) != key {
+		return nil, cacheError.ErrNoKey
+	}
+	off, klen, vlen := f.index(i)
+	return f.data[off+klen : off+klen+vlen : off+klen+vlen], nil
+}
+
+// Set 总是返回 ErrReadOnly。
+func (f *File) Set(context.Context, string, []byte) error {
+	return ErrReadOnly
+}
+
+// Delete 总是返回 ErrReadOnly。
+func (f *File) Delete(context.Context, string) error {
+	return ErrReadOnly
+}
+
+// Keys 按字典序返回所有的键。
+func (f *File) Keys() []string {
+	keys := make([]string, 0, f.count)
+	for i := 0; i < f.count; i++ {
+		keys = append(keys, string(f.key(i)))
+	}
+	return keys
+}
+
+// ReadOnlyGet 实现了 types.ReadOnlyGetter，Get 不会修改内部状态。
+func (f *File) ReadOnlyGet() bool {
+	return true
+}
+
+// Close 释放内存映射，之后不能再使用 File 以及由 Get 返回的切片。
+func (f *File) Close() error {
+	if f.unmap == nil {
+		return nil
+	}
+	unmap := f.unmap
+	f.unmap, f.data, f.count = nil, nil, 0
+	return unmap()
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filecache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/lru"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func build(t *testing.T, entries map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	for k, v := range entries {
+		require.NoError(t, w.Add(k, []byte(v)))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.ggcf")
+	require.NoError(t, os.WriteFile(path, build(t, map[string]string{"b": "2", "a": "1", "c": ""}), 0o600))
+
+	f, err := Open(path)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, f.Close()) }()
+
+	testCases := []struct {
+		name string
+		key  string
+
+		want    []byte
+		wantErr error
+	}{
+		{name: "first", key: "a", want: []byte("1")},
+		{name: "last", key: "c", want: []byte{}},
+		{name: "middle", key: "b", want: []byte("2")},
+		{name: "missing", key: "bb", wantErr: cacheError.ErrNoKey},
+		{name: "after last", key: "d", wantErr: cacheError.ErrNoKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := f.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	assert.Equal(t, 3, f.Len())
+	assert.Equal(t, []string{"a", "b", "c"}, f.Keys())
+	assert.Equal(t, ErrReadOnly, f.Set(context.Background(), "a", nil))
+	assert.Equal(t, ErrReadOnly, f.Delete(context.Background(), "a"))
+}
+
+func TestFromBytes(t *testing.T) {
+	valid := build(t, map[string]string{"a": "1"})
+	testCases := []struct {
+		name string
+		data []byte
+
+		wantErr error
+	}{
+		{name: "valid", data: valid},
+		{name: "empty file", data: build(t, nil)},
+		{name: "too short", data: valid[:8], wantErr: ErrInvalidFile},
+		{name: "bad magic", data: append([]byte("XXXX"), valid[4:]...), wantErr: ErrInvalidFile},
+		{name: "truncated data", data: valid[:len(valid)-1], wantErr: ErrInvalidFile},
+		{name: "truncated index", data: valid[:headerSize+4], wantErr: ErrInvalidFile},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FromBytes(tc.data)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{})
+	value := []byte("1")
+	require.NoError(t, w.Add("a", value))
+	require.NoError(t, w.Add("a", []byte("2")))
+	value[0] = 'x'
+	require.NoError(t, w.Close())
+	assert.Equal(t, ErrClosed, w.Add("b", nil))
+	assert.Equal(t, ErrClosed, w.Close())
+}
+
+func TestOverlay(t *testing.T) {
+	ctx := context.Background()
+	base, err := FromBytes(build(t, map[string]string{"a": "1", "b": "2"}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		ops  func(t *testing.T, o *Overlay)
+		key  string
+
+		want     []byte
+		wantErr  error
+		wantKeys []string
+	}{
+		{
+			name:     "read from base",
+			ops:      func(t *testing.T, o *Overlay) {},
+			key:      "a",
+			want:     []byte("1"),
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name: "override base",
+			ops: func(t *testing.T, o *Overlay) {
+				require.NoError(t, o.Set(ctx, "a", []byte("10")))
+			},
+			key:      "a",
+			want:     []byte("10"),
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name: "delete base key",
+			ops: func(t *testing.T, o *Overlay) {
+				require.NoError(t, o.Delete(ctx, "a"))
+				assert.Equal(t, cacheError.ErrNoKey, o.Delete(ctx, "a"))
+			},
+			key:      "a",
+			wantErr:  cacheError.ErrNoKey,
+			wantKeys: []string{"b"},
+		},
+		{
+			name: "set after delete",
+			ops: func(t *testing.T, o *Overlay) {
+				require.NoError(t, o.Delete(ctx, "a"))
+				require.NoError(t, o.Set(ctx, "a", []byte("11")))
+			},
+			key:      "a",
+			want:     []byte("11"),
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name: "l1 only key",
+			ops: func(t *testing.T, o *Overlay) {
+				require.NoError(t, o.Set(ctx, "c", []byte("3")))
+			},
+			key:      "c",
+			want:     []byte("3"),
+			wantKeys: []string{"a", "b", "c"},
+		},
+		{
+			name: "delete missing key",
+			ops: func(t *testing.T, o *Overlay) {
+				assert.Equal(t, cacheError.ErrNoKey, o.Delete(ctx, "c"))
+			},
+			key:      "c",
+			wantErr:  cacheError.ErrNoKey,
+			wantKeys: []string{"a", "b"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewOverlay(simple.NewCache[string, []byte](0), base)
+			tc.ops(t, o)
+			got, err := o.Get(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+			keys := o.Keys()
+			sort.Strings(keys)
+			assert.Equal(t, tc.wantKeys, keys)
+		})
+	}
+}
+
+func TestOverlay_evicted(t *testing.T) {
+	ctx := context.Background()
+	base, err := FromBytes(build(t, map[string]string{"a": "1"}))
+	require.NoError(t, err)
+	var evicted []string
+	o := NewOverlay(lru.NewCache[string, []byte](1), base)
+	o.SetOnEvicted(func(key string, _ []byte) { evicted = append(evicted, key) })
+
+	require.NoError(t, o.Set(ctx, "a", []byte("10")))
+	require.NoError(t, o.Set(ctx, "b", []byte("2")))
+	require.NoError(t, o.Set(ctx, "c", []byte("3")))
+
+	// 被淘汰的覆盖写入不会让 File 中的旧值重新出现
+	_, err = o.Get(ctx, "a")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+	_, err = o.Get(ctx, "b")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+	assert.Equal(t, []string{"a", "b"}, evicted)
+	assert.Equal(t, []string{"c"}, o.Keys())
+}
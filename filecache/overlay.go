@@ -0,0 +1,121 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filecache
+
+import (
+	"context"
+	"errors"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+var (
+	_ types.ICache[string, []byte]           = (*File)(nil)
+	_ types.ICache[string, []byte]           = (*Overlay)(nil)
+	_ types.EvictionNotifier[string, []byte] = (*Overlay)(nil)
+)
+
+// Overlay 在只读的 File 之上叠加一个可写的 L1 缓存：读取时先查 L1 再查 File，写入只落到 L1，
+// 删除 File 中的键时记录墓碑将其屏蔽。
+//
+// 如果 L1 会自行淘汰元素（实现了 types.EvictionNotifier），被淘汰且在 File 中存在的键同样会被屏蔽，
+// 避免 File 中的旧值在覆盖写入被淘汰后重新出现。
+//
+// 与其他后端一样，Overlay 不是并发安全的，需要由外层（如 cache.Cache）加锁。
+type Overlay struct {
+	l1        types.ICache[string, []byte]
+	base      *File
+	deleted   map[string]struct{}
+	onEvicted func(key string, value []byte)
+}
+
+// NewOverlay 返回以 l1 为可写层、base 为只读层的 Overlay。
+// l1 实现了 types.EvictionNotifier 时，其淘汰回调会被 Overlay 接管，需要通过 Overlay.SetOnEvicted 设置回调。
+func NewOverlay(l1 types.ICache[string, []byte], base *File) *Overlay {
+	o := &Overlay{l1: l1, base: base, deleted: make(map[string]struct{})}
+	if n, ok := l1.(types.EvictionNotifier[string, []byte]); ok {
+		n.SetOnEvicted(o.evicted)
+	}
+	return o
+}
+
+func (o *Overlay) evicted(key string, value []byte) {
+	if _, err := o.base.Get(context.Background(), key); err == nil {
+		o.deleted[key] = struct{}{}
+	}
+	if o.onEvicted != nil {
+		o.onEvicted(key, value)
+	}
+}
+
+// SetOnEvicted 实现了 types.EvictionNotifier，设置 L1 淘汰元素时的回调。
+func (o *Overlay) SetOnEvicted(fn func(key string, value []byte)) {
+	o.onEvicted = fn
+}
+
+func (o *Overlay) Get(ctx context.Context, key string) ([]byte, error) {
+	if _, ok := o.deleted[key]; ok {
+		return nil, cacheError.ErrNoKey
+	}
+	value, err := o.l1.Get(ctx, key)
+	if !errors.Is(err, cacheError.ErrNoKey) {
+		return value, err
+	}
+	return o.base.Get(ctx, key)
+}
+
+func (o *Overlay) Set(ctx context.Context, key string, value []byte) error {
+	if err := o.l1.Set(ctx, key, value); err != nil {
+		return err
+	}
+	delete(o.deleted, key)
+	return nil
+}
+
+func (o *Overlay) Delete(ctx context.Context, key string) error {
+	if _, ok := o.deleted[key]; ok {
+		return cacheError.ErrNoKey
+	}
+	err := o.l1.Delete(ctx, key)
+	if err != nil && !errors.Is(err, cacheError.ErrNoKey) {
+		return err
+	}
+	if _, baseErr := o.base.Get(ctx, key); baseErr == nil {
+		o.deleted[key] = struct{}{}
+		return nil
+	}
+	return err
+}
+
+// Keys 返回 L1 中的键，以及 File 中未被覆盖或删除的键。
+func (o *Overlay) Keys() []string {
+	keys := o.l1.Keys()
+	inL1 := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		inL1[key] = struct{}{}
+	}
+	for i := 0; i < o.base.count; i++ {
+		key := string(o.base.key(i))
+		if _, ok := inL1[key]; ok {
+			continue
+		}
+		if _, ok := o.deleted[key]; ok {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filecache 提供一种紧凑、不可变的缓存文件格式：离线构建包含键、序列化后的值与索引的文件，
+// 运行时以内存映射的方式只读打开，Get 直接返回映射区域中的切片，不产生拷贝。
+//
+// 文件布局（整数均为大端序）：
+//
+//	header: magic "GGCF" | version uint32 | count uint64
+//	index:  count 个 { offset uint64 | keyLen uint32 | valueLen uint32 }，按键的字典序排列
+//	data:   每个条目的 key 紧跟 value，offset 为 key 在文件中的起始位置
+package filecache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	magic      = "GGCF"
+	version    = 1
+	headerSize = 16
+	indexSize  = 16
+)
+
+var (
+	ErrInvalidFile = errors.New("filecache: invalid cache file")
+	ErrReadOnly    = errors.New("filecache: cache file is read-only")
+	ErrClosed      = errors.New("filecache: writer is closed")
+	ErrTooLarge    = errors.New("filecache: entry is too large")
+)
+
+// Writer 收集条目并在 Close 时把完整的缓存文件写入底层的 io.Writer。
+// 重复 Add 同一个键时保留最后一次的值。
+type Writer struct {
+	w       io.Writer
+	entries map[string][]byte
+	closed  bool
+}
+
+// NewWriter 返回写入 w 的 Writer。
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, entries: make(map[string][]byte)}
+}
+
+// Add 添加一个条目，value 会被复制。
+func (w *Writer) Add(key string, value []byte) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(key) > math.MaxUint32 || len(value) > math.MaxUint32 {
+		return ErrTooLarge
+	}
+	w.entries[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Close 按键排序后写出文件，之后不能再调用 Add。Close 不会关闭底层的 io.Writer。
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+
+	keys := make([]string, 0, len(w.entries))
+	for key := range w.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w.w)
+	var buf [indexSize]byte
+	copy(buf[:4], magic)
+	binary.BigEndian.PutUint32(buf[4:8], version)
+	binary.BigEndian.PutUint64(buf[8:16], uint64(len(keys)))
+	if _, err := bw.Write(buf[:headerSize]); err != nil {
+		return err
+	}
+
+	offset := uint64(headerSize + indexSize*len(keys))
+	for _, key := range keys {
+		value := w.entries[key]
+		binary.BigEndian.PutUint64(buf[0:8], offset)
+		binary.BigEndian.PutUint32(buf[8:12], uint32(len(key)))
+		binary.BigEndian.PutUint32(buf[12:16], uint32(len(value)))
+		if _, err := bw.Write(buf[:]); err != nil {
+			return err
+		}
+		offset += uint64(len(key) + len(value))
+	}
+	for _, key := range keys {
+		if _, err := bw.WriteString(key); err != nil {
+			return err
+		}
+		if _, err := bw.Write(w.entries[key]); err != nil {
+			return err
+		}
+	}
+	w.entries = nil
+	return bw.Flush()
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+var (
+	_ types.Reader[int, any] = (*Frozen[int, any])(nil)
+	_ types.Lener            = (*Frozen[int, any])(nil)
+	_ types.Ranger[int]      = (*Frozen[int, any])(nil)
+	_ types.Container[int]   = (*Frozen[int, any])(nil)
+)
+
+// Frozen 是 Freeze 返回的不可变快照，实现了 types.Reader。创建之后不再修改，
+// 因此所有方法都可以在没有任何锁的情况下并发调用，也不受原缓存之后的写入、淘汰和过期的影响。
+type Frozen[K comparable, V any] struct {
+	values map[K]V
+	// keys 保存与原缓存的 Keys 相同的顺序
+	keys []K
+	at   time.Time
+}
+
+// Freeze 在一次加锁内复制所有未过期的键和值，返回某一时刻缓存内容的一致视图，适用于需要遍历缓存的批处理任务。
+// 与 Snapshot 相同，值是浅拷贝的，后端实现了 types.Peeker 时不会改变元素的访问顺序。
+// 快照中的元素不会过期：在 Freeze 时未过期的元素之后总能通过 Get 读取。
+func (c *Cache[K, V]) Freeze(ctx context.Context) *Frozen[K, V] {
+	items := c.Items(ctx)
+	f := &Frozen[K, V]{values: make(map[K]V, len(items)), keys: make([]K, 0, len(items)), at: time.Now()}
+	for _, item := range items {
+		f.values[item.Key] = item.Value
+		f.keys = append(f.keys, item.Key)
+	}
+	return f
+}
+
+// At 返回快照创建的时间。
+func (f *Frozen[K, V]) At() time.Time {
+	return f.at
+}
+
+// Get 返回 key 在快照中的值，不存在时返回 cacheError.ErrNoKey。
+func (f *Frozen[K, V]) Get(_ context.Context, key K) (V, error) {
+	v, ok := f.values[key]
+	if !ok {
+		return v, cacheError.ErrNoKey
+	}
+	return v, nil
+}
+
+// Contains 实现了 types.Container，报告 key 是否在快照中。
+func (f *Frozen[K, V]) Contains(key K) bool {
+	_, ok := f.values[key]
+	return ok
+}
+
+// Keys 按 Freeze 时原缓存的 Keys 的顺序返回所有的键，修改返回的切片不影响快照。
+func (f *Frozen[K, V]) Keys() []K {
+	return slices.Clone(f.keys)
+}
+
+// RangeKeys 实现了 types.Ranger，按与 Keys 相同的顺序遍历键。
+func (f *Frozen[K, V]) RangeKeys(fn func(key K) bool) {
+	for _, key := range f.keys {
+		if !fn(key) {
+			return
+		}
+	}
+}
+
+// Range 按与 Keys 相同的顺序遍历键和值，直到 fn 返回 false。
+func (f *Frozen[K, V]) Range(fn func(key K, value V) bool) {
+	for _, key := range f.keys {
+		if !fn(key, f.values[key]) {
+			return
+		}
+	}
+}
+
+// Len 返回快照中元素的数量。
+func (f *Frozen[K, V]) Len() int {
+	return len(f.keys)
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Freeze(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[int, string](ctx, 10, 0)
+	require.NoError(t, c.Set(ctx, 1, "a"))
+	require.NoError(t, c.Set(ctx, 2, "b", WithExpiration(time.Minute)))
+	require.NoError(t, c.Set(ctx, 3, "c", WithExpiration(-time.Second)))
+	_, err := c.Get(ctx, 1)
+	require.NoError(t, err)
+
+	before := time.Now()
+	f := c.Freeze(ctx)
+	assert.False(t, f.At().Before(before))
+	// 已过期的元素不在快照中，顺序与 Keys 相同，Freeze 不改变访问顺序
+	assert.Equal(t, []int{2, 1}, f.Keys())
+	assert.Equal(t, []int{2, 3, 1}, c.Keys())
+	assert.Equal(t, 2, f.Len())
+
+	// 之后对缓存的修改不影响快照
+	require.NoError(t, c.Set(ctx, 1, "z"))
+	require.NoError(t, c.Delete(ctx, 2))
+	require.NoError(t, c.Set(ctx, 4, "d"))
+	v, err := f.Get(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", v)
+	v, err = f.Get(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", v)
+	_, err = f.Get(ctx, 4)
+	assert.ErrorIs(t, err, cacheError.ErrNoKey)
+	assert.True(t, f.Contains(2))
+	assert.False(t, f.Contains(3))
+
+	keys := f.Keys()
+	keys[0] = 42
+	assert.Equal(t, []int{2, 1}, f.Keys())
+
+	var visited []int
+	f.Range(func(key int, value string) bool {
+		visited = append(visited, key)
+		return false
+	})
+	assert.Equal(t, []int{2}, visited)
+	visited = visited[:0]
+	f.RangeKeys(func(key int) bool {
+		visited = append(visited, key)
+		return true
+	})
+	assert.Equal(t, []int{2, 1}, visited)
+}
+
+func TestCache_Freeze_empty(t *testing.T) {
+	f := NewSimpleCache[int, int](context.Background(), 0, 0).Freeze(context.Background())
+	assert.Zero(t, f.Len())
+	assert.Empty(t, f.Keys())
+}
+
+func TestCache_Freeze_concurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[int, int](ctx, 0, 0)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Set(ctx, i, i))
+	}
+	f := c.Freeze(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, c.Set(ctx, j, -j))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			f.Range(func(key, value int) bool {
+				assert.Equal(t, key, value)
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generational
+
+import (
+	"context"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// NewCache 创建一个分代缓存，epoch 为每一代的时长。
+// 新写入的元素进入年轻代；每过一个 epoch，年轻代整体变为老年代，原来的老年代被整体丢弃。
+// 因此元素的存活时间在 epoch 到 2*epoch 之间，并且过期时不需要逐个删除键。
+// 适用于绝大多数元素拥有相同且较短的 TTL 的场景。
+func NewCache[K comparable, V any](epoch time.Duration) *Cache[K, V] {
+	c := &Cache[K, V]{
+		epoch: epoch,
+		young: make(map[K]V),
+		old:   make(map[K]V),
+		now:   time.Now,
+	}
+	c.rotatedAt = c.now()
+	return c
+}
+
+type Cache[K comparable, V any] struct {
+	epoch     time.Duration
+	young     map[K]V
+	old       map[K]V
+	rotatedAt time.Time
+	now       func() time.Time
+	evictions uint64
+}
+
+func (c *Cache[K, V]) Set(_ context.Context, key K, value V) error {
+	c.rotate()
+	delete(c.old, key)
+	c.young[key] = value
+	return nil
+}
+
+func (c *Cache[K, V]) Get(_ context.Context, key K) (v V, err error) {
+	c.rotate()
+	if v, ok := c.young[key]; ok {
+		return v, nil
+	}
+	if v, ok := c.old[key]; ok {
+		return v, nil
+	}
+	return v, cacheError.ErrNoKey
+}
+
+// Peek 实现了 types.Peeker，与 Keys 一样不会触发轮换，只是跳过按时间已经被丢弃的代。
+func (c *Cache[K, V]) Peek(_ context.Context, key K) (v V, err error) {
+	elapsed := c.now().Sub(c.rotatedAt)
+	if v, ok := c.young[key]; ok && elapsed < 2*c.epoch {
+		return v, nil
+	}
+	if v, ok := c.old[key]; ok && elapsed < c.epoch {
+		return v, nil
+	}
+	return v, cacheError.ErrNoKey
+}
+
+func (c *Cache[K, V]) Delete(_ context.Context, key K) error {
+	c.rotate()
+	if _, ok := c.young[key]; ok {
+		delete(c.young, key)
+		return nil
+	}
+	if _, ok := c.old[key]; ok {
+		delete(c.old, key)
+		return nil
+	}
+	return cacheError.ErrNoKey
+}
+
+// Keys 先返回老年代的键，再返回年轻代的键。
+// Keys 不会触发轮换，只是跳过按时间已经被丢弃的代，因此可以与其他只读操作并发执行。
+func (c *Cache[K, V]) Keys() []K {
+	elapsed := c.now().Sub(c.rotatedAt)
+	keys := make([]K, 0, len(c.old)+len(c.young))
+	if elapsed < c.epoch {
+		for key := range c.old {
+			keys = append(keys, key)
+		}
+	}
+	if elapsed < 2*c.epoch {
+		for key := range c.young {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// RangeKeys 实现了 types.Ranger，按与 Keys 相同的顺序遍历键，同样不会触发轮换。
+func (c *Cache[K, V]) RangeKeys(fn func(key K) bool) {
+	elapsed := c.now().Sub(c.rotatedAt)
+	if elapsed < c.epoch {
+		for key := range c.old {
+			if !fn(key) {
+				return
+			}
+		}
+	}
+	if elapsed < 2*c.epoch {
+		for key := range c.young {
+			if !fn(key) {
+				return
+			}
+		}
+	}
+}
+
+// Len 返回 Keys 会返回的键的数量，与 Keys 一样不会触发轮换。
+func (c *Cache[K, V]) Len() int {
+	elapsed := c.now().Sub(c.rotatedAt)
+	n := 0
+	if elapsed < c.epoch {
+		n += len(c.old)
+	}
+	if elapsed < 2*c.epoch {
+		n += len(c.young)
+	}
+	return n
+}
+
+// Contains 实现了 types.Container，报告 key 是否存在且所在的代未过期。
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, err := c.Peek(context.Background(), key)
+	return err == nil
+}
+
+// Clear 实现了 types.Clearer，删除两代中的所有元素。
+func (c *Cache[K, V]) Clear() {
+	clear(c.young)
+	clear(c.old)
+}
+
+// rotate 在 epoch 到期时轮换代：年轻代变为老年代，老年代被整体丢弃。
+// 如果已经过去了两个以上的 epoch，两代都会被丢弃。
+func (c *Cache[K, V]) rotate() {
+	now := c.now()
+	elapsed := now.Sub(c.rotatedAt)
+	if elapsed < c.epoch {
+		return
+	}
+	c.evictions += uint64(len(c.old))
+	if elapsed >= 2*c.epoch {
+		c.evictions += uint64(len(c.young))
+		c.old = make(map[K]V)
+	} else {
+		c.old = c.young
+	}
+	c.young = make(map[K]V, len(c.old))
+	c.rotatedAt = now
+}
+
+// Stats 实现了 types.Inspector，Evictions 为轮换时随代一起被丢弃的元素数量。
+// 与 Keys 一样不会触发轮换，已经按时间被丢弃但尚未轮换的代不计入 Evictions。
+func (c *Cache[K, V]) Stats() types.BackendStats {
+	return types.BackendStats{Len: c.Len(), Evictions: c.evictions}
+}
+
+// OldestEntry 实现了 types.Inspector，返回最老的未过期的代中的任意一个元素，不会触发轮换。
+// 同一代中的元素不区分新旧。
+func (c *Cache[K, V]) OldestEntry() (key K, value V, ok bool) {
+	elapsed := c.now().Sub(c.rotatedAt)
+	if elapsed < c.epoch {
+		for key, value = range c.old {
+			return key, value, true
+		}
+	}
+	if elapsed < 2*c.epoch {
+		for key, value = range c.young {
+			return key, value, true
+		}
+	}
+	return key, value, false
+}
+
+// Config 实现了 types.Inspector，分代缓存没有容量限制，Extra 中的 epoch 为每一代的时长。
+func (c *Cache[K, V]) Config() types.BackendConfig {
+	return types.BackendConfig{Policy: "generational", Extra: map[string]string{"epoch": c.epoch.String()}}
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generational
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock 返回一个可手动推进的时钟。
+func fakeClock(c *Cache[string, int]) func(d time.Duration) {
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+	c.rotatedAt = now
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestNewCache(t *testing.T) {
+	cache := NewCache[string, int](time.Minute)
+	assert.Equal(t, time.Minute, cache.epoch)
+	assert.NotNil(t, cache.young)
+	assert.NotNil(t, cache.old)
+}
+
+func TestCache_Get(t *testing.T) {
+	testCases := []struct {
+		name      string
+		elapsed   time.Duration
+		wantValue int
+		wantError error
+	}{
+		{
+			name:      "young generation",
+			elapsed:   time.Second,
+			wantValue: 1,
+		},
+		{
+			name:      "old generation after one epoch",
+			elapsed:   time.Minute + time.Second,
+			wantValue: 1,
+		},
+		{
+			name:      "dropped after two epochs",
+			elapsed:   2 * time.Minute,
+			wantError: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := NewCache[string, int](time.Minute)
+			advance := fakeClock(cache)
+			assert.NoError(t, cache.Set(context.Background(), "1", 1))
+			advance(tc.elapsed)
+			got, err := cache.Get(context.Background(), "1")
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantValue, got)
+		})
+	}
+}
+
+func TestCache_Peek(t *testing.T) {
+	testCases := []struct {
+		name      string
+		elapsed   time.Duration
+		wantValue int
+		wantError error
+	}{
+		{name: "young generation", elapsed: time.Second, wantValue: 1},
+		{name: "due for rotation", elapsed: time.Minute + time.Second, wantValue: 1},
+		{name: "dropped after two epochs", elapsed: 2 * time.Minute, wantError: cacheError.ErrNoKey},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := NewCache[string, int](time.Minute)
+			advance := fakeClock(cache)
+			rotatedAt := cache.rotatedAt
+			assert.NoError(t, cache.Set(context.Background(), "1", 1))
+			advance(tc.elapsed)
+			got, err := cache.Peek(context.Background(), "1")
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantValue, got)
+			// Peek 不会触发轮换
+			assert.Equal(t, rotatedAt, cache.rotatedAt)
+		})
+	}
+}
+
+func TestCache_Rotate(t *testing.T) {
+	cache := NewCache[string, int](time.Minute)
+	advance := fakeClock(cache)
+	assert.NoError(t, cache.Set(context.Background(), "1", 1))
+	advance(time.Minute)
+	assert.NoError(t, cache.Set(context.Background(), "2", 2))
+	assert.Equal(t, []string{"1", "2"}, cache.Keys())
+
+	// 再过一个 epoch，"1" 所在的老年代被整体丢弃
+	advance(time.Minute)
+	assert.Equal(t, []string{"2"}, cache.Keys())
+
+	// 重新写入老年代中的键会把它移到年轻代
+	assert.NoError(t, cache.Set(context.Background(), "2", 20))
+	assert.Empty(t, cache.old)
+	assert.Equal(t, map[string]int{"2": 20}, cache.young)
+}
+
+func TestCache_Inspector(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache[string, int](time.Minute)
+	advance := fakeClock(cache)
+	assert.Equal(t, types.BackendConfig{Policy: "generational", Extra: map[string]string{"epoch": "1m0s"}}, cache.Config())
+	assert.NoError(t, cache.Set(ctx, "1", 1))
+	advance(time.Minute)
+	assert.NoError(t, cache.Set(ctx, "2", 2))
+
+	// 老年代中的元素最老
+	key, value, ok := cache.OldestEntry()
+	assert.True(t, ok)
+	assert.Equal(t, "1", key)
+	assert.Equal(t, 1, value)
+
+	// 轮换时随老年代一起丢弃的元素计入 Evictions
+	advance(time.Minute)
+	_, _ = cache.Get(ctx, "2")
+	assert.Equal(t, types.BackendStats{Len: 1, Evictions: 1}, cache.Stats())
+	key, _, ok = cache.OldestEntry()
+	assert.True(t, ok)
+	assert.Equal(t, "2", key)
+
+	advance(2 * time.Minute)
+	// 两代都已过期
+	_, _, ok = cache.OldestEntry()
+	assert.False(t, ok)
+}
+
+func TestCache_Delete(t *testing.T) {
+	cache := NewCache[string, int](time.Minute)
+	advance := fakeClock(cache)
+	assert.NoError(t, cache.Set(context.Background(), "1", 1))
+	advance(time.Minute)
+	assert.NoError(t, cache.Set(context.Background(), "2", 2))
+
+	assert.NoError(t, cache.Delete(context.Background(), "1"))
+	assert.NoError(t, cache.Delete(context.Background(), "2"))
+	assert.Equal(t, cacheError.ErrNoKey, cache.Delete(context.Background(), "3"))
+	assert.Empty(t, cache.Keys())
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return NewCache[string, string](time.Hour)
+	})
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// WithGhostList 开启影子列表，记录最近被淘汰的 size 个键（不保存值）。未命中的键如果在影子列表中，
+// 说明容量再增加 size 个元素时本次读取就会命中，这类未命中计入 Stats.GhostHits，Advise 据此估算扩容的收益。
+// 该选项依赖后端实现 types.EvictionNotifier。
+func WithGhostList[K comparable, V any](size int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.ghostSize = size
+	}
+}
+
+// ghostList 是按淘汰顺序排列、容量有限的键集合。
+// 未命中的读操作可能持有读锁，因此 ghostList 使用自己的锁。
+type ghostList[K comparable] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	keys  map[K]*list.Element
+}
+
+func newGhostList[K comparable](size int) *ghostList[K] {
+	return &ghostList[K]{size: size, order: list.New(), keys: make(map[K]*list.Element, size)}
+}
+
+// add 记录一个被淘汰的键，超出容量时丢弃最早被淘汰的键。
+func (g *ghostList[K]) add(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e, ok := g.keys[key]; ok {
+		g.order.MoveToBack(e)
+		return
+	}
+	g.keys[key] = g.order.PushBack(key)
+	if g.order.Len() > g.size {
+		oldest := g.order.Front()
+		g.order.Remove(oldest)
+		delete(g.keys, oldest.Value.(K))
+	}
+}
+
+// remove 删除 key 并返回它是否在影子列表中，每个被淘汰的键最多计为一次影子命中。
+func (g *ghostList[K]) remove(key K) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e, ok := g.keys[key]
+	if !ok {
+		return false
+	}
+	g.order.Remove(e)
+	delete(g.keys, key)
+	return true
+}
+
+// Advice 是 Advise 给出的扩容建议。
+type Advice struct {
+	// Capacity 当前容量
+	Capacity int
+	// SuggestedCapacity 建议的容量，即当前容量加上影子列表的长度
+	SuggestedCapacity int
+	// HitRatio 当前的命中率
+	HitRatio float64
+	// ProjectedHitRatio 容量为 SuggestedCapacity 时预计的命中率
+	ProjectedHitRatio float64
+	// ExtraBytes 扩容预计额外占用的内存，没有提供 sizer 时为 0
+	ExtraBytes int64
+}
+
+// String 以可以直接记录到日志的形式描述建议。
+func (a Advice) String() string {
+	s := fmt.Sprintf("increasing capacity %.3gx (%d -> %d) would raise hit ratio from %.1f%% to %.1f%%",
+		float64(a.SuggestedCapacity)/float64(max(a.Capacity, 1)), a.Capacity, a.SuggestedCapacity,
+		a.HitRatio*100, a.ProjectedHitRatio*100)
+	if a.ExtraBytes > 0 {
+		s += ", costing ~" + formatBytes(a.ExtraBytes)
+	}
+	return s
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.0f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// adviseSampleSize 估算元素平均大小时抽样的元素数量。
+const adviseSampleSize = 64
+
+// Advise 根据影子列表的命中数据，估算容量从 capacity 增加到 capacity 加影子列表长度时的命中率。
+// sizer 返回单个元素占用的字节数，不为 nil 时通过抽样估算扩容额外占用的内存。
+// 统计数据是累计值，可以定期调用 Advise 并记录 Advice.String 的结果。未开启 WithGhostList 时预计命中率与当前相同。
+func (c *Cache[K, V]) Advise(capacity int, sizer func(key K, value V) int) Advice {
+	s := c.Stats()
+	a := Advice{
+		Capacity:          capacity,
+		SuggestedCapacity: capacity + c.opts.ghostSize,
+		HitRatio:          s.HitRatio(),
+		ProjectedHitRatio: s.HitRatio(),
+	}
+	if total := s.Hits + s.Misses; total > 0 {
+		a.ProjectedHitRatio = float64(s.Hits+s.GhostHits) / float64(total)
+	}
+	if sizer != nil && c.opts.ghostSize > 0 {
+		entries := c.Sample(adviseSampleSize)
+		if len(entries) > 0 {
+			var total int64
+			for _, e := range entries {
+				total += int64(sizer(e.Key, e.Value))
+			}
+			a.ExtraBytes = total / int64(len(entries)) * int64(c.opts.ghostSize)
+		}
+	}
+	return a
+}
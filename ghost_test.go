@@ -0,0 +1,84 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGhostList(t *testing.T) {
+	g := newGhostList[int](2)
+	g.add(1)
+	g.add(2)
+	g.add(1)
+	// 容量为 2，最早被淘汰的 2 被丢弃
+	g.add(3)
+	assert.False(t, g.remove(2))
+	assert.True(t, g.remove(1))
+	assert.False(t, g.remove(1))
+	assert.True(t, g.remove(3))
+}
+
+func TestCache_Advise(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[int, string](ctx, 2, time.Minute, WithGhostList[int, string](2))
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, c.Set(ctx, i, "value"))
+	}
+	// 0 和 1 被淘汰，容量为 4 时读取它们会命中
+	for _, key := range []int{0, 1, 2, 3, 9} {
+		_, _ = c.Get(ctx, key)
+	}
+	assert.Equal(t, uint64(2), c.Stats().GhostHits)
+
+	testCases := []struct {
+		name  string
+		sizer func(int, string) int
+
+		want       Advice
+		wantString string
+	}{
+		{
+			name:       "without sizer",
+			want:       Advice{Capacity: 2, SuggestedCapacity: 4, HitRatio: 0.4, ProjectedHitRatio: 0.8},
+			wantString: "increasing capacity 2x (2 -> 4) would raise hit ratio from 40.0% to 80.0%",
+		},
+		{
+			name:       "with sizer",
+			sizer:      func(_ int, v string) int { return 1024 * len(v) },
+			want:       Advice{Capacity: 2, SuggestedCapacity: 4, HitRatio: 0.4, ProjectedHitRatio: 0.8, ExtraBytes: 10240},
+			wantString: "increasing capacity 2x (2 -> 4) would raise hit ratio from 40.0% to 80.0%, costing ~10 KB",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := c.Advise(2, tc.sizer)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantString, got.String())
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "2 KB", formatBytes(2048))
+	assert.Equal(t, "210 MB", formatBytes(210<<20))
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import "github.com/chenmingyong0423/go-generics-cache/types"
+
+// BackendInfo 是 Inspect 返回的后端运行时信息。
+type BackendInfo[K comparable, V any] struct {
+	// Stats 为后端内部的统计信息，与 Cache.Stats 不同，只包含后端自身记录的数据
+	Stats types.BackendStats
+	// Config 为后端当前的配置
+	Config types.BackendConfig
+	// OldestKey 和 OldestValue 为后端认为最老的元素，即下一个被淘汰的元素，可能已经过期但尚未清理。
+	// HasOldest 为 false 时两者均为零值
+	OldestKey   K
+	OldestValue V
+	HasOldest   bool
+}
+
+// Inspect 在锁内收集后端通过 types.Inspector 暴露的运行时信息，便于调试工具直接读取而不与缓存的其他操作竞争。
+// 后端没有实现 types.Inspector 时返回 false，内置的后端均已实现。设置了 WithCloner 时 OldestValue 为副本。
+func (c *Cache[K, V]) Inspect() (info BackendInfo[K, V], ok bool) {
+	info, ok = c.inspect()
+	if info.HasOldest {
+		info.OldestValue = c.clone(info.OldestValue)
+	}
+	return info, ok
+}
+
+func (c *Cache[K, V]) inspect() (info BackendInfo[K, V], ok bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	in, ok := c.cache.(types.Inspector[K, *Item[V]])
+	if !ok {
+		return info, false
+	}
+	info.Stats, info.Config = in.Stats(), in.Config()
+	if key, item, ok := in.OldestEntry(); ok {
+		info.OldestKey, info.OldestValue, info.HasOldest = key, item.value, true
+	}
+	return info, true
+}
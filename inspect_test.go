@@ -0,0 +1,85 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Inspect(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache func() *Cache[string, int]
+
+		want   BackendInfo[string, int]
+		wantOK bool
+	}{
+		{
+			name:  "lru",
+			cache: func() *Cache[string, int] { return NewLruCache[string, int](ctx, 2, time.Hour) },
+			want: BackendInfo[string, int]{
+				Stats:     types.BackendStats{Len: 2, Evictions: 1},
+				Config:    types.BackendConfig{Policy: "lru", Capacity: 2},
+				OldestKey: "c", OldestValue: 3, HasOldest: true,
+			},
+			wantOK: true,
+		},
+		{
+			name:  "fifo",
+			cache: func() *Cache[string, int] { return NewFifoCache[string, int](ctx, 2, time.Hour) },
+			want: BackendInfo[string, int]{
+				Stats:     types.BackendStats{Len: 2, Evictions: 1},
+				Config:    types.BackendConfig{Policy: "fifo", Capacity: 2},
+				OldestKey: "b", OldestValue: 2, HasOldest: true,
+			},
+			wantOK: true,
+		},
+		{
+			name:  "simple",
+			cache: func() *Cache[string, int] { return NewSimpleCache[string, int](ctx, 2, time.Hour) },
+			want: BackendInfo[string, int]{
+				Stats:  types.BackendStats{Len: 3},
+				Config: types.BackendConfig{Policy: "simple"},
+			},
+			wantOK: true,
+		},
+		{
+			name:  "not an inspector",
+			cache: func() *Cache[string, int] { return New[string, int](ctx, errorCache[string, *Item[int]]{}, time.Hour) },
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache()
+			defer c.Close()
+			require.NoError(t, c.Set(ctx, "a", 1))
+			require.NoError(t, c.Set(ctx, "b", 2))
+			_, _ = c.Get(ctx, "b")
+			require.NoError(t, c.Set(ctx, "c", 3))
+			_, _ = c.Get(ctx, "b")
+
+			info, ok := c.Inspect()
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, info)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{pattern: "*", s: "", want: true},
+		{pattern: "*", s: "user:42/profile", want: true},
+		{pattern: "user:*", s: "user:42", want: true},
+		{pattern: "user:*", s: "order:42", want: false},
+		{pattern: "user:*:name", s: "user:42:name", want: true},
+		{pattern: "h?llo", s: "hello", want: true},
+		{pattern: "h?llo", s: "hllo", want: false},
+		{pattern: "h[ae]llo", s: "hallo", want: true},
+		{pattern: "h[ae]llo", s: "hillo", want: false},
+		{pattern: "h[^e]llo", s: "hallo", want: true},
+		{pattern: "h[^e]llo", s: "hello", want: false},
+		{pattern: "h[a-b]llo", s: "hbllo", want: true},
+		{pattern: "h[a-b]llo", s: "hcllo", want: false},
+		{pattern: `h\*llo`, s: "h*llo", want: true},
+		{pattern: `h\*llo`, s: "hello", want: false},
+		{pattern: "h[llo", s: "h[llo", want: true},
+		{pattern: "abc", s: "abcd", want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.pattern+"/"+tc.s, func(t *testing.T) {
+			assert.Equal(t, tc.want, Match(tc.pattern, tc.s))
+		})
+	}
+}
+
+func TestQuoteMeta(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "plain", s: "user:1", want: "user:1"},
+		{name: "empty", s: "", want: ""},
+		{name: "wildcards", s: "a*b?c[d]e\\f", want: "a\\*b\\?c\\[d\\]e\\\\f"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := QuoteMeta(tc.s)
+			assert.Equal(t, tc.want, got)
+			assert.True(t, Match(got, tc.s))
+			assert.True(t, Match(got+"*", tc.s+"suffix"))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keyhash 提供与进程无关的键编码和哈希，保证不同实例对同一个键得到相同的结果。
+package keyhash
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+const (
+	offset64 = 14695981039346656037
+	prime64  = 1099511628211
+)
+
+// Bytes 返回 key 的字节表示：字符串使用其原始字节，其他类型使用 fmt 的 %v 格式。
+func Bytes[K comparable](key K) []byte {
+	if s, ok := any(key).(string); ok {
+		return []byte(s)
+	}
+	return fmt.Append(nil, key)
+}
+
+// Sum64 返回 data 的 64 位哈希值：FNV-1a 之后再经过 MurmurHash3 的 fmix64 混淆，
+// 使相似的短输入（如连续的整数）也能均匀分布在整个取值空间。
+func Sum64(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return fmix64(h.Sum64())
+}
+
+// Key 返回 key 的 64 位哈希值，等价于 Sum64(Bytes(key))。
+// 字符串和整数类型的键不会产生堆内存分配。
+func Key[K comparable](key K) uint64 {
+	var buf [20]byte
+	switch k := any(key).(type) {
+	case string:
+		return sumString(k)
+	case int:
+		return sumBytes(strconv.AppendInt(buf[:0], int64(k), 10))
+	case int32:
+		return sumBytes(strconv.AppendInt(buf[:0], int64(k), 10))
+	case int64:
+		return sumBytes(strconv.AppendInt(buf[:0], k, 10))
+	case uint:
+		return sumBytes(strconv.AppendUint(buf[:0], uint64(k), 10))
+	case uint32:
+		return sumBytes(strconv.AppendUint(buf[:0], uint64(k), 10))
+	case uint64:
+		return sumBytes(strconv.AppendUint(buf[:0], k, 10))
+	}
+	return Sum64(Bytes(key))
+}
+
+func sumString(s string) uint64 {
+	h := uint64(offset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= prime64
+	}
+	return fmix64(h)
+}
+
+func sumBytes(b []byte) uint64 {
+	h := uint64(offset64)
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= prime64
+	}
+	return fmix64(h)
+}
+
+func fmix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
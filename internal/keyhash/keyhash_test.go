@@ -0,0 +1,40 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyhash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type point struct{ x, y int }
+
+func TestKey(t *testing.T) {
+	// 快速路径与通用路径的结果必须一致
+	assert.Equal(t, Sum64([]byte("user:1")), Key("user:1"))
+	assert.Equal(t, Sum64([]byte("-42")), Key(-42))
+	assert.Equal(t, Sum64([]byte("42")), Key(int32(42)))
+	assert.Equal(t, Sum64([]byte("42")), Key(int64(42)))
+	assert.Equal(t, Sum64([]byte("42")), Key(uint(42)))
+	assert.Equal(t, Sum64([]byte("42")), Key(uint32(42)))
+	assert.Equal(t, Sum64([]byte("42")), Key(uint64(42)))
+	assert.Equal(t, Sum64([]byte("{1 2}")), Key(point{1, 2}))
+}
+
+func TestKey_Allocs(t *testing.T) {
+	assert.Zero(t, testing.AllocsPerRun(100, func() { Key("user:1") }))
+	assert.Zero(t, testing.AllocsPerRun(100, func() { Key(123456) }))
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package list 实现带类型参数的双向链表。与 container/list 不同，元素的值直接保存在节点中，
+// 插入一个元素只分配一个节点，读取值也不需要类型断言。
+package list
+
+// Element 是链表中的节点。
+type Element[T any] struct {
+	next, prev *Element[T]
+	list       *List[T]
+	// Value 为节点保存的值，可以直接修改
+	Value T
+}
+
+// Next 返回下一个节点，没有时返回 nil。
+func (e *Element[T]) Next() *Element[T] {
+	if n := e.next; e.list != nil && n != &e.list.root {
+		return n
+	}
+	return nil
+}
+
+// Prev 返回上一个节点，没有时返回 nil。
+func (e *Element[T]) Prev() *Element[T] {
+	if p := e.prev; e.list != nil && p != &e.list.root {
+		return p
+	}
+	return nil
+}
+
+// List 是以 root 为哨兵的环形双向链表，需要通过 New 创建。
+type List[T any] struct {
+	root Element[T]
+	len  int
+}
+
+// New 返回一个空的链表。
+func New[T any]() *List[T] {
+	return new(List[T]).Init()
+}
+
+// Init 清空链表，之后不能再对原有的节点调用 Remove 等方法。
+func (l *List[T]) Init() *List[T] {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+	return l
+}
+
+// Len 返回节点的数量。
+func (l *List[T]) Len() int {
+	return l.len
+}
+
+// Front 返回第一个节点，链表为空时返回 nil。
+func (l *List[T]) Front() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+// Back 返回最后一个节点，链表为空时返回 nil。
+func (l *List[T]) Back() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// PushFront 在链表头部插入保存 v 的节点并返回该节点。
+func (l *List[T]) PushFront(v T) *Element[T] {
+	return l.insert(&Element[T]{Value: v}, &l.root)
+}
+
+// PushBack 在链表尾部插入保存 v 的节点并返回该节点。
+func (l *List[T]) PushBack(v T) *Element[T] {
+	return l.insert(&Element[T]{Value: v}, l.root.prev)
+}
+
+// InsertBefore 在 mark 之前插入保存 v 的节点并返回该节点，mark 必须属于该链表。
+func (l *List[T]) InsertBefore(v T, mark *Element[T]) *Element[T] {
+	return l.insert(&Element[T]{Value: v}, mark.prev)
+}
+
+// InsertAfter 在 mark 之后插入保存 v 的节点并返回该节点，mark 必须属于该链表。
+func (l *List[T]) InsertAfter(v T, mark *Element[T]) *Element[T] {
+	return l.insert(&Element[T]{Value: v}, mark)
+}
+
+// Remove 从链表中删除 e，e 不属于该链表时什么都不做。
+func (l *List[T]) Remove(e *Element[T]) {
+	if e.list != l {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.next, e.prev, e.list = nil, nil, nil
+	l.len--
+}
+
+// MoveToFront 把 e 移动到链表头部，e 不属于该链表时什么都不做。
+func (l *List[T]) MoveToFront(e *Element[T]) {
+	if e.list != l || l.root.next == e {
+		return
+	}
+	l.move(e, &l.root)
+}
+
+// MoveToBack 把 e 移动到链表尾部，e 不属于该链表时什么都不做。
+func (l *List[T]) MoveToBack(e *Element[T]) {
+	if e.list != l || l.root.prev == e {
+		return
+	}
+	l.move(e, l.root.prev)
+}
+
+// insert 把 e 插入到 at 之后。
+func (l *List[T]) insert(e, at *Element[T]) *Element[T] {
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+	e.list = l
+	l.len++
+	return e
+}
+
+// move 把 e 移动到 at 之后。
+func (l *List[T]) move(e, at *Element[T]) {
+	if e == at {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func values(l *List[int]) []int {
+	vs := make([]int, 0, l.Len())
+	for e := l.Front(); e != nil; e = e.Next() {
+		vs = append(vs, e.Value)
+	}
+	// 反向遍历的结果应当相反
+	for i, e := len(vs)-1, l.Back(); e != nil; i, e = i-1, e.Prev() {
+		if vs[i] != e.Value {
+			panic("list links are inconsistent")
+		}
+	}
+	return vs
+}
+
+func TestList(t *testing.T) {
+	testCases := []struct {
+		name string
+		ops  func(l *List[int])
+
+		want []int
+	}{
+		{
+			name: "empty",
+			ops:  func(l *List[int]) {},
+			want: []int{},
+		},
+		{
+			name: "push",
+			ops: func(l *List[int]) {
+				l.PushBack(2)
+				l.PushFront(1)
+				l.PushBack(3)
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name: "remove",
+			ops: func(l *List[int]) {
+				l.PushBack(1)
+				e := l.PushBack(2)
+				l.PushBack(3)
+				l.Remove(e)
+				// 重复删除不会影响链表
+				l.Remove(e)
+			},
+			want: []int{1, 3},
+		},
+		{
+			name: "move",
+			ops: func(l *List[int]) {
+				a := l.PushBack(1)
+				l.PushBack(2)
+				c := l.PushBack(3)
+				l.MoveToFront(c)
+				l.MoveToBack(a)
+				l.MoveToFront(c)
+			},
+			want: []int{3, 2, 1},
+		},
+		{
+			name: "insert",
+			ops: func(l *List[int]) {
+				b := l.PushBack(2)
+				l.InsertBefore(1, b)
+				l.InsertAfter(4, b)
+				l.InsertAfter(3, b)
+			},
+			want: []int{1, 2, 3, 4},
+		},
+		{
+			name: "modify value",
+			ops: func(l *List[int]) {
+				e := l.PushBack(1)
+				e.Value = 10
+			},
+			want: []int{10},
+		},
+		{
+			name: "element of another list",
+			ops: func(l *List[int]) {
+				l.PushBack(1)
+				other := New[int]()
+				e := other.PushBack(2)
+				l.Remove(e)
+				l.MoveToFront(e)
+				l.MoveToBack(e)
+			},
+			want: []int{1},
+		},
+		{
+			name: "init",
+			ops: func(l *List[int]) {
+				l.PushBack(1)
+				l.PushBack(2)
+				l.Init()
+				l.PushBack(3)
+			},
+			want: []int{3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := New[int]()
+			tc.ops(l)
+			assert.Equal(t, tc.want, values(l))
+			assert.Equal(t, len(tc.want), l.Len())
+		})
+	}
+}
+
+func TestList_PushBack_Allocs(t *testing.T) {
+	l := New[[2]int]()
+	allocs := testing.AllocsPerRun(100, func() {
+		e := l.PushBack([2]int{1, 2})
+		l.Remove(e)
+	})
+	assert.Equal(t, 1.0, allocs)
+}
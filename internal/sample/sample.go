@@ -0,0 +1,38 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sample 实现不放回的均匀随机抽样。
+package sample
+
+// Indexes 使用 Floyd 算法从 [0, total) 中不放回地均匀抽取 min(n, total) 个下标，
+// 只需要 n 次 intn 调用，不需要遍历全部下标。intn(m) 需要返回 [0, m) 中的均匀随机数。
+func Indexes(n, total int, intn func(int) int) []int {
+	if n > total {
+		n = total
+	}
+	if n <= 0 {
+		return nil
+	}
+	chosen := make(map[int]struct{}, n)
+	indexes := make([]int, 0, n)
+	for j := total - n; j < total; j++ {
+		t := intn(j + 1)
+		if _, ok := chosen[t]; ok {
+			t = j
+		}
+		chosen[t] = struct{}{}
+		indexes = append(indexes, t)
+	}
+	return indexes
+}
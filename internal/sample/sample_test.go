@@ -0,0 +1,67 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sample
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexes(t *testing.T) {
+	testCases := []struct {
+		name  string
+		n     int
+		total int
+
+		wantLen int
+	}{
+		{name: "empty", n: 3, total: 0},
+		{name: "non-positive n", n: 0, total: 10},
+		{name: "n less than total", n: 3, total: 10, wantLen: 3},
+		{name: "n equals total", n: 10, total: 10, wantLen: 10},
+		{name: "n greater than total", n: 20, total: 10, wantLen: 10},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			got := Indexes(tc.n, tc.total, r.Intn)
+			assert.Len(t, got, tc.wantLen)
+			seen := make(map[int]bool)
+			for _, i := range got {
+				assert.False(t, seen[i], "duplicate index %d", i)
+				assert.True(t, i >= 0 && i < tc.total)
+				seen[i] = true
+			}
+		})
+	}
+}
+
+func TestIndexes_uniform(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const total, n, rounds = 10, 3, 30000
+	counts := make([]int, total)
+	for i := 0; i < rounds; i++ {
+		for _, idx := range Indexes(n, total, r.Intn) {
+			counts[idx]++
+		}
+	}
+	sort.Ints(counts)
+	// 每个下标的期望次数为 rounds*n/total = 9000
+	assert.InDelta(t, 9000, counts[0], 450)
+	assert.InDelta(t, 9000, counts[total-1], 450)
+}
@@ -25,22 +25,55 @@ func newJanitor(ctx context.Context, interval time.Duration) *janitor {
 		ctx:      ctx,
 		interval: interval,
 		done:     make(chan struct{}),
+		exited:   make(chan struct{}),
 	}
 }
 
 type janitor struct {
 	ctx      context.Context
 	interval time.Duration
-	done     chan struct{}
-	once     sync.Once
+	// delay 为启动计时器之前的等待时间
+	delay time.Duration
+	done  chan struct{}
+	once  sync.Once
+	// exited 在清理协程退出后关闭
+	exited chan struct{}
 }
 
 func (j *janitor) stop() {
 	j.once.Do(func() { close(j.done) })
 }
 
+// wait 停止清理协程并等待其执行完最后一次清理，没有启动清理协程时直接返回。
+func (j *janitor) wait() {
+	if j.interval <= 0 {
+		return
+	}
+	j.stop()
+	<-j.exited
+}
+
+// run 启动清理协程，interval 小于等于 0 时不启动，过期元素只会在访问时被识别。
 func (j *janitor) run(cleanup func(ctx context.Context)) {
+	if j.interval <= 0 {
+		return
+	}
 	go func() {
+		defer close(j.exited)
+		if j.delay > 0 {
+			timer := time.NewTimer(j.delay)
+			select {
+			case <-timer.C:
+			case <-j.ctx.Done():
+				timer.Stop()
+				cleanup(j.ctx)
+				return
+			case <-j.done:
+				timer.Stop()
+				cleanup(j.ctx)
+				return
+			}
+		}
 		ticker := time.NewTicker(j.interval)
 		defer ticker.Stop()
 		for {
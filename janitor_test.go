@@ -47,3 +47,25 @@ func Test_janitor(t *testing.T) {
 		t.Fatalf("failed to run cleanup function, num: %d", num)
 	}
 }
+
+func Test_janitor_delay(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	j := newJanitor(ctx, time.Millisecond)
+	j.delay = time.Hour
+	num := int64(0)
+
+	j.run(func(_ context.Context) {
+		atomic.AddInt64(&num, 1)
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt64(&num); n != 0 {
+		t.Fatalf("cleanup ran before the start delay, num: %d", n)
+	}
+	// 等待期间停止时仍然执行最后一次清理
+	j.wait()
+	if n := atomic.LoadInt64(&num); n != 1 {
+		t.Fatalf("expected a final cleanup, num: %d", n)
+	}
+}
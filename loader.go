@@ -0,0 +1,282 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// Loader 从数据源加载缓存未命中的值。
+type Loader[K comparable, V any] interface {
+	Load(ctx context.Context, key K) (V, error)
+}
+
+// LoaderFunc 将普通函数适配为 Loader。
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Load 调用 f(ctx, key)。
+func (f LoaderFunc[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return f(ctx, key)
+}
+
+// WithLoader 为缓存设置读穿透加载器：Get 未命中时通过 loader 加载并写入缓存，
+// 写入的元素在 ttl 后过期，ttl <= 0 时使用 WithDefaultExpiration 设置的默认过期时间，未设置时永不过期。
+func WithLoader[K comparable, V any](loader Loader[K, V], ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.loader = loader
+		o.loaderItemOpts = nil
+		if ttl > 0 {
+			o.loaderItemOpts = []ItemOption{WithExpiration(ttl)}
+		}
+	}
+}
+
+// WithLoadTimeout 限制每次加载的耗时：传给加载器的 ctx 在 d 之后结束，加载器需要据此返回错误，
+// 例如 context.DeadlineExceeded，该错误会返回给所有等待者且不会被缓存。d <= 0 时不限制。
+// 对 WithLoader、GetOrLoad、提前刷新和提前过期触发的加载均生效。
+func WithLoadTimeout[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.loadTimeout = max(d, 0)
+	}
+}
+
+// loaderFunc 返回 WithLoader 设置的加载器，未设置时返回 nil。
+func (c *Cache[K, V]) loaderFunc() func(ctx context.Context, key K) (V, error) {
+	if c.opts.loader == nil {
+		return nil
+	}
+	return c.opts.loader.Load
+}
+
+type loadTTLKey struct{}
+
+// WithLoadTTL 返回携带 TTL 覆盖值的 ctx。使用返回的 ctx 调用 Get（配置了 WithLoader 时）或 GetOrLoad 时，
+// 由本次调用加载并写入缓存的元素在 ttl 后过期，覆盖 WithLoader 的默认 TTL 以及 GetOrLoad 传入的过期时间；
+// ttl <= 0 表示永不过期。例如在已知的故障或数据迁移期间为加载的元素使用更短的 TTL。
+//
+// 并发的加载会被合并，因此只有实际执行加载的调用者的 ctx 生效。
+func WithLoadTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, loadTTLKey{}, ttl)
+}
+
+func loadTTL(ctx context.Context) (time.Duration, bool) {
+	ttl, ok := ctx.Value(loadTTLKey{}).(time.Duration)
+	return ttl, ok
+}
+
+// call 表示一次正在进行的加载，同一个键的并发调用者共享同一个 call。
+type call[V any] struct {
+	// done 在加载结束并写入缓存之后关闭
+	done chan struct{}
+	val  V
+	err  error
+	// delta 为 loader 的耗时
+	delta time.Duration
+	// invalidated 在加载期间键被 Set 或 Delete 时置为 true，持有写锁时读写
+	invalidated bool
+	// waiters 为加入本次加载、等待其结果的调用者数量，不包括发起加载的调用者，持有写锁时读写
+	waiters int
+}
+
+// GetOrLoad 返回 key 对应的值；未命中时调用 loader 加载，并使用 opts 将结果写入缓存。
+//
+// 对同一个键的并发调用只会执行一次 loader（singleflight），所有等待者得到相同的结果。
+// loader 在单独的协程中执行，收到的 ctx 保留调用者 ctx 中的值，但不会随任何调用者的 ctx 结束，
+// 只受 WithLoadTimeout 的限制；调用者的 ctx 结束时 GetOrLoad 立即返回 ctx.Err()，加载继续进行，
+// 结果仍然会写入缓存并返回给其他等待者。
+// loader 返回错误时结果不会被缓存；loader 中的 panic 会被恢复并以 *cacheError.PanicError 的形式返回给所有等待者。
+//
+// 一致性保证：如果在加载期间该键被 Set 或 Delete，加载结果仍然会返回给本次的调用者，但不会写入缓存，
+// 因此加载结果永远不会覆盖更新的写入，也不会让已删除的旧数据重新出现。
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error), opts ...ItemOption) (_ V, err error) {
+	defer c.opEnd(ctx, "get", key, c.opStart())
+	defer c.wrapKeyError("get", key, &err)
+	v, early, err := c.get(ctx, key)
+	if early {
+		return c.expireEarly(ctx, key, v, loader, opts...)
+	}
+	if !errors.Is(err, cacheError.ErrNoKey) {
+		return v, err
+	}
+	return c.loadAndStore(ctx, key, loader, opts...)
+}
+
+// loadAndStore 在未命中后通过 loader 加载 key 并写入缓存，同一个键的并发加载会被合并。
+func (c *Cache[K, V]) loadAndStore(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error), opts ...ItemOption) (V, error) {
+	c.mutex.Lock()
+	// 获取写锁期间可能已经有其他调用者完成了加载
+	if item, err := c.cache.Get(ctx, key); err == nil && !item.Expired() {
+		c.mutex.Unlock()
+		return c.clone(item.value), nil
+	}
+	if cl, ok := c.calls[key]; ok {
+		cl.waiters++
+		c.stats.coalesced.Add(1)
+		c.mutex.Unlock()
+		return c.waitCall(ctx, cl)
+	}
+	cl := c.beginCall(key)
+	c.mutex.Unlock()
+
+	if write, ok := c.pendingWrite(key); ok {
+		// 回写模式下尚未写入 store 的值比 loader 的结果更新
+		if write.deleted {
+			cl.err = cacheError.ErrNoKey
+		} else {
+			cl.val = write.value
+		}
+		c.finishCall(ctx, key, cl, opts...)
+		return c.clone(cl.val), cl.err
+	}
+	c.startCall(ctx, key, cl, loader, opts...)
+	return c.waitCall(ctx, cl)
+}
+
+// waitCall 等待加载结束并返回加载结果的副本，见 WithCloner。
+func (c *Cache[K, V]) waitCall(ctx context.Context, cl *call[V]) (V, error) {
+	v, err := cl.wait(ctx)
+	if err != nil {
+		return v, err
+	}
+	return c.clone(v), nil
+}
+
+// reload 重新加载仍在缓存中的 key。key 上已经有正在进行的加载，或者回写模式下存在比 loader 更新的值时，
+// 不会加载并返回 false。
+func (c *Cache[K, V]) reload(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error), opts ...ItemOption) (*call[V], bool) {
+	if _, ok := c.pendingWrite(key); ok {
+		return nil, false
+	}
+	c.mutex.Lock()
+	if _, ok := c.calls[key]; ok {
+		c.mutex.Unlock()
+		return nil, false
+	}
+	cl := c.beginCall(key)
+	c.mutex.Unlock()
+	c.startCall(ctx, key, cl, loader, opts...)
+	if _, err := cl.wait(ctx); err != nil {
+		// ctx 可能在加载结束之前结束，此时加载结果仍然会写入缓存
+		return &call[V]{err: err}, true
+	}
+	return cl, true
+}
+
+// beginCall 登记 key 上一次新的加载，调用方必须持有写锁。
+func (c *Cache[K, V]) beginCall(key K) *call[V] {
+	cl := &call[V]{done: make(chan struct{})}
+	if c.calls == nil {
+		c.calls = make(map[K]*call[V])
+	}
+	c.calls[key] = cl
+	return cl
+}
+
+// finishCall 结束 key 上的加载：加载成功且期间键没有被修改时写入缓存，然后唤醒所有等待者。
+func (c *Cache[K, V]) finishCall(ctx context.Context, key K, cl *call[V], opts ...ItemOption) {
+	c.mutex.Lock()
+	delete(c.calls, key)
+	if n := uint64(cl.waiters + 1); n > c.stats.maxCoalesced.Load() {
+		c.stats.maxCoalesced.Store(n)
+	}
+	if cl.err == nil && !cl.invalidated {
+		item := c.newItem(cl.val, opts...)
+		if ttl, ok := loadTTL(ctx); ok {
+			item.expiration, item.ttl = time.Time{}, 0
+			if ttl > 0 {
+				item.expiration, item.ttl = time.Now().Add(ttl), ttl
+			}
+		}
+		if cl.delta > 0 {
+			item.delta = cl.delta
+		}
+		if err := c.cache.Set(ctx, key, item); err != nil {
+			cl.err = err
+		} else {
+			c.track(key, item)
+			c.stats.sets.Add(1)
+		}
+	}
+	c.unlock()
+	close(cl.done)
+}
+
+// wait 等待加载结束，ctx 先结束时返回 ctx.Err()。
+func (cl *call[V]) wait(ctx context.Context) (V, error) {
+	select {
+	case <-cl.done:
+		return cl.val, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// startCall 在新的协程中执行 loader 并结束加载。loader 使用的 ctx 与调用者的 ctx 分离，
+// 因此发起加载的调用者的 ctx 结束不会影响共享同一个 call 的其他等待者。
+func (c *Cache[K, V]) startCall(ctx context.Context, key K, cl *call[V], loader func(ctx context.Context, key K) (V, error), opts ...ItemOption) {
+	ctx = context.WithoutCancel(ctx)
+	c.stats.loads.Add(1)
+	go func() {
+		c.runCall(ctx, key, cl, loader)
+		c.finishCall(ctx, key, cl, opts...)
+	}()
+}
+
+// runCall 执行 loader 并记录加载耗时，设置了 WithLoadTimeout 时 loader 的 ctx 在超时之后结束。
+func (c *Cache[K, V]) runCall(ctx context.Context, key K, cl *call[V], loader func(ctx context.Context, key K) (V, error)) {
+	if c.opts.loadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.loadTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	cl.val, cl.err = c.load(ctx, key, loader)
+	cl.delta = time.Since(start)
+}
+
+// load 执行 loader 并恢复其中的 panic。
+func (c *Cache[K, V]) load(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (v V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &cacheError.PanicError{Value: r, Stack: debug.Stack()}
+			if c.opts.panicHandler != nil {
+				c.safeCall(func() { c.opts.panicHandler(panicErr) })
+			}
+			err = panicErr
+		}
+	}()
+	return loader(ctx, key)
+}
+
+// pendingWrite 返回回写模式下 key 尚未写入 store 的最新操作，一致性为 Eventual 时总是返回 false。
+func (c *Cache[K, V]) pendingWrite(key K) (pendingWrite[V], bool) {
+	if c.wb == nil || c.wb.cfg.consistency == Eventual {
+		return pendingWrite[V]{}, false
+	}
+	return c.wb.lookup(key)
+}
+
+// invalidate 使 key 上正在进行的加载失效，调用方必须持有写锁。
+func (c *Cache[K, V]) invalidate(key K) {
+	if cl, ok := c.calls[key]; ok {
+		cl.invalidated = true
+	}
+}
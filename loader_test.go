@@ -0,0 +1,416 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetOrLoad(t *testing.T) {
+	loadErr := errors.New("load error")
+	testCases := []struct {
+		name   string
+		cache  func(t *testing.T) *Cache[string, int]
+		loader func(ctx context.Context, key string) (int, error)
+
+		wantValue  int
+		wantErr    error
+		wantCached bool
+	}{
+		{
+			name: "hit",
+			cache: func(t *testing.T) *Cache[string, int] {
+				c := NewSimpleCache[string, int](context.Background(), 10, 0)
+				require.NoError(t, c.Set(context.Background(), "1", 1))
+				return c
+			},
+			loader: func(ctx context.Context, key string) (int, error) {
+				t.Fatal("loader should not be called")
+				return 0, nil
+			},
+			wantValue:  1,
+			wantCached: true,
+		},
+		{
+			name: "miss",
+			cache: func(t *testing.T) *Cache[string, int] {
+				return NewSimpleCache[string, int](context.Background(), 10, 0)
+			},
+			loader: func(ctx context.Context, key string) (int, error) {
+				return 1, nil
+			},
+			wantValue:  1,
+			wantCached: true,
+		},
+		{
+			name: "expired",
+			cache: func(t *testing.T) *Cache[string, int] {
+				c := NewSimpleCache[string, int](context.Background(), 10, 0)
+				require.NoError(t, c.Set(context.Background(), "1", 0, WithExpiration(-time.Second)))
+				return c
+			},
+			loader: func(ctx context.Context, key string) (int, error) {
+				return 1, nil
+			},
+			wantValue:  1,
+			wantCached: true,
+		},
+		{
+			name: "loader error",
+			cache: func(t *testing.T) *Cache[string, int] {
+				return NewSimpleCache[string, int](context.Background(), 10, 0)
+			},
+			loader: func(ctx context.Context, key string) (int, error) {
+				return 0, loadErr
+			},
+			wantErr: loadErr,
+		},
+		{
+			name: "loader panic",
+			cache: func(t *testing.T) *Cache[string, int] {
+				return NewSimpleCache[string, int](context.Background(), 10, 0)
+			},
+			loader: func(ctx context.Context, key string) (int, error) {
+				panic("boom")
+			},
+			wantErr: cacheError.ErrCallbackPanic,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache(t)
+			got, err := c.GetOrLoad(context.Background(), "1", tc.loader)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantValue, got)
+			v, err := c.Get(context.Background(), "1")
+			if tc.wantCached {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantValue, v)
+			} else {
+				assert.ErrorIs(t, err, cacheError.ErrNoKey)
+			}
+		})
+	}
+}
+
+func TestCache_GetOrLoad_Singleflight(t *testing.T) {
+	c := NewSimpleCache[string, int](context.Background(), 10, 0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	results := make([]int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "k", loader)
+			assert.NoError(t, err)
+			results[i] = v
+		}(i)
+	}
+	// 等待第一个调用者进入 loader、其余调用者都加入这次加载后再放行
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return c.Stats().CoalescedLoads == n-1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, v := range results {
+		assert.Equal(t, 42, v)
+	}
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Loads)
+	assert.Equal(t, uint64(n-1), stats.CoalescedLoads)
+	assert.Equal(t, uint64(n), stats.MaxCoalesced)
+	assert.Equal(t, float64(n), stats.MeanCoalesced())
+}
+
+type ctxKey struct{}
+
+func TestCache_GetOrLoad_CallerCanceled(t *testing.T) {
+	c := NewSimpleCache[string, int](context.Background(), 10, 0)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (int, error) {
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+		// ctx 中的值仍然可以读取
+		assert.Equal(t, "v", ctx.Value(ctxKey{}))
+		return 42, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "v"))
+	first := make(chan error)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "k", loader)
+		first <- err
+	}()
+	<-started
+	second := make(chan int)
+	go func() {
+		v, err := c.GetOrLoad(context.Background(), "k", loader)
+		assert.NoError(t, err)
+		second <- v
+	}()
+
+	// 发起加载的调用者的 ctx 结束后立即返回，加载不受影响
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(release)
+	assert.Equal(t, 42, <-second)
+	v, err := c.Get(context.Background(), "k")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+}
+
+func TestWithLoadTimeout(t *testing.T) {
+	testCases := []struct {
+		name    string
+		timeout time.Duration
+
+		wantValue int
+		wantErr   error
+	}{
+		{
+			name:    "timeout",
+			timeout: time.Millisecond,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:      "no timeout",
+			wantValue: 42,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSimpleCache[string, int](context.Background(), 10, 0, WithLoadTimeout[string, int](tc.timeout))
+			loader := func(ctx context.Context, key string) (int, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					return 42, nil
+				}
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			v, err := c.GetOrLoad(context.Background(), "k", loader)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantValue, v)
+			// 超时的结果不会被缓存
+			_, err = c.Peek(context.Background(), "k")
+			assert.Equal(t, tc.wantErr != nil, errors.Is(err, cacheError.ErrNoKey))
+		})
+	}
+}
+
+func TestCache_GetOrLoad_Invalidation(t *testing.T) {
+	testCases := []struct {
+		name   string
+		during func(t *testing.T, c *Cache[string, int])
+
+		wantValue int
+		wantErr   error
+	}{
+		{
+			name: "delete during load",
+			during: func(t *testing.T, c *Cache[string, int]) {
+				assert.ErrorIs(t, c.Delete(context.Background(), "k"), cacheError.ErrNoKey)
+			},
+			wantErr: cacheError.ErrNoKey,
+		},
+		{
+			name: "set during load",
+			during: func(t *testing.T, c *Cache[string, int]) {
+				assert.NoError(t, c.Set(context.Background(), "k", 2))
+			},
+			wantValue: 2,
+		},
+		{
+			name: "setNX during load",
+			during: func(t *testing.T, c *Cache[string, int]) {
+				ok, err := c.SetNX(context.Background(), "k", 3)
+				assert.NoError(t, err)
+				assert.True(t, ok)
+			},
+			wantValue: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSimpleCache[string, int](context.Background(), 10, 0)
+			started := make(chan struct{})
+			release := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				v, err := c.GetOrLoad(context.Background(), "k", func(ctx context.Context, key string) (int, error) {
+					close(started)
+					<-release
+					return 1, nil
+				})
+				// 加载结果仍然返回给调用者
+				assert.NoError(t, err)
+				assert.Equal(t, 1, v)
+			}()
+			<-started
+			tc.during(t, c)
+			close(release)
+			<-done
+
+			v, err := c.Get(context.Background(), "k")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantValue, v)
+		})
+	}
+}
+
+func TestWithLoader(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int32
+	loader := LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		if key == "missing" {
+			return 0, cacheError.ErrNoKey
+		}
+		return len(key), nil
+	})
+
+	testCases := []struct {
+		name string
+		ttl  time.Duration
+		key  string
+
+		wantValue      int
+		wantErr        error
+		wantExpiration bool
+	}{
+		{
+			name:      "load without ttl",
+			key:       "abc",
+			wantValue: 3,
+		},
+		{
+			name:           "load with ttl",
+			ttl:            time.Minute,
+			key:            "abcd",
+			wantValue:      4,
+			wantExpiration: true,
+		},
+		{
+			name:    "loader error",
+			key:     "missing",
+			wantErr: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls.Store(0)
+			c := NewSimpleCache[string, int](ctx, 10, 0, WithLoader[string, int](loader, tc.ttl))
+			for i := 0; i < 2; i++ {
+				v, err := c.Get(ctx, tc.key)
+				assert.Equal(t, tc.wantErr, err)
+				assert.Equal(t, tc.wantValue, v)
+			}
+			if tc.wantErr != nil {
+				// 加载失败不会被缓存，每次 Get 都会重新加载
+				assert.Equal(t, int32(2), calls.Load())
+				return
+			}
+			assert.Equal(t, int32(1), calls.Load())
+			info, err := c.EntryInfo(ctx, tc.key)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantExpiration, !info.Expiration.IsZero())
+		})
+	}
+}
+
+func TestWithLoadTTL(t *testing.T) {
+	loader := LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		return 1, nil
+	})
+	testCases := []struct {
+		name string
+		ctx  context.Context
+		get  func(ctx context.Context, c *Cache[string, int]) (int, error)
+
+		wantTTL time.Duration
+	}{
+		{
+			name: "loader default",
+			ctx:  context.Background(),
+			get: func(ctx context.Context, c *Cache[string, int]) (int, error) {
+				return c.Get(ctx, "k")
+			},
+			wantTTL: time.Hour,
+		},
+		{
+			name: "override read-through",
+			ctx:  WithLoadTTL(context.Background(), time.Minute),
+			get: func(ctx context.Context, c *Cache[string, int]) (int, error) {
+				return c.Get(ctx, "k")
+			},
+			wantTTL: time.Minute,
+		},
+		{
+			name: "override GetOrLoad options",
+			ctx:  WithLoadTTL(context.Background(), time.Minute),
+			get: func(ctx context.Context, c *Cache[string, int]) (int, error) {
+				return c.GetOrLoad(ctx, "k", loader, WithExpiration(time.Hour))
+			},
+			wantTTL: time.Minute,
+		},
+		{
+			name: "override without expiration",
+			ctx:  WithLoadTTL(context.Background(), 0),
+			get: func(ctx context.Context, c *Cache[string, int]) (int, error) {
+				return c.Get(ctx, "k")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSimpleCache[string, int](context.Background(), 0, 0, WithLoader[string, int](loader, time.Hour))
+			v, err := tc.get(tc.ctx, c)
+			require.NoError(t, err)
+			assert.Equal(t, 1, v)
+			info, err := c.EntryInfo(context.Background(), "k")
+			require.NoError(t, err)
+			if tc.wantTTL == 0 {
+				assert.True(t, info.Expiration.IsZero())
+				return
+			}
+			assert.WithinDuration(t, time.Now().Add(tc.wantTTL), info.Expiration, time.Second)
+		})
+	}
+}
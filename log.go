@@ -0,0 +1,115 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LogOption 配置 WithLogger 的日志行为。
+type LogOption func(*logConfig)
+
+type logConfig struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	slowLevel     slog.Level
+	evictionLevel slog.Level
+	cleanupLevel  slog.Level
+}
+
+// WithLogger 使用 logger 记录慢操作、淘汰和过期清理的摘要。
+// 默认只在 Debug 级别记录淘汰和清理摘要，慢操作日志需要通过 WithSlowThreshold 开启。logger 不能为 nil。
+func WithLogger[K comparable, V any](logger *slog.Logger, opts ...LogOption) Option[K, V] {
+	return func(o *options[K, V]) {
+		cfg := &logConfig{
+			logger:        logger,
+			slowLevel:     slog.LevelWarn,
+			evictionLevel: slog.LevelDebug,
+			cleanupLevel:  slog.LevelDebug,
+		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		o.log = cfg
+	}
+}
+
+// WithSlowThreshold 记录耗时不少于 d 的 Get、Set、SetNX 和 Delete 操作，d 小于等于 0 时不记录。
+func WithSlowThreshold(d time.Duration) LogOption {
+	return func(c *logConfig) {
+		c.slowThreshold = d
+	}
+}
+
+// WithSlowLevel 设置慢操作日志的级别，默认为 Warn。
+func WithSlowLevel(level slog.Level) LogOption {
+	return func(c *logConfig) {
+		c.slowLevel = level
+	}
+}
+
+// WithEvictionLevel 设置淘汰日志的级别，默认为 Debug。
+func WithEvictionLevel(level slog.Level) LogOption {
+	return func(c *logConfig) {
+		c.evictionLevel = level
+	}
+}
+
+// WithCleanupLevel 设置过期清理摘要日志的级别，默认为 Debug。
+func WithCleanupLevel(level slog.Level) LogOption {
+	return func(c *logConfig) {
+		c.cleanupLevel = level
+	}
+}
+
+// opStart 在需要记录慢操作时返回当前时间，否则返回零值，避免在热路径上读取时钟。
+func (c *Cache[K, V]) opStart() time.Time {
+	if c.opts.log == nil || c.opts.log.slowThreshold <= 0 {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// opEnd 在操作耗时超过阈值时记录日志。
+func (c *Cache[K, V]) opEnd(ctx context.Context, op string, key K, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	if d := time.Since(start); d >= c.opts.log.slowThreshold {
+		c.opts.log.logger.Log(ctx, c.opts.log.slowLevel, "cache: slow operation",
+			slog.String("cache", c.opts.name), slog.String("op", op), slog.Any("key", key), slog.Duration("duration", d))
+	}
+}
+
+func (c *Cache[K, V]) logEviction(key K, meta map[string]string) {
+	if c.opts.log == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("cache", c.opts.name), slog.Any("key", key)}
+	if len(meta) > 0 {
+		attrs = append(attrs, slog.Any("meta", meta))
+	}
+	c.opts.log.logger.LogAttrs(context.Background(), c.opts.log.evictionLevel, "cache: entry evicted", attrs...)
+}
+
+func (c *Cache[K, V]) logCleanup(ctx context.Context, scanned, removed int, d time.Duration) {
+	if c.opts.log == nil {
+		return
+	}
+	c.opts.log.logger.Log(ctx, c.opts.log.cleanupLevel, "cache: expired entries cleaned up",
+		slog.String("cache", c.opts.name), slog.Int("scanned", scanned), slog.Int("removed", removed), slog.Duration("duration", d))
+}
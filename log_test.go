@@ -0,0 +1,78 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		require.NoError(t, dec.Decode(&record))
+		delete(record, "time")
+		delete(record, "duration")
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestWithLogger(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cache := NewLruCache[int, int](ctx, 1, time.Minute,
+		WithName[int, int]("logged"),
+		WithLogger[int, int](logger, WithEvictionLevel(slog.LevelInfo)),
+	)
+
+	require.NoError(t, cache.Set(ctx, 1, 1, WithExpiration(time.Millisecond), WithMeta(map[string]string{"trace_id": "abc"})))
+	require.NoError(t, cache.Set(ctx, 2, 2, WithExpiration(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+	cache.DeleteExpired(ctx)
+
+	assert.Equal(t, []map[string]any{
+		{"level": "INFO", "msg": "cache: entry evicted", "cache": "logged", "key": float64(1), "meta": map[string]any{"trace_id": "abc"}},
+		{"level": "DEBUG", "msg": "cache: expired entries cleaned up", "cache": "logged", "scanned": float64(1), "removed": float64(1)},
+	}, logRecords(t, buf))
+}
+
+func TestWithSlowThreshold(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	cache := NewSimpleCache[int, int](ctx, 0, time.Minute,
+		WithLogger[int, int](logger, WithSlowThreshold(time.Nanosecond), WithSlowLevel(slog.LevelError)),
+	)
+
+	require.NoError(t, cache.Set(ctx, 1, 1))
+	_, err := cache.Get(ctx, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []map[string]any{
+		{"level": "ERROR", "msg": "cache: slow operation", "cache": "", "op": "set", "key": float64(1)},
+		{"level": "ERROR", "msg": "cache: slow operation", "cache": "", "op": "get", "key": float64(1)},
+	}, logRecords(t, buf))
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/chenmingyong0423/go-generics-cache/internal/glob"
+)
+
+// DeleteByPrefix 删除所有以 prefix 开头的键并返回实际删除的数量，例如使用 "user:42:" 清除某个用户的所有缓存。
+// 匹配的键在读锁内收集，之后与 MDelete 相同地在一次加锁内删除并同步到 WithStore，期间新写入的键可能不会被删除。
+func DeleteByPrefix[K ~string, V any](ctx context.Context, c *Cache[K, V], prefix K) (int, error) {
+	keys, err := keysWithPrefix(ctx, c, prefix)
+	if err != nil {
+		return 0, err
+	}
+	return c.MDelete(ctx, keys...)
+}
+
+// DeleteMatch 删除所有匹配 pattern 的键并返回实际删除的数量，pattern 的语法与 Redis 的 KEYS 命令相同：
+// '*' 匹配任意长度的任意字符，'?' 匹配单个字符，[abc] 匹配字符类，'\' 转义。其他行为与 DeleteByPrefix 相同。
+func DeleteMatch[K ~string, V any](ctx context.Context, c *Cache[K, V], pattern string) (int, error) {
+	keys, err := matchingKeys(ctx, c, func(key string) bool {
+		return glob.Match(pattern, key)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return c.MDelete(ctx, keys...)
+}
+
+// keysWithPrefix 返回 c 中以 prefix 开头的键，与 Keys 相同，可能包含已过期但尚未清理的键。
+func keysWithPrefix[K ~string, V any](ctx context.Context, c *Cache[K, V], prefix K) ([]K, error) {
+	return matchingKeys(ctx, c, func(key string) bool {
+		return strings.HasPrefix(key, string(prefix))
+	})
+}
+
+// matchingKeys 在读锁内遍历所有的键，返回 match 为 true 的键，遍历期间 ctx 结束时返回 ctx.Err()。
+func matchingKeys[K ~string, V any](ctx context.Context, c *Cache[K, V], match func(key string) bool) ([]K, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	keys := make([]K, 0)
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	var err error
+	i := 0
+	c.rangeKeys(func(key K) bool {
+		if err = checkCtx(ctx, i); err != nil {
+			return false
+		}
+		i++
+		if match(string(key)) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var matchKeys = []string{"user:42:name", "user:42:email", "user:420:name", "user:7:name", "order:42", "user:*"}
+
+func TestDeleteByPrefix(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prefix string
+
+		wantN    int
+		wantKeys []string
+	}{
+		{
+			name:     "prefix",
+			prefix:   "user:42:",
+			wantN:    2,
+			wantKeys: []string{"user:420:name", "user:7:name", "order:42", "user:*"},
+		},
+		{
+			name:     "wildcards are literal",
+			prefix:   "user:*",
+			wantN:    1,
+			wantKeys: []string{"user:42:name", "user:42:email", "user:420:name", "user:7:name", "order:42"},
+		},
+		{
+			name:     "no match",
+			prefix:   "session:",
+			wantN:    0,
+			wantKeys: matchKeys,
+		},
+		{
+			name:     "empty prefix",
+			prefix:   "",
+			wantN:    len(matchKeys),
+			wantKeys: []string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewSimpleCache[string, int](ctx, 100, time.Hour)
+			for _, key := range matchKeys {
+				require.NoError(t, c.Set(ctx, key, 1))
+			}
+			n, err := DeleteByPrefix(ctx, c, tc.prefix)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantN, n)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+		})
+	}
+}
+
+func TestDeleteMatch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+
+		wantN    int
+		wantKeys []string
+	}{
+		{
+			name:     "star",
+			pattern:  "user:42:*",
+			wantN:    2,
+			wantKeys: []string{"user:420:name", "user:7:name", "order:42", "user:*"},
+		},
+		{
+			name:     "star in the middle",
+			pattern:  "user:*:name",
+			wantN:    3,
+			wantKeys: []string{"user:42:email", "order:42", "user:*"},
+		},
+		{
+			name:     "question mark and class",
+			pattern:  "user:[0-9]:?ame",
+			wantN:    1,
+			wantKeys: []string{"user:42:name", "user:42:email", "user:420:name", "order:42", "user:*"},
+		},
+		{
+			name:     "escaped star",
+			pattern:  `user:\*`,
+			wantN:    1,
+			wantKeys: []string{"user:42:name", "user:42:email", "user:420:name", "user:7:name", "order:42"},
+		},
+		{
+			name:     "exact key",
+			pattern:  "order:42",
+			wantN:    1,
+			wantKeys: []string{"user:42:name", "user:42:email", "user:420:name", "user:7:name", "user:*"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewSimpleCache[string, int](ctx, 100, time.Hour)
+			for _, key := range matchKeys {
+				require.NoError(t, c.Set(ctx, key, 1))
+			}
+			n, err := DeleteMatch(ctx, c, tc.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantN, n)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+		})
+	}
+}
+
+func TestDeleteMatch_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewSimpleCache[string, int](context.Background(), 0, 0)
+	require.NoError(t, c.Set(ctx, "user:1", 1))
+	cancel()
+
+	n, err := DeleteMatch(ctx, c, "user:*")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, n)
+	n, err = DeleteByPrefix(ctx, c, "user:")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, n)
+	assert.Equal(t, 1, c.Len())
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics 提供记录操作次数和耗时的 types.ICache 装饰器，适用于不使用 cache.Cache、
+// 而是自行组合后端的场景。使用 cache.Cache 时可以直接通过 Stats 获取命中率等统计信息。
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+var _ types.ICache[string, int] = (*Cache[string, int])(nil)
+
+// Op 是被记录的操作。
+type Op uint8
+
+const (
+	OpGet Op = iota + 1
+	OpSet
+	OpDelete
+	OpKeys
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpGet:
+		return "get"
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	case OpKeys:
+		return "keys"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome 是操作的结果。
+type Outcome uint8
+
+const (
+	// OutcomeOK 表示 Set、Keys 成功，或者 Get、Delete 找到了键。
+	OutcomeOK Outcome = iota + 1
+	// OutcomeMiss 表示 Get 或 Delete 返回了可以被 errors.Is 识别的 cacheError.ErrNoKey。
+	OutcomeMiss
+	// OutcomeError 表示操作返回了其他错误。
+	OutcomeError
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeOK:
+		return "ok"
+	case OutcomeMiss:
+		return "miss"
+	case OutcomeError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Sink 接收每一次操作的结果和耗时，通常将其转换为 Prometheus、OpenTelemetry 等系统的计数器和直方图。
+// Observe 在操作返回之前同步调用，需要是并发安全且足够快的。
+type Sink interface {
+	Observe(op Op, outcome Outcome, d time.Duration)
+}
+
+// SinkFunc 将普通函数适配为 Sink。
+type SinkFunc func(op Op, outcome Outcome, d time.Duration)
+
+// Observe 调用 f(op, outcome, d)。
+func (f SinkFunc) Observe(op Op, outcome Outcome, d time.Duration) {
+	f(op, outcome, d)
+}
+
+// Cache 包装任意的 types.ICache，将每次操作的结果和耗时记录到 Sink，自身实现了 types.ICache。
+// Cache 只转发 types.ICache 的方法，被包装的缓存实现的其他可选接口（如 types.Evicter）不会被暴露，
+// 需要时可以通过 Unwrap 获取被包装的缓存。Cache 是否并发安全取决于被包装的缓存。
+type Cache[K comparable, V any] struct {
+	cache types.ICache[K, V]
+	sink  Sink
+	now   func() time.Time
+}
+
+// New 返回记录 c 的操作的装饰器。
+func New[K comparable, V any](c types.ICache[K, V], sink Sink) *Cache[K, V] {
+	return &Cache[K, V]{cache: c, sink: sink, now: time.Now}
+}
+
+// Unwrap 返回被包装的缓存。
+func (c *Cache[K, V]) Unwrap() types.ICache[K, V] {
+	return c.cache
+}
+
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	start := c.now()
+	v, err := c.cache.Get(ctx, key)
+	c.observe(OpGet, err, start)
+	return v, err
+}
+
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) error {
+	start := c.now()
+	err := c.cache.Set(ctx, key, value)
+	c.observe(OpSet, err, start)
+	return err
+}
+
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	start := c.now()
+	err := c.cache.Delete(ctx, key)
+	c.observe(OpDelete, err, start)
+	return err
+}
+
+func (c *Cache[K, V]) Keys() []K {
+	start := c.now()
+	keys := c.cache.Keys()
+	c.observe(OpKeys, nil, start)
+	return keys
+}
+
+func (c *Cache[K, V]) observe(op Op, err error, start time.Time) {
+	d := c.now().Sub(start)
+	switch {
+	case err == nil:
+		c.sink.Observe(op, OutcomeOK, d)
+	case errors.Is(err, cacheError.ErrNoKey):
+		c.sink.Observe(op, OutcomeMiss, d)
+	default:
+		c.sink.Observe(op, OutcomeError, d)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type observation struct {
+	op      Op
+	outcome Outcome
+	d       time.Duration
+}
+
+// failingCache 的所有操作都返回错误。
+type failingCache struct {
+	types.ICache[string, int]
+}
+
+var errBackend = errors.New("backend error")
+
+func (failingCache) Get(context.Context, string) (int, error) { return 0, errBackend }
+func (failingCache) Set(context.Context, string, int) error   { return errBackend }
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name    string
+		backend types.ICache[string, int]
+		op      func(c *Cache[string, int]) error
+
+		wantErr error
+		want    observation
+	}{
+		{
+			name:    "get hit",
+			backend: withKey(t),
+			op: func(c *Cache[string, int]) error {
+				v, err := c.Get(ctx, "a")
+				assert.Equal(t, 1, v)
+				return err
+			},
+			want: observation{op: OpGet, outcome: OutcomeOK, d: time.Millisecond},
+		},
+		{
+			name:    "get miss",
+			backend: withKey(t),
+			op: func(c *Cache[string, int]) error {
+				_, err := c.Get(ctx, "b")
+				return err
+			},
+			wantErr: cacheError.ErrNoKey,
+			want:    observation{op: OpGet, outcome: OutcomeMiss, d: time.Millisecond},
+		},
+		{
+			name:    "get error",
+			backend: failingCache{},
+			op: func(c *Cache[string, int]) error {
+				_, err := c.Get(ctx, "a")
+				return err
+			},
+			wantErr: errBackend,
+			want:    observation{op: OpGet, outcome: OutcomeError, d: time.Millisecond},
+		},
+		{
+			name:    "set",
+			backend: withKey(t),
+			op: func(c *Cache[string, int]) error {
+				return c.Set(ctx, "b", 2)
+			},
+			want: observation{op: OpSet, outcome: OutcomeOK, d: time.Millisecond},
+		},
+		{
+			name:    "set error",
+			backend: failingCache{},
+			op: func(c *Cache[string, int]) error {
+				return c.Set(ctx, "b", 2)
+			},
+			wantErr: errBackend,
+			want:    observation{op: OpSet, outcome: OutcomeError, d: time.Millisecond},
+		},
+		{
+			name:    "delete",
+			backend: withKey(t),
+			op: func(c *Cache[string, int]) error {
+				return c.Delete(ctx, "a")
+			},
+			want: observation{op: OpDelete, outcome: OutcomeOK, d: time.Millisecond},
+		},
+		{
+			name:    "delete miss",
+			backend: withKey(t),
+			op: func(c *Cache[string, int]) error {
+				return c.Delete(ctx, "b")
+			},
+			wantErr: cacheError.ErrNoKey,
+			want:    observation{op: OpDelete, outcome: OutcomeMiss, d: time.Millisecond},
+		},
+		{
+			name:    "keys",
+			backend: withKey(t),
+			op: func(c *Cache[string, int]) error {
+				assert.Equal(t, []string{"a"}, c.Keys())
+				return nil
+			},
+			want: observation{op: OpKeys, outcome: OutcomeOK, d: time.Millisecond},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []observation
+			c := New(tc.backend, SinkFunc(func(op Op, outcome Outcome, d time.Duration) {
+				got = append(got, observation{op: op, outcome: outcome, d: d})
+			}))
+			// 每次读取时钟前进 1ms，因此每次操作耗时 1ms
+			now := time.Unix(0, 0)
+			c.now = func() time.Time {
+				now = now.Add(time.Millisecond)
+				return now
+			}
+			assert.ErrorIs(t, tc.op(c), tc.wantErr)
+			assert.Equal(t, []observation{tc.want}, got)
+			assert.Equal(t, tc.backend, c.Unwrap())
+		})
+	}
+}
+
+func withKey(t *testing.T) types.ICache[string, int] {
+	c := simple.NewCache[string, int](0)
+	assert.NoError(t, c.Set(context.Background(), "a", 1))
+	return c
+}
+
+func TestOpAndOutcome_String(t *testing.T) {
+	assert.Equal(t, "get", OpGet.String())
+	assert.Equal(t, "keys", OpKeys.String())
+	assert.Equal(t, "unknown", Op(0).String())
+	assert.Equal(t, "miss", OutcomeMiss.String())
+	assert.Equal(t, "unknown", Outcome(0).String())
+}
+
+func TestCache_Conformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return New[string, string](simple.NewCache[string, string](0), SinkFunc(func(Op, Outcome, time.Duration) {}))
+	})
+}
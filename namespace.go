@@ -0,0 +1,103 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// NamespacedCache 是 Cache 中以同一个前缀开头的键组成的视图，多个模块共享一个缓存时各自使用一个命名空间，
+// 避免键冲突。视图的方法接收和返回不带前缀的键，容量、过期清理和 Option 由底层的 Cache 统一管理。
+type NamespacedCache[K ~string, V any] struct {
+	c      *Cache[K, V]
+	prefix K
+}
+
+// Namespace 返回 c 中以 prefix 为前缀的键组成的视图。命名空间之间通过前缀划分，"user" 会包含 "users" 中的键，
+// 因此 prefix 通常以分隔符结尾，例如 "user:"。
+func Namespace[K ~string, V any](c *Cache[K, V], prefix K) *NamespacedCache[K, V] {
+	return &NamespacedCache[K, V]{c: c, prefix: prefix}
+}
+
+// Namespace 返回嵌套的命名空间，其前缀为当前前缀加上 prefix。
+func (n *NamespacedCache[K, V]) Namespace(prefix K) *NamespacedCache[K, V] {
+	return Namespace(n.c, n.prefix+prefix)
+}
+
+// Prefix 返回命名空间的前缀。
+func (n *NamespacedCache[K, V]) Prefix() K {
+	return n.prefix
+}
+
+func (n *NamespacedCache[K, V]) key(key K) K {
+	return n.prefix + key
+}
+
+func (n *NamespacedCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return n.c.Get(ctx, n.key(key))
+}
+
+func (n *NamespacedCache[K, V]) Peek(ctx context.Context, key K) (V, error) {
+	return n.c.Peek(ctx, n.key(key))
+}
+
+func (n *NamespacedCache[K, V]) Contains(ctx context.Context, key K) bool {
+	return n.c.Contains(ctx, n.key(key))
+}
+
+func (n *NamespacedCache[K, V]) Set(ctx context.Context, key K, value V, opts ...ItemOption) error {
+	return n.c.Set(ctx, n.key(key), value, opts...)
+}
+
+func (n *NamespacedCache[K, V]) SetNX(ctx context.Context, key K, value V, opts ...ItemOption) (bool, error) {
+	return n.c.SetNX(ctx, n.key(key), value, opts...)
+}
+
+func (n *NamespacedCache[K, V]) GetOrSet(ctx context.Context, key K, value V, opts ...ItemOption) (V, bool, error) {
+	return n.c.GetOrSet(ctx, n.key(key), value, opts...)
+}
+
+func (n *NamespacedCache[K, V]) Delete(ctx context.Context, key K) error {
+	return n.c.Delete(ctx, n.key(key))
+}
+
+func (n *NamespacedCache[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) error {
+	return n.c.Expire(ctx, n.key(key), ttl)
+}
+
+func (n *NamespacedCache[K, V]) TTL(ctx context.Context, key K) (time.Duration, error) {
+	return n.c.TTL(ctx, n.key(key))
+}
+
+// Keys 返回命名空间中所有的键（不带前缀），需要遍历底层缓存的所有键。
+func (n *NamespacedCache[K, V]) Keys() []K {
+	keys, _ := keysWithPrefix(context.Background(), n.c, n.prefix)
+	for i, key := range keys {
+		keys[i] = key[len(n.prefix):]
+	}
+	return keys
+}
+
+// Len 返回命名空间中键的数量，需要遍历底层缓存的所有键。
+func (n *NamespacedCache[K, V]) Len() int {
+	keys, _ := keysWithPrefix(context.Background(), n.c, n.prefix)
+	return len(keys)
+}
+
+// Clear 删除命名空间中所有的键并返回删除的数量，其他命名空间不受影响，见 DeleteByPrefix。
+func (n *NamespacedCache[K, V]) Clear(ctx context.Context) (int, error) {
+	return DeleteByPrefix(ctx, n.c, n.prefix)
+}
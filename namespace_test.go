@@ -0,0 +1,117 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespace(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 100, time.Hour)
+	users := Namespace(c, "user:")
+	orders := Namespace(c, "order:")
+
+	require.NoError(t, users.Set(ctx, "1", 1))
+	require.NoError(t, orders.Set(ctx, "1", 100, WithExpiration(time.Minute)))
+	require.NoError(t, c.Set(ctx, "other", 0))
+
+	v, err := users.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	v, err = c.Get(ctx, "order:1")
+	require.NoError(t, err)
+	assert.Equal(t, 100, v)
+	ttl, err := orders.TTL(ctx, "1")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	ok, err := users.SetNX(ctx, "1", 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	actual, loaded, err := users.GetOrSet(ctx, "2", 2)
+	require.NoError(t, err)
+	assert.False(t, loaded)
+	assert.Equal(t, 2, actual)
+	assert.True(t, users.Contains(ctx, "2"))
+	assert.False(t, users.Contains(ctx, "other"))
+
+	assert.ElementsMatch(t, []string{"1", "2"}, users.Keys())
+	assert.Equal(t, 2, users.Len())
+	assert.Equal(t, []string{"1"}, orders.Keys())
+
+	require.NoError(t, users.Delete(ctx, "2"))
+	_, err = users.Peek(ctx, "2")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+}
+
+func TestNamespacedCache_Clear(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prefix string
+
+		wantN    int
+		wantKeys []string
+	}{
+		{
+			name:     "namespace",
+			prefix:   "user:",
+			wantN:    3,
+			wantKeys: []string{"users", "order:1"},
+		},
+		{
+			name:     "nested namespace",
+			prefix:   "user:42:",
+			wantN:    2,
+			wantKeys: []string{"user:7:name", "users", "order:1"},
+		},
+		{
+			name:     "empty namespace",
+			prefix:   "session:",
+			wantN:    0,
+			wantKeys: []string{"user:42:name", "user:42:email", "user:7:name", "users", "order:1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewSimpleCache[string, int](ctx, 100, time.Hour)
+			for _, key := range []string{"user:42:name", "user:42:email", "user:7:name", "users", "order:1"} {
+				require.NoError(t, c.Set(ctx, key, 1))
+			}
+			n, err := Namespace(c, tc.prefix).Clear(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantN, n)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+		})
+	}
+}
+
+func TestNamespacedCache_Namespace(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 100, time.Hour)
+	user := Namespace(c, "user:").Namespace("42:")
+	assert.Equal(t, "user:42:", user.Prefix())
+	require.NoError(t, user.Set(ctx, "age", 30))
+	require.NoError(t, user.Expire(ctx, "age", time.Minute))
+	assert.Equal(t, []string{"user:42:age"}, c.Keys())
+	assert.Equal(t, []string{"42:age"}, Namespace(c, "user:").Keys())
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"runtime/debug"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// Option 配置 Cache 的行为。
+type Option[K comparable, V any] func(*options[K, V])
+
+type options[K comparable, V any] struct {
+	name         string
+	expvarName   string
+	panicHandler func(err error)
+	onEvicted    func(key K, value V)
+	onExpired    func(key K, value V)
+	onDeleted    func(key K)
+	onClose      []func()
+	log          *logConfig
+	// loader 为读穿透加载器，loaderItemOpts 为加载结果写入缓存时使用的选项
+	loader         Loader[K, V]
+	loaderItemOpts []ItemOption
+	// loadTimeout 为 WithLoadTimeout 设置的单次加载的超时时间，0 表示不限制
+	loadTimeout time.Duration
+	// store 为缓存背后的持久化存储，写操作按 storeMode 同步过去
+	store     Store[K, V]
+	storeMode WriteMode
+	storeCfg  storeConfig
+	// refreshRatio 大于 0 时启用提前刷新
+	refreshRatio   float64
+	refreshWorkers int
+	// beta 大于 0 时启用概率提前过期，rand 为其使用的随机数源
+	beta float64
+	rand *lockedRand
+	// ghostSize 大于 0 时启用影子列表
+	ghostSize int
+	// cleanup 限制每次过期清理的工作量，nil 时使用 defaultCleanupConfig
+	cleanup *cleanupConfig
+	// snapshot 不为 nil 时定期把缓存保存到文件，并在创建时从文件恢复
+	snapshot *snapshotConfig
+	// wal 不为 nil 时启用预写日志
+	wal *walConfig
+	// maxCost 大于 0 时按元素的总成本限制容量，maxBytes 大于 0 时按 sizer 估算的总字节数限制容量
+	maxCost  int64
+	maxBytes int64
+	sizer    Sizer[V]
+	// evictWindow 大于 1 时按成本淘汰会在这么多个候选元素中淘汰重新计算耗时最短的一个
+	evictWindow int
+	// entryStats 为 true 时记录每个元素的命中次数和最近一次命中的时间
+	entryStats bool
+	// defaultExpiration 大于 0 时为未显式设置过期时间的元素设置的过期时长
+	defaultExpiration time.Duration
+	// keyErrors 为 true 时对单个键的操作返回 *cacheError.KeyError
+	keyErrors bool
+	// cloner 为 WithCloner 设置的复制函数，nil 表示直接返回缓存的值
+	cloner func(V) V
+	// rejectWhenFull 为 true 时缓存已满的写入返回 cacheError.ErrCacheFull 而不是淘汰元素
+	rejectWhenFull bool
+}
+
+// WithDefaultExpiration 设置默认过期时间：写入时没有通过 WithExpiration 等选项设置过期时间的元素在 d 之后过期，
+// 包括加载器加载的元素。需要永不过期的元素可以通过 WithoutExpiration 显式声明。
+func WithDefaultExpiration[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.defaultExpiration = d
+	}
+}
+
+// WithPanicHandler 设置用户回调发生 panic 时的处理函数。
+// 回调中的 panic 总是会被恢复，避免清理协程退出或锁无法释放；恢复后的 panic 以 *cacheError.PanicError
+// 的形式交给 handler，handler 可以记录日志，也可以自行再次 panic 以升级为进程级故障。
+// 未设置时 panic 会被恢复并忽略。
+func WithPanicHandler[K comparable, V any](handler func(err error)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.panicHandler = handler
+	}
+}
+
+// WithOnEvicted 设置元素因容量不足被淘汰时的回调，显式删除和过期清理不会触发该回调。
+// 该回调依赖后端实现 types.EvictionNotifier，内置的 lru、fifo、slru 和 random 均已实现；
+// 后端的淘汰回调由 Cache 接管，后端原有的淘汰回调会被替换。
+func WithOnEvicted[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onEvicted = fn
+	}
+}
+
+// WithKeyErrors 使 Get、Set、Delete 等对单个键的操作把 cacheError.ErrNoKey、cacheError.ErrKeyExpired 和
+// cacheError.ErrCacheFull 包装为 *cacheError.KeyError，附带操作、键、WithName 设置的名称和后端类型，
+// 便于在多个缓存共享的中间件中定位出错的缓存。包装之后仍然可以通过 errors.Is 匹配原来的错误，
+// 但不能再使用 == 比较。每次未命中都会分配 KeyError，因此默认关闭。
+func WithKeyErrors[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.keyErrors = true
+	}
+}
+
+// WithCloner 使读取操作返回 fn 复制的值而不是缓存中保存的值，适用于 V 是指针或者包含切片、map 的类型，
+// 避免调用方修改返回的值时改变了缓存的内容，fn 需要进行足够深的复制。Get、GetOrLoad、Peek、MGet、GetOrSet、
+// Sample 以及 Items、Snapshot、Freeze 等返回值的方法都会调用 fn，回调（如 WithOnEvicted）收到的是缓存中保存的值。
+// fn 在释放内部锁之后调用。Set 保存的是传入的值本身，调用方在 Set 之后同样不应该再修改它。
+func WithCloner[K comparable, V any](fn func(V) V) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.cloner = fn
+	}
+}
+
+// WithRejectWhenFull 使缓存已满时写入新的键返回 cacheError.ErrCacheFull，而不是淘汰已有的元素，
+// 适用于保存进行中的状态、不能静默丢弃元素而需要向调用方施加反压的缓存。覆盖已有的键总是成功，
+// 已过期但尚未被清理的元素同样占用容量；SetCapacity 缩小容量时仍然会淘汰多出的元素。
+// 写穿模式下值已经写入 store 之后才会发现缓存已满，此时 store 中的值保持不变。
+// 后端需要实现 types.FullRejecter，内置的 LRU 和 FIFO 后端都满足要求；不能与 WithMaxCost 和 WithMaxBytes 同时使用。
+func WithRejectWhenFull[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.rejectWhenFull = true
+	}
+}
+
+// WithOnExpired 设置过期元素被 DeleteExpired 清理时的回调，回调收到的是被删除前的值。
+func WithOnExpired[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onExpired = fn
+	}
+}
+
+// WithOnDeleted 设置元素被 Delete 或 MDelete 显式删除时的回调，淘汰、过期清理和 Clear 不会触发该回调。
+func WithOnDeleted[K comparable, V any](fn func(key K)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onDeleted = fn
+	}
+}
+
+// safeCall 执行 fn 并恢复其中的 panic。
+func (c *Cache[K, V]) safeCall(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if c.opts.panicHandler != nil {
+				c.opts.panicHandler(&cacheError.PanicError{Value: r, Stack: debug.Stack()})
+			}
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,388 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// panicCache 在 Get 时 panic，用于模拟出错的后端或回调。
+type panicCache[K comparable, V any] struct {
+	*simple.Cache[K, V]
+}
+
+func (p panicCache[K, V]) Get(_ context.Context, _ K) (V, error) {
+	panic("boom")
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var panics int64
+	var lastErr atomic.Value
+	backend := panicCache[int, *Item[int]]{Cache: simple.NewCache[int, *Item[int]](0)}
+	cache := New[int, int](ctx, backend, time.Millisecond, WithPanicHandler[int, int](func(err error) {
+		lastErr.Store(err)
+		atomic.AddInt64(&panics, 1)
+	}))
+	// 清理协程只会读取到期的键，每个到期的键触发一次 panic
+	assert.NoError(t, cache.Set(context.Background(), 1, 1, WithExpiration(time.Millisecond)))
+	assert.NoError(t, cache.Set(context.Background(), 3, 3, WithExpiration(time.Millisecond)))
+
+	// 清理协程在 panic 之后仍然继续运行
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&panics) >= 2
+	}, time.Second, time.Millisecond)
+
+	err := lastErr.Load().(error)
+	assert.True(t, errors.Is(err, cacheError.ErrCallbackPanic))
+	var panicErr *cacheError.PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "boom", panicErr.Value)
+
+	// panic 没有导致锁无法释放
+	assert.NoError(t, cache.Set(context.Background(), 2, 2))
+}
+
+func TestWithDefaultExpiration(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 0, time.Minute,
+		WithDefaultExpiration[string, int](time.Hour),
+		WithLoader[string, int](LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+			return 1, nil
+		}), 0))
+
+	testCases := []struct {
+		name string
+		set  func(key string) error
+
+		wantTTL time.Duration
+	}{
+		{
+			name:    "default",
+			set:     func(key string) error { return c.Set(ctx, key, 1) },
+			wantTTL: time.Hour,
+		},
+		{
+			name:    "explicit expiration",
+			set:     func(key string) error { return c.Set(ctx, key, 1, WithExpiration(time.Minute)) },
+			wantTTL: time.Minute,
+		},
+		{
+			name:    "without expiration",
+			set:     func(key string) error { return c.Set(ctx, key, 1, WithoutExpiration()) },
+			wantTTL: NoExpiration,
+		},
+		{
+			name: "multi",
+			set: func(key string) error {
+				return c.MSet(ctx, map[string]int{key: 1})
+			},
+			wantTTL: time.Hour,
+		},
+		{
+			name: "loader",
+			set: func(key string) error {
+				_, err := c.Get(ctx, key)
+				return err
+			},
+			wantTTL: time.Hour,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.set(tc.name))
+			ttl, err := c.TTL(ctx, tc.name)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.wantTTL, ttl, float64(time.Second))
+		})
+	}
+}
+
+func TestWithKeyErrors(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) *Cache[string, int]
+		op    func(c *Cache[string, int]) error
+
+		wantErr     error
+		wantKeyErr  *cacheError.KeyError
+		wantMessage string
+	}{
+		{
+			name: "get missing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				return NewLruCache[string, int](ctx, 2, 0, WithKeyErrors[string, int](), WithName[string, int]("sessions"))
+			},
+			op: func(c *Cache[string, int]) error {
+				_, err := c.Get(ctx, "42")
+				return err
+			},
+			wantErr:     cacheError.ErrNoKey,
+			wantKeyErr:  &cacheError.KeyError{Op: "get", Key: "42", Cache: "sessions", Backend: "*lru.Cache", Err: cacheError.ErrNoKey},
+			wantMessage: `cache "sessions" (*lru.Cache): get 42: cache: no key in cache`,
+		},
+		{
+			name: "get expired key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				c := NewSimpleCache[string, int](ctx, 0, 0, WithKeyErrors[string, int]())
+				require.NoError(t, c.Set(ctx, "42", 1, WithExpiration(-time.Second)))
+				return c
+			},
+			op: func(c *Cache[string, int]) error {
+				_, err := c.Get(ctx, "42")
+				return err
+			},
+			wantErr:     cacheError.ErrKeyExpired,
+			wantKeyErr:  &cacheError.KeyError{Op: "get", Key: "42", Backend: "*simple.Cache", Err: cacheError.ErrKeyExpired},
+			wantMessage: "cache (*simple.Cache): get 42: cache: key expired",
+		},
+		{
+			name: "delete missing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				return NewFifoCache[string, int](ctx, 2, 0, WithKeyErrors[string, int]())
+			},
+			op: func(c *Cache[string, int]) error {
+				return c.Delete(ctx, "42")
+			},
+			wantErr:     cacheError.ErrNoKey,
+			wantKeyErr:  &cacheError.KeyError{Op: "delete", Key: "42", Backend: "*fifo.Cache", Err: cacheError.ErrNoKey},
+			wantMessage: "cache (*fifo.Cache): delete 42: cache: no key in cache",
+		},
+		{
+			name: "set on full cache",
+			cache: func(t *testing.T) *Cache[string, int] {
+				c := NewLruCache[string, int](ctx, 1, 0, WithKeyErrors[string, int](), WithRejectWhenFull[string, int]())
+				require.NoError(t, c.Set(ctx, "1", 1))
+				return c
+			},
+			op: func(c *Cache[string, int]) error {
+				return c.Set(ctx, "42", 1)
+			},
+			wantErr:     cacheError.ErrCacheFull,
+			wantKeyErr:  &cacheError.KeyError{Op: "set", Key: "42", Backend: "*lru.Cache", Err: cacheError.ErrCacheFull},
+			wantMessage: "cache (*lru.Cache): set 42: cache: cache is full",
+		},
+		{
+			name: "other errors are not wrapped",
+			cache: func(t *testing.T) *Cache[string, int] {
+				c := NewLruCache[string, int](ctx, 1, 0, WithKeyErrors[string, int]())
+				require.NoError(t, c.Close())
+				return c
+			},
+			op: func(c *Cache[string, int]) error {
+				return c.Set(ctx, "42", 1)
+			},
+			wantErr:     cacheError.ErrClosed,
+			wantMessage: "cache: cache is closed",
+		},
+		{
+			name: "disabled",
+			cache: func(t *testing.T) *Cache[string, int] {
+				return NewLruCache[string, int](ctx, 2, 0)
+			},
+			op: func(c *Cache[string, int]) error {
+				_, err := c.TTL(ctx, "42")
+				return err
+			},
+			wantErr:     cacheError.ErrNoKey,
+			wantMessage: "cache: no key in cache",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.op(tc.cache(t))
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.EqualError(t, err, tc.wantMessage)
+			var keyErr *cacheError.KeyError
+			if tc.wantKeyErr == nil {
+				assert.False(t, errors.As(err, &keyErr))
+				return
+			}
+			require.ErrorAs(t, err, &keyErr)
+			assert.Equal(t, tc.wantKeyErr, keyErr)
+		})
+	}
+}
+
+func TestWithCloner(t *testing.T) {
+	ctx := context.Background()
+	cloneSlice := func(v []int) []int { return append([]int(nil), v...) }
+	testCases := []struct {
+		name string
+		// read 返回读取到的值，修改它不应该影响缓存
+		read func(t *testing.T, c *Cache[string, []int]) []int
+	}{
+		{
+			name: "get",
+			read: func(t *testing.T, c *Cache[string, []int]) []int {
+				v, err := c.Get(ctx, "k")
+				require.NoError(t, err)
+				return v
+			},
+		},
+		{
+			name: "peek",
+			read: func(t *testing.T, c *Cache[string, []int]) []int {
+				v, err := c.Peek(ctx, "k")
+				require.NoError(t, err)
+				return v
+			},
+		},
+		{
+			name: "mget",
+			read: func(t *testing.T, c *Cache[string, []int]) []int {
+				values, err := c.MGet(ctx, "k")
+				require.NoError(t, err)
+				return values["k"]
+			},
+		},
+		{
+			name: "get or set",
+			read: func(t *testing.T, c *Cache[string, []int]) []int {
+				v, loaded, err := c.GetOrSet(ctx, "k", nil)
+				require.NoError(t, err)
+				require.True(t, loaded)
+				return v
+			},
+		},
+		{
+			name: "sample",
+			read: func(t *testing.T, c *Cache[string, []int]) []int {
+				entries := c.Sample(1)
+				require.Len(t, entries, 1)
+				return entries[0].Value
+			},
+		},
+		{
+			name: "snapshot",
+			read: func(t *testing.T, c *Cache[string, []int]) []int {
+				return c.Snapshot(ctx)["k"]
+			},
+		},
+		{
+			name: "freeze",
+			read: func(t *testing.T, c *Cache[string, []int]) []int {
+				v, err := c.Freeze(ctx).Get(ctx, "k")
+				require.NoError(t, err)
+				return v
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSimpleCache[string, []int](ctx, 0, 0, WithCloner[string, []int](cloneSlice))
+			require.NoError(t, c.Set(ctx, "k", []int{1, 2}))
+			tc.read(t, c)[0] = 42
+			v, err := c.Peek(ctx, "k")
+			require.NoError(t, err)
+			assert.Equal(t, []int{1, 2}, v)
+		})
+	}
+}
+
+func TestWithCloner_loader(t *testing.T) {
+	ctx := context.Background()
+	var clones atomic.Int32
+	c := NewSimpleCache[string, []int](ctx, 0, 0,
+		WithCloner[string, []int](func(v []int) []int {
+			clones.Add(1)
+			return append([]int(nil), v...)
+		}),
+		WithLoader[string, []int](LoaderFunc[string, []int](func(ctx context.Context, key string) ([]int, error) {
+			return []int{1}, nil
+		}), 0))
+	v, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	v[0] = 42
+	v, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, v)
+	assert.Equal(t, int32(2), clones.Load())
+}
+
+func TestWithCloner_reentrant(t *testing.T) {
+	// 复制函数在释放锁之后调用，可以再次调用缓存的方法
+	ctx := context.Background()
+	var c *Cache[string, int]
+	c = NewLruCache[string, int](ctx, 10, 0, WithCloner[string, int](func(v int) int {
+		c.Len()
+		return v
+	}))
+	require.NoError(t, c.Set(ctx, "k", 1))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.Get(ctx, "k")
+		_, _ = c.Peek(ctx, "k")
+		_, _ = c.MGet(ctx, "k")
+		_, _, _ = c.GetOrSet(ctx, "k", 2)
+		c.Sample(1)
+		c.Items(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("cloner called while holding the lock")
+	}
+}
+
+func TestWithRejectWhenFull(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache *Cache[string, int]
+	}{
+		{name: "lru", cache: NewLruCache[string, int](ctx, 2, 0, WithRejectWhenFull[string, int]())},
+		{name: "fifo", cache: NewFifoCache[string, int](ctx, 2, 0, WithRejectWhenFull[string, int]())},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache
+			require.NoError(t, c.Set(ctx, "a", 1))
+			require.NoError(t, c.Set(ctx, "b", 2))
+			assert.Equal(t, cacheError.ErrCacheFull, c.Set(ctx, "c", 3))
+			ok, err := c.SetNX(ctx, "c", 3)
+			assert.Equal(t, cacheError.ErrCacheFull, err)
+			assert.False(t, ok)
+			_, err = Incr(ctx, c, "c", 1)
+			assert.Equal(t, cacheError.ErrCacheFull, err)
+			// 覆盖已有的键总是成功
+			require.NoError(t, c.Set(ctx, "a", 10))
+			require.NoError(t, c.Delete(ctx, "b"))
+			require.NoError(t, c.Set(ctx, "c", 3))
+			assert.ElementsMatch(t, []string{"a", "c"}, c.Keys())
+			assert.Equal(t, uint64(0), c.Stats().Evictions)
+		})
+	}
+}
+
+func TestCache_safeCall(t *testing.T) {
+	cache := NewSimpleCache[int, int](context.Background(), 0, time.Minute)
+	assert.NotPanics(t, func() {
+		cache.safeCall(func() { panic("boom") })
+	})
+}
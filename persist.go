@@ -0,0 +1,160 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// snapshotVersion 为 SaveTo 写入的格式版本，格式不兼容地变化时递增。
+const snapshotVersion = 1
+
+// snapshotHeader 位于 SaveTo 输出的开头，Count 为其后元素的数量。
+type snapshotHeader struct {
+	Version int
+	Count   int
+}
+
+// snapshotRecord 是 SaveTo 输出中的一个元素。
+type snapshotRecord[K comparable, V any] struct {
+	Key   K
+	Value V
+	// Remaining 为保存时的剩余存活时间，0 表示永不过期，小于 0 表示已经过期
+	Remaining time.Duration
+	TTL       time.Duration
+	Sliding   bool
+	// Deadline 为保存时距离 WithMaxIdle 的绝对过期时间的剩余时间，0 表示没有限制
+	Deadline time.Duration
+	Meta     map[string]string
+	Cost     int64
+}
+
+// SaveTo 使用 encoding/gob 将所有未过期的元素及其剩余存活时间写入 w，配合 LoadFrom 可以在重启之后恢复缓存。
+// K 和 V 需要能够被 gob 编码，接口类型的值需要事先通过 gob.Register 注册。
+func (c *Cache[K, V]) SaveTo(w io.Writer) error {
+	records := c.records()
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, Count: len(records)}); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// records 复制所有未过期的元素，并将过期时间换算为剩余存活时间。
+func (c *Cache[K, V]) records() []snapshotRecord[K, V] {
+	items := c.items(context.Background())
+	records := make([]snapshotRecord[K, V], 0, len(items))
+	now := time.Now()
+	for _, item := range items {
+		record := snapshotRecord[K, V]{
+			Key:     item.Key,
+			Value:   item.Value,
+			TTL:     item.TTL,
+			Sliding: item.Sliding,
+			Meta:    item.Meta,
+			Cost:    item.Cost,
+		}
+		if !item.Expiration.IsZero() {
+			if record.Remaining = item.Expiration.Sub(now); record.Remaining <= 0 {
+				continue
+			}
+		}
+		if !item.Deadline.IsZero() {
+			// 不会小于 Remaining，因此大于 0
+			record.Deadline = item.Deadline.Sub(now)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// LoadFrom 读取 SaveTo 写入的元素并写入缓存，已存在的键会被覆盖。元素从加载时起按保存时的剩余存活时间过期，
+// 已经过期的元素会被跳过。加载的元素只写入缓存，不会同步到 WithStore 设置的 store。
+func (c *Cache[K, V]) LoadFrom(r io.Reader) error {
+	var records []snapshotRecord[K, V]
+	err := decodeSnapshot(r, func(record snapshotRecord[K, V]) {
+		records = append(records, record)
+	})
+	if err != nil {
+		return err
+	}
+	return c.restore(context.Background(), records, time.Now())
+}
+
+// decodeSnapshot 读取 SaveTo 写入的快照，对每个元素调用 fn。头部的元素数量来自输入，不可信，
+// 只用于确定读取的次数，不能据此预先分配内存。
+// R 为 snapshotRecord 或者省略了部分字段的结构体，gob 会忽略 R 中不存在的字段。
+func decodeSnapshot[R any](r io.Reader, fn func(record R)) error {
+	dec := gob.NewDecoder(r)
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("cache: read snapshot header: %w", err)
+	}
+	if header.Version != snapshotVersion {
+		return fmt.Errorf("cache: unsupported snapshot version %d", header.Version)
+	}
+	if header.Count < 0 {
+		return fmt.Errorf("cache: invalid snapshot entry count %d", header.Count)
+	}
+	for i := 0; i < header.Count; i++ {
+		var record R
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("cache: read snapshot entry %d: %w", i, err)
+		}
+		fn(record)
+	}
+	return nil
+}
+
+// restore 在一次加锁内写入 records，元素在 now 加上剩余存活时间之后过期，跳过已经过期的元素。
+func (c *Cache[K, V]) restore(ctx context.Context, records []snapshotRecord[K, V], now time.Time) error {
+	c.mutex.Lock()
+	defer c.unlock()
+	if err := c.checkClosed(); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if r.Remaining < 0 {
+			continue
+		}
+		item := &Item[V]{value: r.Value, ttl: r.TTL, sliding: r.Sliding, meta: r.Meta, created: now, cost: r.Cost}
+		if r.Remaining > 0 {
+			item.expiration = now.Add(r.Remaining)
+		}
+		if r.Deadline > 0 {
+			item.deadline = now.Add(r.Deadline)
+		}
+		if err := c.cache.Set(ctx, r.Key, item); err != nil {
+			return err
+		}
+		c.track(r.Key, item)
+		c.stats.sets.Add(1)
+		c.invalidate(r.Key)
+	}
+	return nil
+}
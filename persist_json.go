@@ -0,0 +1,105 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// jsonDump 是 DumpJSON 输出的格式，便于人工查看和编辑。
+type jsonDump[K comparable, V any] struct {
+	Version int               `json:"version"`
+	Entries []jsonEntry[K, V] `json:"entries"`
+}
+
+type jsonEntry[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+	// Expiration 为绝对过期时间（RFC 3339），省略时永不过期
+	Expiration *time.Time `json:"expiration,omitempty"`
+	// TTL 为 time.Duration 的字符串形式，如 "1m30s"，Touch 和滑动过期据此推迟过期时间
+	TTL     string `json:"ttl,omitempty"`
+	Sliding bool   `json:"sliding,omitempty"`
+	// Deadline 为 WithMaxIdle 的元素的绝对过期时间（RFC 3339），滑动过期不会超过它，省略时没有限制
+	Deadline *time.Time        `json:"deadline,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Cost     int64             `json:"cost,omitempty"`
+}
+
+// DumpJSON 将所有未过期的元素以带缩进的 JSON 写入 w，每个元素包含键、值和绝对过期时间，
+// 便于在排查问题时查看和手工修改，修改后可以通过 RestoreJSON 恢复。K 和 V 需要能够被 encoding/json 编解码。
+func (c *Cache[K, V]) DumpJSON(w io.Writer) error {
+	items := c.items(context.Background())
+	dump := jsonDump[K, V]{Version: snapshotVersion, Entries: make([]jsonEntry[K, V], 0, len(items))}
+	for _, item := range items {
+		e := jsonEntry[K, V]{Key: item.Key, Value: item.Value, Sliding: item.Sliding, Meta: item.Meta, Cost: item.Cost}
+		if !item.Expiration.IsZero() {
+			expiration := item.Expiration.UTC()
+			e.Expiration = &expiration
+		}
+		if item.TTL != 0 {
+			e.TTL = item.TTL.String()
+		}
+		if !item.Deadline.IsZero() {
+			deadline := item.Deadline.UTC()
+			e.Deadline = &deadline
+		}
+		dump.Entries = append(dump.Entries, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dump)
+}
+
+// RestoreJSON 读取 DumpJSON 写入的元素并写入缓存，已存在的键会被覆盖，过期时间已过的元素会被跳过。
+// 与 LoadFrom 不同，过期时间是绝对时间，dump 之后经过的时间同样计入元素的存活时间。
+// 加载的元素只写入缓存，不会同步到 WithStore 设置的 store。
+func (c *Cache[K, V]) RestoreJSON(r io.Reader) error {
+	var dump jsonDump[K, V]
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return fmt.Errorf("cache: read json dump: %w", err)
+	}
+	if dump.Version != snapshotVersion {
+		return fmt.Errorf("cache: unsupported snapshot version %d", dump.Version)
+	}
+	records := make([]snapshotRecord[K, V], 0, len(dump.Entries))
+	now := time.Now()
+	for i, e := range dump.Entries {
+		record := snapshotRecord[K, V]{Key: e.Key, Value: e.Value, Sliding: e.Sliding, Meta: e.Meta, Cost: e.Cost}
+		if e.TTL != "" {
+			ttl, err := time.ParseDuration(e.TTL)
+			if err != nil {
+				return fmt.Errorf("cache: json dump entry %d: %w", i, err)
+			}
+			record.TTL = ttl
+		}
+		if e.Expiration != nil {
+			if record.Remaining = e.Expiration.Sub(now); record.Remaining <= 0 {
+				continue
+			}
+		}
+		if e.Deadline != nil {
+			if record.Deadline = e.Deadline.Sub(now); record.Deadline <= 0 {
+				continue
+			}
+		}
+		records = append(records, record)
+	}
+	return c.restore(context.Background(), records, now)
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_DumpJSON(t *testing.T) {
+	ctx := context.Background()
+	c := NewFifoCache[string, int](ctx, 10, 0)
+	require.NoError(t, c.Set(ctx, "a", 1, WithMeta(map[string]string{"source": "db"})))
+	require.NoError(t, c.Set(ctx, "b", 2))
+	require.NoError(t, c.Set(ctx, "expired", 3, WithExpiration(-time.Second)))
+
+	var buf bytes.Buffer
+	require.NoError(t, c.DumpJSON(&buf))
+	assert.Equal(t, `{
+  "version": 1,
+  "entries": [
+    {
+      "key": "a",
+      "value": 1,
+      "meta": {
+        "source": "db"
+      }
+    },
+    {
+      "key": "b",
+      "value": 2
+    }
+  ]
+}
+`, buf.String())
+}
+
+func TestCache_DumpJSON_RestoreJSON(t *testing.T) {
+	ctx := context.Background()
+	src := NewFifoCache[string, []string](ctx, 10, 0)
+	require.NoError(t, src.Set(ctx, "a", []string{"x", "y"}))
+	require.NoError(t, src.Set(ctx, "b", nil, WithExpiration(time.Hour)))
+	require.NoError(t, src.Set(ctx, "c", []string{"z"}, WithSlidingExpiration(time.Minute), WithMeta(map[string]string{"k": "v"})))
+
+	var buf bytes.Buffer
+	require.NoError(t, src.DumpJSON(&buf))
+	dst := NewFifoCache[string, []string](ctx, 10, 0)
+	require.NoError(t, dst.RestoreJSON(&buf))
+
+	want := src.Items(ctx)
+	got := dst.Items(ctx)
+	require.Len(t, got, len(want))
+	for i := range want {
+		// 过期时间是绝对时间，恢复前后一致
+		assert.True(t, want[i].Expiration.Round(0).Equal(got[i].Expiration.Round(0)))
+		want[i].Expiration, got[i].Expiration = time.Time{}, time.Time{}
+		// 恢复的元素的写入时间为恢复的时间
+		assert.False(t, got[i].Created.Before(want[i].Created))
+		want[i].Created, got[i].Created = time.Time{}, time.Time{}
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestCache_RestoreJSON(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+
+		want    map[string]int
+		wantErr string
+	}{
+		{
+			name: "hand edited",
+			input: `{"version": 1, "entries": [
+				{"key": "live", "value": 1, "expiration": "2999-01-01T00:00:00Z", "ttl": "1h"},
+				{"key": "forever", "value": 2},
+				{"key": "expired", "value": 3, "expiration": "2000-01-01T00:00:00Z"}
+			]}`,
+			want: map[string]int{"live": 1, "forever": 2},
+		},
+		{
+			name:    "invalid ttl",
+			input:   `{"version": 1, "entries": [{"key": "a", "value": 1, "ttl": "soon"}]}`,
+			wantErr: `cache: json dump entry 0: time: invalid duration "soon"`,
+		},
+		{
+			name:    "unsupported version",
+			input:   `{"version": 2, "entries": []}`,
+			wantErr: "cache: unsupported snapshot version 2",
+		},
+		{
+			name:    "invalid json",
+			input:   `{"version": 1, "entries": [{"key": 1}]}`,
+			wantErr: "cache: read json dump: json: cannot unmarshal number",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewSimpleCache[string, int](ctx, 0, 0)
+			err := c.RestoreJSON(strings.NewReader(tc.input))
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, c.Snapshot(ctx))
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_SaveTo_LoadFrom(t *testing.T) {
+	ctx := context.Background()
+	src := NewFifoCache[int, int](ctx, 10, 0)
+	require.NoError(t, src.Set(ctx, 1, 1, WithMeta(map[string]string{"source": "db"})))
+	require.NoError(t, src.Set(ctx, 2, 2, WithExpiration(time.Hour)))
+	require.NoError(t, src.Set(ctx, 3, 3, WithSlidingExpiration(time.Minute)))
+	require.NoError(t, src.Set(ctx, 4, 4, WithExpiration(-time.Second)))
+
+	var buf bytes.Buffer
+	require.NoError(t, src.SaveTo(&buf))
+
+	s := newMemStore()
+	dst := NewFifoCache[int, int](ctx, 10, 0, WithStore[int, int](s, WriteThrough))
+	require.NoError(t, dst.Set(ctx, 1, 100))
+	require.NoError(t, dst.LoadFrom(&buf))
+
+	want := src.Items(ctx)
+	got := dst.Items(ctx)
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.WithinDuration(t, want[i].Expiration, got[i].Expiration, time.Second)
+		want[i].Expiration, got[i].Expiration = time.Time{}, time.Time{}
+		// 恢复的元素的写入时间为恢复的时间
+		assert.False(t, got[i].Created.Before(want[i].Created))
+		want[i].Created, got[i].Created = time.Time{}, time.Time{}
+	}
+	assert.Equal(t, want, got)
+	// 加载的元素不会写入 store
+	data, _ := s.snapshot()
+	assert.Equal(t, map[int]int{1: 100}, data)
+}
+
+func TestCache_LoadFrom(t *testing.T) {
+	encode := func(values ...any) []byte {
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		for _, v := range values {
+			require.NoError(t, enc.Encode(v))
+		}
+		return buf.Bytes()
+	}
+	testCases := []struct {
+		name  string
+		input []byte
+		close bool
+
+		want    map[string]int
+		wantErr error
+	}{
+		{
+			name: "skip expired",
+			input: encode(snapshotHeader{Version: snapshotVersion, Count: 2},
+				snapshotRecord[string, int]{Key: "live", Value: 1, Remaining: time.Hour},
+				snapshotRecord[string, int]{Key: "expired", Value: 2, Remaining: -time.Second}),
+			want: map[string]int{"live": 1},
+		},
+		{
+			name:    "empty input",
+			wantErr: io.EOF,
+		},
+		{
+			name:    "unsupported version",
+			input:   encode(snapshotHeader{Version: snapshotVersion + 1}),
+			wantErr: errors.New("cache: unsupported snapshot version 2"),
+		},
+		{
+			name:    "negative count",
+			input:   encode(snapshotHeader{Version: snapshotVersion, Count: -1}),
+			wantErr: errors.New("cache: invalid snapshot entry count -1"),
+		},
+		{
+			// 元素数量不会被用于预先分配内存
+			name:    "huge count",
+			input:   encode(snapshotHeader{Version: snapshotVersion, Count: math.MaxInt}),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "truncated",
+			input:   encode(snapshotHeader{Version: snapshotVersion, Count: 1}),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "closed",
+			input:   encode(snapshotHeader{Version: snapshotVersion}),
+			close:   true,
+			wantErr: cacheError.ErrClosed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewSimpleCache[string, int](ctx, 0, 0)
+			if tc.close {
+				require.NoError(t, c.Close())
+			}
+			err := c.LoadFrom(bytes.NewReader(tc.input))
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					assert.EqualError(t, err, tc.wantErr.Error())
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, c.Snapshot(ctx))
+		})
+	}
+}
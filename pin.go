@@ -0,0 +1,75 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// WithPinned 在写入之后固定元素，被固定的元素不会因容量不足、WithMaxCost 或 WithMaxBytes 被淘汰，
+// 只会被 Delete 删除或者过期，适合不能为临时数据让出空间的配置等数据。覆盖写入不会取消固定，需要调用 Unpin。
+// 缓存已满且所有元素都被固定时，写入新的键返回 cacheError.ErrCacheFull。
+// 后端没有实现 types.Pinner 时忽略，内置的 LRU 和 FIFO 后端都实现了 types.Pinner。
+func WithPinned() ItemOption {
+	return func(o *itemOptions) {
+		o.pinned = true
+	}
+}
+
+// Pin 固定已经存在的 key，效果与写入时使用 WithPinned 相同。
+// key 不存在或已过期时返回 cacheError.ErrNoKey，后端没有实现 types.Pinner 时返回 cacheError.ErrPinUnsupported。
+func (c *Cache[K, V]) Pin(ctx context.Context, key K) error {
+	return c.setPinned(ctx, key, true)
+}
+
+// Unpin 取消 key 的固定，之后 key 可以再次被淘汰，错误与 Pin 相同。
+func (c *Cache[K, V]) Unpin(ctx context.Context, key K) error {
+	return c.setPinned(ctx, key, false)
+}
+
+func (c *Cache[K, V]) setPinned(ctx context.Context, key K, pinned bool) (err error) {
+	op := "unpin"
+	if pinned {
+		op = "pin"
+	}
+	defer c.wrapKeyError(op, key, &err)
+	if c.pinner == nil {
+		return cacheError.ErrPinUnsupported
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	item, err := c.peek(ctx, key)
+	if err != nil {
+		return err
+	}
+	if item.Expired() {
+		return cacheError.ErrNoKey
+	}
+	if pinned {
+		c.pinner.Pin(key)
+	} else {
+		c.pinner.Unpin(key)
+	}
+	return nil
+}
+
+// pin 固定刚写入的 key，调用方需要持有写锁。
+func (c *Cache[K, V]) pin(key K) {
+	if c.pinner != nil {
+		c.pinner.Pin(key)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPinned(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name     string
+		newCache func() *Cache[string, int]
+		ops      func(t *testing.T, c *Cache[string, int])
+
+		wantKeys []string
+	}{
+		{
+			name:     "lru capacity",
+			newCache: func() *Cache[string, int] { return NewLruCache[string, int](ctx, 2, 0) },
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "config", 1, WithPinned()))
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 1))
+				require.NoError(t, c.Set(ctx, "c", 1))
+			},
+			wantKeys: []string{"config", "c"},
+		},
+		{
+			name:     "fifo capacity",
+			newCache: func() *Cache[string, int] { return NewFifoCache[string, int](ctx, 2, 0) },
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "config", 1, WithPinned()))
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 1))
+			},
+			wantKeys: []string{"config", "b"},
+		},
+		{
+			name: "max cost",
+			newCache: func() *Cache[string, int] {
+				return NewLruCache[string, int](ctx, 100, 0, WithMaxCost[string, int](10))
+			},
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "config", 1, WithCost(6), WithPinned()))
+				require.NoError(t, c.Set(ctx, "a", 1, WithCost(4)))
+				require.NoError(t, c.Set(ctx, "b", 1, WithCost(4)))
+			},
+			wantKeys: []string{"config", "b"},
+		},
+		{
+			name:     "pin existing key",
+			newCache: func() *Cache[string, int] { return NewLruCache[string, int](ctx, 2, 0) },
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "config", 1))
+				require.NoError(t, c.Pin(ctx, "config"))
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 1))
+			},
+			wantKeys: []string{"config", "b"},
+		},
+		{
+			name:     "unpin",
+			newCache: func() *Cache[string, int] { return NewLruCache[string, int](ctx, 2, 0) },
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "config", 1, WithPinned()))
+				require.NoError(t, c.Unpin(ctx, "config"))
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 1))
+			},
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name:     "delete and expire",
+			newCache: func() *Cache[string, int] { return NewLruCache[string, int](ctx, 2, 0) },
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "config", 1, WithPinned()))
+				require.NoError(t, c.Set(ctx, "tmp", 1, WithPinned(), WithExpiration(-time.Second)))
+				require.NoError(t, c.Delete(ctx, "config"))
+				c.DeleteExpired(ctx)
+				require.NoError(t, c.Set(ctx, "a", 1))
+			},
+			wantKeys: []string{"a"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.newCache()
+			tc.ops(t, c)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+		})
+	}
+}
+
+func TestCache_Pin_errors(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 2, 0)
+	assert.Equal(t, cacheError.ErrNoKey, c.Pin(ctx, "missing"))
+	require.NoError(t, c.Set(ctx, "expired", 1, WithExpiration(-time.Second)))
+	assert.Equal(t, cacheError.ErrNoKey, c.Unpin(ctx, "expired"))
+
+	s := NewSimpleCache[string, int](ctx, 2, 0)
+	require.NoError(t, s.Set(ctx, "a", 1, WithPinned()))
+	assert.Equal(t, cacheError.ErrPinUnsupported, s.Pin(ctx, "a"))
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, cacheError.ErrClosed, c.Pin(ctx, "a"))
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package random
+
+import (
+	"context"
+	"math/rand"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/internal/sample"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Option 配置 Cache 的行为。
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithOnEvicted 设置元素因容量不足被淘汰时的回调，显式调用 Delete 删除的元素不会触发回调。
+func WithOnEvicted[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.onEvicted = fn
+	}
+}
+
+// WithRandSource 设置选择淘汰元素时使用的随机源，相同的随机源和相同的操作序列会得到相同的淘汰结果，
+// 便于测试和模拟复现。未设置时使用 math/rand 的全局随机源。
+// 作为 cache.New 的后端时，cache.WithRandSource 设置的随机源会覆盖该选项，见 SetRandIntn。
+func WithRandSource[K comparable, V any](src rand.Source) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.randIntn = rand.New(src).Intn
+	}
+}
+
+// SetRandIntn 实现了 types.RandSetter，替换选择淘汰元素和抽样时使用的随机函数。
+func (c *Cache[K, V]) SetRandIntn(fn func(n int) int) {
+	c.randIntn = fn
+}
+
+// SetOnEvicted 替换淘汰回调，实现了 types.EvictionNotifier。
+func (c *Cache[K, V]) SetOnEvicted(fn func(key K, value V)) {
+	c.onEvicted = fn
+}
+
+// NewCache 创建一个随机淘汰缓存，容量满时随机淘汰一个元素。
+func NewCache[K comparable, V any](cap int, opts ...Option[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{
+		maxEntries: cap,
+		cache:      make(map[K]int, cap),
+		entries:    make([]entry[K, V], 0, cap),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type Cache[K comparable, V any] struct {
+	maxEntries int
+	// 键到 entries 下标的映射
+	cache     map[K]int
+	entries   []entry[K, V]
+	onEvicted func(key K, value V)
+	randIntn  func(n int) int
+	evictions uint64
+}
+
+func (c *Cache[K, V]) Set(_ context.Context, key K, value V) error {
+	if i, ok := c.cache[key]; ok {
+		// 元素存在
+		c.entries[i].value = value
+		return nil
+	}
+	// 元素不存在
+	c.cache[key] = len(c.entries)
+	c.entries = append(c.entries, entry[K, V]{
+		key:   key,
+		value: value,
+	})
+	if len(c.entries) > c.maxEntries {
+		c.EvictOne()
+	}
+	return nil
+}
+
+// EvictOne 实现了 types.Evicter，随机淘汰一个元素。
+func (c *Cache[K, V]) EvictOne() (key K, ok bool) {
+	if len(c.entries) == 0 {
+		return key, false
+	}
+	i := c.intn(len(c.entries))
+	victim := c.entries[i]
+	c.remove(i)
+	c.evictions++
+	if c.onEvicted != nil {
+		c.onEvicted(victim.key, victim.value)
+	}
+	return victim.key, true
+}
+
+func (c *Cache[K, V]) Get(_ context.Context, key K) (v V, err error) {
+	if i, ok := c.cache[key]; ok {
+		return c.entries[i].value, nil
+	}
+	return v, cacheError.ErrNoKey
+}
+
+func (c *Cache[K, V]) Delete(_ context.Context, key K) error {
+	if i, ok := c.cache[key]; ok {
+		c.remove(i)
+		return nil
+	}
+	return cacheError.ErrNoKey
+}
+
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.entries))
+	for _, e := range c.entries {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
+
+// RangeKeys 实现了 types.Ranger，按与 Keys 相同的顺序遍历键。
+func (c *Cache[K, V]) RangeKeys(fn func(key K) bool) {
+	for _, e := range c.entries {
+		if !fn(e.key) {
+			return
+		}
+	}
+}
+
+// Len 返回元素的数量。
+func (c *Cache[K, V]) Len() int {
+	return len(c.entries)
+}
+
+// Contains 实现了 types.Container，报告 key 是否存在。
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.cache[key]
+	return ok
+}
+
+// Clear 实现了 types.Clearer，删除所有元素，不会触发淘汰回调。
+func (c *Cache[K, V]) Clear() {
+	clear(c.cache)
+	clear(c.entries)
+	c.entries = c.entries[:0]
+}
+
+// SampleKeys 实现了 types.Sampler，不放回地均匀抽取最多 n 个键，耗时与 n 成正比。
+func (c *Cache[K, V]) SampleKeys(n int) []K {
+	indexes := sample.Indexes(n, len(c.entries), c.intn)
+	keys := make([]K, 0, len(indexes))
+	for _, i := range indexes {
+		keys = append(keys, c.entries[i].key)
+	}
+	return keys
+}
+
+func (c *Cache[K, V]) intn(n int) int {
+	if c.randIntn != nil {
+		return c.randIntn(n)
+	}
+	return rand.Intn(n)
+}
+
+// remove 删除下标为 i 的元素：用最后一个元素填补空位，保证删除为 O(1)。
+func (c *Cache[K, V]) remove(i int) {
+	last := len(c.entries) - 1
+	delete(c.cache, c.entries[i].key)
+	if i != last {
+		c.entries[i] = c.entries[last]
+		c.cache[c.entries[i].key] = i
+	}
+	c.entries[last] = entry[K, V]{}
+	c.entries = c.entries[:last]
+}
+
+// ReadOnlyGet 实现了 types.ReadOnlyGetter，Get 不会修改内部状态。
+func (c *Cache[K, V]) ReadOnlyGet() bool {
+	return true
+}
+
+// Stats 实现了 types.Inspector。
+func (c *Cache[K, V]) Stats() types.BackendStats {
+	return types.BackendStats{Len: len(c.entries), Evictions: c.evictions}
+}
+
+// OldestEntry 实现了 types.Inspector。随机淘汰不区分元素的新旧，总是返回 false。
+func (c *Cache[K, V]) OldestEntry() (key K, value V, ok bool) {
+	return key, value, false
+}
+
+// Config 实现了 types.Inspector。
+func (c *Cache[K, V]) Config() types.BackendConfig {
+	return types.BackendConfig{Policy: "random", Capacity: c.maxEntries}
+}
@@ -0,0 +1,241 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package random
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCache(t *testing.T) {
+	cache := NewCache[string, int](10)
+	assert.Equal(t, 10, cache.maxEntries)
+	assert.NotNil(t, cache.cache)
+}
+
+func TestCache_Set(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) *Cache[string, int]
+		key   string
+		value int
+
+		wantLen   int
+		wantError error
+	}{
+		{
+			name: "set a new key",
+			cache: func(_ *testing.T) *Cache[string, int] {
+				return NewCache[string, int](1)
+			},
+			key:     "1",
+			value:   1,
+			wantLen: 1,
+		},
+		{
+			name: "set a existing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](1)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				return cache
+			},
+			key:     "1",
+			value:   2,
+			wantLen: 1,
+		},
+		{
+			name: "set a new key with a full cache",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](3)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				assert.NoError(t, cache.Set(context.Background(), "2", 2))
+				assert.NoError(t, cache.Set(context.Background(), "3", 3))
+				return cache
+			},
+			key:     "4",
+			value:   4,
+			wantLen: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache(t)
+			err := cache.Set(context.Background(), tc.key, tc.value)
+			assert.Equal(t, tc.wantError, err)
+			assert.Len(t, cache.Keys(), tc.wantLen)
+			assert.Len(t, cache.cache, tc.wantLen)
+			// 所有剩余的键都必须能通过下标找到自己
+			for key, i := range cache.cache {
+				assert.Equal(t, key, cache.entries[i].key)
+			}
+		})
+	}
+}
+
+func TestCache_Get(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cache     func(t *testing.T) *Cache[string, int]
+		key       string
+		wantValue int
+		wantError error
+	}{
+		{
+			name: "get a existing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](1)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				return cache
+			},
+			key:       "1",
+			wantValue: 1,
+		},
+		{
+			name: "get a non-existing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](1)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				return cache
+			},
+			key:       "2",
+			wantError: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache(t)
+			got, err := cache.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantValue, got)
+		})
+	}
+}
+
+func TestCache_Delete(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cache     func(t *testing.T) *Cache[string, int]
+		key       string
+		wantKeys  []string
+		wantError error
+	}{
+		{
+			name: "delete a existing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](3)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				assert.NoError(t, cache.Set(context.Background(), "2", 2))
+				assert.NoError(t, cache.Set(context.Background(), "3", 3))
+				return cache
+			},
+			key:      "1",
+			wantKeys: []string{"3", "2"},
+		},
+		{
+			name: "delete a non-existing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](1)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				return cache
+			},
+			key:       "2",
+			wantKeys:  []string{"1"},
+			wantError: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache(t)
+			err := cache.Delete(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantKeys, cache.Keys())
+		})
+	}
+}
+
+func TestWithOnEvicted(t *testing.T) {
+	var evicted []string
+	cache := NewCache[string, int](1, WithOnEvicted(func(key string, value int) {
+		evicted = append(evicted, key)
+		assert.Equal(t, key, strconv.Itoa(value))
+	}))
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, cache.Set(context.Background(), strconv.Itoa(i), i))
+	}
+	// 容量为 1，每次写入新键都会随机淘汰一个键，显式删除不会触发回调
+	assert.Len(t, evicted, 2)
+	assert.NoError(t, cache.Delete(context.Background(), cache.Keys()[0]))
+	assert.Len(t, evicted, 2)
+}
+
+func TestWithRandSource(t *testing.T) {
+	run := func(seed int64) []string {
+		var evicted []string
+		cache := NewCache[string, int](3,
+			WithRandSource[string, int](rand.NewSource(seed)),
+			WithOnEvicted(func(key string, _ int) { evicted = append(evicted, key) }),
+		)
+		for i := 0; i < 20; i++ {
+			assert.NoError(t, cache.Set(context.Background(), strconv.Itoa(i), i))
+		}
+		return evicted
+	}
+	// 相同的随机源得到完全相同的淘汰序列
+	assert.Equal(t, run(1), run(1))
+	assert.Len(t, run(1), 17)
+}
+
+func TestCache_SampleKeys(t *testing.T) {
+	cache := NewCache[string, int](10, WithRandSource[string, int](rand.NewSource(1)))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, cache.Set(context.Background(), strconv.Itoa(i), i))
+	}
+	testCases := []struct {
+		name    string
+		n       int
+		wantLen int
+	}{
+		{name: "zero", n: 0, wantLen: 0},
+		{name: "partial", n: 3, wantLen: 3},
+		{name: "more than size", n: 20, wantLen: 10},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys := cache.SampleKeys(tc.n)
+			assert.Len(t, keys, tc.wantLen)
+			seen := make(map[string]bool)
+			for _, key := range keys {
+				assert.False(t, seen[key])
+				seen[key] = true
+				_, err := cache.Get(context.Background(), key)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return NewCache[string, string](cachetest.Capacity)
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit 限制缓存对下游的压力：LimitLoader 限制同时执行的加载数量，
+// Cache 按令牌桶限制写入的速率。适用于部署之后缓存为空、大量请求同时穿透到数据源或远程缓存的场景。
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+var _ types.ICache[string, int] = (*Cache[string, int])(nil)
+
+// LimitLoader 返回最多同时执行 n 次 loader 的加载器，超出的加载等待空闲的名额，等待期间 ctx 结束时返回 ctx.Err()。
+// cache.Cache 已经合并了同一个键的并发加载，LimitLoader 限制的是不同的键。n <= 0 时不限制，直接返回 loader。
+func LimitLoader[K comparable, V any](loader cache.Loader[K, V], n int) cache.Loader[K, V] {
+	if n <= 0 {
+		return loader
+	}
+	sem := make(chan struct{}, n)
+	return cache.LoaderFunc[K, V](func(ctx context.Context, key K) (v V, err error) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return v, ctx.Err()
+		}
+		defer func() { <-sem }()
+		return loader.Load(ctx, key)
+	})
+}
+
+// Option 配置 Cache 的行为。
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithSetRate 使用令牌桶限制 Set 的速率：平均每秒最多 rate 次，最多允许 burst 次突发。
+// 超出速率的 Set 等待令牌，等待期间 ctx 结束时返回 ctx.Err() 且不会写入。rate <= 0 时不限制，burst 小于 1 时视为 1。
+func WithSetRate[K comparable, V any](rate float64, burst int) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.setLimiter = nil
+		if rate > 0 {
+			c.setLimiter = newBucket(rate, max(burst, 1))
+		}
+	}
+}
+
+// Cache 包装任意的 types.ICache 并限制写入的速率，自身实现了 types.ICache。Get、Delete 和 Keys 不受限制。
+// 与 metrics.Cache 相同，只转发 types.ICache 的方法，需要时可以通过 Unwrap 获取被包装的缓存。
+type Cache[K comparable, V any] struct {
+	cache      types.ICache[K, V]
+	setLimiter *bucket
+}
+
+// New 返回限制 c 的写入速率的装饰器，未设置任何选项时不做限制。
+func New[K comparable, V any](c types.ICache[K, V], opts ...Option[K, V]) *Cache[K, V] {
+	rc := &Cache[K, V]{cache: c}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	return rc
+}
+
+// Unwrap 返回被包装的缓存。
+func (c *Cache[K, V]) Unwrap() types.ICache[K, V] {
+	return c.cache
+}
+
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return c.cache.Get(ctx, key)
+}
+
+// Set 在取得令牌之后写入 key，见 WithSetRate。
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) error {
+	if c.setLimiter != nil {
+		if err := c.setLimiter.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return c.cache.Set(ctx, key, value)
+}
+
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	return c.cache.Delete(ctx, key)
+}
+
+func (c *Cache[K, V]) Keys() []K {
+	return c.cache.Keys()
+}
+
+// bucket 是令牌桶，令牌按 rate 个每秒的速度补充，最多积累 burst 个。
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), now: time.Now}
+}
+
+// wait 取走一个令牌，令牌不足时等待补充，ctx 先结束时归还令牌并返回 ctx.Err()。
+func (b *bucket) wait(ctx context.Context) error {
+	d := b.reserve()
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve 预先取走一个令牌并返回需要等待的时间，令牌数可以为负，表示已经被预订的未来的令牌。
+func (b *bucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还 reserve 取走的令牌。
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitLoader(t *testing.T) {
+	const limit = 3
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	loader := LimitLoader[string, int](cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		return strconv.Atoi(key)
+	}), limit)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := loader.Load(context.Background(), strconv.Itoa(i))
+			assert.NoError(t, err)
+			assert.Equal(t, i, v)
+		}(i)
+	}
+	require.Eventually(t, func() bool { return running.Load() == limit }, time.Second, time.Millisecond)
+
+	// 名额已满时等待的加载随 ctx 结束
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := loader.Load(ctx, "42")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(limit), peak.Load())
+}
+
+func TestLimitLoader_unlimited(t *testing.T) {
+	loader := cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		return 1, nil
+	})
+	v, err := LimitLoader[string, int](loader, 0).Load(context.Background(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestBucket_reserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBucket(10, 2)
+	b.now = func() time.Time { return now }
+
+	// 初始有 burst 个令牌
+	assert.Zero(t, b.reserve())
+	assert.Zero(t, b.reserve())
+	// 之后每个令牌需要等待 100ms，已经预订的令牌会累加
+	assert.Equal(t, 100*time.Millisecond, b.reserve())
+	assert.Equal(t, 200*time.Millisecond, b.reserve())
+	b.cancel()
+	b.cancel()
+
+	// 补充的令牌不会超过 burst
+	now = now.Add(time.Hour)
+	assert.Zero(t, b.reserve())
+	assert.Zero(t, b.reserve())
+	assert.Equal(t, 100*time.Millisecond, b.reserve())
+
+	now = now.Add(50 * time.Millisecond)
+	assert.Equal(t, 150*time.Millisecond, b.reserve())
+}
+
+func TestCache_Set(t *testing.T) {
+	ctx := context.Background()
+	backend := simple.NewCache[string, int](0)
+	c := New[string, int](backend, WithSetRate[string, int](1, 1))
+	assert.Equal(t, backend, c.Unwrap())
+
+	require.NoError(t, c.Set(ctx, "a", 1))
+	// 令牌已经用完，下一个令牌需要等待 1s
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.Set(timeout, "b", 2), context.DeadlineExceeded)
+	assert.Equal(t, []string{"a"}, c.Keys())
+
+	// 读取和删除不受限制
+	v, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+	assert.NoError(t, c.Delete(ctx, "a"))
+}
+
+func TestCache_Set_wait(t *testing.T) {
+	ctx := context.Background()
+	c := New[string, int](simple.NewCache[string, int](0), WithSetRate[string, int](100, 1))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.Set(ctx, strconv.Itoa(i), i))
+	}
+	// 第一次写入使用初始的令牌，之后两次各等待约 10ms
+	assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+	assert.Len(t, c.Keys(), 3)
+}
+
+func TestCache_Conformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return New[string, string](simple.NewCache[string, string](0), WithSetRate[string, string](1e6, 100))
+	})
+}
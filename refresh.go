@@ -0,0 +1,100 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRefreshAhead 启用提前刷新：Get 命中的元素剩余寿命低于其 TTL 的 ratio 时（例如 0.2 表示剩余不足 20%），
+// 由后台 workers 个协程通过 WithLoader 设置的加载器重新加载，避免热点键过期时的延迟尖刺。
+//
+// 刷新请求排队数量有上限，队列已满时会被丢弃，元素在下次命中时会再次触发刷新；同一个键同时只会有一个刷新。
+// 刷新期间键被 Set 或 Delete 时刷新结果会被丢弃，与 GetOrLoad 的一致性保证相同。
+// 没有过期时间的元素不会被刷新。
+func WithRefreshAhead[K comparable, V any](ratio float64, workers int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.refreshRatio = ratio
+		o.refreshWorkers = workers
+	}
+}
+
+// refreshQueueFactor 决定刷新队列的长度：每个 worker 对应的排队数量。
+const refreshQueueFactor = 64
+
+type refresher[K comparable] struct {
+	workers int
+	queue   chan K
+
+	mu     sync.Mutex
+	queued map[K]struct{}
+}
+
+func newRefresher[K comparable](workers int) *refresher[K] {
+	return &refresher[K]{
+		workers: workers,
+		queue:   make(chan K, workers*refreshQueueFactor),
+		queued:  make(map[K]struct{}),
+	}
+}
+
+// trigger 将 key 加入刷新队列，已经在队列中或队列已满时直接返回。
+func (r *refresher[K]) trigger(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.queued[key]; ok {
+		return
+	}
+	select {
+	case r.queue <- key:
+		r.queued[key] = struct{}{}
+	default:
+	}
+}
+
+func (r *refresher[K]) done(key K) {
+	r.mu.Lock()
+	delete(r.queued, key)
+	r.mu.Unlock()
+}
+
+// run 启动 workers 个刷新协程，ctx 结束时退出。
+func (r *refresher[K]) run(ctx context.Context, refresh func(ctx context.Context, key K)) {
+	for i := 0; i < r.workers; i++ {
+		go func() {
+			for {
+				select {
+				case key := <-r.queue:
+					refresh(ctx, key)
+					r.done(key)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// refreshDue 判断元素是否需要提前刷新，调用方必须持有读锁。
+func (c *Cache[K, V]) refreshDue(item *Item[V]) bool {
+	return item.ttl > 0 && time.Until(item.expiration) < time.Duration(float64(item.ttl)*c.opts.refreshRatio)
+}
+
+// refresh 通过加载器重新加载 key。
+func (c *Cache[K, V]) refresh(ctx context.Context, key K) {
+	c.reload(ctx, key, c.opts.loader.Load, c.opts.loaderItemOpts...)
+}
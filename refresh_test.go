@@ -0,0 +1,97 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRefreshAhead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var version atomic.Int32
+	loader := LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		return int(version.Add(1)), nil
+	})
+	c := NewSimpleCache[string, int](ctx, 10, 0,
+		WithLoader[string, int](loader, 200*time.Millisecond),
+		WithRefreshAhead[string, int](0.5, 1),
+	)
+
+	v, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	// 剩余寿命充足时不刷新
+	v, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, int32(1), version.Load())
+
+	// 剩余寿命不足一半时返回旧值并在后台刷新
+	time.Sleep(120 * time.Millisecond)
+	v, err = c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	require.Eventually(t, func() bool {
+		v, err := c.Get(ctx, "k")
+		return err == nil && v == 2
+	}, time.Second, time.Millisecond)
+
+	// 刷新后的元素获得新的 TTL
+	info, err := c.EntryInfo(ctx, "k")
+	require.NoError(t, err)
+	assert.Greater(t, time.Until(info.Expiration), 150*time.Millisecond)
+}
+
+func TestWithRefreshAhead_noExpiration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int32
+	loader := LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		return 0, nil
+	})
+	c := NewSimpleCache[string, int](ctx, 10, 0,
+		WithLoader[string, int](loader, time.Minute),
+		WithRefreshAhead[string, int](0.5, 1),
+	)
+	require.NoError(t, c.Set(ctx, "k", 1))
+	for i := 0; i < 3; i++ {
+		v, err := c.Get(ctx, "k")
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}
+	assert.Zero(t, calls.Load())
+}
+
+func TestRefresher_trigger(t *testing.T) {
+	r := newRefresher[int](1)
+	// 同一个键在队列中只出现一次，队列满时丢弃
+	for i := 0; i < refreshQueueFactor+1; i++ {
+		r.trigger(i)
+		r.trigger(i)
+	}
+	assert.Len(t, r.queue, refreshQueueFactor)
+	assert.Len(t, r.queued, refreshQueueFactor)
+	r.done(0)
+	assert.Len(t, r.queued, refreshQueueFactor-1)
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"sort"
+	"sync"
+)
+
+// NamedCache 是全局注册表中的缓存视图，与缓存的键值类型无关，便于统一暴露给管理端点或监控采集器。
+type NamedCache interface {
+	Name() string
+	Stats() Stats
+}
+
+var registry = struct {
+	sync.RWMutex
+	caches map[NamedCache]struct{}
+}{
+	caches: make(map[NamedCache]struct{}),
+}
+
+// WithName 为缓存命名。命名的缓存会自动加入全局注册表，在构造时传入的 context 结束后自动移除。
+func WithName[K comparable, V any](name string) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.name = name
+	}
+}
+
+// Name 返回缓存的名称，未通过 WithName 命名时为空字符串。
+func (c *Cache[K, V]) Name() string {
+	return c.opts.name
+}
+
+// Caches 返回全局注册表中所有存活的命名缓存，按名称排序。
+func Caches() []NamedCache {
+	registry.RLock()
+	defer registry.RUnlock()
+	caches := make([]NamedCache, 0, len(registry.caches))
+	for c := range registry.caches {
+		caches = append(caches, c)
+	}
+	sort.SliceStable(caches, func(i, j int) bool {
+		return caches[i].Name() < caches[j].Name()
+	})
+	return caches
+}
+
+func register(c NamedCache) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.caches[c] = struct{}{}
+}
+
+func unregister(c NamedCache) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.caches, c)
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func names(caches []NamedCache) []string {
+	res := make([]string, 0, len(caches))
+	for _, c := range caches {
+		res = append(res, c.Name())
+	}
+	return res
+}
+
+func TestCaches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	users := NewSimpleCache[int, int](ctx, 0, time.Minute, WithName[int, int]("users"))
+	orders := NewLruCache[string, int](context.Background(), 10, time.Minute, WithName[string, int]("orders"))
+	// 未命名的缓存不会注册
+	NewSimpleCache[int, int](context.Background(), 0, time.Minute)
+
+	assert.Equal(t, "users", users.Name())
+	assert.Equal(t, "orders", orders.Name())
+	assert.Subset(t, names(Caches()), []string{"orders", "users"})
+	assert.NotContains(t, names(Caches()), "")
+
+	// context 结束后缓存从注册表中移除
+	cancel()
+	assert.Eventually(t, func() bool {
+		for _, name := range names(Caches()) {
+			if name == "users" {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+	assert.Contains(t, names(Caches()), "orders")
+}
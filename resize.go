@@ -0,0 +1,43 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// SetCapacity 在运行时将后端的容量修改为 n，不需要重建缓存，可以配合 Advise 由控制面调整容量。
+// 缩小容量时按后端的淘汰策略立即淘汰多出的元素，与容量不足时的淘汰相同地触发 WithOnEvicted 等回调。
+// n 小于等于 0 时返回错误，后端没有实现 types.Resizer 时返回 cacheError.ErrResizeUnsupported，
+// 内置的 LRU 和 FIFO 后端都实现了 types.Resizer。
+func (c *Cache[K, V]) SetCapacity(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("cache: capacity must be positive, got %d", n)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	if err := c.checkClosed(); err != nil {
+		return err
+	}
+	r, ok := c.cache.(types.Resizer)
+	if !ok {
+		return cacheError.ErrResizeUnsupported
+	}
+	r.SetCapacity(n)
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_SetCapacity(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name     string
+		newCache func(onEvicted func(key string, value int)) *Cache[string, int]
+		n        int
+
+		wantKeys    []string
+		wantEvicted []string
+		wantErr     error
+	}{
+		{
+			name: "shrink lru",
+			newCache: func(onEvicted func(key string, value int)) *Cache[string, int] {
+				return NewLruCache[string, int](ctx, 4, 0, WithOnEvicted[string, int](onEvicted))
+			},
+			n:           2,
+			wantKeys:    []string{"c", "d"},
+			wantEvicted: []string{"a", "b"},
+		},
+		{
+			name: "grow fifo",
+			newCache: func(onEvicted func(key string, value int)) *Cache[string, int] {
+				return NewFifoCache[string, int](ctx, 4, 0, WithOnEvicted[string, int](onEvicted))
+			},
+			n:        8,
+			wantKeys: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "non-positive",
+			newCache: func(onEvicted func(key string, value int)) *Cache[string, int] {
+				return NewLruCache[string, int](ctx, 4, 0)
+			},
+			n:        0,
+			wantKeys: []string{"a", "b", "c", "d"},
+			wantErr:  errors.New("cache: capacity must be positive, got 0"),
+		},
+		{
+			name: "unsupported backend",
+			newCache: func(onEvicted func(key string, value int)) *Cache[string, int] {
+				return NewSimpleCache[string, int](ctx, 4, 0)
+			},
+			n:        2,
+			wantKeys: []string{"a", "b", "c", "d"},
+			wantErr:  cacheError.ErrResizeUnsupported,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var evicted []string
+			c := tc.newCache(func(key string, _ int) { evicted = append(evicted, key) })
+			for i, key := range []string{"a", "b", "c", "d"} {
+				require.NoError(t, c.Set(ctx, key, i))
+			}
+			assert.Equal(t, tc.wantErr, c.SetCapacity(tc.n))
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+			assert.Equal(t, tc.wantEvicted, evicted)
+		})
+	}
+}
+
+func TestCache_SetCapacity_grow(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[int, int](ctx, 2, 0)
+	require.NoError(t, c.SetCapacity(4))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.Set(ctx, i, i))
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, c.Keys())
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, cacheError.ErrClosed, c.SetCapacity(2))
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// maxBulkLen 单个批量字符串的最大长度，与 Redis 的默认 proto-max-bulk-len 一致。
+	maxBulkLen = 512 << 20
+	// maxArrayLen 数组的最大元素个数，与 Redis 对多批量请求的限制一致。
+	maxArrayLen = 1 << 20
+	// maxDepth 回复中数组的最大嵌套层数。
+	maxDepth = 32
+)
+
+var ErrProtocol = errors.New("resp: protocol error")
+
+// Error 是服务端返回的错误回复。
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// reader 解析 RESP2 协议。
+type reader struct {
+	*bufio.Reader
+}
+
+func (r reader) readLine() ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, ErrProtocol
+		}
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, ErrProtocol
+	}
+	return line[:len(line)-2], nil
+}
+
+func (r reader) readInt() (int64, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(string(line), 10, 64)
+	if err != nil {
+		return 0, ErrProtocol
+	}
+	return n, nil
+}
+
+// readValue 读取一个回复，类型对应关系为：简单字符串 -> string，错误 -> Error，整数 -> int64，
+// 批量字符串 -> []byte，空值 -> nil，数组 -> []any。
+func (r reader) readValue() (any, error) {
+	return r.readNested(0)
+}
+
+// readNested 读取一个嵌套在 depth 层数组中的回复。
+func (r reader) readNested(depth int) (any, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch prefix {
+	case '+', '-':
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if prefix == '-' {
+			return Error(line), nil
+		}
+		return string(line), nil
+	case ':':
+		return r.readInt()
+	case '$':
+		b, err := r.readBulk()
+		if b == nil || err != nil {
+			// 空值返回无类型的 nil
+			return nil, err
+		}
+		return b, nil
+	case '*':
+		if depth >= maxDepth {
+			return nil, ErrProtocol
+		}
+		n, err := r.readArrayLen()
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		// 不根据声明的长度预先分配，避免一行头部就申请大量内存
+		values := []any{}
+		for i := 0; i < n; i++ {
+			v, err := r.readNested(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	default:
+		return nil, ErrProtocol
+	}
+}
+
+// readBulk 读取 '$' 之后的批量字符串，空值返回 nil。
+func (r reader) readBulk() ([]byte, error) {
+	n, err := r.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, nil
+	}
+	if n > maxBulkLen {
+		return nil, ErrProtocol
+	}
+	buf := make([]byte, n+2)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	if buf[n] != '\r' || buf[n+1] != '\n' {
+		return nil, ErrProtocol
+	}
+	return buf[:n], nil
+}
+
+// readArrayLen 读取 '*' 之后的数组长度，空数组返回 -1，超过 maxArrayLen 时返回 ErrProtocol。
+func (r reader) readArrayLen() (int, error) {
+	n, err := r.readInt()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return -1, nil
+	}
+	if n > maxArrayLen {
+		return 0, ErrProtocol
+	}
+	return int(n), nil
+}
+
+// readCommand 读取客户端发送的命令，支持批量字符串数组和以空白分隔的内联命令两种形式。
+// 与 Redis 相同，数组形式的命令只能包含批量字符串，不能嵌套数组。
+func (r reader) readCommand() ([][]byte, error) {
+	prefix, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if prefix[0] != '*' {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.Fields(line), nil
+	}
+	_, _ = r.ReadByte()
+	n, err := r.readArrayLen()
+	if err != nil {
+		return nil, err
+	}
+	args := [][]byte{}
+	for i := 0; i < n; i++ {
+		prefix, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if prefix != '$' {
+			return nil, ErrProtocol
+		}
+		arg, err := r.readBulk()
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			return nil, ErrProtocol
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// writer 生成 RESP2 协议的回复。
+type writer struct {
+	*bufio.Writer
+}
+
+func (w writer) writeSimple(s string) {
+	_, _ = w.WriteString("+" + s + "\r\n")
+}
+
+func (w writer) writeError(msg string) {
+	_, _ = w.WriteString("-" + msg + "\r\n")
+}
+
+func (w writer) writeInt(n int64) {
+	_, _ = w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+func (w writer) writeBulk(b []byte) {
+	_, _ = w.WriteString("$" + strconv.Itoa(len(b)) + "\r\n")
+	_, _ = w.Write(b)
+	_, _ = w.WriteString("\r\n")
+}
+
+func (w writer) writeNull() {
+	_, _ = w.WriteString("$-1\r\n")
+}
+
+func (w writer) writeArrayHeader(n int) {
+	_, _ = w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+func errWrongArgs(cmd string) string {
+	return fmt.Sprintf("ERR wrong number of arguments for '%s' command", cmd)
+}
@@ -0,0 +1,495 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resp 通过 Redis 的 RESP 协议暴露进程内缓存，便于使用 redis-cli 等工具查看和修改缓存，仅用于调试。
+// 支持的命令：PING、GET、SET（EX/PX/NX）、DEL、EXISTS、EXPIRE、PERSIST、TTL、PTTL、KEYS、PUBLISH、SUBSCRIBE、UNSUBSCRIBE、COMMAND、QUIT。
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/internal/glob"
+)
+
+var ErrServerClosed = errors.New("resp: server closed")
+
+// Server 是缓存的 RESP 协议服务端，键固定为字符串，值通过 marshal 和 unmarshal 与字节相互转换。
+type Server[V any] struct {
+	cache     *cache.Cache[string, V]
+	marshal   func(V) ([]byte, error)
+	unmarshal func([]byte) (V, error)
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+
+	// 频道到订阅者的映射，由 psMu 保护
+	psMu     sync.Mutex
+	channels map[string]map[*session]struct{}
+}
+
+// session 是一个连接的状态，mu 保护对连接的写入，发布消息时其他连接的协程也会写入订阅者的连接。
+type session struct {
+	mu   sync.Mutex
+	w    writer
+	subs map[string]struct{}
+}
+
+// NewServer 创建一个 RESP 服务端。
+func NewServer[V any](c *cache.Cache[string, V], marshal func(V) ([]byte, error), unmarshal func([]byte) (V, error)) *Server[V] {
+	return &Server[V]{
+		cache:     c,
+		marshal:   marshal,
+		unmarshal: unmarshal,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+		channels:  make(map[string]map[*session]struct{}),
+	}
+}
+
+// NewStringServer 为值类型为 string 的缓存创建 RESP 服务端。
+func NewStringServer(c *cache.Cache[string, string]) *Server[string] {
+	return NewServer(c,
+		func(v string) ([]byte, error) { return []byte(v), nil },
+		func(b []byte) (string, error) { return string(b), nil },
+	)
+}
+
+// ListenAndServe 监听 TCP 地址 addr 并处理连接。
+func (s *Server[V]) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve 在 l 上接受连接并为每个连接启动一个协程，直到 l 出错或服务端被关闭。
+// 服务端关闭后返回 ErrServerClosed。
+func (s *Server[V]) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.track(conn) {
+			_ = conn.Close()
+			return ErrServerClosed
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close 关闭所有监听器和连接，并等待正在处理的连接退出。
+func (s *Server[V]) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server[V]) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server[V]) serveConn(conn net.Conn) {
+	sess := &session{w: writer{bufio.NewWriter(conn)}}
+	defer func() {
+		s.unsubscribe(sess, nil)
+		_ = conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+	r := reader{bufio.NewReader(conn)}
+	for {
+		args, err := r.readCommand()
+		if err != nil {
+			if errors.Is(err, ErrProtocol) {
+				sess.mu.Lock()
+				sess.w.writeError("ERR Protocol error")
+				_ = sess.w.Flush()
+				sess.mu.Unlock()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		sess.mu.Lock()
+		quit := s.exec(context.Background(), sess, args)
+		// 客户端使用管道批量发送命令时，等缓冲区中的命令都处理完再统一写回
+		if r.Buffered() == 0 || quit {
+			if sess.w.Flush() != nil {
+				quit = true
+			}
+		}
+		sess.mu.Unlock()
+		if quit {
+			return
+		}
+	}
+}
+
+// exec 执行一条命令并写入回复，返回是否需要关闭连接，调用方需要持有 sess.mu。
+func (s *Server[V]) exec(ctx context.Context, sess *session, args [][]byte) bool {
+	w := sess.w
+	cmd := strings.ToLower(string(args[0]))
+	args = args[1:]
+	// 与 Redis 一致，订阅状态下只能执行订阅相关的命令
+	if len(sess.subs) > 0 {
+		switch cmd {
+		case "subscribe", "unsubscribe", "ping", "quit":
+		default:
+			w.writeError("ERR Can't execute '" + cmd + "': only SUBSCRIBE / UNSUBSCRIBE / PING / QUIT are allowed in this context")
+			return false
+		}
+	}
+	switch cmd {
+	case "ping":
+		switch len(args) {
+		case 0:
+			w.writeSimple("PONG")
+		case 1:
+			w.writeBulk(args[0])
+		default:
+			w.writeError(errWrongArgs(cmd))
+		}
+	case "get":
+		if len(args) != 1 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		s.get(ctx, w, string(args[0]))
+	case "set":
+		if len(args) < 2 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		s.set(ctx, w, string(args[0]), args[1], args[2:])
+	case "del":
+		if len(args) == 0 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		var n int64
+		for _, key := range args {
+			if s.cache.Delete(ctx, string(key)) == nil {
+				n++
+			}
+		}
+		w.writeInt(n)
+	case "exists":
+		if len(args) == 0 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		var n int64
+		for _, key := range args {
+			if s.cache.Contains(ctx, string(key)) {
+				n++
+			}
+		}
+		w.writeInt(n)
+	case "expire":
+		if len(args) != 2 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		seconds, err := strconv.ParseInt(string(args[1]), 10, 64)
+		if err != nil {
+			w.writeError("ERR value is not an integer or out of range")
+			return false
+		}
+		n, err := s.cache.ExpireMulti(ctx, []string{string(args[0])}, time.Duration(seconds)*time.Second)
+		if err != nil {
+			w.writeError("ERR " + err.Error())
+			return false
+		}
+		w.writeInt(int64(n))
+	case "persist":
+		if len(args) != 1 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		ok, err := s.cache.Persist(ctx, string(args[0]))
+		switch {
+		case errors.Is(err, cacheError.ErrNoKey):
+			w.writeInt(0)
+		case err != nil:
+			w.writeError("ERR " + err.Error())
+		case ok:
+			w.writeInt(1)
+		default:
+			w.writeInt(0)
+		}
+	case "ttl", "pttl":
+		if len(args) != 1 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		unit := time.Second
+		if cmd == "pttl" {
+			unit = time.Millisecond
+		}
+		w.writeInt(s.ttl(ctx, string(args[0]), unit))
+	case "keys":
+		if len(args) != 1 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		pattern := string(args[0])
+		var matched []string
+		for _, key := range s.cache.Keys() {
+			if glob.Match(pattern, key) {
+				matched = append(matched, key)
+			}
+		}
+		w.writeArrayHeader(len(matched))
+		for _, key := range matched {
+			w.writeBulk([]byte(key))
+		}
+	case "publish":
+		if len(args) != 2 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		w.writeInt(s.publish(string(args[0]), args[1]))
+	case "subscribe":
+		if len(args) == 0 {
+			w.writeError(errWrongArgs(cmd))
+			return false
+		}
+		s.subscribe(sess, args)
+	case "unsubscribe":
+		s.unsubscribe(sess, args)
+	case "command":
+		// redis-cli 连接时会发送 COMMAND DOCS，返回空数组即可
+		w.writeArrayHeader(0)
+	case "quit":
+		w.writeSimple("OK")
+		return true
+	default:
+		w.writeError("ERR unknown command '" + cmd + "'")
+	}
+	return false
+}
+
+func (s *Server[V]) get(ctx context.Context, w writer, key string) {
+	v, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, cacheError.ErrNoKey) {
+			w.writeNull()
+			return
+		}
+		w.writeError("ERR " + err.Error())
+		return
+	}
+	b, err := s.marshal(v)
+	if err != nil {
+		w.writeError("ERR " + err.Error())
+		return
+	}
+	w.writeBulk(b)
+}
+
+// ttl 以 unit 为单位返回 key 的剩余过期时间，与 Redis 一致，key 不存在时返回 -2，永不过期时返回 -1。
+func (s *Server[V]) ttl(ctx context.Context, key string, unit time.Duration) int64 {
+	ttl, err := s.cache.TTL(ctx, key)
+	if err != nil {
+		return -2
+	}
+	if ttl == cache.NoExpiration {
+		return -1
+	}
+	// 与 Redis 一致，按四舍五入换算
+	return int64((ttl + unit/2) / unit)
+}
+
+func (s *Server[V]) set(ctx context.Context, w writer, key string, raw []byte, flags [][]byte) {
+	var (
+		opts []cache.ItemOption
+		nx   bool
+	)
+	for i := 0; i < len(flags); i++ {
+		switch flag := strings.ToLower(string(flags[i])); flag {
+		case "nx":
+			nx = true
+		case "ex", "px":
+			if i+1 >= len(flags) {
+				w.writeError("ERR syntax error")
+				return
+			}
+			n, err := strconv.ParseInt(string(flags[i+1]), 10, 64)
+			if err != nil || n <= 0 {
+				w.writeError("ERR invalid expire time in 'set' command")
+				return
+			}
+			unit := time.Second
+			if flag == "px" {
+				unit = time.Millisecond
+			}
+			opts = append(opts, cache.WithExpiration(time.Duration(n)*unit))
+			i++
+		default:
+			w.writeError("ERR syntax error")
+			return
+		}
+	}
+	v, err := s.unmarshal(raw)
+	if err != nil {
+		w.writeError("ERR " + err.Error())
+		return
+	}
+	if nx {
+		ok, err := s.cache.SetNX(ctx, key, v, opts...)
+		switch {
+		case err != nil:
+			w.writeError("ERR " + err.Error())
+		case ok:
+			w.writeSimple("OK")
+		default:
+			w.writeNull()
+		}
+		return
+	}
+	if err = s.cache.Set(ctx, key, v, opts...); err != nil {
+		w.writeError("ERR " + err.Error())
+		return
+	}
+	w.writeSimple("OK")
+}
+
+// publish 将消息发送给频道的所有订阅者，返回收到消息的订阅者数量。
+func (s *Server[V]) publish(channel string, msg []byte) int64 {
+	s.psMu.Lock()
+	subs := make([]*session, 0, len(s.channels[channel]))
+	for sess := range s.channels[channel] {
+		subs = append(subs, sess)
+	}
+	s.psMu.Unlock()
+
+	// 订阅状态下的连接不能发布消息，因此这里不会与持有 sess.mu 的发布者相互等待
+	for _, sess := range subs {
+		sess.mu.Lock()
+		sess.w.writeArrayHeader(3)
+		sess.w.writeBulk([]byte("message"))
+		sess.w.writeBulk([]byte(channel))
+		sess.w.writeBulk(msg)
+		_ = sess.w.Flush()
+		sess.mu.Unlock()
+	}
+	return int64(len(subs))
+}
+
+func (s *Server[V]) subscribe(sess *session, channels [][]byte) {
+	if sess.subs == nil {
+		sess.subs = make(map[string]struct{})
+	}
+	s.psMu.Lock()
+	defer s.psMu.Unlock()
+	for _, ch := range channels {
+		channel := string(ch)
+		sess.subs[channel] = struct{}{}
+		if s.channels[channel] == nil {
+			s.channels[channel] = make(map[*session]struct{})
+		}
+		s.channels[channel][sess] = struct{}{}
+		sess.w.writeArrayHeader(3)
+		sess.w.writeBulk([]byte("subscribe"))
+		sess.w.writeBulk(ch)
+		sess.w.writeInt(int64(len(sess.subs)))
+	}
+}
+
+// unsubscribe 取消订阅 channels，channels 为空时取消所有订阅。连接关闭时以 nil 调用，此时不写回复。
+func (s *Server[V]) unsubscribe(sess *session, channels [][]byte) {
+	reply := channels != nil
+	if len(channels) == 0 {
+		for channel := range sess.subs {
+			channels = append(channels, []byte(channel))
+		}
+		if reply && len(channels) == 0 {
+			sess.w.writeArrayHeader(3)
+			sess.w.writeBulk([]byte("unsubscribe"))
+			sess.w.writeNull()
+			sess.w.writeInt(0)
+			return
+		}
+	}
+	s.psMu.Lock()
+	defer s.psMu.Unlock()
+	for _, ch := range channels {
+		channel := string(ch)
+		delete(sess.subs, channel)
+		if subs := s.channels[channel]; subs != nil {
+			delete(subs, sess)
+			if len(subs) == 0 {
+				delete(s.channels, channel)
+			}
+		}
+		if reply {
+			sess.w.writeArrayHeader(3)
+			sess.w.writeBulk([]byte("unsubscribe"))
+			sess.w.writeBulk(ch)
+			sess.w.writeInt(int64(len(sess.subs)))
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listen 启动一个服务端并返回其监听地址，测试结束时关闭服务端。
+func listen(t *testing.T) (*cache.Cache[string, string], string) {
+	c := cache.NewSimpleCache[string, string](context.Background(), 0, time.Minute)
+	s := NewStringServer(c)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+	t.Cleanup(func() {
+		assert.NoError(t, s.Close())
+		assert.Equal(t, ErrServerClosed, <-done)
+	})
+	return c, l.Addr().String()
+}
+
+func startServer(t *testing.T) (*cache.Cache[string, string], net.Conn, reader) {
+	c, addr := listen(t)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return c, conn, reader{bufio.NewReader(conn)}
+}
+
+// send 以数组形式发送命令并读取回复。
+func send(t *testing.T, conn net.Conn, r reader, args ...string) any {
+	var sb strings.Builder
+	w := writer{bufio.NewWriter(&sb)}
+	w.writeArrayHeader(len(args))
+	for _, arg := range args {
+		w.writeBulk([]byte(arg))
+	}
+	require.NoError(t, w.Flush())
+	_, err := conn.Write([]byte(sb.String()))
+	require.NoError(t, err)
+	v, err := r.readValue()
+	require.NoError(t, err)
+	return v
+}
+
+func TestServer(t *testing.T) {
+	c, conn, r := startServer(t)
+
+	testCases := []struct {
+		name string
+		args []string
+		want any
+	}{
+		{name: "ping", args: []string{"PING"}, want: "PONG"},
+		{name: "ping with message", args: []string{"ping", "hi"}, want: []byte("hi")},
+		{name: "get missing key", args: []string{"GET", "a"}, want: nil},
+		{name: "set", args: []string{"SET", "a", "1"}, want: "OK"},
+		{name: "get", args: []string{"GET", "a"}, want: []byte("1")},
+		{name: "set nx on existing key", args: []string{"SET", "a", "2", "NX"}, want: nil},
+		{name: "set nx on new key", args: []string{"SET", "b", "2", "NX"}, want: "OK"},
+		{name: "set with ex", args: []string{"SET", "c", "3", "EX", "100"}, want: "OK"},
+		{name: "set with invalid ex", args: []string{"SET", "c", "3", "EX", "x"}, want: Error("ERR invalid expire time in 'set' command")},
+		{name: "set with unknown flag", args: []string{"SET", "c", "3", "XX"}, want: Error("ERR syntax error")},
+		{name: "keys", args: []string{"KEYS", "[ab]"}, want: []any{[]byte("a"), []byte("b")}},
+		{name: "expire", args: []string{"EXPIRE", "a", "100"}, want: int64(1)},
+		{name: "expire missing key", args: []string{"EXPIRE", "z", "100"}, want: int64(0)},
+		{name: "ttl", args: []string{"TTL", "c"}, want: int64(100)},
+		{name: "persist", args: []string{"PERSIST", "c"}, want: int64(1)},
+		{name: "persist without expiration", args: []string{"PERSIST", "c"}, want: int64(0)},
+		{name: "ttl after persist", args: []string{"TTL", "c"}, want: int64(-1)},
+		{name: "persist missing key", args: []string{"PERSIST", "z"}, want: int64(0)},
+		{name: "pttl without expiration", args: []string{"PTTL", "b"}, want: int64(-1)},
+		{name: "pttl missing key", args: []string{"PTTL", "z"}, want: int64(-2)},
+		{name: "exists", args: []string{"EXISTS", "a", "b", "a", "z"}, want: int64(3)},
+		{name: "del", args: []string{"DEL", "a", "b", "z"}, want: int64(2)},
+		{name: "wrong number of arguments", args: []string{"GET"}, want: Error("ERR wrong number of arguments for 'get' command")},
+		{name: "unknown command", args: []string{"FLUSHALL"}, want: Error("ERR unknown command 'flushall'")},
+		{name: "command", args: []string{"COMMAND", "DOCS"}, want: []any{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := send(t, conn, r, tc.args...)
+			if want, ok := tc.want.([]any); ok && len(want) > 1 {
+				assert.ElementsMatch(t, want, got)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	assert.Equal(t, []string{"c"}, c.Keys())
+}
+
+func TestServer_Inline(t *testing.T) {
+	_, conn, r := startServer(t)
+	_, err := conn.Write([]byte("SET a 1\r\nGET a\r\nQUIT\r\n"))
+	require.NoError(t, err)
+	for _, want := range []any{"OK", []byte("1"), "OK"} {
+		got, err := r.readValue()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	// QUIT 之后服务端关闭连接
+	_, err = r.readValue()
+	assert.Error(t, err)
+}
+
+func TestServer_ProtocolError(t *testing.T) {
+	testCases := []struct {
+		name string
+		req  string
+	}{
+		{name: "integer argument", req: "*1\r\n:1\r\n"},
+		{name: "null argument", req: "*1\r\n$-1\r\n"},
+		{name: "nested array", req: "*1\r\n*1\r\n$1\r\na\r\n"},
+		// 只有头部的超长数组不会导致大量的内存分配
+		{name: "array too long", req: "*536870912\r\n"},
+		{name: "array over the multibulk limit", req: "*1048577\r\n"},
+		{name: "bulk too long", req: "*1\r\n$536870913\r\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, conn, r := startServer(t)
+			_, err := conn.Write([]byte(tc.req))
+			require.NoError(t, err)
+			got, err := r.readValue()
+			require.NoError(t, err)
+			assert.Equal(t, Error("ERR Protocol error"), got)
+			// 服务端在协议错误之后关闭连接
+			_, err = r.readValue()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReader_readValue(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+
+		want    any
+		wantErr error
+	}{
+		{name: "nested array", data: "*2\r\n*1\r\n:1\r\n$-1\r\n", want: []any{[]any{int64(1)}, nil}},
+		{name: "empty array", data: "*0\r\n", want: []any{}},
+		{name: "null array", data: "*-1\r\n"},
+		{name: "too deep", data: strings.Repeat("*1\r\n", maxDepth+1) + ":1\r\n", wantErr: ErrProtocol},
+		{name: "array too long", data: "*1048577\r\n", wantErr: ErrProtocol},
+		// 声明的长度不会被预先分配，数据不足时返回 EOF
+		{name: "truncated array", data: "*1048576\r\n:1\r\n", wantErr: io.EOF},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := reader{bufio.NewReader(strings.NewReader(tc.data))}.readValue()
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestServer_PubSub(t *testing.T) {
+	_, sub, subR := startServer(t)
+	pub, err := net.Dial("tcp", sub.RemoteAddr().String())
+	require.NoError(t, err)
+	defer pub.Close()
+	pubR := reader{bufio.NewReader(pub)}
+
+	assert.Equal(t, int64(0), send(t, pub, pubR, "PUBLISH", "ch", "nobody"))
+	assert.Equal(t, []any{[]byte("subscribe"), []byte("ch"), int64(1)}, send(t, sub, subR, "SUBSCRIBE", "ch"))
+	// 订阅状态下不能执行普通命令
+	assert.Equal(t, Error("ERR Can't execute 'get': only SUBSCRIBE / UNSUBSCRIBE / PING / QUIT are allowed in this context"),
+		send(t, sub, subR, "GET", "a"))
+
+	assert.Equal(t, int64(1), send(t, pub, pubR, "PUBLISH", "ch", "hello"))
+	got, err := subR.readValue()
+	require.NoError(t, err)
+	assert.Equal(t, []any{[]byte("message"), []byte("ch"), []byte("hello")}, got)
+
+	assert.Equal(t, []any{[]byte("unsubscribe"), []byte("ch"), int64(0)}, send(t, sub, subR, "UNSUBSCRIBE"))
+	assert.Equal(t, []any{[]byte("unsubscribe"), nil, int64(0)}, send(t, sub, subR, "UNSUBSCRIBE"))
+	assert.Equal(t, int64(0), send(t, pub, pubR, "PUBLISH", "ch", "again"))
+	assert.Equal(t, "OK", send(t, sub, subR, "SET", "a", "1"))
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/internal/sample"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// Sample 不放回地均匀随机抽取最多 n 个元素，用于对缓存内容做统计监控。
+// 后端实现了 types.Sampler 时（如 random）无需遍历全部元素，耗时与 n 成正比；否则需要遍历全部的键。
+// 抽中的已过期元素会被跳过，因此返回的元素可能少于 n 个。与 Peek 相同，抽样不会改变元素的访问顺序和命中统计。
+func (c *Cache[K, V]) Sample(n int) []Entry[K, V] {
+	entries := c.sample(n)
+	if c.opts.cloner != nil {
+		// 在释放锁之后复制，见 WithCloner
+		for i := range entries {
+			entries[i].Value = c.opts.cloner(entries[i].Value)
+		}
+	}
+	return entries
+}
+
+func (c *Cache[K, V]) sample(n int) []Entry[K, V] {
+	if n <= 0 {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+	var keys []K
+	if s, ok := c.cache.(types.Sampler[K]); ok {
+		keys = s.SampleKeys(n)
+	} else {
+		all := c.cache.Keys()
+		for _, i := range sample.Indexes(n, len(all), c.randIntn) {
+			keys = append(keys, all[i])
+		}
+	}
+	entries := make([]Entry[K, V], 0, len(keys))
+	for _, key := range keys {
+		item, err := c.peek(context.Background(), key)
+		if err != nil || item.Expired() {
+			continue
+		}
+		entries = append(entries, Entry[K, V]{Key: key, Value: item.value})
+	}
+	return entries
+}
+
+// randomKeyAttempts 是 RandomKey 随机抽中已过期的键时重试的次数，超过之后退化为遍历全部的键。
+const randomKeyAttempts = 16
+
+// RandomKey 与 Redis 的 RANDOMKEY 命令类似，从未过期的键中均匀随机地返回一个，缓存中没有未过期的键时返回 cacheError.ErrNoKey。
+// 抽中已过期的键时重新抽取，因此结果在未过期的键中是均匀的；后端实现了 types.Sampler 时通常不需要遍历全部的键。
+// 与 Sample 相同，RandomKey 不会改变元素的访问顺序。
+func (c *Cache[K, V]) RandomKey(ctx context.Context) (key K, err error) {
+	c.mutex.Lock()
+	defer c.unlock()
+	if err = c.checkClosed(); err != nil {
+		return key, err
+	}
+	live := func(key K) bool {
+		item, err := c.peek(ctx, key)
+		return err == nil && !item.Expired()
+	}
+	var keys []K
+	pick := func() (K, bool) {
+		if s, ok := c.cache.(types.Sampler[K]); ok {
+			if got := s.SampleKeys(1); len(got) == 1 {
+				return got[0], true
+			}
+			var zero K
+			return zero, false
+		}
+		if keys == nil {
+			keys = c.cache.Keys()
+		}
+		if len(keys) == 0 {
+			var zero K
+			return zero, false
+		}
+		return keys[c.randIntn(len(keys))], true
+	}
+	for i := 0; i < randomKeyAttempts; i++ {
+		k, ok := pick()
+		if !ok {
+			return key, cacheError.ErrNoKey
+		}
+		if live(k) {
+			return k, nil
+		}
+	}
+	// 大部分键都已经过期，从全部未过期的键中抽取
+	var alive []K
+	c.rangeKeys(func(key K) bool {
+		if live(key) {
+			alive = append(alive, key)
+		}
+		return true
+	})
+	if len(alive) == 0 {
+		return key, cacheError.ErrNoKey
+	}
+	return alive[c.randIntn(len(alive))], nil
+}
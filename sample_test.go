@@ -0,0 +1,181 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/random"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Sample(t *testing.T) {
+	ctx := context.Background()
+	fill := func(t *testing.T, c *Cache[int, int]) *Cache[int, int] {
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Set(ctx, i, i*10))
+		}
+		require.NoError(t, c.Set(ctx, 10, 100, WithExpiration(-time.Second)))
+		return c
+	}
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) *Cache[int, int]
+		n     int
+
+		wantLen    int
+		wantMaxLen int
+	}{
+		{
+			name: "non-positive n",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return fill(t, NewSimpleCache[int, int](ctx, 0, 0))
+			},
+			n: 0,
+		},
+		{
+			name: "reservoir over keys",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return fill(t, NewSimpleCache[int, int](ctx, 0, 0, WithRandSource[int, int](rand.NewSource(1))))
+			},
+			n:          3,
+			wantMaxLen: 3,
+		},
+		{
+			name: "backend sampler",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return fill(t, New[int, int](ctx, random.NewCache[int, *Item[int]](20, random.WithRandSource[int, *Item[int]](rand.NewSource(1))), 0))
+			},
+			n:          3,
+			wantMaxLen: 3,
+		},
+		{
+			name: "n larger than size skips expired",
+			cache: func(t *testing.T) *Cache[int, int] {
+				return fill(t, NewLruCache[int, int](ctx, 20, 0))
+			},
+			n:          100,
+			wantLen:    10,
+			wantMaxLen: 10,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.cache(t).Sample(tc.n)
+			assert.LessOrEqual(t, len(got), tc.wantMaxLen)
+			assert.GreaterOrEqual(t, len(got), tc.wantLen)
+			seen := make(map[int]bool)
+			for _, e := range got {
+				assert.False(t, seen[e.Key])
+				seen[e.Key] = true
+				assert.Equal(t, e.Key*10, e.Value)
+				assert.NotEqual(t, 10, e.Key)
+			}
+		})
+	}
+}
+
+func TestCache_Sample_keepsRecency(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[int, int](ctx, 2, 0)
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Set(ctx, 2, 2))
+	assert.Len(t, c.Sample(2), 2)
+	_, err := c.RandomKey(ctx)
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, 3, 3))
+	assert.ElementsMatch(t, []int{2, 3}, c.Keys())
+}
+
+func TestCache_RandomKey(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache func() *Cache[int, int]
+		// live 为未过期的键，expired 为已过期的键
+		live    []int
+		expired []int
+
+		wantErr error
+	}{
+		{
+			name:    "empty",
+			cache:   func() *Cache[int, int] { return NewSimpleCache[int, int](ctx, 0, 0) },
+			wantErr: cacheError.ErrNoKey,
+		},
+		{
+			name:    "all expired",
+			cache:   func() *Cache[int, int] { return NewSimpleCache[int, int](ctx, 0, 0) },
+			expired: []int{1, 2, 3},
+			wantErr: cacheError.ErrNoKey,
+		},
+		{
+			name: "mostly expired",
+			cache: func() *Cache[int, int] {
+				return NewSimpleCache[int, int](ctx, 0, 0, WithRandSource[int, int](rand.NewSource(1)))
+			},
+			live:    []int{100, 101},
+			expired: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19},
+		},
+		{
+			name: "backend sampler",
+			cache: func() *Cache[int, int] {
+				return New[int, int](ctx, random.NewCache[int, *Item[int]](100, random.WithRandSource[int, *Item[int]](rand.NewSource(1))), 0)
+			},
+			live:    []int{100, 101, 102},
+			expired: []int{0, 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache()
+			for _, key := range tc.live {
+				require.NoError(t, c.Set(ctx, key, key))
+			}
+			for _, key := range tc.expired {
+				require.NoError(t, c.Set(ctx, key, key, WithExpiration(-time.Second)))
+			}
+			seen := make(map[int]int)
+			for i := 0; i < 300; i++ {
+				key, err := c.RandomKey(ctx)
+				assert.Equal(t, tc.wantErr, err)
+				if err != nil {
+					return
+				}
+				seen[key]++
+			}
+			// 每个未过期的键都会被抽中，已过期的键不会
+			for _, key := range tc.live {
+				assert.Positive(t, seen[key], "key %d", key)
+			}
+			assert.Len(t, seen, len(tc.live))
+		})
+	}
+}
+
+func TestCache_RandomKey_closed(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[int, int](ctx, 0, 0)
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Close())
+	_, err := c.RandomKey(ctx)
+	assert.Equal(t, cacheError.ErrClosed, err)
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/heap"
+	"context"
+
+	"github.com/chenmingyong0423/go-generics-cache/internal/glob"
+	"github.com/chenmingyong0423/go-generics-cache/internal/keyhash"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// KeysN 返回至多 limit 个键，limit 小于等于 0 时返回空切片。与 Keys 相同，返回的键可能包含已过期但尚未清理的键。
+// 后端实现了 types.Ranger 时只会遍历需要的键，内置的后端均已实现。
+func (c *Cache[K, V]) KeysN(limit int) []K {
+	if limit <= 0 {
+		return make([]K, 0)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	keys := make([]K, 0, min(limit, c.len()))
+	c.rangeKeys(func(key K) bool {
+		keys = append(keys, key)
+		return len(keys) < limit
+	})
+	return keys
+}
+
+// Scan 与 Redis 的 SCAN 命令类似，按游标分页遍历所有的键：第一次调用时 cursor 为 0，
+// 之后使用上一次返回的 next，next 为 0 时遍历结束。每次返回大约 count 个键，内存占用与 count 而不是键的总数相关。
+//
+// 键按其哈希值的顺序被遍历，因此遍历期间一直存在的键恰好被返回一次（哈希值冲突时可能重复），
+// 遍历期间新增或删除的键可能被返回，也可能不被返回。与 Keys 相同，返回的键可能包含已过期但尚未清理的键。
+// 每次调用都需要遍历所有的键并计算哈希，完整地遍历一次的耗时大约为 O(n²/count)。
+func (c *Cache[K, V]) Scan(cursor uint64, count int) (keys []K, next uint64) {
+	return c.scan(cursor, count, nil)
+}
+
+// ScanMatch 与 Scan 相同，但只返回匹配 match 的键，match 的语法与 DeleteMatch 相同，为空时匹配所有的键。
+// 与 Redis 的 SCAN 不同，count 限制的是返回的匹配键的数量而不是检查的键的数量，因此 next 不为 0 时返回的键不会为空。
+// 每次调用只在遍历期间持有读锁，调用之间不持有锁；ctx 已经结束时返回 ctx.Err()。
+func ScanMatch[K ~string, V any](ctx context.Context, c *Cache[K, V], cursor uint64, match string, count int) (keys []K, next uint64, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	var fn func(key K) bool
+	if match != "" {
+		fn = func(key K) bool { return glob.Match(match, string(key)) }
+	}
+	keys, next = c.scan(cursor, count, fn)
+	return keys, next, nil
+}
+
+// scan 实现了 Scan 和 ScanMatch，match 为 nil 时返回所有的键。
+func (c *Cache[K, V]) scan(cursor uint64, count int, match func(key K) bool) (keys []K, next uint64) {
+	count = max(count, 1)
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	// h 保留哈希值最小的 count 个键，dropped 记录被舍弃的键中最小的哈希值
+	h := &scanHeap[K]{}
+	var dropped uint64
+	hasDropped := false
+	drop := func(hash uint64) {
+		if !hasDropped || hash < dropped {
+			dropped, hasDropped = hash, true
+		}
+	}
+	c.rangeKeys(func(key K) bool {
+		if match != nil && !match(key) {
+			return true
+		}
+		hash := keyhash.Key(key)
+		switch {
+		case hash < cursor:
+		case h.Len() < count:
+			heap.Push(h, scanEntry[K]{key: key, hash: hash})
+		case hash < (*h)[0].hash:
+			drop(heap.Pop(h).(scanEntry[K]).hash)
+			heap.Push(h, scanEntry[K]{key: key, hash: hash})
+		default:
+			drop(hash)
+		}
+		return true
+	})
+	keys = make([]K, 0, h.Len())
+	for _, e := range *h {
+		keys = append(keys, e.key)
+	}
+	// 哈希值小于 dropped 的键都已经返回
+	if hasDropped {
+		return keys, dropped
+	}
+	return keys, 0
+}
+
+// rangeKeys 遍历所有的键，后端没有实现 types.Ranger 时退化为遍历 Keys 的结果，调用方需要持有锁。
+func (c *Cache[K, V]) rangeKeys(fn func(key K) bool) {
+	if r, ok := c.cache.(types.Ranger[K]); ok {
+		r.RangeKeys(fn)
+		return
+	}
+	for _, key := range c.cache.Keys() {
+		if !fn(key) {
+			return
+		}
+	}
+}
+
+type scanEntry[K comparable] struct {
+	key  K
+	hash uint64
+}
+
+// scanHeap 是按哈希值排序的大顶堆。
+type scanHeap[K comparable] []scanEntry[K]
+
+func (h scanHeap[K]) Len() int           { return len(h) }
+func (h scanHeap[K]) Less(i, j int) bool { return h[i].hash > h[j].hash }
+func (h scanHeap[K]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *scanHeap[K]) Push(x any) {
+	*h = append(*h, x.(scanEntry[K]))
+}
+
+func (h *scanHeap[K]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
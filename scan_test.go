@@ -0,0 +1,177 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// iCacheOnly 只暴露 types.ICache 的方法，用于测试后端没有实现可选接口时的退化行为。
+type iCacheOnly[K comparable, V any] struct {
+	types.ICache[K, V]
+}
+
+func TestCache_KeysN(t *testing.T) {
+	ctx := context.Background()
+	backends := map[string]types.ICache[int, *Item[int]]{
+		"ranger":    simple.NewCache[int, *Item[int]](0),
+		"keys only": iCacheOnly[int, *Item[int]]{ICache: simple.NewCache[int, *Item[int]](0)},
+	}
+	testCases := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "zero", limit: 0, want: 0},
+		{name: "negative", limit: -1, want: 0},
+		{name: "partial", limit: 3, want: 3},
+		{name: "all", limit: 5, want: 5},
+		{name: "more than all", limit: 10, want: 5},
+	}
+	for name, backend := range backends {
+		c := New[int, int](ctx, backend, 0)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, c.Set(ctx, i, i))
+		}
+		for _, tc := range testCases {
+			t.Run(name+"/"+tc.name, func(t *testing.T) {
+				got := c.KeysN(tc.limit)
+				assert.Len(t, got, tc.want)
+				assert.Subset(t, c.Keys(), got)
+			})
+		}
+	}
+}
+
+func TestCache_Scan(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		cache *Cache[int, int]
+		count int
+	}{
+		{name: "simple", cache: NewSimpleCache[int, int](ctx, 0, 0), count: 10},
+		{name: "lru", cache: NewLruCache[int, int](ctx, 1000, 0), count: 7},
+		{name: "keys only", cache: New[int, int](ctx, iCacheOnly[int, *Item[int]]{ICache: simple.NewCache[int, *Item[int]](0)}, 0), count: 10},
+		{name: "count less than one", cache: NewSimpleCache[int, int](ctx, 0, 0), count: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache
+			for i := 0; i < 200; i++ {
+				require.NoError(t, c.Set(ctx, i, i))
+			}
+			seen := make(map[int]int)
+			var cursor uint64
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 1000, "scan does not terminate")
+				keys, next := c.Scan(cursor, tc.count)
+				assert.LessOrEqual(t, len(keys), max(tc.count, 1))
+				for _, key := range keys {
+					seen[key]++
+				}
+				// 遍历期间修改缓存：删除和新增的键不影响一直存在的键
+				if pages == 2 {
+					require.NoError(t, c.Delete(ctx, 199))
+					require.NoError(t, c.Set(ctx, 1000, 1000))
+				}
+				// 读取改变 LRU 的访问顺序，不影响遍历
+				_, _ = c.Get(ctx, pages)
+				if next == 0 {
+					break
+				}
+				cursor = next
+			}
+			for i := 0; i < 199; i++ {
+				assert.Equal(t, 1, seen[i], "key %d", i)
+			}
+		})
+	}
+}
+
+func TestCache_Scan_empty(t *testing.T) {
+	c := NewSimpleCache[int, int](context.Background(), 0, 0)
+	keys, next := c.Scan(0, 10)
+	assert.Empty(t, keys)
+	assert.Zero(t, next)
+}
+
+func TestScanMatch(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name  string
+		match string
+		count int
+
+		want []string
+	}{
+		{name: "prefix", match: "user:*", count: 3, want: []string{"user:0", "user:1", "user:2", "user:3", "user:4", "user:5", "user:6", "user:7", "user:8", "user:9"}},
+		{name: "class", match: "*:[0-2]", count: 2, want: []string{"user:0", "user:1", "user:2", "order:0", "order:1", "order:2"}},
+		{name: "empty match", match: "", count: 100, want: nil},
+		{name: "no match", match: "session:*", count: 5, want: []string{}},
+	}
+	c := NewSimpleCache[string, int](ctx, 0, 0)
+	var all []string
+	for i := 0; i < 10; i++ {
+		for _, prefix := range []string{"user:", "order:"} {
+			key := prefix + strconv.Itoa(i)
+			all = append(all, key)
+			require.NoError(t, c.Set(ctx, key, i))
+		}
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			want := tc.want
+			if want == nil {
+				want = all
+			}
+			got := make([]string, 0)
+			var cursor uint64
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 100, "scan does not terminate")
+				keys, next, err := ScanMatch(ctx, c, cursor, tc.match, tc.count)
+				require.NoError(t, err)
+				assert.LessOrEqual(t, len(keys), tc.count)
+				if next != 0 {
+					assert.NotEmpty(t, keys)
+				}
+				got = append(got, keys...)
+				if next == 0 {
+					break
+				}
+				cursor = next
+			}
+			assert.ElementsMatch(t, want, got)
+		})
+	}
+}
+
+func TestScanMatch_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewSimpleCache[string, int](context.Background(), 0, 0)
+	require.NoError(t, c.Set(context.Background(), "a", 1))
+	keys, next, err := ScanMatch(ctx, c, 0, "*", 10)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, keys)
+	assert.Zero(t, next)
+}
@@ -0,0 +1,383 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharded
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/internal/keyhash"
+)
+
+// DefaultShards 未指定分片数时使用的分片数。
+const DefaultShards = 16
+
+// Option 配置 Cache 的行为。
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithHasher 设置将键映射到分片的哈希函数，默认对字符串和整数使用无内存分配的 FNV-1a。
+func WithHasher[K comparable, V any](hasher func(key K) uint64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.hasher = hasher
+	}
+}
+
+// Cache 将键分散到多个独立加锁的 cache.Cache 上，降低高并发下的锁竞争。
+// 每个分片的容量和过期清理相互独立，容量类淘汰只在单个分片内发生。
+type Cache[K comparable, V any] struct {
+	shards []*cache.Cache[K, V]
+	hasher func(key K) uint64
+}
+
+// New 创建一个包含 n 个分片的缓存，factory 负责创建第 i 个分片，n 小于等于 0 时使用 DefaultShards。
+func New[K comparable, V any](n int, factory func(i int) *cache.Cache[K, V], opts ...Option[K, V]) *Cache[K, V] {
+	if n <= 0 {
+		n = DefaultShards
+	}
+	c := &Cache[K, V]{
+		shards: make([]*cache.Cache[K, V], n),
+		hasher: keyhash.Key[K],
+	}
+	for i := range c.shards {
+		c.shards[i] = factory(i)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewLruCache 创建 n 个分片，每个分片是容量为 capPerShard 的 LRU 缓存。
+func NewLruCache[K comparable, V any](ctx context.Context, n int, capPerShard int, interval time.Duration, opts ...Option[K, V]) *Cache[K, V] {
+	return New[K, V](n, func(int) *cache.Cache[K, V] {
+		return cache.NewLruCache[K, V](ctx, capPerShard, interval)
+	}, opts...)
+}
+
+// Shard 返回 key 所在的分片。
+func (c *Cache[K, V]) Shard(key K) *cache.Cache[K, V] {
+	return c.shards[c.hasher(key)%uint64(len(c.shards))]
+}
+
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return c.Shard(key).Get(ctx, key)
+}
+
+func (c *Cache[K, V]) Peek(ctx context.Context, key K) (V, error) {
+	return c.Shard(key).Peek(ctx, key)
+}
+
+func (c *Cache[K, V]) EntryStats(ctx context.Context, key K) (cache.EntryStats, error) {
+	return c.Shard(key).EntryStats(ctx, key)
+}
+
+func (c *Cache[K, V]) Pin(ctx context.Context, key K) error {
+	return c.Shard(key).Pin(ctx, key)
+}
+
+func (c *Cache[K, V]) Unpin(ctx context.Context, key K) error {
+	return c.Shard(key).Unpin(ctx, key)
+}
+
+func (c *Cache[K, V]) Contains(ctx context.Context, key K) bool {
+	return c.Shard(key).Contains(ctx, key)
+}
+
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V, opts ...cache.ItemOption) error {
+	return c.Shard(key).Set(ctx, key, value, opts...)
+}
+
+func (c *Cache[K, V]) SetNX(ctx context.Context, key K, value V, opts ...cache.ItemOption) (bool, error) {
+	return c.Shard(key).SetNX(ctx, key, value, opts...)
+}
+
+func (c *Cache[K, V]) GetOrSet(ctx context.Context, key K, value V, opts ...cache.ItemOption) (V, bool, error) {
+	return c.Shard(key).GetOrSet(ctx, key, value, opts...)
+}
+
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	return c.Shard(key).Delete(ctx, key)
+}
+
+// MDelete 按分片分组后在每个分片上各加锁一次，只保证单个分片内的原子性，返回实际存在的键的数量。
+func (c *Cache[K, V]) MDelete(ctx context.Context, keys ...K) (int, error) {
+	total := 0
+	for i, group := range c.group(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		n, err := c.shards[i].MDelete(ctx, group...)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (c *Cache[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) error {
+	return c.Shard(key).Expire(ctx, key, ttl)
+}
+
+func (c *Cache[K, V]) Touch(ctx context.Context, key K) error {
+	return c.Shard(key).Touch(ctx, key)
+}
+
+func (c *Cache[K, V]) Persist(ctx context.Context, key K) (bool, error) {
+	return c.Shard(key).Persist(ctx, key)
+}
+
+// ExpireMulti 按分片分组后在每个分片上各加锁一次。
+func (c *Cache[K, V]) ExpireMulti(ctx context.Context, keys []K, ttl time.Duration) (int, error) {
+	total := 0
+	for i, group := range c.group(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		n, err := c.shards[i].ExpireMulti(ctx, group, ttl)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// SetMulti 按分片分组后在每个分片上各加锁一次，只保证单个分片内的原子性。
+func (c *Cache[K, V]) SetMulti(ctx context.Context, entries []cache.Entry[K, V]) error {
+	groups := make([][]cache.Entry[K, V], len(c.shards))
+	for _, e := range entries {
+		i := c.hasher(e.Key) % uint64(len(c.shards))
+		groups[i] = append(groups[i], e)
+	}
+	for i, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if err := c.shards[i].SetMulti(ctx, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MSet 按分片分组后在每个分片上各加锁一次，opts 作用于每一个元素。
+func (c *Cache[K, V]) MSet(ctx context.Context, entries map[K]V, opts ...cache.ItemOption) error {
+	list := make([]cache.Entry[K, V], 0, len(entries))
+	for key, value := range entries {
+		list = append(list, cache.Entry[K, V]{Key: key, Value: value, Opts: opts})
+	}
+	return c.SetMulti(ctx, list)
+}
+
+// MGet 按分片分组后在每个分片上各加锁一次，返回所有分片中存在且未过期的键和值。
+func (c *Cache[K, V]) MGet(ctx context.Context, keys ...K) (map[K]V, error) {
+	values := make(map[K]V, len(keys))
+	for i, group := range c.group(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		got, err := c.shards[i].MGet(ctx, group...)
+		if err != nil {
+			return nil, err
+		}
+		for key, v := range got {
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+// Len 返回所有分片中元素数量之和。
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Clear 依次清空每个分片并返回删除的总数，分片之间不是原子的，遇到错误时停止。
+func (c *Cache[K, V]) Clear(ctx context.Context) (int, error) {
+	total := 0
+	for _, shard := range c.shards {
+		n, err := shard.Clear(ctx)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Cost 返回所有分片中元素的总成本之和，每个分片分别按自己的 WithMaxCost 限制淘汰。
+func (c *Cache[K, V]) Cost() int64 {
+	var n int64
+	for _, shard := range c.shards {
+		n += shard.Cost()
+	}
+	return n
+}
+
+// Bytes 返回所有分片中 WithMaxBytes 估算的总字节数之和，每个分片分别按自己的限制淘汰。
+func (c *Cache[K, V]) Bytes() int64 {
+	var n int64
+	for _, shard := range c.shards {
+		n += shard.Bytes()
+	}
+	return n
+}
+
+// CountValid 返回所有分片中未过期的元素数量之和。
+func (c *Cache[K, V]) CountValid(ctx context.Context) int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.CountValid(ctx)
+	}
+	return n
+}
+
+// Snapshot 合并每个分片的快照，各分片分别加锁，因此结果不是整个缓存在同一时刻的状态。
+func (c *Cache[K, V]) Snapshot(ctx context.Context) map[K]V {
+	snapshot := make(map[K]V)
+	for _, shard := range c.shards {
+		maps.Copy(snapshot, shard.Snapshot(ctx))
+	}
+	return snapshot
+}
+
+// Items 依次返回每个分片中的元素，各分片分别加锁。
+func (c *Cache[K, V]) Items(ctx context.Context) []cache.ItemInfo[K, V] {
+	var items []cache.ItemInfo[K, V]
+	for _, shard := range c.shards {
+		items = append(items, shard.Items(ctx)...)
+	}
+	return items
+}
+
+// Keys 依次返回每个分片中的键。
+func (c *Cache[K, V]) Keys() []K {
+	var keys []K
+	for _, shard := range c.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	if keys == nil {
+		keys = make([]K, 0)
+	}
+	return keys
+}
+
+// KeysN 依次从每个分片中取键，返回至多 limit 个键。
+func (c *Cache[K, V]) KeysN(limit int) []K {
+	keys := make([]K, 0)
+	for _, shard := range c.shards {
+		if len(keys) >= limit {
+			break
+		}
+		keys = append(keys, shard.KeysN(limit-len(keys))...)
+	}
+	return keys
+}
+
+// Scan 与 cache.Cache.Scan 的语义相同。各分片使用相同的哈希值游标，Scan 合并各分片的结果后
+// 只返回所有分片都已经遍历过的哈希值范围内的键。
+func (c *Cache[K, V]) Scan(cursor uint64, count int) (keys []K, next uint64) {
+	count = max(count, 1)
+	type entry struct {
+		key  K
+		hash uint64
+	}
+	var (
+		entries  []entry
+		bound    uint64
+		hasBound bool
+	)
+	for _, shard := range c.shards {
+		got, n := shard.Scan(cursor, count)
+		for _, key := range got {
+			entries = append(entries, entry{key: key, hash: keyhash.Key(key)})
+		}
+		if n != 0 && (!hasBound || n < bound) {
+			bound, hasBound = n, true
+		}
+	}
+	// 哈希值不小于 bound 的键在某些分片中还没有被遍历到，留到下一次返回
+	if hasBound {
+		entries = slices.DeleteFunc(entries, func(e entry) bool { return e.hash >= bound })
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return cmp.Compare(a.hash, b.hash) })
+	next = bound
+	if len(entries) > count {
+		next = entries[count].hash
+		entries = entries[:count]
+	}
+	keys = make([]K, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.key)
+	}
+	return keys, next
+}
+
+// DeleteExpired 依次清理每个分片，返回删除的元素总数。
+func (c *Cache[K, V]) DeleteExpired(ctx context.Context) int {
+	removed := 0
+	for _, shard := range c.shards {
+		removed += shard.DeleteExpired(ctx)
+	}
+	return removed
+}
+
+// Close 关闭所有分片，返回各分片关闭时的错误。
+func (c *Cache[K, V]) Close() error {
+	errs := make([]error, 0, len(c.shards))
+	for _, shard := range c.shards {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Stats 返回所有分片统计信息之和。
+func (c *Cache[K, V]) Stats() cache.Stats {
+	var s cache.Stats
+	for _, shard := range c.shards {
+		st := shard.Stats()
+		s.Hits += st.Hits
+		s.Misses += st.Misses
+		s.Sets += st.Sets
+		s.Deletes += st.Deletes
+		s.Evictions += st.Evictions
+		s.Expired += st.Expired
+		s.GhostHits += st.GhostHits
+		s.Loads += st.Loads
+		s.CoalescedLoads += st.CoalescedLoads
+		s.MaxCoalesced = max(s.MaxCoalesced, st.MaxCoalesced)
+	}
+	return s
+}
+
+// group 将 keys 按所属分片分组，结果的下标与分片下标一致。
+func (c *Cache[K, V]) group(keys []K) [][]K {
+	groups := make([][]K, len(c.shards))
+	for _, key := range keys {
+		i := c.hasher(key) % uint64(len(c.shards))
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharded
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	c := NewLruCache[string, int](context.Background(), 0, 10, time.Minute)
+	assert.Len(t, c.shards, DefaultShards)
+
+	c = New[string, int](4, func(int) *cache.Cache[string, int] {
+		return cache.NewSimpleCache[string, int](context.Background(), 0, time.Minute)
+	}, WithHasher[string, int](func(string) uint64 { return 2 }))
+	assert.Len(t, c.shards, 4)
+	assert.Same(t, c.shards[2], c.Shard("any"))
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 8, 100, time.Minute)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Set(ctx, strconv.Itoa(i), i))
+	}
+	assert.Len(t, c.Keys(), 100)
+
+	got, err := c.Get(ctx, "42")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, got)
+	_, err = c.Get(ctx, "missing")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+
+	ok, err := c.SetNX(ctx, "42", 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	actual, loaded, err := c.GetOrSet(ctx, "42", 0)
+	assert.NoError(t, err)
+	assert.True(t, loaded)
+	assert.Equal(t, 42, actual)
+
+	assert.NoError(t, c.Delete(ctx, "42"))
+	assert.Equal(t, cacheError.ErrNoKey, c.Delete(ctx, "42"))
+
+	n, err := c.ExpireMulti(ctx, []string{"1", "2", "3", "42"}, -time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	c.DeleteExpired(ctx)
+	assert.Len(t, c.Keys(), 96)
+
+	require.NoError(t, c.SetMulti(ctx, []cache.Entry[string, int]{
+		{Key: "a", Value: 1},
+		{Key: "b", Value: 2, Opts: []cache.ItemOption{cache.WithExpiration(time.Minute)}},
+	}))
+	assert.Len(t, c.Keys(), 98)
+
+	require.NoError(t, c.MSet(ctx, map[string]int{"c": 3, "d": 4}, cache.WithExpiration(time.Minute)))
+	assert.Len(t, c.Keys(), 100)
+
+	deleted, err := c.MDelete(ctx, "c", "d", "42")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.Len(t, c.Keys(), 98)
+
+	values, err := c.MGet(ctx, "a", "b", "42", "50")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "50": 50}, values)
+
+	assert.Equal(t, cache.Stats{
+		Hits:    5,
+		Misses:  2,
+		Sets:    104,
+		Deletes: 3,
+		Expired: 3,
+	}, c.Stats())
+}
+
+func TestCache_Clear(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 4, 100, time.Minute)
+	for i := 0; i < 50; i++ {
+		require.NoError(t, c.Set(ctx, strconv.Itoa(i), i))
+	}
+	n, err := c.Clear(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 50, n)
+	assert.Zero(t, c.Len())
+}
+
+func TestCache_Scan(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 8, 100, time.Minute)
+	for i := 0; i < 300; i++ {
+		require.NoError(t, c.Set(ctx, strconv.Itoa(i), i))
+	}
+	assert.Len(t, c.KeysN(10), 10)
+	assert.Len(t, c.KeysN(1000), 300)
+
+	seen := make(map[string]int)
+	var cursor uint64
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 1000, "scan does not terminate")
+		keys, next := c.Scan(cursor, 16)
+		assert.LessOrEqual(t, len(keys), 16)
+		for _, key := range keys {
+			seen[key]++
+		}
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	assert.Len(t, seen, 300)
+	for key, n := range seen {
+		assert.Equal(t, 1, n, "key %s", key)
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[int, int](ctx, 4, 4000, time.Minute)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := g*500 + i
+				assert.NoError(t, c.Set(ctx, key, key))
+				got, err := c.Get(ctx, key)
+				assert.NoError(t, err)
+				assert.Equal(t, key, got)
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Len(t, c.Keys(), 4000)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"sync"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// ConcurrentCache 是基于 sync.Map 的简单缓存，所有方法都可以并发调用，读操作不需要加锁。
+// 适用于读多写少、键集合相对稳定的场景。它同样实现了 types.ICache，可以作为 cache.New 的后端使用。
+type ConcurrentCache[K comparable, V any] struct {
+	cache sync.Map
+}
+
+func NewConcurrentCache[K comparable, V any]() *ConcurrentCache[K, V] {
+	return &ConcurrentCache[K, V]{}
+}
+
+func (c *ConcurrentCache[K, V]) Set(_ context.Context, key K, value V) error {
+	c.cache.Store(key, value)
+	return nil
+}
+
+func (c *ConcurrentCache[K, V]) Get(_ context.Context, key K) (v V, err error) {
+	value, ok := c.cache.Load(key)
+	if !ok {
+		return v, cacheError.ErrNoKey
+	}
+	return value.(V), nil
+}
+
+func (c *ConcurrentCache[K, V]) Delete(_ context.Context, key K) error {
+	if _, ok := c.cache.LoadAndDelete(key); ok {
+		return nil
+	}
+	return cacheError.ErrNoKey
+}
+
+func (c *ConcurrentCache[K, V]) Keys() []K {
+	keys := make([]K, 0)
+	c.cache.Range(func(key, _ any) bool {
+		keys = append(keys, key.(K))
+		return true
+	})
+	return keys
+}
+
+// RangeKeys 实现了 types.Ranger，按与 Keys 相同的顺序遍历键。
+func (c *ConcurrentCache[K, V]) RangeKeys(fn func(key K) bool) {
+	c.cache.Range(func(key, _ any) bool {
+		return fn(key.(K))
+	})
+}
+
+// Len 返回元素的数量。sync.Map 不记录元素数量，Len 需要遍历所有元素，但不会分配内存。
+func (c *ConcurrentCache[K, V]) Len() int {
+	n := 0
+	c.cache.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Contains 实现了 types.Container，报告 key 是否存在。
+func (c *ConcurrentCache[K, V]) Contains(key K) bool {
+	_, ok := c.cache.Load(key)
+	return ok
+}
+
+// Clear 实现了 types.Clearer，删除所有元素。与其他方法并发调用时，期间写入的元素可能不会被删除。
+func (c *ConcurrentCache[K, V]) Clear() {
+	c.cache.Range(func(key, _ any) bool {
+		c.cache.Delete(key)
+		return true
+	})
+}
+
+// ReadOnlyGet 实现了 types.ReadOnlyGetter，Get 不会修改内部状态。
+func (c *ConcurrentCache[K, V]) ReadOnlyGet() bool {
+	return true
+}
+
+// Stats 实现了 types.Inspector，与 Len 一样需要遍历所有元素。
+func (c *ConcurrentCache[K, V]) Stats() types.BackendStats {
+	return types.BackendStats{Len: c.Len()}
+}
+
+// OldestEntry 实现了 types.Inspector。sync.Map 不记录元素的顺序，总是返回 false。
+func (c *ConcurrentCache[K, V]) OldestEntry() (key K, value V, ok bool) {
+	return key, value, false
+}
+
+// Config 实现了 types.Inspector，ConcurrentCache 没有容量限制。
+func (c *ConcurrentCache[K, V]) Config() types.BackendConfig {
+	return types.BackendConfig{Policy: "concurrent"}
+}
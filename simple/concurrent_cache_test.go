@@ -0,0 +1,74 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewConcurrentCache[int, string]()
+
+	_, err := cache.Get(ctx, 1)
+	assert.Equal(t, cacheError.ErrNoKey, err)
+
+	assert.NoError(t, cache.Set(ctx, 1, "a"))
+	assert.NoError(t, cache.Set(ctx, 2, "b"))
+	assert.NoError(t, cache.Set(ctx, 1, "c"))
+	got, err := cache.Get(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "c", got)
+	assert.ElementsMatch(t, []int{1, 2}, cache.Keys())
+
+	assert.NoError(t, cache.Delete(ctx, 1))
+	assert.Equal(t, cacheError.ErrNoKey, cache.Delete(ctx, 1))
+	assert.Equal(t, []int{2}, cache.Keys())
+	assert.True(t, cache.ReadOnlyGet())
+}
+
+func TestConcurrentCache_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	cache := NewConcurrentCache[int, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := g*100 + i
+				assert.NoError(t, cache.Set(ctx, key, key))
+				got, err := cache.Get(ctx, key)
+				assert.NoError(t, err)
+				assert.Equal(t, key, got)
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Len(t, cache.Keys(), 800)
+}
+
+func TestConcurrentCache_Conformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return NewConcurrentCache[string, string]()
+	}, cachetest.WithConcurrency())
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 
 	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
 )
 
 type Cache[K comparable, V any] struct {
@@ -61,3 +62,48 @@ func (c *Cache[K, V]) Keys() []K {
 	}
 	return keys
 }
+
+// RangeKeys 实现了 types.Ranger，按与 Keys 相同的顺序遍历键。
+func (c *Cache[K, V]) RangeKeys(fn func(key K) bool) {
+	for key := range c.cache {
+		if !fn(key) {
+			return
+		}
+	}
+}
+
+// Len 返回元素的数量。
+func (c *Cache[K, V]) Len() int {
+	return len(c.cache)
+}
+
+// Contains 实现了 types.Container，报告 key 是否存在。
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.cache[key]
+	return ok
+}
+
+// Clear 实现了 types.Clearer，删除所有元素。
+func (c *Cache[K, V]) Clear() {
+	clear(c.cache)
+}
+
+// ReadOnlyGet 实现了 types.ReadOnlyGetter，Get 不会修改内部状态。
+func (c *Cache[K, V]) ReadOnlyGet() bool {
+	return true
+}
+
+// Stats 实现了 types.Inspector，简单缓存不会淘汰元素。
+func (c *Cache[K, V]) Stats() types.BackendStats {
+	return types.BackendStats{Len: len(c.cache)}
+}
+
+// OldestEntry 实现了 types.Inspector。简单缓存不记录元素的顺序，总是返回 false。
+func (c *Cache[K, V]) OldestEntry() (key K, value V, ok bool) {
+	return key, value, false
+}
+
+// Config 实现了 types.Inspector，简单缓存没有容量限制。
+func (c *Cache[K, V]) Config() types.BackendConfig {
+	return types.BackendConfig{Policy: "simple"}
+}
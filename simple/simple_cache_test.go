@@ -18,7 +18,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
 	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -160,7 +162,7 @@ func TestCache_Delete(t *testing.T) {
 			},
 			keys:     1,
 			wantKeys: []int{},
-			wantErr:  nil,
+			wantErr:  cacheError.ErrNoKey,
 		},
 		{
 			name: "Delete non-existent key from the empty cache",
@@ -171,7 +173,7 @@ func TestCache_Delete(t *testing.T) {
 			},
 			keys:     2,
 			wantKeys: []int{1},
-			wantErr:  nil,
+			wantErr:  cacheError.ErrNoKey,
 		},
 		{
 			name: "Delete existing keys from the cache",
@@ -196,3 +198,9 @@ func TestCache_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestConformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return NewCache[string, string](0)
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+// Sizer 估算值占用的字节数。
+type Sizer[V any] func(value V) int
+
+// WithMaxBytes 按值的总字节数限制缓存容量：写入之后总字节数超过 max 时，按后端的淘汰策略淘汰元素，
+// 直到总字节数不超过 max。值的大小由 sizer 估算，sizer 为 nil 时 V 必须是 string 或 []byte，使用 len 作为大小；
+// 通过 WithCost 写入的元素以其成本作为大小。单个超过 max 的值写入之后会被立即淘汰。不能与 WithMaxCost 同时使用。
+//
+// 后端需要实现 types.Evicter 和 types.EvictionNotifier，内置的 LRU、FIFO、SLRU 和随机淘汰后端都满足要求。
+// 后端自身的元素数量限制仍然生效，只需要按字节数限制时把元素数量设置得足够大即可。
+func WithMaxBytes[K comparable, V any](max int64, sizer Sizer[V]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxBytes = max
+		o.sizer = sizer
+		if sizer == nil {
+			o.sizer = defaultSizer[V]()
+		}
+	}
+}
+
+// defaultSizer 返回 string 和 []byte 的默认 Sizer，V 为其他类型时返回 nil。
+func defaultSizer[V any]() Sizer[V] {
+	var zero V
+	switch any(zero).(type) {
+	case string:
+		return func(v V) int { return len(any(v).(string)) }
+	case []byte:
+		return func(v V) int { return len(any(v).([]byte)) }
+	}
+	return nil
+}
+
+// Bytes 返回 WithMaxBytes 估算的当前总字节数，未设置 WithMaxBytes 时返回 0。
+func (c *Cache[K, V]) Bytes() int64 {
+	if c.opts.maxBytes <= 0 {
+		return 0
+	}
+	return c.Cost()
+}
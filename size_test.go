@@ -0,0 +1,156 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxBytes(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		ops  func(t *testing.T, c *Cache[string, string])
+
+		wantKeys  []string
+		wantBytes int64
+	}{
+		{
+			name: "within budget",
+			ops: func(t *testing.T, c *Cache[string, string]) {
+				require.NoError(t, c.Set(ctx, "a", strings.Repeat("x", 4)))
+				require.NoError(t, c.Set(ctx, "b", strings.Repeat("x", 6)))
+			},
+			wantKeys:  []string{"a", "b"},
+			wantBytes: 10,
+		},
+		{
+			name: "evict least recently used until under budget",
+			ops: func(t *testing.T, c *Cache[string, string]) {
+				require.NoError(t, c.Set(ctx, "a", strings.Repeat("x", 4)))
+				require.NoError(t, c.Set(ctx, "b", strings.Repeat("x", 3)))
+				require.NoError(t, c.Set(ctx, "c", strings.Repeat("x", 3)))
+				_, err := c.Get(ctx, "a")
+				require.NoError(t, err)
+				require.NoError(t, c.Set(ctx, "d", strings.Repeat("x", 5)))
+			},
+			wantKeys:  []string{"a", "d"},
+			wantBytes: 9,
+		},
+		{
+			name: "overwrite replaces size",
+			ops: func(t *testing.T, c *Cache[string, string]) {
+				require.NoError(t, c.Set(ctx, "a", strings.Repeat("x", 8)))
+				require.NoError(t, c.Set(ctx, "a", strings.Repeat("x", 2)))
+				require.NoError(t, c.Set(ctx, "b", strings.Repeat("x", 8)))
+			},
+			wantKeys:  []string{"a", "b"},
+			wantBytes: 10,
+		},
+		{
+			name: "value larger than budget",
+			ops: func(t *testing.T, c *Cache[string, string]) {
+				require.NoError(t, c.Set(ctx, "a", strings.Repeat("x", 2)))
+				require.NoError(t, c.Set(ctx, "big", strings.Repeat("x", 11)))
+			},
+			wantKeys:  []string{},
+			wantBytes: 0,
+		},
+		{
+			name: "delete and expire release bytes",
+			ops: func(t *testing.T, c *Cache[string, string]) {
+				require.NoError(t, c.Set(ctx, "a", strings.Repeat("x", 4)))
+				require.NoError(t, c.Set(ctx, "b", strings.Repeat("x", 3), WithExpiration(-time.Second)))
+				require.NoError(t, c.Set(ctx, "c", strings.Repeat("x", 2)))
+				require.NoError(t, c.Delete(ctx, "a"))
+				c.DeleteExpired(ctx)
+			},
+			wantKeys:  []string{"c"},
+			wantBytes: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewLruCache[string, string](ctx, 100, 0, WithMaxBytes[string, string](10, nil))
+			tc.ops(t, c)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+			assert.Equal(t, tc.wantBytes, c.Bytes())
+		})
+	}
+}
+
+func TestWithMaxBytes_sizer(t *testing.T) {
+	ctx := context.Background()
+	c := NewFifoCache[int, []int](ctx, 100, 0, WithMaxBytes[int, []int](64, func(v []int) int { return 8 * len(v) }))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.Set(ctx, i, make([]int, 2)))
+	}
+	// FIFO 淘汰最早写入的元素
+	assert.Equal(t, []int{1, 2, 3, 4}, c.Keys())
+	assert.Equal(t, int64(64), c.Bytes())
+}
+
+func TestWithMaxBytes_invalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		newCache func() (*Cache[string, int], error)
+		wantErr  string
+	}{
+		{
+			name: "negative",
+			newCache: func() (*Cache[string, int], error) {
+				return NewWithConfig(context.Background(), Config[string, int]{
+					Backend: simple.NewCache[string, *Item[int]](10),
+					Options: []Option[string, int]{WithMaxBytes[string, int](-1, func(int) int { return 8 })},
+				})
+			},
+			wantErr: "WithMaxBytes must not be negative, got -1",
+		},
+		{
+			name: "no sizer",
+			newCache: func() (*Cache[string, int], error) {
+				return NewWithConfig(context.Background(), Config[string, int]{
+					Backend: simple.NewCache[string, *Item[int]](10),
+					Options: []Option[string, int]{WithMaxBytes[string, int](10, nil)},
+				})
+			},
+			wantErr: "WithMaxBytes requires a Sizer for value type int",
+		},
+		{
+			name: "backend without eviction",
+			newCache: func() (*Cache[string, int], error) {
+				return NewWithConfig(context.Background(), Config[string, int]{
+					Backend: simple.NewCache[string, *Item[int]](10),
+					Options: []Option[string, int]{WithMaxBytes[string, int](10, func(int) int { return 8 })},
+				})
+			},
+			wantErr: "WithMaxBytes requires a backend implementing types.Evicter and types.EvictionNotifier",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := tc.newCache()
+			assert.Nil(t, c)
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slru
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// DefaultProtectedRatio 受保护段默认占总容量的比例。
+const DefaultProtectedRatio = 0.8
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	protected bool
+}
+
+// Option 配置 Cache 的行为。
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithOnEvicted 设置元素因容量不足被淘汰时的回调，显式调用 Delete 删除的元素不会触发回调。
+func WithOnEvicted[K comparable, V any](fn func(key K, value V)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.onEvicted = fn
+	}
+}
+
+// SetOnEvicted 替换淘汰回调，实现了 types.EvictionNotifier。
+func (c *Cache[K, V]) SetOnEvicted(fn func(key K, value V)) {
+	c.onEvicted = fn
+}
+
+// NewCache 创建一个分段 LRU 缓存，protectedRatio 为受保护段占总容量的比例，取值范围 (0, 1)，
+// 超出范围时使用 DefaultProtectedRatio。
+// 新写入的元素进入试用段，在试用段中被再次访问后晋升到受保护段，
+// 因此只被访问一次的元素不会挤掉热点数据。
+func NewCache[K comparable, V any](cap int, protectedRatio float64, opts ...Option[K, V]) *Cache[K, V] {
+	if protectedRatio <= 0 || protectedRatio >= 1 {
+		protectedRatio = DefaultProtectedRatio
+	}
+	protectedCap := int(float64(cap) * protectedRatio)
+	if protectedCap >= cap && cap > 0 {
+		protectedCap = cap - 1
+	}
+	c := &Cache[K, V]{
+		maxEntries:   cap,
+		protectedCap: protectedCap,
+		cache:        make(map[K]*list.Element, cap),
+		probation:    list.New(),
+		protected:    list.New(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type Cache[K comparable, V any] struct {
+	maxEntries   int
+	protectedCap int
+	cache        map[K]*list.Element
+	// 试用段，新元素从这里进入，淘汰也优先发生在这里
+	probation *list.List
+	// 受保护段，保存至少被访问过两次的元素
+	protected *list.List
+	onEvicted func(key K, value V)
+	evictions uint64
+}
+
+func (c *Cache[K, V]) Set(_ context.Context, key K, value V) error {
+	if e, ok := c.cache[key]; ok {
+		// 元素存在，更新值并视为一次访问
+		e.Value.(*entry[K, V]).value = value
+		c.access(e)
+		return nil
+	}
+	// 元素不存在，放入试用段
+	c.cache[key] = c.probation.PushFront(&entry[K, V]{
+		key:   key,
+		value: value,
+	})
+	if len(c.cache) > c.maxEntries {
+		c.EvictOne()
+	}
+	return nil
+}
+
+func (c *Cache[K, V]) Get(_ context.Context, key K) (v V, err error) {
+	if e, ok := c.cache[key]; ok {
+		// access 可能会交换节点保存的值，需要先取出元素
+		en := e.Value.(*entry[K, V])
+		c.access(e)
+		return en.value, nil
+	}
+	return v, cacheError.ErrNoKey
+}
+
+// Peek 实现了 types.Peeker，返回 key 对应的值，不会将元素晋升到受保护段或调整访问顺序。
+func (c *Cache[K, V]) Peek(_ context.Context, key K) (v V, err error) {
+	if e, ok := c.cache[key]; ok {
+		return e.Value.(*entry[K, V]).value, nil
+	}
+	return v, cacheError.ErrNoKey
+}
+
+func (c *Cache[K, V]) Delete(_ context.Context, key K) error {
+	if e, ok := c.cache[key]; ok {
+		c.segment(e).Remove(e)
+		delete(c.cache, key)
+		return nil
+	}
+	return cacheError.ErrNoKey
+}
+
+// Keys 按照从冷到热的顺序返回所有键：先是试用段，再是受保护段，段内由最久未使用到最近使用。
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.cache))
+	for _, l := range []*list.List{c.probation, c.protected} {
+		for e := l.Back(); e != nil; e = e.Prev() {
+			keys = append(keys, e.Value.(*entry[K, V]).key)
+		}
+	}
+	return keys
+}
+
+// RangeKeys 实现了 types.Ranger，按与 Keys 相同的顺序遍历键。
+func (c *Cache[K, V]) RangeKeys(fn func(key K) bool) {
+	for _, l := range []*list.List{c.probation, c.protected} {
+		for e := l.Back(); e != nil; e = e.Prev() {
+			if !fn(e.Value.(*entry[K, V]).key) {
+				return
+			}
+		}
+	}
+}
+
+// Len 返回元素的数量。
+func (c *Cache[K, V]) Len() int {
+	return len(c.cache)
+}
+
+// Contains 实现了 types.Container，报告 key 是否存在，不会晋升或移动元素。
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.cache[key]
+	return ok
+}
+
+// Clear 实现了 types.Clearer，删除两个段中的所有元素，不会触发淘汰回调。
+func (c *Cache[K, V]) Clear() {
+	clear(c.cache)
+	c.probation.Init()
+	c.protected.Init()
+}
+
+func (c *Cache[K, V]) segment(e *list.Element) *list.List {
+	if e.Value.(*entry[K, V]).protected {
+		return c.protected
+	}
+	return c.probation
+}
+
+// access 处理一次命中：受保护段内的元素移到队首，试用段内的元素晋升到受保护段。
+func (c *Cache[K, V]) access(e *list.Element) {
+	en := e.Value.(*entry[K, V])
+	if en.protected {
+		c.protected.MoveToFront(e)
+		return
+	}
+	if c.protectedCap <= 0 {
+		c.probation.MoveToFront(e)
+		return
+	}
+	if c.protected.Len() < c.protectedCap {
+		c.probation.Remove(e)
+		en.protected = true
+		c.cache[en.key] = c.protected.PushFront(en)
+		return
+	}
+	// 受保护段已满，与其中最久未使用的元素交换位置：该元素降级回试用段。
+	// 直接交换两个链表节点保存的值，避免分配新的节点。
+	back := c.protected.Back()
+	demoted := back.Value.(*entry[K, V])
+	e.Value, back.Value = demoted, en
+	en.protected, demoted.protected = true, false
+	c.cache[en.key], c.cache[demoted.key] = back, e
+	c.protected.MoveToFront(back)
+	c.probation.MoveToFront(e)
+}
+
+// EvictOne 实现了 types.Evicter，淘汰试用段中最久未使用的元素，试用段为空时才淘汰受保护段中的元素。
+func (c *Cache[K, V]) EvictOne() (key K, ok bool) {
+	l := c.probation
+	if l.Len() == 0 {
+		l = c.protected
+	}
+	e := l.Back()
+	if e == nil {
+		return key, false
+	}
+	l.Remove(e)
+	en := e.Value.(*entry[K, V])
+	delete(c.cache, en.key)
+	c.evictions++
+	if c.onEvicted != nil {
+		c.onEvicted(en.key, en.value)
+	}
+	return en.key, true
+}
+
+// Stats 实现了 types.Inspector。
+func (c *Cache[K, V]) Stats() types.BackendStats {
+	return types.BackendStats{Len: len(c.cache), Evictions: c.evictions}
+}
+
+// OldestEntry 实现了 types.Inspector，返回与 EvictOne 相同的元素：
+// 试用段中最久未使用的元素，试用段为空时为受保护段中最久未使用的元素。
+func (c *Cache[K, V]) OldestEntry() (key K, value V, ok bool) {
+	e := c.probation.Back()
+	if e == nil {
+		e = c.protected.Back()
+	}
+	if e == nil {
+		return key, value, false
+	}
+	en := e.Value.(*entry[K, V])
+	return en.key, en.value, true
+}
+
+// Config 实现了 types.Inspector，Extra 中的 protected_capacity 为受保护段的容量。
+func (c *Cache[K, V]) Config() types.BackendConfig {
+	return types.BackendConfig{
+		Policy:   "slru",
+		Capacity: c.maxEntries,
+		Extra:    map[string]string{"protected_capacity": strconv.Itoa(c.protectedCap)},
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slru
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCache(t *testing.T) {
+	testCases := []struct {
+		name             string
+		cap              int
+		ratio            float64
+		wantProtectedCap int
+	}{
+		{
+			name:             "custom ratio",
+			cap:              10,
+			ratio:            0.5,
+			wantProtectedCap: 5,
+		},
+		{
+			name:             "invalid ratio falls back to default",
+			cap:              10,
+			ratio:            1.5,
+			wantProtectedCap: 8,
+		},
+		{
+			name:             "probation keeps at least one slot",
+			cap:              2,
+			ratio:            0.99,
+			wantProtectedCap: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := NewCache[string, int](tc.cap, tc.ratio)
+			assert.Equal(t, tc.cap, cache.maxEntries)
+			assert.Equal(t, tc.wantProtectedCap, cache.protectedCap)
+			assert.NotNil(t, cache.cache)
+		})
+	}
+}
+
+func TestCache_Set(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache func(t *testing.T) *Cache[string, int]
+		key   string
+		value int
+
+		wantKeys  []string
+		wantError error
+	}{
+		{
+			name: "set a new key",
+			cache: func(_ *testing.T) *Cache[string, int] {
+				return NewCache[string, int](2, 0.5)
+			},
+			key:      "1",
+			value:    1,
+			wantKeys: []string{"1"},
+		},
+		{
+			name: "set a existing key promotes it",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](4, 0.5)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				assert.NoError(t, cache.Set(context.Background(), "2", 2))
+				return cache
+			},
+			key:      "1",
+			value:    10,
+			wantKeys: []string{"2", "1"},
+		},
+		{
+			name: "one-hit-wonders are evicted before protected keys",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](2, 0.5)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				_, err := cache.Get(context.Background(), "1")
+				assert.NoError(t, err)
+				assert.NoError(t, cache.Set(context.Background(), "2", 2))
+				return cache
+			},
+			key:      "3",
+			value:    3,
+			wantKeys: []string{"3", "1"},
+		},
+		{
+			name: "evict from protected segment when probation is empty",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](1, 0.5)
+				return cache
+			},
+			key:      "1",
+			value:    1,
+			wantKeys: []string{"1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache(t)
+			err := cache.Set(context.Background(), tc.key, tc.value)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantKeys, cache.Keys())
+			got, err := cache.Get(context.Background(), tc.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.value, got)
+		})
+	}
+}
+
+func TestCache_Get(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cache     func(t *testing.T) *Cache[string, int]
+		key       string
+		wantValue int
+		wantKeys  []string
+		wantError error
+	}{
+		{
+			name: "get a probationary key promotes it",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](4, 0.5)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				assert.NoError(t, cache.Set(context.Background(), "2", 2))
+				return cache
+			},
+			key:       "1",
+			wantValue: 1,
+			wantKeys:  []string{"2", "1"},
+		},
+		{
+			name: "promotion demotes the coldest protected key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](4, 0.25)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				assert.NoError(t, cache.Set(context.Background(), "2", 2))
+				_, err := cache.Get(context.Background(), "1")
+				assert.NoError(t, err)
+				return cache
+			},
+			key:       "2",
+			wantValue: 2,
+			wantKeys:  []string{"1", "2"},
+		},
+		{
+			name: "get a non-existing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](1, 0.5)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				return cache
+			},
+			key:       "2",
+			wantKeys:  []string{"1"},
+			wantError: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache(t)
+			got, err := cache.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantValue, got)
+			assert.Equal(t, tc.wantKeys, cache.Keys())
+		})
+	}
+}
+
+func TestCache_Delete(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cache     func(t *testing.T) *Cache[string, int]
+		key       string
+		wantKeys  []string
+		wantError error
+	}{
+		{
+			name: "delete a probationary key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](2, 0.5)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				return cache
+			},
+			key:      "1",
+			wantKeys: []string{},
+		},
+		{
+			name: "delete a protected key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](2, 0.5)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				_, err := cache.Get(context.Background(), "1")
+				assert.NoError(t, err)
+				return cache
+			},
+			key:      "1",
+			wantKeys: []string{},
+		},
+		{
+			name: "delete a non-existing key",
+			cache: func(t *testing.T) *Cache[string, int] {
+				cache := NewCache[string, int](2, 0.5)
+				assert.NoError(t, cache.Set(context.Background(), "1", 1))
+				return cache
+			},
+			key:       "2",
+			wantKeys:  []string{"1"},
+			wantError: cacheError.ErrNoKey,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.cache(t)
+			err := cache.Delete(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantKeys, cache.Keys())
+		})
+	}
+}
+
+func TestWithOnEvicted(t *testing.T) {
+	var evicted []string
+	cache := NewCache[string, int](1, 0.5, WithOnEvicted(func(key string, value int) {
+		evicted = append(evicted, key)
+		assert.Equal(t, key, strconv.Itoa(value))
+	}))
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, cache.Set(context.Background(), strconv.Itoa(i), i))
+	}
+	assert.NoError(t, cache.Delete(context.Background(), "3"))
+	assert.Equal(t, []string{"1", "2"}, evicted)
+}
+
+func TestCache_Peek(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache[string, int](4, 0.5)
+	assert.NoError(t, cache.Set(ctx, "1", 1))
+
+	v, err := cache.Peek(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+	_, err = cache.Peek(ctx, "2")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+	// Peek 没有将元素晋升到受保护段
+	assert.Equal(t, 0, cache.protected.Len())
+}
+
+func TestCache_Inspector(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache[string, int](4, 0.5)
+	assert.Equal(t, types.BackendConfig{Policy: "slru", Capacity: 4, Extra: map[string]string{"protected_capacity": "2"}}, cache.Config())
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, cache.Set(ctx, strconv.Itoa(i), i))
+	}
+	// 被访问过的 "1" 晋升到受保护段，试用段中最久未使用的 "2" 最老
+	_, _ = cache.Get(ctx, "1")
+	key, value, ok := cache.OldestEntry()
+	assert.True(t, ok)
+	assert.Equal(t, "2", key)
+	assert.Equal(t, 2, value)
+
+	assert.NoError(t, cache.Delete(ctx, "2"))
+	assert.NoError(t, cache.Delete(ctx, "3"))
+	// 试用段为空时返回受保护段中最久未使用的元素
+	key, _, ok = cache.OldestEntry()
+	assert.True(t, ok)
+	assert.Equal(t, "1", key)
+	assert.Equal(t, types.BackendStats{Len: 1}, cache.Stats())
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return NewCache[string, string](cachetest.Capacity, DefaultProtectedRatio)
+	})
+}
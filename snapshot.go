@@ -0,0 +1,122 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// ItemInfo 是 Items 返回的元素副本，包含值和过期信息。
+type ItemInfo[K comparable, V any] struct {
+	Key   K
+	Value V
+	// Expiration 为过期时间，零值表示永不过期
+	Expiration time.Time
+	// TTL 为最近一次设置过期时间时使用的时长，Touch 和滑动过期据此推迟过期时间
+	TTL time.Duration
+	// Sliding 表示元素通过 WithSlidingExpiration 或 WithMaxIdle 写入
+	Sliding bool
+	// Deadline 为 WithMaxIdle 的元素的绝对过期时间，滑动过期不会超过它，零值表示没有限制
+	Deadline time.Time
+	// Meta 为通过 WithMeta 附加的元数据副本
+	Meta map[string]string
+	// Cost 为通过 WithCost 设置的成本，未设置时为 0
+	Cost int64
+	// Created 为元素写入缓存的时间，覆盖写入时更新；通过 LoadFrom、RestoreJSON 或 WithWAL 恢复的元素为恢复的时间
+	Created time.Time
+	// RecencyRank 为元素在最近使用顺序中的位置，0 表示最近一次被访问的元素；
+	// 后端不支持 types.OrderRecency（例如 FIFO）时为 -1
+	RecencyRank int
+}
+
+// Snapshot 在一次加锁内复制所有未过期的键和值，可用于调试或预热新的实例。
+// 值是浅拷贝的；后端实现了 types.Peeker 时不会改变元素的访问顺序。
+func (c *Cache[K, V]) Snapshot(ctx context.Context) map[K]V {
+	items := c.Items(ctx)
+	snapshot := make(map[K]V, len(items))
+	for _, item := range items {
+		snapshot[item.Key] = item.Value
+	}
+	return snapshot
+}
+
+// Items 与 Snapshot 类似，在一次加锁内复制所有未过期的元素，同时包含每个元素的过期时间、写入时间、
+// 访问顺序等信息，便于排查问题。顺序与 Keys 相同。
+func (c *Cache[K, V]) Items(ctx context.Context) []ItemInfo[K, V] {
+	items := c.items(ctx)
+	if c.opts.cloner != nil {
+		// 在释放锁之后复制，见 WithCloner
+		for i := range items {
+			items[i].Value = c.opts.cloner(items[i].Value)
+		}
+	}
+	return items
+}
+
+func (c *Cache[K, V]) items(ctx context.Context) []ItemInfo[K, V] {
+	c.readLock()
+	defer c.readUnlock()
+	keys := c.cache.Keys()
+	ranks := c.recencyRanks()
+	items := make([]ItemInfo[K, V], 0, len(keys))
+	for _, key := range keys {
+		item, err := c.peek(ctx, key)
+		if err != nil || item.Expired() {
+			continue
+		}
+		items = append(items, ItemInfo[K, V]{
+			Key:         key,
+			Value:       item.value,
+			Expiration:  item.expiration,
+			TTL:         item.ttl,
+			Sliding:     item.sliding,
+			Deadline:    item.deadline,
+			Meta:        maps.Clone(item.meta),
+			Cost:        item.cost,
+			Created:     item.created,
+			RecencyRank: rank(ranks, key),
+		})
+	}
+	return items
+}
+
+// recencyRanks 返回每个键在最近使用顺序中的位置，后端不记录访问顺序时返回 nil。调用方需要持有锁。
+func (c *Cache[K, V]) recencyRanks() map[K]int {
+	o, ok := c.cache.(types.KeyOrderer[K])
+	if !ok {
+		return nil
+	}
+	keys, err := o.KeysIn(types.OrderRecency)
+	if err != nil {
+		return nil
+	}
+	ranks := make(map[K]int, len(keys))
+	for i, key := range keys {
+		// KeysIn 按从最久未使用到最近使用的顺序返回
+		ranks[key] = len(keys) - 1 - i
+	}
+	return ranks
+}
+
+func rank[K comparable](ranks map[K]int, key K) int {
+	if r, ok := ranks[key]; ok {
+		return r
+	}
+	return -1
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotOption 配置 WithSnapshot 的行为。
+type SnapshotOption func(*snapshotConfig)
+
+type snapshotConfig struct {
+	path     string
+	interval time.Duration
+	onError  func(err error)
+	key      []byte
+}
+
+// WithSnapshotErrorHandler 设置定期保存快照失败时的处理函数，失败不会影响下一次保存。
+func WithSnapshotErrorHandler(fn func(err error)) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.onError = fn
+	}
+}
+
+// WithSnapshotEncryption 使用 AES-GCM 加密快照文件，格式与 NewEncryptWriter 相同。key 的长度为 16、24 或 32 字节，
+// 由调用方负责保管，密钥错误或文件被篡改时恢复失败并返回 cacheError.ErrDecrypt。
+func WithSnapshotEncryption(key []byte) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.key = key
+	}
+}
+
+// WithSnapshot 让缓存每隔 interval 使用 SaveTo 的格式把自身保存到 path，并在创建时从 path 恢复，
+// 使重启之后的缓存无需额外的代码即可预热。interval 为 0 时只在 Close 或 ctx 结束时保存。
+//
+// 保存时先写入同一目录下的临时文件，再通过重命名替换 path，因此 path 上永远是一份完整的快照。
+// 创建缓存时 path 不存在会被忽略，读取失败时 NewWithConfig 返回错误（New 系列函数会 panic）。
+// 快照文件的权限为 0600。
+func WithSnapshot[K comparable, V any](path string, interval time.Duration, opts ...SnapshotOption) Option[K, V] {
+	return func(o *options[K, V]) {
+		cfg := &snapshotConfig{path: path, interval: interval}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		o.snapshot = cfg
+	}
+}
+
+// loadSnapshot 从 WithSnapshot 设置的文件恢复缓存，文件不存在时直接返回 nil。
+func (c *Cache[K, V]) loadSnapshot() error {
+	f, err := os.Open(c.opts.snapshot.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cache: open snapshot: %w", err)
+	}
+	defer f.Close()
+	if err := c.loadSnapshotFrom(f, c.opts.snapshot.key); err != nil {
+		return fmt.Errorf("cache: load snapshot %s: %w", c.opts.snapshot.path, err)
+	}
+	return nil
+}
+
+// loadSnapshotFrom 使用 LoadFrom 从 r 恢复缓存，key 不为空时先解密。
+func (c *Cache[K, V]) loadSnapshotFrom(r io.Reader, key []byte) error {
+	if key != nil {
+		dr, err := NewDecryptReader(r, key)
+		if err != nil {
+			return err
+		}
+		r = dr
+	}
+	return c.LoadFrom(r)
+}
+
+// saveSnapshot 把缓存保存到 WithSnapshot 设置的文件。同一时刻只有一次保存，保证较早的快照不会覆盖较新的快照；
+// 缓存关闭之后只有 Close 自身（final 为 true）的保存会执行，避免用关闭后的空缓存覆盖最后一次快照。
+func (c *Cache[K, V]) saveSnapshot(final bool) error {
+	c.snapshotMu.Lock()
+	defer c.snapshotMu.Unlock()
+	if c.closed.Load() && !final {
+		return nil
+	}
+	if err := c.writeSnapshot(c.opts.snapshot.path, c.opts.snapshot.key); err != nil {
+		return fmt.Errorf("cache: save snapshot: %w", err)
+	}
+	return nil
+}
+
+// writeSnapshot 使用 SaveTo 的格式把缓存写入 path：先写入同一目录下的临时文件，再通过重命名替换 path。
+// key 不为空时使用 AES-GCM 加密。
+func (c *Cache[K, V]) writeSnapshot(path string, key []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := c.saveSnapshotTo(tmp, key); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// saveSnapshotTo 使用 SaveTo 把缓存写入 w，key 不为空时先加密。
+// gob 每次写入的数据很少，加密前先缓冲，避免每次写入都产生一个加密帧。
+func (c *Cache[K, V]) saveSnapshotTo(w io.Writer, key []byte) error {
+	if key == nil {
+		return c.SaveTo(w)
+	}
+	ew, err := NewEncryptWriter(w, key)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriterSize(ew, encryptMaxChunk)
+	if err := c.SaveTo(bw); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return ew.Close()
+}
+
+// runSnapshots 启动定期保存快照的协程，ctx 结束时执行最后一次保存后退出。
+func (c *Cache[K, V]) runSnapshots(ctx context.Context) {
+	save := func() {
+		if err := c.saveSnapshot(false); err != nil && c.opts.snapshot.onError != nil {
+			c.safeCall(func() { c.opts.snapshot.onError(err) })
+		}
+	}
+	go func() {
+		var tick <-chan time.Time
+		if c.opts.snapshot.interval > 0 {
+			ticker := time.NewTicker(c.opts.snapshot.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-tick:
+				save()
+			case <-ctx.Done():
+				save()
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	// 文件不存在时创建空缓存
+	c := NewSimpleCache[string, int](ctx, 10, time.Hour, WithSnapshot[string, int](path, 0))
+	assert.Empty(t, c.Keys())
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.Set(ctx, "b", 2, WithExpiration(time.Hour)))
+	require.NoError(t, c.Close())
+
+	// 保存时没有留下临时文件
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "cache.snapshot", entries[0].Name())
+
+	restored := NewSimpleCache[string, int](ctx, 10, time.Hour, WithSnapshot[string, int](path, 0))
+	defer restored.Close()
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, restored.Snapshot(ctx))
+	ttl, err := restored.TTL(ctx, "b")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+}
+
+func TestWithSnapshot_interval(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	c := NewSimpleCache[string, int](ctx, 10, time.Hour, WithSnapshot[string, int](path, 10*time.Millisecond))
+	defer c.Close()
+	require.NoError(t, c.Set(ctx, "a", 1))
+
+	// 定期保存的快照可以被另一个缓存读取，而不需要等待 Close
+	assert.Eventually(t, func() bool {
+		other := NewSimpleCache[string, int](ctx, 10, time.Hour)
+		f, err := os.Open(path)
+		if err != nil {
+			return false
+		}
+		defer f.Close()
+		return other.LoadFrom(f) == nil && other.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// 保存失败时交给错误处理函数，快照所在的目录不存在时每次保存都会失败
+	errs := make(chan error, 1)
+	broken := NewSimpleCache[string, int](ctx, 10, time.Hour,
+		WithSnapshot[string, int](filepath.Join(t.TempDir(), "missing", "cache.snapshot"), 10*time.Millisecond,
+			WithSnapshotErrorHandler(func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			})))
+	defer func() { assert.Error(t, broken.Close()) }()
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "cache: save snapshot")
+	case <-time.After(time.Second):
+		t.Fatal("snapshot error handler was not called")
+	}
+}
+
+func TestWithSnapshot_contextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	c := NewSimpleCache[string, int](ctx, 10, time.Hour, WithSnapshot[string, int](path, 0))
+	require.NoError(t, c.Set(ctx, "a", 1))
+	cancel()
+
+	// ctx 结束时执行最后一次保存
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestWithSnapshot_invalid(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt")
+	require.NoError(t, os.WriteFile(corrupt, []byte("not a snapshot"), 0o600))
+	negative := filepath.Join(dir, "negative")
+	writeSnapshotHeader(t, negative, snapshotHeader{Version: snapshotVersion, Count: -1})
+
+	testCases := []struct {
+		name    string
+		opt     Option[string, int]
+		wantErr string
+	}{
+		{
+			name:    "empty path",
+			opt:     WithSnapshot[string, int]("", time.Second),
+			wantErr: "WithSnapshot requires a file path",
+		},
+		{
+			name:    "negative interval",
+			opt:     WithSnapshot[string, int](filepath.Join(dir, "x"), -time.Second),
+			wantErr: "WithSnapshot interval must not be negative",
+		},
+		{
+			name:    "invalid encryption key",
+			opt:     WithSnapshot[string, int](filepath.Join(dir, "x"), time.Second, WithSnapshotEncryption([]byte("short"))),
+			wantErr: "WithSnapshotEncryption key must be 16, 24 or 32 bytes, got 5",
+		},
+		{
+			name:    "corrupt file",
+			opt:     WithSnapshot[string, int](corrupt, time.Second),
+			wantErr: "cache: load snapshot " + corrupt,
+		},
+		{
+			name:    "negative count",
+			opt:     WithSnapshot[string, int](negative, time.Second),
+			wantErr: "cache: invalid snapshot entry count -1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewWithConfig(ctx, Config[string, int]{
+				Backend:  simple.NewCache[string, *Item[int]](10),
+				Interval: time.Hour,
+				Options:  []Option[string, int]{tc.opt},
+			})
+			assert.Nil(t, c)
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// writeSnapshotHeader 创建只包含头部的快照文件，用于测试损坏的头部。
+func writeSnapshotHeader(t *testing.T, path string, header snapshotHeader) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, gob.NewEncoder(f).Encode(header))
+}
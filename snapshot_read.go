@@ -0,0 +1,211 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// SnapshotEntry 是 ReadSnapshot 和 ReadWAL 读取到的一个元素。
+type SnapshotEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	// Remaining 为从现在起恢复缓存时元素的剩余存活时间，0 表示永不过期
+	Remaining time.Duration
+	TTL       time.Duration
+	Sliding   bool
+	Meta      map[string]string
+	Cost      int64
+}
+
+// Ignored 作为 ReadSnapshot 和 ReadWAL 的值类型时不读取元素的值，
+// 用于只关心键和过期时间、不知道值的具体类型的工具。
+type Ignored struct{}
+
+// snapshotKeyRecord 是省略了 Value 的 snapshotRecord，gob 解码时会跳过值。
+type snapshotKeyRecord[K comparable] struct {
+	Key       K
+	Remaining time.Duration
+	TTL       time.Duration
+	Sliding   bool
+	Deadline  time.Duration
+	Meta      map[string]string
+	Cost      int64
+}
+
+// walKeyRecord 是省略了 Value 的 walRecord，gob 解码时会跳过值。
+type walKeyRecord[K comparable] struct {
+	Op         walOp
+	Key        K
+	Expiration time.Time
+	TTL        time.Duration
+	Sliding    bool
+	Deadline   time.Time
+	Meta       map[string]string
+	Cost       int64
+}
+
+func ignoresValue[V any]() bool {
+	var zero V
+	_, ok := any(zero).(Ignored)
+	return ok
+}
+
+// ReadSnapshot 读取 SaveTo 写入的快照而不创建缓存，返回 LoadFrom 会恢复的元素，即跳过已经过期的元素。
+// K 和 V 需要与保存时的类型兼容，V 为 Ignored 时不读取值。加密的快照需要先通过 NewDecryptReader 解密。
+func ReadSnapshot[K comparable, V any](r io.Reader) ([]SnapshotEntry[K, V], error) {
+	var entries []SnapshotEntry[K, V]
+	err := readSnapshotRecords(r, func(r snapshotRecord[K, V]) {
+		if r.Remaining >= 0 {
+			entries = append(entries, SnapshotEntry[K, V]{
+				Key: r.Key, Value: r.Value, Remaining: r.Remaining, TTL: r.TTL, Sliding: r.Sliding, Meta: r.Meta, Cost: r.Cost,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func readSnapshotRecords[K comparable, V any](r io.Reader, fn func(record snapshotRecord[K, V])) error {
+	if !ignoresValue[V]() {
+		return decodeSnapshot(r, fn)
+	}
+	return decodeSnapshot(r, func(r snapshotKeyRecord[K]) {
+		fn(snapshotRecord[K, V]{
+			Key: r.Key, Remaining: r.Remaining, TTL: r.TTL, Sliding: r.Sliding, Deadline: r.Deadline, Meta: r.Meta, Cost: r.Cost,
+		})
+	})
+}
+
+// ReadWAL 读取 WithWAL 目录中的快照并重放日志，返回以相同的选项创建缓存时会恢复的元素，按最后一次写入的顺序排列。
+// ReadWAL 只读取文件，不会像 WithWAL 一样压缩日志，可以在缓存运行时用于检查目录的内容。
+// 加密的目录需要传入 WithWALEncryption，其他选项会被忽略。K 和 V 的要求与 ReadSnapshot 相同。
+func ReadWAL[K comparable, V any](dir string, opts ...WALOption) ([]SnapshotEntry[K, V], error) {
+	cfg := &walConfig{dir: dir}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	w := &walLog{cfg: cfg}
+	var state walState[K, V]
+	if err := state.loadSnapshot(w.path(walSnapshotFile), cfg.key); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, name := range []string{walOldLogFile, walLogFile} {
+		if err := decodeWALRecords(w.path(name), cfg.key, func(r walRecord[K, V]) error {
+			return state.apply(r, now)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return state.result(), nil
+}
+
+func decodeWALRecords[K comparable, V any](path string, key []byte, fn func(record walRecord[K, V]) error) error {
+	if !ignoresValue[V]() {
+		return decodeWAL(path, key, fn)
+	}
+	return decodeWAL(path, key, func(r walKeyRecord[K]) error {
+		return fn(walRecord[K, V]{
+			Op: r.Op, Key: r.Key, Expiration: r.Expiration, TTL: r.TTL, Sliding: r.Sliding, Deadline: r.Deadline, Meta: r.Meta, Cost: r.Cost,
+		})
+	})
+}
+
+// walState 在内存中重放日志，entries 中被删除的元素为 nil。
+type walState[K comparable, V any] struct {
+	index   map[K]int
+	entries []*SnapshotEntry[K, V]
+}
+
+func (s *walState[K, V]) loadSnapshot(path string, key []byte) error {
+	s.index = make(map[K]int)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cache: open wal snapshot: %w", err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if key != nil {
+		if r, err = NewDecryptReader(f, key); err != nil {
+			return fmt.Errorf("cache: load wal snapshot: %w", err)
+		}
+	}
+	entries, err := ReadSnapshot[K, V](r)
+	if err != nil {
+		return fmt.Errorf("cache: load wal snapshot: %w", err)
+	}
+	for i := range entries {
+		s.set(&entries[i])
+	}
+	return nil
+}
+
+func (s *walState[K, V]) set(e *SnapshotEntry[K, V]) {
+	s.delete(e.Key)
+	s.index[e.Key] = len(s.entries)
+	s.entries = append(s.entries, e)
+}
+
+func (s *walState[K, V]) delete(key K) {
+	if i, ok := s.index[key]; ok {
+		s.entries[i] = nil
+		delete(s.index, key)
+	}
+}
+
+// apply 与 Cache.apply 相同地应用一条日志记录，剩余存活时间从 now 开始计算。
+func (s *walState[K, V]) apply(r walRecord[K, V], now time.Time) error {
+	expired := !r.Expiration.IsZero() && !r.Expiration.After(now)
+	var remaining time.Duration
+	if !r.Expiration.IsZero() {
+		remaining = r.Expiration.Sub(now)
+	}
+	switch {
+	case r.Op == walSet && !expired:
+		s.set(&SnapshotEntry[K, V]{
+			Key: r.Key, Value: r.Value, Remaining: remaining, TTL: r.TTL, Sliding: r.Sliding, Meta: r.Meta, Cost: r.Cost,
+		})
+	case r.Op == walExpire && !expired:
+		if i, ok := s.index[r.Key]; ok {
+			s.entries[i].Remaining, s.entries[i].TTL = remaining, r.TTL
+		}
+	case r.Op == walSet, r.Op == walExpire, r.Op == walDelete:
+		s.delete(r.Key)
+	default:
+		return fmt.Errorf("unknown wal operation %d", r.Op)
+	}
+	return nil
+}
+
+func (s *walState[K, V]) result() []SnapshotEntry[K, V] {
+	entries := make([]SnapshotEntry[K, V], 0, len(s.index))
+	for _, e := range s.entries {
+		if e != nil {
+			entries = append(entries, *e)
+		}
+	}
+	return entries
+}
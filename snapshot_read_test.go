@@ -0,0 +1,113 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type profile struct {
+	Name string
+	Age  int
+}
+
+func TestReadSnapshot(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, profile](ctx, 10, time.Hour)
+	defer c.Close()
+	require.NoError(t, c.Set(ctx, "a", profile{Name: "alice", Age: 30}))
+	require.NoError(t, c.Set(ctx, "b", profile{Name: "bob"}, WithExpiration(time.Hour), WithMeta(map[string]string{"tenant": "x"})))
+	var buf bytes.Buffer
+	require.NoError(t, c.SaveTo(&buf))
+	data := buf.Bytes()
+
+	entries, err := ReadSnapshot[string, profile](bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, SnapshotEntry[string, profile]{Key: "a", Value: profile{Name: "alice", Age: 30}}, entries[0])
+	assert.Equal(t, "b", entries[1].Key)
+	assert.Equal(t, profile{Name: "bob"}, entries[1].Value)
+	assert.Equal(t, time.Hour, entries[1].TTL)
+	assert.InDelta(t, time.Hour, entries[1].Remaining, float64(time.Minute))
+	assert.Equal(t, map[string]string{"tenant": "x"}, entries[1].Meta)
+
+	// 不知道值的类型时可以只读取键
+	keys, err := ReadSnapshot[string, Ignored](bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "b", keys[1].Key)
+	assert.Equal(t, entries[1].Remaining, keys[1].Remaining)
+
+	// 键的类型不兼容
+	_, err = ReadSnapshot[int, Ignored](bytes.NewReader(data))
+	assert.ErrorContains(t, err, "cache: read snapshot entry 0")
+}
+
+func TestReadWAL(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	opts := []WALOption{WithCompactionInterval(0), WithWALEncryption(testKey)}
+	c := NewLruCache[string, int](ctx, 10, time.Hour, WithWAL[string, int](dir, opts...))
+	// 写入快照的元素
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.compactWAL(false))
+	// 只在日志中的操作
+	require.NoError(t, c.Set(ctx, "b", 2))
+	require.NoError(t, c.Set(ctx, "c", 3))
+	require.NoError(t, c.Delete(ctx, "a"))
+	require.NoError(t, c.Expire(ctx, "b", time.Hour))
+	require.NoError(t, c.Set(ctx, "d", 4, WithExpiration(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+
+	before, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	entries, err := ReadWAL[string, int](dir, opts...)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[0].Key)
+	assert.Equal(t, 2, entries[0].Value)
+	assert.Equal(t, time.Hour, entries[0].TTL)
+	assert.InDelta(t, time.Hour, entries[0].Remaining, float64(time.Minute))
+	assert.Equal(t, SnapshotEntry[string, int]{Key: "c", Value: 3}, entries[1])
+
+	keys, err := ReadWAL[string, Ignored](dir, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, []SnapshotEntry[string, Ignored]{
+		{Key: "b", Remaining: keys[0].Remaining, TTL: time.Hour},
+		{Key: "c"},
+	}, keys)
+
+	// ReadWAL 不会修改目录
+	after, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	_, err = ReadWAL[string, int](dir)
+	assert.ErrorContains(t, err, "cache: load wal snapshot")
+	require.NoError(t, c.Close())
+
+	// 目录为空时没有元素
+	entries, err = ReadWAL[string, int](filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
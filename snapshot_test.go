@@ -0,0 +1,108 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 3, 0)
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.Set(ctx, "b", 2, WithExpiration(time.Hour)))
+	require.NoError(t, c.Set(ctx, "expired", 3, WithExpiration(-time.Second)))
+
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, c.Snapshot(ctx))
+	// 快照没有改变访问顺序，a 仍然最先被淘汰
+	require.NoError(t, c.Delete(ctx, "expired"))
+	require.NoError(t, c.Set(ctx, "c", 3))
+	require.NoError(t, c.Set(ctx, "d", 4))
+	assert.Equal(t, map[string]int{"b": 2, "c": 3, "d": 4}, c.Snapshot(ctx))
+}
+
+func TestCache_Items(t *testing.T) {
+	ctx := context.Background()
+	c := NewFifoCache[string, int](ctx, 10, 0)
+	meta := map[string]string{"source": "db"}
+	require.NoError(t, c.Set(ctx, "a", 1, WithMeta(meta)))
+	require.NoError(t, c.Set(ctx, "b", 2, WithExpiration(time.Hour)))
+	require.NoError(t, c.Set(ctx, "c", 3, WithSlidingExpiration(time.Minute)))
+	require.NoError(t, c.Set(ctx, "expired", 4, WithExpiration(-time.Second)))
+
+	items := c.Items(ctx)
+	require.Len(t, items, 3)
+	testCases := []struct {
+		name string
+		got  ItemInfo[string, int]
+
+		want           ItemInfo[string, int]
+		wantExpiration time.Duration
+	}{
+		{name: "without expiration", got: items[0], want: ItemInfo[string, int]{Key: "a", Value: 1, Meta: meta, RecencyRank: -1}},
+		{name: "with expiration", got: items[1], want: ItemInfo[string, int]{Key: "b", Value: 2, TTL: time.Hour, RecencyRank: -1}, wantExpiration: time.Hour},
+		{name: "sliding", got: items[2], want: ItemInfo[string, int]{Key: "c", Value: 3, TTL: time.Minute, Sliding: true, RecencyRank: -1}, wantExpiration: time.Minute},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.got
+			if tc.wantExpiration > 0 {
+				assert.WithinDuration(t, time.Now().Add(tc.wantExpiration), got.Expiration, time.Second)
+			}
+			assert.WithinDuration(t, time.Now(), got.Created, time.Second)
+			got.Expiration, got.Created = time.Time{}, time.Time{}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	// 元数据是副本
+	items[0].Meta["source"] = "changed"
+	info, err := c.EntryInfo(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, meta, info.Meta)
+}
+
+func TestCache_Items_metadata(t *testing.T) {
+	ctx := context.Background()
+	c := NewLruCache[string, int](ctx, 10, 0)
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.Set(ctx, "b", 2))
+	require.NoError(t, c.Set(ctx, "c", 3))
+	_, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	before := c.Items(ctx)
+	// 覆盖写入更新写入时间
+	time.Sleep(time.Millisecond)
+	require.NoError(t, c.Set(ctx, "b", 20))
+
+	ranks := make(map[string]int)
+	created := make(map[string]time.Time)
+	for _, item := range c.Items(ctx) {
+		ranks[item.Key] = item.RecencyRank
+		created[item.Key] = item.Created
+	}
+	assert.Equal(t, map[string]int{"b": 0, "a": 1, "c": 2}, ranks)
+	for _, item := range before {
+		if item.Key == "b" {
+			assert.True(t, created["b"].After(item.Created))
+		} else {
+			assert.Equal(t, item.Created, created[item.Key])
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// SortedKeys 按升序返回所有的键，便于分页展示或生成稳定的输出。与 Keys 相同，返回的键可能包含已过期但尚未清理的键。
+func SortedKeys[K types.Ordered, V any](c *Cache[K, V]) []K {
+	keys := c.Keys()
+	slices.Sort(keys)
+	return keys
+}
+
+// SortedItems 与 Items 相同，在一次加锁内复制所有未过期的元素，但按键的升序返回。
+func SortedItems[K types.Ordered, V any](ctx context.Context, c *Cache[K, V]) []ItemInfo[K, V] {
+	items := c.Items(ctx)
+	slices.SortFunc(items, func(a, b ItemInfo[K, V]) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return items
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type score float64
+
+func TestSortedKeys(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[string, int](ctx, 0, 0)
+	for _, key := range []string{"b", "c", "a"} {
+		require.NoError(t, c.Set(ctx, key, 1))
+	}
+	require.NoError(t, c.Set(ctx, "d", 1, WithExpiration(-time.Second)))
+	// 与 Keys 相同，包含已过期但尚未清理的键
+	assert.Equal(t, []string{"a", "b", "c", "d"}, SortedKeys(c))
+
+	// 支持底层类型为数字的自定义类型
+	scores := NewLruCache[score, string](ctx, 10, 0)
+	for _, s := range []score{2.5, -1, 0.5} {
+		require.NoError(t, scores.Set(ctx, s, ""))
+	}
+	assert.Equal(t, []score{-1, 0.5, 2.5}, SortedKeys(scores))
+	assert.Empty(t, SortedKeys(NewFifoCache[int, int](ctx, 10, 0)))
+}
+
+func TestSortedItems(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[int, string](ctx, 0, 0)
+	for _, key := range []int{3, 1, 2} {
+		require.NoError(t, c.Set(ctx, key, string(rune('a'+key))))
+	}
+	require.NoError(t, c.Set(ctx, 0, "a", WithExpiration(-time.Second)))
+
+	items := SortedItems(ctx, c)
+	require.Len(t, items, 3)
+	for i, item := range items {
+		assert.Equal(t, i+1, item.Key)
+		assert.Equal(t, string(rune('a'+item.Key)), item.Value)
+	}
+}
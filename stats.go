@@ -0,0 +1,91 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import "sync/atomic"
+
+// Stats 是缓存统计信息的快照。
+type Stats struct {
+	// Hits Get 命中的次数
+	Hits uint64
+	// Misses Get 未命中的次数，包括键已过期的情况
+	Misses uint64
+	// Sets 成功写入的次数
+	Sets uint64
+	// Deletes 显式删除成功的次数
+	Deletes uint64
+	// Evictions 因容量不足被淘汰的元素数量
+	Evictions uint64
+	// Expired 被 DeleteExpired 清理的过期元素数量
+	Expired uint64
+	// GhostHits 未命中的键恰好在影子列表中的次数，只在开启 WithGhostList 时统计
+	GhostHits uint64
+	// Loads 执行加载器的次数，包括 WithLoader、GetOrLoad、提前刷新和提前过期触发的加载
+	Loads uint64
+	// CoalescedLoads 加入同一个键上正在进行的加载、共享其结果的调用次数，即 singleflight 避免的加载次数
+	CoalescedLoads uint64
+	// MaxCoalesced 单次加载合并的调用者数量的最大值，包括发起加载的调用者；
+	// 该值较大说明存在热点键的缓存击穿，可以考虑为其开启 WithRefreshAhead
+	MaxCoalesced uint64
+}
+
+// HitRatio 返回命中率，没有任何 Get 调用时返回 0。
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// MeanCoalesced 返回平均每次加载合并的调用者数量，包括发起加载的调用者，没有任何加载时返回 0。
+func (s Stats) MeanCoalesced() float64 {
+	if s.Loads == 0 {
+		return 0
+	}
+	return float64(s.Loads+s.CoalescedLoads) / float64(s.Loads)
+}
+
+// stats 使用原子计数器维护统计信息，不需要持有缓存的锁。
+type stats struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	sets      atomic.Uint64
+	deletes   atomic.Uint64
+	evictions atomic.Uint64
+	expired   atomic.Uint64
+	ghostHits atomic.Uint64
+	loads     atomic.Uint64
+	coalesced atomic.Uint64
+	// maxCoalesced 只在持有写锁时写入
+	maxCoalesced atomic.Uint64
+}
+
+// Stats 返回缓存的统计信息。
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		Hits:      c.stats.hits.Load(),
+		Misses:    c.stats.misses.Load(),
+		Sets:      c.stats.sets.Load(),
+		Deletes:   c.stats.deletes.Load(),
+		Evictions: c.stats.evictions.Load(),
+		Expired:   c.stats.expired.Load(),
+		GhostHits: c.stats.ghostHits.Load(),
+
+		Loads:          c.stats.loads.Load(),
+		CoalescedLoads: c.stats.coalesced.Load(),
+		MaxCoalesced:   c.stats.maxCoalesced.Load(),
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLruCache[int, int](ctx, 2, time.Minute)
+
+	assert.NoError(t, cache.Set(ctx, 1, 1, WithExpiration(time.Millisecond)))
+	assert.NoError(t, cache.Set(ctx, 2, 2))
+	assert.NoError(t, cache.Set(ctx, 3, 3))
+	ok, err := cache.SetNX(ctx, 4, 4)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = cache.SetNX(ctx, 4, 4)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _ = cache.Get(ctx, 3)
+	_, _ = cache.Get(ctx, 4)
+	_, _ = cache.Get(ctx, 5)
+
+	assert.NoError(t, cache.Delete(ctx, 4))
+	assert.Error(t, cache.Delete(ctx, 4))
+
+	assert.NoError(t, cache.Set(ctx, 6, 6, WithExpiration(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+	cache.DeleteExpired(ctx)
+
+	assert.Equal(t, Stats{
+		Hits:      2,
+		Misses:    1,
+		Sets:      5,
+		Deletes:   1,
+		Evictions: 2,
+		Expired:   1,
+	}, cache.Stats())
+}
+
+func TestStats_HitRatio(t *testing.T) {
+	assert.Zero(t, Stats{}.HitRatio())
+	assert.Equal(t, 0.75, Stats{Hits: 3, Misses: 1}.HitRatio())
+}
+
+func TestStats_MeanCoalesced(t *testing.T) {
+	assert.Zero(t, Stats{}.MeanCoalesced())
+	assert.Equal(t, 2.5, Stats{Loads: 2, CoalescedLoads: 3}.MeanCoalesced())
+}
@@ -0,0 +1,435 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store 是缓存背后的持久化存储，例如数据库。
+type Store[K comparable, V any] interface {
+	Save(ctx context.Context, key K, value V) error
+	Delete(ctx context.Context, key K) error
+}
+
+// BatchStore 是支持批量写入的 Store，回写模式下会优先使用批量接口。
+type BatchStore[K comparable, V any] interface {
+	Store[K, V]
+	SaveBatch(ctx context.Context, entries map[K]V) error
+	DeleteBatch(ctx context.Context, keys []K) error
+}
+
+// WriteMode 决定写操作如何同步到 Store。
+type WriteMode int
+
+const (
+	// WriteThrough 同步写穿：Set 和 Delete 先写 Store，成功后才更新缓存。
+	WriteThrough WriteMode = iota
+	// WriteBack 异步回写：Set 和 Delete 只更新缓存，Store 的写入被合并后批量执行。
+	WriteBack
+)
+
+// Consistency 决定回写模式下未命中的读取如何对待尚未写入 store 的操作。
+type Consistency int
+
+const (
+	// ReadYourWrites 读己之写（默认）：通过加载器加载（Get、GetOrLoad 以及提前刷新）之前先查询待写入队列，
+	// 元素在刷新之前被淘汰或过期时直接使用队列中的值，删除过的键返回 cacheError.ErrNoKey，
+	// 因此 Set 之后的读取不会通过加载器读到 store 中的旧值。
+	ReadYourWrites Consistency = iota
+	// Eventual 最终一致：未命中时直接调用加载器，刷新完成之前可能读到 store 中的旧值，省去了查询待写入队列的开销。
+	Eventual
+)
+
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+)
+
+// StoreOption 配置回写模式的行为。
+type StoreOption func(*storeConfig)
+
+type storeConfig struct {
+	batchSize     int
+	flushInterval time.Duration
+	onError       func(err error)
+	// opsPerSecond 大于 0 时限制每秒写入 store 的操作数，见 WithWriteBudget
+	opsPerSecond int
+	minTTL       time.Duration
+	consistency  Consistency
+}
+
+// WithBatchSize 设置回写模式下触发立即刷新的待写入键数量，默认为 DefaultBatchSize。
+func WithBatchSize(n int) StoreOption {
+	return func(c *storeConfig) {
+		c.batchSize = n
+	}
+}
+
+// WithFlushInterval 设置回写模式下定时刷新的间隔，默认为 DefaultFlushInterval。
+func WithFlushInterval(d time.Duration) StoreOption {
+	return func(c *storeConfig) {
+		c.flushInterval = d
+	}
+}
+
+// WithStoreErrorHandler 设置回写失败时的处理函数。写入失败的键会保留在待写入队列中，在下次刷新时重试。
+func WithStoreErrorHandler(fn func(err error)) StoreOption {
+	return func(c *storeConfig) {
+		c.onError = fn
+	}
+}
+
+// WithConsistency 设置回写模式下读取的一致性，默认为 ReadYourWrites。
+func WithConsistency(mode Consistency) StoreOption {
+	return func(c *storeConfig) {
+		c.consistency = mode
+	}
+}
+
+// WithWriteBudget 限制回写模式下定时刷新和批量触发的刷新每秒写入 store 的操作数，避免缓存压垮多个实例共享的 Redis 或磁盘。
+// 超出预算时，删除和永不过期的写入优先，其次是过期时间更长的写入；存活时间短于 minTTL 的写入被丢弃，
+// 它们多半在写入之前就已经过期，store 中保留旧值；其余的写入留在队列中，与之后对同一个键的写入合并，在下次刷新时写入。
+// 显式调用 Flush 和 ctx 结束时的最后一次刷新不受限制。opsPerSecond <= 0 时不限制，写入情况可以通过 WriteBackStats 查看。
+func WithWriteBudget(opsPerSecond int, minTTL time.Duration) StoreOption {
+	return func(c *storeConfig) {
+		c.opsPerSecond = opsPerSecond
+		c.minTTL = minTTL
+	}
+}
+
+// WithStore 将缓存放在 store 之前，写操作按 mode 同步到 store。
+// 从 store 加载的值（GetOrLoad、WithLoader）不会被写回。
+//
+// 写穿模式下写操作之间相互串行，以保证缓存与 store 中同一个键的写入顺序一致，读操作不受影响。
+// 回写模式下，尚未写入 store 的值默认在加载时优先于 loader 的结果，因此元素在刷新前被淘汰也不会读到旧值，
+// 见 WithConsistency；ctx 结束时会执行最后一次刷新。
+func WithStore[K comparable, V any](store Store[K, V], mode WriteMode, opts ...StoreOption) Option[K, V] {
+	return func(o *options[K, V]) {
+		cfg := storeConfig{
+			batchSize:     DefaultBatchSize,
+			flushInterval: DefaultFlushInterval,
+		}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		o.store = store
+		o.storeMode = mode
+		o.storeCfg = cfg
+	}
+}
+
+func (c *Cache[K, V]) writeThrough() bool {
+	return c.opts.store != nil && c.opts.storeMode == WriteThrough
+}
+
+// saveMulti 将 entries 写入 store，store 实现了 BatchStore 时使用一次批量写入。
+func (c *Cache[K, V]) saveMulti(ctx context.Context, entries []Entry[K, V]) error {
+	if bs, ok := c.opts.store.(BatchStore[K, V]); ok {
+		batch := make(map[K]V, len(entries))
+		for _, e := range entries {
+			batch[e.Key] = e.Value
+		}
+		return bs.SaveBatch(ctx, batch)
+	}
+	for _, e := range entries {
+		if err := c.opts.store.Save(ctx, e.Key, e.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMulti 从 store 中删除 keys，store 实现了 BatchStore 时批量删除。
+func (c *Cache[K, V]) deleteMulti(ctx context.Context, keys []K) error {
+	if bs, ok := c.opts.store.(BatchStore[K, V]); ok {
+		return bs.DeleteBatch(ctx, keys)
+	}
+	for _, key := range keys {
+		if err := c.opts.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingWrite 是一次尚未写入 store 的操作，deleted 为 true 时表示删除。
+type pendingWrite[V any] struct {
+	value   V
+	deleted bool
+	// ttl 为写入时元素的过期时长，0 表示永不过期，超出 WithWriteBudget 时据此决定写入的优先级
+	ttl time.Duration
+}
+
+// WriteBackStats 是回写模式下写入 store 的统计信息。
+type WriteBackStats struct {
+	// Written 写入 store 的操作数量，包括失败的写入
+	Written uint64
+	// Deferred 因超出 WithWriteBudget 推迟到下次刷新的操作数量，同一个操作可能被多次推迟
+	Deferred uint64
+	// Dropped 因超出 WithWriteBudget 被丢弃的短期写入数量
+	Dropped uint64
+}
+
+// WriteBackStats 返回回写模式下写入 store 的统计信息，未配置回写模式时返回零值。
+func (c *Cache[K, V]) WriteBackStats() WriteBackStats {
+	if c.wb == nil {
+		return WriteBackStats{}
+	}
+	return WriteBackStats{
+		Written:  c.wb.written.Load(),
+		Deferred: c.wb.deferred.Load(),
+		Dropped:  c.wb.dropped.Load(),
+	}
+}
+
+// writeBack 合并待写入的操作并定期刷新到 store，同一个键只保留最后一次操作。
+type writeBack[K comparable, V any] struct {
+	store Store[K, V]
+	cfg   storeConfig
+
+	mu      sync.Mutex
+	pending map[K]pendingWrite[V]
+	// flushing 为正在刷新的操作，刷新完成前加载时仍需要参考它们
+	flushing map[K]pendingWrite[V]
+	// flushMu 保证同一时刻只有一次刷新
+	flushMu sync.Mutex
+	kick    chan struct{}
+	// tokens 和 refilled 为 WithWriteBudget 的令牌桶，持有 flushMu 时读写
+	tokens   float64
+	refilled time.Time
+
+	written  atomic.Uint64
+	deferred atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+func newWriteBack[K comparable, V any](store Store[K, V], cfg storeConfig) *writeBack[K, V] {
+	return &writeBack[K, V]{
+		store:    store,
+		cfg:      cfg,
+		pending:  make(map[K]pendingWrite[V]),
+		kick:     make(chan struct{}, 1),
+		tokens:   float64(cfg.opsPerSecond),
+		refilled: time.Now(),
+	}
+}
+
+func (w *writeBack[K, V]) add(key K, write pendingWrite[V]) {
+	w.mu.Lock()
+	w.pending[key] = write
+	full := w.cfg.batchSize > 0 && len(w.pending) >= w.cfg.batchSize
+	w.mu.Unlock()
+	if full {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// lookup 返回 key 尚未写入 store 的最新操作。
+func (w *writeBack[K, V]) lookup(key K) (pendingWrite[V], bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if write, ok := w.pending[key]; ok {
+		return write, true
+	}
+	write, ok := w.flushing[key]
+	return write, ok
+}
+
+// flush 将待写入的操作写入 store，失败的操作如果没有被更新的操作覆盖，会重新放回队列。
+// limited 为 true 时遵守 WithWriteBudget 的限制。
+func (w *writeBack[K, V]) flush(ctx context.Context, limited bool) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.pending
+	if len(batch) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	budget := len(batch)
+	if limited && w.cfg.opsPerSecond > 0 {
+		if budget = min(w.refill(), budget); budget <= 0 {
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	w.pending = make(map[K]pendingWrite[V])
+	// 显式刷新不受预算限制，也不消耗令牌，否则令牌会变为负数，之后的定时刷新长时间无法写入
+	if limited && w.cfg.opsPerSecond > 0 {
+		w.tokens -= float64(budget)
+	}
+	deferred := w.limit(batch, budget)
+	w.flushing = batch
+	w.mu.Unlock()
+
+	w.written.Add(uint64(len(batch)))
+	failed, err := w.write(ctx, batch)
+
+	w.mu.Lock()
+	// 推迟和失败的操作如果没有被更新的操作覆盖，重新放回队列
+	for _, m := range []map[K]pendingWrite[V]{deferred, failed} {
+		for key, write := range m {
+			if _, ok := w.pending[key]; !ok {
+				w.pending[key] = write
+			}
+		}
+	}
+	w.flushing = nil
+	w.mu.Unlock()
+	return err
+}
+
+// refill 按经过的时间补充令牌并返回本次可以写入的操作数，调用方需要持有 flushMu。
+// 令牌最多累积一秒的预算，因此空闲之后的突发写入不会超过 opsPerSecond。
+func (w *writeBack[K, V]) refill() int {
+	now := time.Now()
+	rate := float64(w.cfg.opsPerSecond)
+	w.tokens = min(w.tokens+now.Sub(w.refilled).Seconds()*rate, rate)
+	w.refilled = now
+	return int(w.tokens)
+}
+
+// limit 在 batch 中保留优先级最高的 budget 个操作，丢弃其余操作中的短期写入，返回被推迟的操作。
+// 调用方需要持有 flushMu。
+func (w *writeBack[K, V]) limit(batch map[K]pendingWrite[V], budget int) map[K]pendingWrite[V] {
+	if budget >= len(batch) {
+		return nil
+	}
+	keys := make([]K, 0, len(batch))
+	for key := range batch {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b K) int {
+		return cmp.Compare(writePriority(batch[b]), writePriority(batch[a]))
+	})
+	deferred := make(map[K]pendingWrite[V])
+	for _, key := range keys[budget:] {
+		write := batch[key]
+		delete(batch, key)
+		if !write.deleted && write.ttl > 0 && write.ttl < w.cfg.minTTL {
+			w.dropped.Add(1)
+			continue
+		}
+		deferred[key] = write
+		w.deferred.Add(1)
+	}
+	return deferred
+}
+
+// writePriority 返回操作在超出 WithWriteBudget 时的优先级，越大越先写入：删除和永不过期的写入优先，其次按过期时长。
+func writePriority[V any](write pendingWrite[V]) time.Duration {
+	if write.deleted || write.ttl <= 0 {
+		return math.MaxInt64
+	}
+	return write.ttl
+}
+
+func (w *writeBack[K, V]) write(ctx context.Context, batch map[K]pendingWrite[V]) (map[K]pendingWrite[V], error) {
+	failed := make(map[K]pendingWrite[V])
+	if bs, ok := w.store.(BatchStore[K, V]); ok {
+		saves := make(map[K]V)
+		var deletes []K
+		for key, write := range batch {
+			if write.deleted {
+				deletes = append(deletes, key)
+			} else {
+				saves[key] = write.value
+			}
+		}
+		var errs []error
+		if len(saves) > 0 {
+			if err := bs.SaveBatch(ctx, saves); err != nil {
+				errs = append(errs, fmt.Errorf("cache: write back %d entries: %w", len(saves), err))
+				for key := range saves {
+					failed[key] = batch[key]
+				}
+			}
+		}
+		if len(deletes) > 0 {
+			if err := bs.DeleteBatch(ctx, deletes); err != nil {
+				errs = append(errs, fmt.Errorf("cache: write back deletion of %d keys: %w", len(deletes), err))
+				for _, key := range deletes {
+					failed[key] = batch[key]
+				}
+			}
+		}
+		return failed, errors.Join(errs...)
+	}
+
+	var errs []error
+	for key, write := range batch {
+		var err error
+		if write.deleted {
+			err = w.store.Delete(ctx, key)
+		} else {
+			err = w.store.Save(ctx, key, write.value)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cache: write back %v: %w", key, err))
+			failed[key] = write
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// run 启动刷新协程，ctx 结束时执行最后一次刷新后退出。
+func (w *writeBack[K, V]) run(ctx context.Context, safeCall func(fn func())) {
+	flush := func(ctx context.Context, limited bool) {
+		if err := w.flush(ctx, limited); err != nil && w.cfg.onError != nil {
+			safeCall(func() { w.cfg.onError(err) })
+		}
+	}
+	go func() {
+		var tick <-chan time.Time
+		if w.cfg.flushInterval > 0 {
+			ticker := time.NewTicker(w.cfg.flushInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-tick:
+				flush(ctx, true)
+			case <-w.kick:
+				flush(ctx, true)
+			case <-ctx.Done():
+				flush(context.WithoutCancel(ctx), false)
+				return
+			}
+		}
+	}()
+}
+
+// Flush 立即把回写模式下待写入的操作写入 store，返回本次写入失败的错误，不受 WithWriteBudget 的限制。
+// 未配置回写模式时直接返回 nil。
+func (c *Cache[K, V]) Flush(ctx context.Context) error {
+	if c.wb == nil {
+		return nil
+	}
+	return c.wb.flush(ctx, false)
+}
@@ -0,0 +1,458 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/lru"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errStore = errors.New("store error")
+
+type memStore struct {
+	mu     sync.Mutex
+	data   map[int]int
+	writes int
+	fail   bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: make(map[int]int)}
+}
+
+func (s *memStore) Save(_ context.Context, key int, value int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errStore
+	}
+	s.writes++
+	s.data[key] = value
+	return nil
+}
+
+func (s *memStore) Delete(_ context.Context, key int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errStore
+	}
+	s.writes++
+	delete(s.data, key)
+	return nil
+}
+
+func (s *memStore) snapshot() (map[int]int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := make(map[int]int, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return data, s.writes
+}
+
+func (s *memStore) setFail(fail bool) {
+	s.mu.Lock()
+	s.fail = fail
+	s.mu.Unlock()
+}
+
+type memBatchStore struct {
+	*memStore
+	batches atomic.Int32
+}
+
+func (s *memBatchStore) SaveBatch(ctx context.Context, entries map[int]int) error {
+	s.batches.Add(1)
+	for k, v := range entries {
+		if err := s.Save(ctx, k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memBatchStore) DeleteBatch(ctx context.Context, keys []int) error {
+	s.batches.Add(1)
+	for _, k := range keys {
+		if err := s.Delete(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWithStore_WriteThrough(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		ops  func(t *testing.T, c *Cache[int, int], s *memStore)
+
+		wantStore map[int]int
+		wantKeys  []int
+	}{
+		{
+			name: "set",
+			ops: func(t *testing.T, c *Cache[int, int], s *memStore) {
+				require.NoError(t, c.Set(ctx, 1, 1))
+			},
+			wantStore: map[int]int{1: 1},
+			wantKeys:  []int{1},
+		},
+		{
+			name: "set fails",
+			ops: func(t *testing.T, c *Cache[int, int], s *memStore) {
+				s.setFail(true)
+				assert.Equal(t, errStore, c.Set(ctx, 1, 1))
+				s.setFail(false)
+			},
+			wantStore: map[int]int{},
+			wantKeys:  []int{},
+		},
+		{
+			name: "setNX",
+			ops: func(t *testing.T, c *Cache[int, int], s *memStore) {
+				ok, err := c.SetNX(ctx, 1, 1)
+				require.NoError(t, err)
+				assert.True(t, ok)
+				ok, err = c.SetNX(ctx, 1, 2)
+				require.NoError(t, err)
+				assert.False(t, ok)
+			},
+			wantStore: map[int]int{1: 1},
+			wantKeys:  []int{1},
+		},
+		{
+			name: "setNX fails",
+			ops: func(t *testing.T, c *Cache[int, int], s *memStore) {
+				s.setFail(true)
+				ok, err := c.SetNX(ctx, 1, 1)
+				assert.Equal(t, errStore, err)
+				assert.False(t, ok)
+				s.setFail(false)
+			},
+			wantStore: map[int]int{},
+			wantKeys:  []int{},
+		},
+		{
+			name: "delete uncached key",
+			ops: func(t *testing.T, c *Cache[int, int], s *memStore) {
+				require.NoError(t, s.Save(ctx, 2, 2))
+				require.NoError(t, c.Set(ctx, 1, 1))
+				require.NoError(t, c.Delete(ctx, 1))
+				require.NoError(t, c.Delete(ctx, 2))
+			},
+			wantStore: map[int]int{},
+			wantKeys:  []int{},
+		},
+		{
+			name: "delete fails",
+			ops: func(t *testing.T, c *Cache[int, int], s *memStore) {
+				require.NoError(t, c.Set(ctx, 1, 1))
+				s.setFail(true)
+				assert.Equal(t, errStore, c.Delete(ctx, 1))
+				s.setFail(false)
+			},
+			wantStore: map[int]int{1: 1},
+			wantKeys:  []int{1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newMemStore()
+			c := NewLruCache[int, int](ctx, 10, 0, WithStore[int, int](s, WriteThrough))
+			tc.ops(t, c, s)
+			data, _ := s.snapshot()
+			assert.Equal(t, tc.wantStore, data)
+			assert.ElementsMatch(t, tc.wantKeys, c.Keys())
+		})
+	}
+}
+
+func TestWithStore_WriteBack(t *testing.T) {
+	ctx := context.Background()
+	s := newMemStore()
+	c := NewLruCache[int, int](ctx, 10, 0, WithStore[int, int](s, WriteBack, WithFlushInterval(time.Hour)))
+
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Set(ctx, 1, 2))
+	require.NoError(t, c.Set(ctx, 2, 2))
+	require.NoError(t, c.Set(ctx, 3, 3))
+	require.NoError(t, c.Delete(ctx, 3))
+	// 删除未缓存的键同样会写入 store
+	require.NoError(t, c.Delete(ctx, 4))
+	data, writes := s.snapshot()
+	assert.Empty(t, data)
+	assert.Zero(t, writes)
+
+	require.NoError(t, c.Flush(ctx))
+	data, writes = s.snapshot()
+	assert.Equal(t, map[int]int{1: 2, 2: 2}, data)
+	// 同一个键的多次写入被合并
+	assert.Equal(t, 4, writes)
+
+	require.NoError(t, c.Flush(ctx))
+	_, writes = s.snapshot()
+	assert.Equal(t, 4, writes)
+}
+
+func TestWithWriteBudget(t *testing.T) {
+	ctx := context.Background()
+	s := newMemStore()
+	c := NewLruCache[int, int](ctx, 10, 0, WithStore[int, int](s, WriteBack,
+		WithFlushInterval(time.Hour), WithBatchSize(5), WithWriteBudget(3, time.Minute)))
+
+	require.NoError(t, c.Set(ctx, 1, 1, WithExpiration(time.Hour)))
+	// 短期写入在超出预算时被丢弃
+	require.NoError(t, c.Set(ctx, 2, 2, WithExpiration(time.Second)))
+	require.NoError(t, c.Set(ctx, 3, 3))
+	require.NoError(t, c.Set(ctx, 4, 4))
+	// 第 5 个操作触发刷新，预算只允许写入删除和永不过期的 3、4
+	require.NoError(t, c.Delete(ctx, 5))
+	require.Eventually(t, func() bool {
+		_, writes := s.snapshot()
+		return writes == 3
+	}, time.Second, time.Millisecond)
+	data, _ := s.snapshot()
+	assert.Equal(t, map[int]int{3: 3, 4: 4}, data)
+	assert.Equal(t, WriteBackStats{Written: 3, Deferred: 1, Dropped: 1}, c.WriteBackStats())
+
+	// 显式的 Flush 不受预算限制，推迟的 1 最终写入，被丢弃的 2 不会写入
+	require.NoError(t, c.Flush(ctx))
+	data, _ = s.snapshot()
+	assert.Equal(t, map[int]int{1: 1, 3: 3, 4: 4}, data)
+	assert.Equal(t, WriteBackStats{Written: 4, Deferred: 1, Dropped: 1}, c.WriteBackStats())
+}
+
+func TestWithStore_WriteBackRetry(t *testing.T) {
+	ctx := context.Background()
+	s := newMemStore()
+	var handled atomic.Int32
+	c := NewSimpleCache[int, int](ctx, 10, 0, WithStore[int, int](s, WriteBack,
+		WithFlushInterval(time.Millisecond),
+		WithStoreErrorHandler(func(err error) {
+			assert.ErrorIs(t, err, errStore)
+			handled.Add(1)
+		})))
+
+	s.setFail(true)
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.Eventually(t, func() bool { return handled.Load() > 0 }, time.Second, time.Millisecond)
+	s.setFail(false)
+	require.Eventually(t, func() bool {
+		data, _ := s.snapshot()
+		return data[1] == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestWithStore_WriteBackBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &memBatchStore{memStore: newMemStore()}
+	c := NewSimpleCache[int, int](ctx, 10, 0, WithStore[int, int](s, WriteBack, WithFlushInterval(time.Hour), WithBatchSize(2)))
+
+	// 待写入的键达到批量大小时立即刷新
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Set(ctx, 2, 2))
+	require.Eventually(t, func() bool {
+		data, _ := s.snapshot()
+		return len(data) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), s.batches.Load())
+
+	// 写穿模式下 SetMulti 使用一次批量写入
+	through := &memBatchStore{memStore: newMemStore()}
+	tc := NewSimpleCache[int, int](ctx, 10, 0, WithStore[int, int](through, WriteThrough))
+	require.NoError(t, tc.SetMulti(ctx, []Entry[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}}))
+	data, _ := through.snapshot()
+	assert.Equal(t, map[int]int{1: 1, 2: 2}, data)
+	assert.Equal(t, int32(1), through.batches.Load())
+
+	// ctx 结束时执行最后一次刷新
+	require.NoError(t, c.Delete(ctx, 1))
+	cancel()
+	require.Eventually(t, func() bool {
+		data, _ := s.snapshot()
+		return len(data) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestWriteBack_flush(t *testing.T) {
+	ctx := context.Background()
+
+	// 批量写入失败时重新放回队列的操作保留过期时长，之后仍然按 WithWriteBudget 的优先级写入
+	s := &memBatchStore{memStore: newMemStore()}
+	w := newWriteBack[int, int](s, storeConfig{})
+	w.add(1, pendingWrite[int]{value: 1, ttl: time.Minute})
+	w.add(2, pendingWrite[int]{deleted: true})
+	s.setFail(true)
+	assert.ErrorIs(t, w.flush(ctx, false), errStore)
+	write, ok := w.lookup(1)
+	assert.True(t, ok)
+	assert.Equal(t, pendingWrite[int]{value: 1, ttl: time.Minute}, write)
+	write, ok = w.lookup(2)
+	assert.True(t, ok)
+	assert.Equal(t, pendingWrite[int]{deleted: true}, write)
+
+	// 显式刷新不消耗令牌，之后的定时刷新仍然可以使用完整的预算
+	s.setFail(false)
+	w = newWriteBack[int, int](s, storeConfig{opsPerSecond: 2})
+	for i := 0; i < 10; i++ {
+		w.add(i, pendingWrite[int]{value: i})
+	}
+	require.NoError(t, w.flush(ctx, false))
+	w.add(10, pendingWrite[int]{value: 10})
+	w.add(11, pendingWrite[int]{value: 11})
+	require.NoError(t, w.flush(ctx, true))
+	_, ok = w.lookup(10)
+	assert.False(t, ok)
+	_, ok = w.lookup(11)
+	assert.False(t, ok)
+}
+
+// blockingStore 的 Save 在写入之前通知 started，并等待 release 关闭。
+type blockingStore struct {
+	*memStore
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Save(ctx context.Context, key int, value int) error {
+	s.started <- struct{}{}
+	<-s.release
+	return s.memStore.Save(ctx, key, value)
+}
+
+func TestWithConsistency(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		mode Consistency
+
+		want1    int
+		want2    int
+		wantErr2 error
+	}{
+		{
+			name:     "read your writes",
+			mode:     ReadYourWrites,
+			want1:    1,
+			wantErr2: cacheError.ErrNoKey,
+		},
+		{
+			// 刷新之前读到 store 中的旧值
+			name: "eventual",
+			mode: Eventual,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newMemStore()
+			require.NoError(t, s.Save(ctx, 1, 0))
+			require.NoError(t, s.Save(ctx, 2, 0))
+			c := New[int, int](ctx, lru.NewCache[int, *Item[int]](1), 0,
+				WithStore[int, int](s, WriteBack, WithFlushInterval(time.Hour), WithConsistency(tc.mode)),
+				WithLoader[int, int](storeLoader(s), 0))
+
+			require.NoError(t, c.Set(ctx, 1, 1))
+			require.NoError(t, c.Delete(ctx, 2))
+			// 淘汰键 1，使其只存在于待写入队列中
+			require.NoError(t, c.Set(ctx, 3, 3))
+
+			v, err := c.Get(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want1, v)
+			v, err = c.Get(ctx, 2)
+			assert.Equal(t, tc.wantErr2, err)
+			assert.Equal(t, tc.want2, v)
+		})
+	}
+}
+
+func TestWithConsistency_duringFlush(t *testing.T) {
+	ctx := context.Background()
+	s := &blockingStore{memStore: newMemStore(), started: make(chan struct{}), release: make(chan struct{})}
+	require.NoError(t, s.memStore.Save(ctx, 1, 0))
+	c := New[int, int](ctx, lru.NewCache[int, *Item[int]](1), 0,
+		WithStore[int, int](s, WriteBack, WithFlushInterval(time.Hour)),
+		WithLoader[int, int](storeLoader(s.memStore), 0))
+
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Set(ctx, 2, 2))
+	flushed := make(chan error, 1)
+	go func() { flushed <- c.Flush(ctx) }()
+	<-s.started
+	// 正在写入 store 的值同样优先于 store 中的旧值
+	for _, key := range []int{1, 2} {
+		v, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, key, v)
+	}
+	close(s.release)
+	<-s.started
+	require.NoError(t, <-flushed)
+	data, _ := s.snapshot()
+	assert.Equal(t, map[int]int{1: 1, 2: 2}, data)
+}
+
+// storeLoader 返回从 s 中读取的加载器。
+func storeLoader(s *memStore) LoaderFunc[int, int] {
+	return func(ctx context.Context, key int) (int, error) {
+		data, _ := s.snapshot()
+		v, ok := data[key]
+		if !ok {
+			return 0, cacheError.ErrNoKey
+		}
+		return v, nil
+	}
+}
+
+func TestWithStore_WriteBackLoad(t *testing.T) {
+	ctx := context.Background()
+	s := newMemStore()
+	require.NoError(t, s.Save(ctx, 1, 0))
+	require.NoError(t, s.Save(ctx, 2, 0))
+	c := New[int, int](ctx, lru.NewCache[int, *Item[int]](1), 0,
+		WithStore[int, int](s, WriteBack, WithFlushInterval(time.Hour)),
+		WithLoader[int, int](LoaderFunc[int, int](func(ctx context.Context, key int) (int, error) {
+			data, _ := s.snapshot()
+			v, ok := data[key]
+			if !ok {
+				return 0, cacheError.ErrNoKey
+			}
+			return v, nil
+		}), 0))
+
+	require.NoError(t, c.Set(ctx, 1, 1))
+	require.NoError(t, c.Delete(ctx, 2))
+	// 淘汰键 1，使其只存在于待写入队列中
+	require.NoError(t, c.Set(ctx, 3, 3))
+
+	v, err := c.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	_, err = c.Get(ctx, 2)
+	assert.Equal(t, cacheError.ErrNoKey, err)
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiered
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+const (
+	// DefaultAuditSampleSize 每轮审计默认抽样的键数量。
+	DefaultAuditSampleSize = 32
+	// DefaultAuditInterval 后台审计的默认间隔。
+	DefaultAuditInterval = time.Minute
+	// DefaultTTLTolerance 比较 L1 和 L2 的过期时间时默认允许的误差。
+	DefaultTTLTolerance = time.Second
+)
+
+// TTLReader 是可以读取键的剩余过期时间的 L2，tiered/goredis 中的 Redis 适配器实现了该接口。
+type TTLReader[K comparable] interface {
+	// TTL 返回 key 的剩余过期时间，永不过期时返回 0，key 不存在时返回 cacheError.ErrNoKey。
+	TTL(ctx context.Context, key K) (time.Duration, error)
+}
+
+// AuditReport 是一轮审计的结果。
+type AuditReport struct {
+	// Sampled 为抽样检查的 L1 元素数量
+	Sampled int
+	// Mismatched 为 L1 与 L2 的值不一致的数量
+	Mismatched int
+	// Missing 为 L1 中存在而 L2 中不存在的数量
+	Missing int
+	// TTLDrift 为值一致，但 L1 的过期时间比 L2 晚超过容忍误差的数量，L2 过期后这些元素会继续从 L1 返回旧值
+	TTLDrift int
+	// Errors 为访问 L2 出错而无法比较的数量
+	Errors int
+	// Healed 为从 L1 中删除的不一致元素的数量
+	Healed int
+}
+
+// Divergent 返回不一致的元素数量。
+func (r AuditReport) Divergent() int {
+	return r.Mismatched + r.Missing + r.TTLDrift
+}
+
+// AuditOption 配置 Auditor 的行为。
+type AuditOption func(*auditConfig)
+
+type auditConfig struct {
+	sampleSize   int
+	interval     time.Duration
+	ttlTolerance time.Duration
+	heal         bool
+	onReport     func(AuditReport)
+}
+
+// WithAuditSampleSize 设置每轮审计抽样的键数量，默认为 DefaultAuditSampleSize。
+func WithAuditSampleSize(n int) AuditOption {
+	return func(c *auditConfig) {
+		c.sampleSize = n
+	}
+}
+
+// WithAuditInterval 设置 Run 的审计间隔，默认为 DefaultAuditInterval。
+func WithAuditInterval(d time.Duration) AuditOption {
+	return func(c *auditConfig) {
+		c.interval = d
+	}
+}
+
+// WithTTLTolerance 设置比较过期时间时允许的误差，默认为 DefaultTTLTolerance。
+func WithTTLTolerance(d time.Duration) AuditOption {
+	return func(c *auditConfig) {
+		c.ttlTolerance = d
+	}
+}
+
+// WithSelfHeal 使审计发现不一致时从 L1 删除该元素，之后的读取会从 L2 回填。
+func WithSelfHeal() AuditOption {
+	return func(c *auditConfig) {
+		c.heal = true
+	}
+}
+
+// WithAuditReport 设置 Run 每轮审计结束后的回调，可用于上报指标。
+func WithAuditReport(fn func(AuditReport)) AuditOption {
+	return func(c *auditConfig) {
+		c.onReport = fn
+	}
+}
+
+// Auditor 抽样比较两级缓存中的值和过期时间，用于检验失效广播等机制是否生效。
+// 审计与读写并发进行，刚被其他实例修改、失效事件尚未送达的键也会被计为不一致。
+type Auditor[K comparable, V any] struct {
+	c     *Cache[K, V]
+	equal func(a, b V) bool
+	cfg   auditConfig
+}
+
+// NewAuditor 创建审计 c 的 Auditor，equal 用于比较 L1 和 L2 中的值。
+// 只有 L2 实现了 TTLReader 时才会比较过期时间。
+func NewAuditor[K comparable, V any](c *Cache[K, V], equal func(a, b V) bool, opts ...AuditOption) *Auditor[K, V] {
+	a := &Auditor[K, V]{
+		c:     c,
+		equal: equal,
+		cfg: auditConfig{
+			sampleSize:   DefaultAuditSampleSize,
+			interval:     DefaultAuditInterval,
+			ttlTolerance: DefaultTTLTolerance,
+		},
+	}
+	for _, opt := range opts {
+		opt(&a.cfg)
+	}
+	return a
+}
+
+// Audit 执行一轮审计并返回结果。
+func (a *Auditor[K, V]) Audit(ctx context.Context) AuditReport {
+	var report AuditReport
+	ttlReader, _ := a.c.l2.(TTLReader[K])
+	for _, entry := range a.c.l1.Sample(a.cfg.sampleSize) {
+		report.Sampled++
+		remote, err := a.c.l2.Get(ctx, entry.Key)
+		var divergent bool
+		switch {
+		case errors.Is(err, cacheError.ErrNoKey):
+			report.Missing++
+			divergent = true
+		case err != nil:
+			report.Errors++
+			continue
+		case !a.equal(entry.Value, remote):
+			report.Mismatched++
+			divergent = true
+		case ttlReader != nil:
+			drift, err := a.ttlDrift(ctx, ttlReader, entry.Key)
+			if err != nil {
+				report.Errors++
+				continue
+			}
+			if drift {
+				report.TTLDrift++
+				divergent = true
+			}
+		}
+		if divergent && a.cfg.heal {
+			if err = a.c.l1.Delete(ctx, entry.Key); err == nil {
+				report.Healed++
+			}
+		}
+	}
+	return report
+}
+
+// ttlDrift 判断 L1 中 key 的过期时间是否比 L2 晚超过容忍误差。
+func (a *Auditor[K, V]) ttlDrift(ctx context.Context, reader TTLReader[K], key K) (bool, error) {
+	remote, err := reader.TTL(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if remote <= 0 {
+		// L2 永不过期
+		return false, nil
+	}
+	info, err := a.c.l1.EntryInfo(ctx, key)
+	if err != nil {
+		// 元素已经从 L1 中移除
+		return false, nil
+	}
+	if info.Expiration.IsZero() {
+		return true, nil
+	}
+	return time.Until(info.Expiration) > remote+a.cfg.ttlTolerance, nil
+}
+
+// Run 每隔一个审计间隔执行一轮审计，并将结果交给 WithAuditReport 设置的回调，直到 ctx 结束。
+func (a *Auditor[K, V]) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report := a.Audit(ctx)
+			if a.cfg.onReport != nil {
+				a.cfg.onReport(report)
+			}
+		}
+	}
+}
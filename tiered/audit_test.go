@@ -0,0 +1,112 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiered
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func equalString(a, b string) bool { return a == b }
+
+// remoteCache 模拟带有过期时间的 L2，写入的元素在 ttl 之后过期。
+type remoteCache struct {
+	*cache.Cache[string, string]
+	ttl time.Duration
+}
+
+func (r remoteCache) Set(ctx context.Context, key, value string) error {
+	return r.Cache.Set(ctx, key, value, cache.WithExpiration(r.ttl))
+}
+
+// TTL 实现了 TTLReader，永不过期时返回 0。
+func (r remoteCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.Cache.TTL(ctx, key)
+	if ttl == cache.NoExpiration {
+		ttl = 0
+	}
+	return ttl, err
+}
+
+func TestAuditor_Audit(t *testing.T) {
+	ctx := context.Background()
+	backing := cache.NewSimpleCache[string, string](ctx, 0, time.Minute)
+	l1 := cache.NewSimpleCache[string, string](ctx, 0, time.Minute)
+	l2 := remoteCache{Cache: backing, ttl: time.Minute}
+	c := New[string, string](l1, l2, WithL1TTL[string, string](time.Second))
+
+	require.NoError(t, c.Set(ctx, "ok", "v"))
+	require.NoError(t, l1.Set(ctx, "stale", "old"))
+	require.NoError(t, backing.Set(ctx, "stale", "new"))
+	require.NoError(t, l1.Set(ctx, "gone", "v"))
+	require.NoError(t, l1.Set(ctx, "drift", "v", cache.WithExpiration(time.Hour)))
+	require.NoError(t, backing.Set(ctx, "drift", "v", cache.WithExpiration(time.Minute)))
+
+	report := NewAuditor(c, equalString).Audit(ctx)
+	assert.Equal(t, AuditReport{Sampled: 4, Mismatched: 1, Missing: 1, TTLDrift: 1}, report)
+	assert.Equal(t, 3, report.Divergent())
+	assert.Len(t, l1.Keys(), 4)
+
+	report = NewAuditor(c, equalString, WithSelfHeal(), WithAuditSampleSize(10)).Audit(ctx)
+	assert.Equal(t, AuditReport{Sampled: 4, Mismatched: 1, Missing: 1, TTLDrift: 1, Healed: 3}, report)
+	assert.Equal(t, []string{"ok"}, l1.Keys())
+
+	// 修复后从 L2 回填最新的值
+	got, err := c.Get(ctx, "stale")
+	require.NoError(t, err)
+	assert.Equal(t, "new", got)
+}
+
+func TestAuditor_l2Unavailable(t *testing.T) {
+	ctx := context.Background()
+	l1 := cache.NewSimpleCache[string, int](ctx, 0, time.Minute)
+	require.NoError(t, l1.Set(ctx, "k", 1))
+	c := New[string, int](l1, brokenCache{})
+
+	report := NewAuditor(c, func(a, b int) bool { return a == b }, WithSelfHeal()).Audit(ctx)
+	assert.Equal(t, AuditReport{Sampled: 1, Errors: 1}, report)
+	assert.Equal(t, []string{"k"}, l1.Keys())
+}
+
+func TestAuditor_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l1 := cache.NewSimpleCache[string, int](ctx, 0, time.Minute)
+	require.NoError(t, l1.Set(ctx, "k", 1))
+	c := New[string, int](l1, brokenCache{})
+
+	reports := make(chan AuditReport, 1)
+	a := NewAuditor(c, func(a, b int) bool { return a == b },
+		WithAuditInterval(time.Millisecond),
+		WithAuditReport(func(r AuditReport) {
+			select {
+			case reports <- r:
+			default:
+			}
+		}))
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+	assert.Equal(t, AuditReport{Sampled: 1, Errors: 1}, <-reports)
+	cancel()
+	<-done
+}
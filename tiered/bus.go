@@ -0,0 +1,135 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiered
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+var ErrNoBus = errors.New("tiered: no invalidation bus configured")
+
+// Op 是失效事件的类型。
+type Op uint8
+
+const (
+	// OpSet 表示键被写入了新值。
+	OpSet Op = iota + 1
+	// OpDelete 表示键被删除。
+	OpDelete
+	// OpReset 表示订阅中断过，期间的事件可能已经丢失，收到后应当清空本地缓存。
+	OpReset
+)
+
+// Event 是在多个实例之间广播的失效事件，Source 是发布事件的实例的标识，用于忽略自己发布的事件。
+type Event[K comparable] struct {
+	Op     Op     `json:"op"`
+	Key    K      `json:"key"`
+	Source string `json:"source"`
+}
+
+// Bus 是失效事件的广播通道，基于 Redis pub/sub 的实现见 tiered/goredis。
+type Bus[K comparable] interface {
+	// Publish 将事件广播给所有订阅者，包括发布者自己。
+	Publish(ctx context.Context, e Event[K]) error
+	// Subscribe 订阅事件，返回时订阅已经生效。之后按顺序对每个事件调用 fn，直到 ctx 结束。
+	Subscribe(ctx context.Context, fn func(Event[K])) error
+}
+
+// WithBus 设置失效事件的广播通道：Set 和 Delete 成功后会广播事件，
+// 调用 Listen 后，其他实例广播的事件会使本地 L1 中对应的键失效，之后的读取从 L2 获取最新的值。
+func WithBus[K comparable, V any](bus Bus[K]) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.bus = bus
+	}
+}
+
+func newSource() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Listen 订阅广播通道并将其他实例的事件应用到 L1，返回时订阅已经生效，ctx 结束后停止。
+// 未设置广播通道时返回 ErrNoBus。
+func (c *Cache[K, V]) Listen(ctx context.Context) error {
+	if c.bus == nil {
+		return ErrNoBus
+	}
+	return c.bus.Subscribe(ctx, func(e Event[K]) {
+		if e.Source == c.source {
+			return
+		}
+		switch e.Op {
+		case OpSet, OpDelete:
+			// 值不随事件传递，删除后由下一次读取从 L2 回填
+			_ = c.l1.Delete(ctx, e.Key)
+		case OpReset:
+			for _, key := range c.l1.Keys() {
+				_ = c.l1.Delete(ctx, key)
+			}
+		}
+	})
+}
+
+// publish 广播本实例的修改，失败时交给错误处理函数，不影响已经完成的修改。
+func (c *Cache[K, V]) publish(ctx context.Context, op Op, key K) {
+	if c.bus == nil {
+		return
+	}
+	if err := c.bus.Publish(ctx, Event[K]{Op: op, Key: key, Source: c.source}); err != nil {
+		c.report(err)
+	}
+}
+
+// MemoryBus 是进程内的广播通道，Publish 在调用方的协程中依次调用所有订阅者，适用于测试以及同一进程内的多个缓存。
+type MemoryBus[K comparable] struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]func(Event[K])
+}
+
+// NewMemoryBus 创建一个进程内的广播通道。
+func NewMemoryBus[K comparable]() *MemoryBus[K] {
+	return &MemoryBus[K]{subs: make(map[int]func(Event[K]))}
+}
+
+// Publish 实现了 Bus。
+func (b *MemoryBus[K]) Publish(_ context.Context, e Event[K]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, fn := range b.subs {
+		fn(e)
+	}
+	return nil
+}
+
+// Subscribe 实现了 Bus。
+func (b *MemoryBus[K]) Subscribe(ctx context.Context, fn func(Event[K])) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subs[id] = fn
+	context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+	})
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiered
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Listen(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2 := simple.NewCache[string, int](0)
+	bus := NewMemoryBus[string]()
+	newInstance := func() *Cache[string, int] {
+		c := New[string, int](cache.NewSimpleCache[string, int](ctx, 0, time.Minute), l2, WithBus[string, int](bus))
+		require.NoError(t, c.Listen(ctx))
+		return c
+	}
+	a, b := newInstance(), newInstance()
+
+	require.NoError(t, a.Set(ctx, "k", 1))
+	got, err := b.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+
+	// a 的修改使 b 本地的旧值失效，而 a 自己的 L1 不受影响
+	require.NoError(t, a.Set(ctx, "k", 2))
+	assert.Equal(t, []string{"k"}, a.l1.Keys())
+	assert.Empty(t, b.l1.Keys())
+	got, err = b.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, 2, got)
+
+	require.NoError(t, a.Delete(ctx, "k"))
+	_, err = b.Get(ctx, "k")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+
+	// 取消订阅后不再接收事件
+	cancel()
+	require.Eventually(t, func() bool {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		return len(bus.subs) == 0
+	}, time.Second, time.Millisecond)
+	require.NoError(t, b.l1.Set(context.Background(), "k", 3))
+	require.NoError(t, a.Set(context.Background(), "k", 4))
+	assert.Equal(t, []string{"k"}, b.l1.Keys())
+
+	assert.Equal(t, ErrNoBus, New[string, int](nil, l2).Listen(ctx))
+}
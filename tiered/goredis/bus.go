@@ -0,0 +1,147 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goredis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/tiered"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ tiered.Bus[string] = (*RedisBus[string])(nil)
+
+// DefaultRetryInterval 订阅断开后重新订阅的默认间隔。
+const DefaultRetryInterval = time.Second
+
+// BusOption 配置 RedisBus 的行为。
+type BusOption func(*busConfig)
+
+type busConfig struct {
+	retry   time.Duration
+	onError func(err error)
+}
+
+// WithRetryInterval 设置订阅断开后重新订阅的间隔，默认为 DefaultRetryInterval。
+func WithRetryInterval(d time.Duration) BusOption {
+	return func(c *busConfig) {
+		c.retry = d
+	}
+}
+
+// WithBusErrorHandler 设置订阅断开或者收到无法解析的消息时的处理函数。
+func WithBusErrorHandler(fn func(err error)) BusOption {
+	return func(c *busConfig) {
+		c.onError = fn
+	}
+}
+
+// RedisBus 是基于 Redis pub/sub 的广播通道，事件以 JSON 格式发布到 channel。
+// pub/sub 不保证送达，订阅断开后会自动重新订阅，并以 tiered.OpReset 事件通知订阅者期间的事件可能已经丢失。
+type RedisBus[K comparable] struct {
+	client  redis.UniversalClient
+	channel string
+	cfg     busConfig
+}
+
+// NewRedisBus 创建使用 client 在 channel 上广播事件的 RedisBus。
+func NewRedisBus[K comparable](client redis.UniversalClient, channel string, opts ...BusOption) *RedisBus[K] {
+	b := &RedisBus[K]{client: client, channel: channel, cfg: busConfig{retry: DefaultRetryInterval}}
+	for _, opt := range opts {
+		opt(&b.cfg)
+	}
+	return b
+}
+
+// Publish 实现了 tiered.Bus。
+func (b *RedisBus[K]) Publish(ctx context.Context, e tiered.Event[K]) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Subscribe 实现了 tiered.Bus，返回时订阅已经生效，事件在后台协程中按顺序处理。
+func (b *RedisBus[K]) Subscribe(ctx context.Context, fn func(tiered.Event[K])) error {
+	sub := b.client.Subscribe(ctx, b.channel)
+	// 等待订阅确认，使连接错误在这里返回
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return err
+	}
+	go func() {
+		// Receive 不会因为 ctx 结束而返回，关闭订阅使其返回
+		<-ctx.Done()
+		_ = sub.Close()
+	}()
+	go b.receive(ctx, sub, fn)
+	return nil
+}
+
+// receive 处理订阅收到的消息。连接断开后，go-redis 在下一次 Receive 时重新连接并重新订阅，
+// 收到新的订阅确认时发送 tiered.OpReset 事件。
+func (b *RedisBus[K]) receive(ctx context.Context, sub *redis.PubSub, fn func(tiered.Event[K])) {
+	lost := false
+	for {
+		msg, err := sub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.report(err)
+			lost = true
+			if !b.wait(ctx) {
+				return
+			}
+			continue
+		}
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if lost {
+				lost = false
+				fn(tiered.Event[K]{Op: tiered.OpReset})
+			}
+		case *redis.Message:
+			var e tiered.Event[K]
+			if err = json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				b.report(fmt.Errorf("goredis: decode event: %w", err))
+				continue
+			}
+			fn(e)
+		}
+	}
+}
+
+// wait 等待 cfg.retry 后再次尝试接收，ctx 结束时返回 false。
+func (b *RedisBus[K]) wait(ctx context.Context) bool {
+	timer := time.NewTimer(b.cfg.retry)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (b *RedisBus[K]) report(err error) {
+	if b.cfg.onError != nil {
+		b.cfg.onError(err)
+	}
+}
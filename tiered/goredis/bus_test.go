@@ -0,0 +1,97 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/tiered"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisBus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, client := startRedis(t)
+
+	errs := make(chan error, 16)
+	bus := NewRedisBus[string](client, "invalidation",
+		WithRetryInterval(10*time.Millisecond),
+		WithBusErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}))
+	events := make(chan tiered.Event[string], 16)
+	require.NoError(t, bus.Subscribe(ctx, func(e tiered.Event[string]) { events <- e }))
+
+	want := tiered.Event[string]{Op: tiered.OpDelete, Key: "k", Source: "a"}
+	require.NoError(t, bus.Publish(ctx, want))
+	assert.Equal(t, want, <-events)
+
+	// 无法解析的消息被跳过
+	require.NoError(t, client.Publish(ctx, "invalidation", "garbage").Err())
+	assert.Error(t, <-errs)
+
+	// 服务端重启后重新订阅，并通知订阅者期间的事件可能已经丢失
+	s.Close()
+	assert.Error(t, <-errs)
+	require.NoError(t, s.Restart())
+	assert.Equal(t, tiered.Event[string]{Op: tiered.OpReset}, <-events)
+	require.Eventually(t, func() bool { return bus.Publish(ctx, want) == nil }, time.Second, time.Millisecond)
+	assert.Equal(t, want, <-events)
+
+	// ctx 结束后不再接收事件
+	cancel()
+	require.Eventually(t, func() bool { return s.PubSubNumSub("invalidation")["invalidation"] == 0 }, time.Second, time.Millisecond)
+}
+
+func TestRedisBus_subscribeError(t *testing.T) {
+	s, client := startRedis(t)
+	s.Close()
+	bus := NewRedisBus[string](client, "invalidation")
+	assert.Error(t, bus.Subscribe(context.Background(), func(tiered.Event[string]) {}))
+}
+
+func TestTiered_Listen(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, client := startRedis(t)
+	l2 := stringRedis(client)
+	newInstance := func() *tiered.Cache[string, string] {
+		bus := NewRedisBus[string](client, "invalidation")
+		c := tiered.New[string, string](cache.NewSimpleCache[string, string](ctx, 0, time.Minute), l2, tiered.WithBus[string, string](bus))
+		require.NoError(t, c.Listen(ctx))
+		return c
+	}
+	a, b := newInstance(), newInstance()
+
+	require.NoError(t, a.Set(ctx, "k", "1"))
+	got, err := b.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "1", got)
+
+	// a 的修改通过 Redis pub/sub 使 b 本地的旧值失效
+	require.NoError(t, a.Set(ctx, "k", "2"))
+	require.Eventually(t, func() bool {
+		got, err := b.Get(ctx, "k")
+		return err == nil && got == "2"
+	}, time.Second, time.Millisecond)
+}
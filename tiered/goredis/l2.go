@@ -0,0 +1,137 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package goredis 基于 go-redis 实现 tiered 包的 L2 适配器和失效事件的广播通道。
+// 该包是一个单独的模块，只有使用 Redis 作为 L2 的项目才会依赖 go-redis。
+package goredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/internal/glob"
+	"github.com/chenmingyong0423/go-generics-cache/tiered"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_ types.ICache[string, int] = (*Redis[int])(nil)
+	_ tiered.TTLReader[string]  = (*Redis[int])(nil)
+)
+
+// RedisOption 配置 Redis 的行为。
+type RedisOption func(*redisConfig)
+
+type redisConfig struct {
+	prefix string
+	ttl    time.Duration
+}
+
+// WithPrefix 为所有的键加上前缀，多个缓存共享同一个 Redis 时用于区分命名空间。
+func WithPrefix(prefix string) RedisOption {
+	return func(c *redisConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithTTL 设置写入 Redis 的键的过期时间，默认永不过期。
+func WithTTL(ttl time.Duration) RedisOption {
+	return func(c *redisConfig) {
+		c.ttl = ttl
+	}
+}
+
+// Redis 是基于 go-redis 的 L2 适配器，实现了 types.ICache，值通过 marshal 和 unmarshal 与字节相互转换。
+type Redis[V any] struct {
+	client    redis.Cmdable
+	marshal   func(V) ([]byte, error)
+	unmarshal func([]byte) (V, error)
+	cfg       redisConfig
+}
+
+// NewRedis 创建使用 client 访问 Redis 的适配器，client 可以是 *redis.Client、*redis.ClusterClient 等。
+func NewRedis[V any](client redis.Cmdable, marshal func(V) ([]byte, error), unmarshal func([]byte) (V, error), opts ...RedisOption) *Redis[V] {
+	r := &Redis[V]{client: client, marshal: marshal, unmarshal: unmarshal}
+	for _, opt := range opts {
+		opt(&r.cfg)
+	}
+	return r
+}
+
+func (r *Redis[V]) Get(ctx context.Context, key string) (v V, err error) {
+	b, err := r.client.Get(ctx, r.cfg.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return v, cacheError.ErrNoKey
+	}
+	if err != nil {
+		return v, err
+	}
+	return r.unmarshal(b)
+}
+
+func (r *Redis[V]) Set(ctx context.Context, key string, value V) error {
+	b, err := r.marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, r.cfg.prefix+key, b, r.cfg.ttl).Err()
+}
+
+func (r *Redis[V]) Delete(ctx context.Context, key string) error {
+	n, err := r.client.Del(ctx, r.cfg.prefix+key).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return cacheError.ErrNoKey
+	}
+	return nil
+}
+
+// TTL 通过 PTTL 命令返回 key 的剩余过期时间，实现了 tiered.TTLReader。
+func (r *Redis[V]) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, r.cfg.prefix+key).Result()
+	switch {
+	case err != nil:
+		return 0, err
+	case ttl == -2:
+		// go-redis 原样返回 PTTL 的 -2（不存在）和 -1（永不过期）
+		return 0, cacheError.ErrNoKey
+	case ttl < 0:
+		return 0, nil
+	default:
+		return ttl, nil
+	}
+}
+
+// Keys 通过 SCAN 命令返回带有前缀的键（已去掉前缀），SCAN 可能多次返回同一个键，这里已经去重。
+// 由于 types.ICache 的 Keys 不返回错误，请求失败时返回已经读取到的键。
+func (r *Redis[V]) Keys() []string {
+	keys := make([]string, 0)
+	seen := make(map[string]struct{})
+	iter := r.client.Scan(context.Background(), 0, glob.QuoteMeta(r.cfg.prefix)+"*", 0).Iterator()
+	for iter.Next(context.Background()) {
+		key := strings.TrimPrefix(iter.Val(), r.cfg.prefix)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goredis
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/tiered"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startRedis 使用 miniredis 模拟 Redis，返回服务端和连接它的客户端。
+func startRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() {
+		assert.NoError(t, client.Close())
+	})
+	return s, client
+}
+
+func stringRedis(client redis.Cmdable, opts ...RedisOption) *Redis[string] {
+	return NewRedis[string](client,
+		func(v string) ([]byte, error) { return []byte(v), nil },
+		func(b []byte) (string, error) { return string(b), nil },
+		opts...)
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	s, client := startRedis(t)
+	r := NewRedis[int](client,
+		func(v int) ([]byte, error) { return []byte(strconv.Itoa(v)), nil },
+		func(b []byte) (int, error) { return strconv.Atoi(string(b)) },
+		WithPrefix("app:*:"), WithTTL(time.Minute))
+
+	require.NoError(t, s.Set("other", "1"))
+	require.NoError(t, s.Set("app:x:1", "1"))
+
+	_, err := r.Get(ctx, "a")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+	require.NoError(t, r.Set(ctx, "a", 1))
+	require.NoError(t, r.Set(ctx, "b", 2))
+	got, err := r.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.Equal(t, time.Minute, s.TTL("app:*:a"))
+
+	// 前缀中的通配符被转义，只返回本命名空间的键
+	keys := r.Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	require.NoError(t, r.Delete(ctx, "a"))
+	assert.Equal(t, cacheError.ErrNoKey, r.Delete(ctx, "a"))
+
+	require.NoError(t, s.Set("app:*:bad", "x"))
+	_, err = r.Get(ctx, "bad")
+	assert.Error(t, err)
+
+	// 连接失败时返回错误
+	s.Close()
+	_, err = r.Get(ctx, "b")
+	assert.Error(t, err)
+	assert.NotEqual(t, cacheError.ErrNoKey, err)
+}
+
+func TestRedis_TTL(t *testing.T) {
+	ctx := context.Background()
+	s, client := startRedis(t)
+	r := stringRedis(client, WithPrefix("p:"))
+	require.NoError(t, s.Set("p:a", "1"))
+	s.SetTTL("p:a", time.Minute)
+	require.NoError(t, s.Set("p:b", "2"))
+
+	ttl, err := r.TTL(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+	ttl, err = r.TTL(ctx, "b")
+	require.NoError(t, err)
+	assert.Zero(t, ttl)
+	_, err = r.TTL(ctx, "missing")
+	assert.Equal(t, cacheError.ErrNoKey, err)
+}
+
+func TestRedis_Conformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		_, client := startRedis(t)
+		return stringRedis(client)
+	}, cachetest.WithConcurrency())
+}
+
+func TestTiered_withRedis(t *testing.T) {
+	ctx := context.Background()
+	_, client := startRedis(t)
+	l2 := stringRedis(client)
+
+	// 两个实例共享同一个 L2
+	a := tiered.New[string, string](cache.NewLruCache[string, string](ctx, 10, 0), l2)
+	b := tiered.New[string, string](cache.NewLruCache[string, string](ctx, 10, 0), l2)
+
+	require.NoError(t, a.Set(ctx, "k", "v"))
+	got, err := b.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", got)
+}
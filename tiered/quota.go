@@ -0,0 +1,118 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiered
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+// quotaSlackRatio 是软配额允许超出的比例，超出后才统计 L1 的实际大小，把统计的开销分摊到多次写入上。
+const quotaSlackRatio = 10
+
+// WithL1Quota 为 L1 设置以元素数量计的软配额。多个两级缓存（例如通过 WithPrefix 区分的不同命名空间）
+// 共享进程内存时，可以用它防止某个命名空间占用过多内存。
+// L1 的元素数量可能短暂超过配额，超出一定比例后最冷的元素会被降级到 L2：L2 中已有该键时直接从 L1 删除，
+// 否则先写入 L2 再删除，之后的读取可以从 L2 取回。冷热顺序取决于 L1 的后端，支持 types.OrderRecency 时
+// 按最近使用的顺序，支持 types.OrderInsertion 时按写入顺序，否则顺序不确定。quota 小于等于 0 时不限制。
+//
+// 如果其他实例删除了某个键而失效事件尚未送达，降级可能把本地的旧值重新写入 L2。
+func WithL1Quota[K comparable, V any](quota int) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.quota = quota
+	}
+}
+
+// quotaState 记录软配额的状态。
+type quotaState struct {
+	mu sync.Mutex
+	// size 是 L1 大小的上界估计：统计时设置为实际大小，之后每次写入 L1 加一
+	size int
+	// demoting 表示有协程正在降级，其他协程的写入不会等待降级完成
+	demoting bool
+}
+
+// noteL1Write 记录一次 L1 写入，估计的大小超出配额一定比例后降级最冷的元素。
+func (c *Cache[K, V]) noteL1Write(ctx context.Context) {
+	if c.quota <= 0 {
+		return
+	}
+	q := &c.quotaState
+	q.mu.Lock()
+	q.size++
+	if q.demoting || q.size <= c.quota+c.quota/quotaSlackRatio {
+		q.mu.Unlock()
+		return
+	}
+	q.demoting = true
+	q.size = 0
+	q.mu.Unlock()
+
+	keys := c.coldestKeys()
+	n := len(keys)
+	for _, key := range keys[:max(len(keys)-c.quota, 0)] {
+		if c.demote(ctx, key) {
+			n--
+		}
+	}
+
+	q.mu.Lock()
+	// 降级期间的写入已经计入 size
+	q.size += n
+	q.demoting = false
+	q.mu.Unlock()
+}
+
+// coldestKeys 返回 L1 中的键，尽量按从冷到热的顺序。
+func (c *Cache[K, V]) coldestKeys() []K {
+	for _, order := range []types.Order{types.OrderRecency, types.OrderInsertion} {
+		if keys, err := c.l1.KeysIn(order); err == nil {
+			return keys
+		}
+	}
+	return c.l1.Keys()
+}
+
+// demote 将 key 从 L1 降级到 L2，返回 key 是否已经不在 L1 中。
+func (c *Cache[K, V]) demote(ctx context.Context, key K) bool {
+	values, err := c.l1.MGet(ctx, key)
+	if err != nil {
+		c.report(err)
+		return false
+	}
+	v, ok := values[key]
+	if !ok {
+		// 已经过期或被删除
+		return true
+	}
+	_, err = c.l2.Get(ctx, key)
+	switch {
+	case errors.Is(err, cacheError.ErrNoKey):
+		if err = c.l2.Set(ctx, key, v); err != nil {
+			// 无法保留到 L2 时留在 L1 中，避免丢失
+			c.report(err)
+			return false
+		}
+	case err != nil:
+		c.report(err)
+		return false
+	}
+	err = c.l1.Delete(ctx, key)
+	return err == nil || errors.Is(err, cacheError.ErrNoKey)
+}
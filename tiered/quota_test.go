@@ -0,0 +1,88 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiered
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_L1Quota(t *testing.T) {
+	ctx := context.Background()
+	l1 := cache.NewLruCache[string, int](ctx, 100, time.Minute)
+	l2 := simple.NewCache[string, int](0)
+	c := New[string, int](l1, l2, WithL1Quota[string, int](10))
+
+	// 超出配额 10% 后才降级，最冷的两个元素已经在 L2 中，直接从 L1 删除
+	for i := 0; i < 12; i++ {
+		require.NoError(t, c.Set(ctx, strconv.Itoa(i), i))
+	}
+	assert.Len(t, l1.Keys(), 10)
+	assert.NotContains(t, l1.Keys(), "0")
+	assert.NotContains(t, l1.Keys(), "1")
+	got, err := c.Get(ctx, "0")
+	require.NoError(t, err)
+	assert.Equal(t, 0, got)
+}
+
+func TestCache_L1Quota_spill(t *testing.T) {
+	ctx := context.Background()
+	l1 := cache.NewLruCache[string, int](ctx, 100, time.Minute)
+	l2 := simple.NewCache[string, int](0)
+	c := New[string, int](l1, l2, WithL1Quota[string, int](2))
+
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.Set(ctx, "b", 2))
+	// 模拟 L2 中的键已经过期，降级时写回 L2
+	require.NoError(t, l2.Delete(ctx, "a"))
+	require.NoError(t, c.Set(ctx, "c", 3))
+	assert.Equal(t, []string{"b", "c"}, l1.Keys())
+	v, err := l2.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	// 从 L2 回填同样计入配额
+	got, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.Equal(t, []string{"c", "a"}, l1.Keys())
+}
+
+func TestCache_L1Quota_l2Unavailable(t *testing.T) {
+	ctx := context.Background()
+	l1 := cache.NewLruCache[string, int](ctx, 100, time.Minute)
+	var errs int
+	loader := cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		return len(key), nil
+	})
+	c := New[string, int](l1, brokenCache{}, WithL1Quota[string, int](1), WithLoader[string, int](loader),
+		WithErrorHandler[string, int](func(err error) { errs++ }))
+
+	for _, key := range []string{"x", "y"} {
+		_, err := c.Get(ctx, key)
+		require.NoError(t, err)
+	}
+	// 无法降级到 L2 的元素留在 L1 中
+	assert.ElementsMatch(t, []string{"x", "y"}, l1.Keys())
+	// 两次读取 L2 失败，一次降级失败
+	assert.Equal(t, 3, errs)
+}
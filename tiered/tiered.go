@@ -0,0 +1,174 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tiered 将进程内缓存（L1）与远程缓存（L2，如 Redis）组合为两级缓存。
+// 读取依次查询 L1、L2 和加载器，并回填未命中的层级；写入和删除同时作用于两级缓存。
+// 多个实例共享 L2 时，可以通过 WithBus 设置失效事件的广播通道，使其他实例本地 L1 中的旧值失效。
+// 基于 go-redis 的 L2 适配器和广播通道位于单独的模块 tiered/goredis 中，本模块不依赖 go-redis。
+package tiered
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+var _ types.ICache[string, int] = (*Cache[string, int])(nil)
+
+// Option 配置 Cache 的行为。
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithLoader 设置两级缓存都未命中时使用的加载器，加载结果会回填到 L2 和 L1。
+func WithLoader[K comparable, V any](loader cache.Loader[K, V]) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.loader = loader
+	}
+}
+
+// WithL1TTL 设置写入 L1 的元素的过期时间，包括 Set 以及从 L2 或加载器回填的元素。
+// 较短的 L1 TTL 可以限制其他实例修改 L2 后本地读到旧值的时长。默认永不过期。
+func WithL1TTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.l1Opts = nil
+		if ttl > 0 {
+			c.l1Opts = []cache.ItemOption{cache.WithExpiration(ttl)}
+		}
+	}
+}
+
+// WithErrorHandler 设置 L2 发生错误但读取仍然可以继续时的处理函数，
+// 例如 L2 不可用时降级到加载器，或者回填 L2 失败。
+func WithErrorHandler[K comparable, V any](fn func(err error)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.onError = fn
+	}
+}
+
+// Cache 是以 l1 为本地缓存、l2 为远程缓存的两级缓存，实现了 types.ICache。
+type Cache[K comparable, V any] struct {
+	l1      *cache.Cache[K, V]
+	l2      types.ICache[K, V]
+	loader  cache.Loader[K, V]
+	l1Opts  []cache.ItemOption
+	onError func(err error)
+	bus     Bus[K]
+	// source 是本实例的标识，用于忽略自己广播的事件
+	source     string
+	quota      int
+	quotaState quotaState
+}
+
+// New 创建两级缓存，l1 通常是容量较小的进程内缓存，l2 是多个实例共享的远程缓存。
+func New[K comparable, V any](l1 *cache.Cache[K, V], l2 types.ICache[K, V], opts ...Option[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{l1: l1, l2: l2, source: newSource()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get 依次查询 L1、L2 和加载器，并回填未命中的层级，都未命中时返回 cacheError.ErrNoKey。
+// 对同一个键的并发未命中只会查询一次 L2 和加载器。
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	var filled bool
+	v, err := c.l1.GetOrLoad(ctx, key, func(ctx context.Context, key K) (V, error) {
+		filled = true
+		return c.load(ctx, key)
+	}, c.l1Opts...)
+	if filled && err == nil {
+		c.noteL1Write(ctx)
+	}
+	return v, err
+}
+
+// load 在 L1 未命中后查询 L2，L2 未命中或不可用时使用加载器并回填 L2。
+func (c *Cache[K, V]) load(ctx context.Context, key K) (V, error) {
+	v, err := c.l2.Get(ctx, key)
+	if err == nil {
+		return v, nil
+	}
+	if c.loader == nil {
+		return v, err
+	}
+	l2Missed := errors.Is(err, cacheError.ErrNoKey)
+	if !l2Missed {
+		c.report(err)
+	}
+	v, err = c.loader.Load(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	if l2Missed {
+		if err = c.l2.Set(ctx, key, v); err != nil {
+			c.report(err)
+		}
+	}
+	return v, nil
+}
+
+func (c *Cache[K, V]) report(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// Set 先写入 L2，成功后再写入 L1，L2 写入失败时 L1 不会被修改。设置了广播通道时，写入成功后广播 OpSet 事件。
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) error {
+	if err := c.l2.Set(ctx, key, value); err != nil {
+		return err
+	}
+	if err := c.l1.Set(ctx, key, value, c.l1Opts...); err != nil {
+		return err
+	}
+	c.noteL1Write(ctx)
+	c.publish(ctx, OpSet, key)
+	return nil
+}
+
+// Delete 从两级缓存中删除 key，两级缓存中都不存在时返回 cacheError.ErrNoKey。
+// 设置了广播通道时，即使本地不存在 key 也会广播 OpDelete 事件，其他实例的 L1 中可能仍有旧值。
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	err2 := c.l2.Delete(ctx, key)
+	if err2 != nil && !errors.Is(err2, cacheError.ErrNoKey) {
+		return err2
+	}
+	err1 := c.l1.Delete(ctx, key)
+	if err1 != nil && !errors.Is(err1, cacheError.ErrNoKey) {
+		return err1
+	}
+	c.publish(ctx, OpDelete, key)
+	if err1 != nil && err2 != nil {
+		return cacheError.ErrNoKey
+	}
+	return nil
+}
+
+// Keys 返回两级缓存中所有键的并集。
+func (c *Cache[K, V]) Keys() []K {
+	keys := c.l2.Keys()
+	seen := make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		seen[key] = struct{}{}
+	}
+	for _, key := range c.l1.Keys() {
+		if _, ok := seen[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
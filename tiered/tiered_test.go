@@ -0,0 +1,190 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiered
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/chenmingyong0423/go-generics-cache"
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errL2 = errors.New("l2 error")
+
+// brokenCache 模拟不可用的 L2。
+type brokenCache struct{}
+
+func (brokenCache) Get(context.Context, string) (int, error) { return 0, errL2 }
+func (brokenCache) Set(context.Context, string, int) error   { return errL2 }
+func (brokenCache) Delete(context.Context, string) error     { return errL2 }
+func (brokenCache) Keys() []string                           { return []string{} }
+
+func TestCache_Get(t *testing.T) {
+	ctx := context.Background()
+	var loads atomic.Int32
+	loader := cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		loads.Add(1)
+		if key == "missing" {
+			return 0, cacheError.ErrNoKey
+		}
+		return len(key), nil
+	})
+
+	testCases := []struct {
+		name  string
+		setup func(t *testing.T, l1 *cache.Cache[string, int], l2 *simple.Cache[string, int])
+		opts  []Option[string, int]
+		key   string
+
+		want      int
+		wantErr   error
+		wantLoads int32
+		wantL1    bool
+		wantL2    bool
+	}{
+		{
+			name: "l1 hit",
+			setup: func(t *testing.T, l1 *cache.Cache[string, int], l2 *simple.Cache[string, int]) {
+				require.NoError(t, l1.Set(ctx, "k", 1))
+			},
+			key:    "k",
+			want:   1,
+			wantL1: true,
+		},
+		{
+			name: "l2 hit back-fills l1",
+			setup: func(t *testing.T, l1 *cache.Cache[string, int], l2 *simple.Cache[string, int]) {
+				require.NoError(t, l2.Set(ctx, "k", 2))
+			},
+			key:    "k",
+			want:   2,
+			wantL1: true,
+			wantL2: true,
+		},
+		{
+			name:    "miss without loader",
+			setup:   func(t *testing.T, l1 *cache.Cache[string, int], l2 *simple.Cache[string, int]) {},
+			key:     "k",
+			wantErr: cacheError.ErrNoKey,
+		},
+		{
+			name:      "loader back-fills both tiers",
+			setup:     func(t *testing.T, l1 *cache.Cache[string, int], l2 *simple.Cache[string, int]) {},
+			opts:      []Option[string, int]{WithLoader[string, int](loader)},
+			key:       "abc",
+			want:      3,
+			wantLoads: 1,
+			wantL1:    true,
+			wantL2:    true,
+		},
+		{
+			name:      "loader miss",
+			setup:     func(t *testing.T, l1 *cache.Cache[string, int], l2 *simple.Cache[string, int]) {},
+			opts:      []Option[string, int]{WithLoader[string, int](loader)},
+			key:       "missing",
+			wantErr:   cacheError.ErrNoKey,
+			wantLoads: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			loads.Store(0)
+			l1 := cache.NewSimpleCache[string, int](ctx, 0, 0)
+			l2 := simple.NewCache[string, int](0)
+			tc.setup(t, l1, l2)
+			c := New[string, int](l1, l2, tc.opts...)
+
+			got, err := c.Get(ctx, tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantLoads, loads.Load())
+			_, err = l1.Get(ctx, tc.key)
+			assert.Equal(t, tc.wantL1, err == nil)
+			_, err = l2.Get(ctx, tc.key)
+			assert.Equal(t, tc.wantL2, err == nil)
+		})
+	}
+}
+
+func TestCache_Get_l2Unavailable(t *testing.T) {
+	ctx := context.Background()
+	var reported []error
+	l1 := cache.NewSimpleCache[string, int](ctx, 0, 0)
+	loader := cache.LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+		return 1, nil
+	})
+
+	// 没有加载器时返回 L2 的错误
+	c := New[string, int](l1, brokenCache{})
+	_, err := c.Get(ctx, "k")
+	assert.Equal(t, errL2, err)
+
+	// 有加载器时降级到加载器，不回填不可用的 L2
+	c = New[string, int](l1, brokenCache{}, WithLoader[string, int](loader),
+		WithErrorHandler[string, int](func(err error) { reported = append(reported, err) }))
+	got, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.Equal(t, []error{errL2}, reported)
+}
+
+func TestCache_SetDelete(t *testing.T) {
+	ctx := context.Background()
+	l1 := cache.NewSimpleCache[string, int](ctx, 0, 0)
+	l2 := simple.NewCache[string, int](0)
+	c := New[string, int](l1, l2, WithL1TTL[string, int](time.Minute))
+
+	require.NoError(t, c.Set(ctx, "a", 1))
+	info, err := l1.EntryInfo(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, info.Expiration.IsZero())
+	v, err := l2.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	require.NoError(t, l2.Set(ctx, "b", 2))
+	keys := c.Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	// 只存在于某一级的键也可以被删除
+	require.NoError(t, c.Delete(ctx, "b"))
+	require.NoError(t, c.Delete(ctx, "a"))
+	assert.Equal(t, cacheError.ErrNoKey, c.Delete(ctx, "a"))
+	assert.Empty(t, c.Keys())
+
+	// L2 写入失败时 L1 不会被修改
+	c = New[string, int](l1, brokenCache{})
+	assert.Equal(t, errL2, c.Set(ctx, "c", 3))
+	assert.Empty(t, l1.Keys())
+	assert.Equal(t, errL2, c.Delete(ctx, "c"))
+}
+
+func TestCache_Conformance(t *testing.T) {
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return New[string, string](cache.NewSimpleCache[string, string](context.Background(), 0, 0), simple.NewConcurrentCache[string, string]())
+	}, cachetest.WithConcurrency())
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing 提供在每次操作前后调用 Tracer 的 types.ICache 装饰器。Tracer 只是一个接口，
+// 不依赖 OpenTelemetry，可以接入任意的 APM 系统。
+package tracing
+
+import (
+	"context"
+
+	"github.com/chenmingyong0423/go-generics-cache/types"
+)
+
+var _ types.ICache[string, int] = (*Cache[string, int])(nil)
+
+// 传给 Tracer.Start 的操作名称。
+const (
+	OpGet    = "get"
+	OpSet    = "set"
+	OpDelete = "delete"
+	OpKeys   = "keys"
+)
+
+// Tracer 在每次操作开始时被调用，通常创建一个 span 并将其放入返回的 ctx 中。
+// 返回的 ctx 会传给被包装的缓存，因此多层装饰器或远程后端创建的 span 会成为它的子 span。
+type Tracer[K comparable] interface {
+	// Start 开始 op 对 key 的操作，Keys 没有键，key 为零值且 ctx 为 context.Background()。
+	Start(ctx context.Context, op string, key K) (context.Context, Span)
+}
+
+// Span 表示一次正在进行的操作。
+type Span interface {
+	// End 在操作返回之前调用，err 为操作返回的错误：nil 表示成功，可以被 errors.Is 识别为
+	// cacheError.ErrNoKey 的错误表示未命中，其他错误表示失败。
+	End(err error)
+}
+
+// TracerFunc 将普通函数适配为 Tracer。
+type TracerFunc[K comparable] func(ctx context.Context, op string, key K) (context.Context, Span)
+
+// Start 调用 f(ctx, op, key)。
+func (f TracerFunc[K]) Start(ctx context.Context, op string, key K) (context.Context, Span) {
+	return f(ctx, op, key)
+}
+
+// SpanFunc 将普通函数适配为 Span。
+type SpanFunc func(err error)
+
+// End 调用 f(err)。
+func (f SpanFunc) End(err error) {
+	f(err)
+}
+
+// Cache 包装任意的 types.ICache，在每次操作前后调用 Tracer，自身实现了 types.ICache。
+// 与 metrics.Cache 相同，只转发 types.ICache 的方法，需要时可以通过 Unwrap 获取被包装的缓存。
+type Cache[K comparable, V any] struct {
+	cache  types.ICache[K, V]
+	tracer Tracer[K]
+}
+
+// New 返回在 c 的每次操作前后调用 tracer 的装饰器。
+func New[K comparable, V any](c types.ICache[K, V], tracer Tracer[K]) *Cache[K, V] {
+	return &Cache[K, V]{cache: c, tracer: tracer}
+}
+
+// Unwrap 返回被包装的缓存。
+func (c *Cache[K, V]) Unwrap() types.ICache[K, V] {
+	return c.cache
+}
+
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (v V, err error) {
+	ctx, span := c.tracer.Start(ctx, OpGet, key)
+	defer func() { span.End(err) }()
+	return c.cache.Get(ctx, key)
+}
+
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) (err error) {
+	ctx, span := c.tracer.Start(ctx, OpSet, key)
+	defer func() { span.End(err) }()
+	return c.cache.Set(ctx, key, value)
+}
+
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) (err error) {
+	ctx, span := c.tracer.Start(ctx, OpDelete, key)
+	defer func() { span.End(err) }()
+	return c.cache.Delete(ctx, key)
+}
+
+func (c *Cache[K, V]) Keys() []K {
+	var key K
+	_, span := c.tracer.Start(context.Background(), OpKeys, key)
+	defer span.End(nil)
+	return c.cache.Keys()
+}
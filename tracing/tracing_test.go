@@ -0,0 +1,146 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chenmingyong0423/go-generics-cache/cachetest"
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/chenmingyong0423/go-generics-cache/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type span struct {
+	op    string
+	key   string
+	ended bool
+	err   error
+}
+
+type spanKey struct{}
+
+// recorder 记录所有的 span，并将当前 span 放入 ctx。
+type recorder struct {
+	spans []*span
+}
+
+func (r *recorder) Start(ctx context.Context, op string, key string) (context.Context, Span) {
+	s := &span{op: op, key: key}
+	r.spans = append(r.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), SpanFunc(func(err error) {
+		s.ended, s.err = true, err
+	})
+}
+
+// ctxCache 检查 ctx 中是否携带了 span。
+type ctxCache struct {
+	types.ICache[string, int]
+	t *testing.T
+}
+
+func (c ctxCache) Get(ctx context.Context, key string) (int, error) {
+	s, ok := ctx.Value(spanKey{}).(*span)
+	assert.True(c.t, ok)
+	assert.False(c.t, s.ended)
+	return c.ICache.Get(ctx, key)
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		op   func(c *Cache[string, int]) error
+
+		wantErr  error
+		wantSpan span
+	}{
+		{
+			name: "get hit",
+			op: func(c *Cache[string, int]) error {
+				v, err := c.Get(ctx, "a")
+				assert.Equal(t, 1, v)
+				return err
+			},
+			wantSpan: span{op: OpGet, key: "a", ended: true},
+		},
+		{
+			name: "get miss",
+			op: func(c *Cache[string, int]) error {
+				_, err := c.Get(ctx, "b")
+				return err
+			},
+			wantErr:  cacheError.ErrNoKey,
+			wantSpan: span{op: OpGet, key: "b", ended: true, err: cacheError.ErrNoKey},
+		},
+		{
+			name: "set",
+			op: func(c *Cache[string, int]) error {
+				return c.Set(ctx, "b", 2)
+			},
+			wantSpan: span{op: OpSet, key: "b", ended: true},
+		},
+		{
+			name: "delete miss",
+			op: func(c *Cache[string, int]) error {
+				return c.Delete(ctx, "b")
+			},
+			wantErr:  cacheError.ErrNoKey,
+			wantSpan: span{op: OpDelete, key: "b", ended: true, err: cacheError.ErrNoKey},
+		},
+		{
+			name: "keys",
+			op: func(c *Cache[string, int]) error {
+				assert.Equal(t, []string{"a"}, c.Keys())
+				return nil
+			},
+			wantSpan: span{op: OpKeys, ended: true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := simple.NewCache[string, int](0)
+			assert.NoError(t, backend.Set(ctx, "a", 1))
+			r := &recorder{}
+			c := New[string, int](ctxCache{ICache: backend, t: t}, r)
+			assert.ErrorIs(t, tc.op(c), tc.wantErr)
+			assert.Equal(t, []*span{&tc.wantSpan}, r.spans)
+		})
+	}
+}
+
+func TestCache_Nested(t *testing.T) {
+	// 外层装饰器的 span 通过 ctx 传给内层装饰器
+	var parents []string
+	tracer := TracerFunc[string](func(ctx context.Context, op string, key string) (context.Context, Span) {
+		parent, _ := ctx.Value(spanKey{}).(string)
+		parents = append(parents, parent)
+		return context.WithValue(ctx, spanKey{}, op+" "+key), SpanFunc(func(error) {})
+	})
+	c := New[string, int](New[string, int](simple.NewCache[string, int](0), tracer), tracer)
+	assert.NoError(t, c.Set(context.Background(), "a", 1))
+	assert.Equal(t, []string{"", "set a"}, parents)
+}
+
+func TestCache_Conformance(t *testing.T) {
+	tracer := TracerFunc[string](func(ctx context.Context, op string, key string) (context.Context, Span) {
+		return ctx, SpanFunc(func(error) {})
+	})
+	cachetest.RunICacheConformance(t, func(t *testing.T) types.ICache[string, string] {
+		return New[string, string](simple.NewCache[string, string](0), tracer)
+	})
+}
@@ -0,0 +1,268 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "context"
+
+// ICache defines an interface for a key-value cache.
+type ICache[K comparable, V any] interface {
+
+	// Set stores the given key-value pair in the cache.
+	Set(ctx context.Context, key K, value V) error
+
+	// Get retrieves the value associated with the given key from the cache.
+	Get(ctx context.Context, key K) (V, error)
+
+	// Delete removes the value associated with the given key from the cache.
+	Delete(ctx context.Context, key K) error
+
+	Keys() []K
+}
+
+// Reader is the read side of ICache, implemented by read-only views such as
+// the snapshots returned by cache.Cache.Freeze.
+type Reader[K comparable, V any] interface {
+
+	// Get retrieves the value associated with the given key from the cache.
+	Get(ctx context.Context, key K) (V, error)
+
+	Keys() []K
+}
+
+// EvictionNotifier is implemented by caches that evict entries on their own,
+// e.g. when the capacity is exceeded.
+type EvictionNotifier[K comparable, V any] interface {
+
+	// SetOnEvicted replaces the callback invoked for every evicted entry.
+	SetOnEvicted(fn func(key K, value V))
+}
+
+// Evicter is implemented by caches with an eviction policy that can be asked
+// to evict entries before reaching their own capacity, e.g. to stay within a
+// size budget.
+type Evicter[K comparable] interface {
+
+	// EvictOne removes the entry the policy would evict next, notifying the
+	// callback set by SetOnEvicted, and returns its key. It returns false if
+	// the cache is empty.
+	EvictOne() (K, bool)
+}
+
+// Resizer is implemented by caches whose capacity can be changed at runtime.
+type Resizer interface {
+
+	// SetCapacity changes the maximum number of entries to n, which must be
+	// positive. If the cache holds more than n entries, the surplus is evicted
+	// immediately following the eviction policy, notifying the callback set by
+	// SetOnEvicted.
+	SetCapacity(n int)
+}
+
+// FullRejecter is implemented by caches that can refuse new entries when they
+// are full instead of evicting existing ones.
+type FullRejecter interface {
+
+	// SetRejectWhenFull controls whether Set of a new key on a full cache
+	// returns cacheError.ErrCacheFull instead of evicting an entry. Overwriting
+	// an existing key always succeeds.
+	SetRejectWhenFull(reject bool)
+}
+
+// RandSetter is implemented by caches that make random choices, such as random
+// eviction or sampling, so that the owning cache can drive them from the same
+// random source as its own decisions.
+type RandSetter interface {
+
+	// SetRandIntn replaces the function used to pick a random integer in
+	// [0, n). fn must be safe for concurrent use.
+	SetRandIntn(fn func(n int) int)
+}
+
+// Pinner is implemented by caches that can exempt individual entries from
+// capacity eviction. A pinned entry is only removed by an explicit Delete; when
+// the cache is full and every entry is pinned, Set of a new key returns
+// cacheError.ErrCacheFull.
+type Pinner[K comparable] interface {
+
+	// Pin exempts the entry from eviction until it is unpinned or deleted.
+	// It returns false if the key is not in the cache.
+	Pin(key K) bool
+
+	// Unpin makes the entry evictable again. It returns false if the key is
+	// not in the cache.
+	Unpin(key K) bool
+}
+
+// ReadOnlyGetter is implemented by caches whose Get does not modify any internal
+// state (e.g. recency order), so Get may run concurrently under a shared read lock.
+type ReadOnlyGetter interface {
+
+	// ReadOnlyGet reports whether Get is free of side effects.
+	ReadOnlyGet() bool
+}
+
+// Sampler is implemented by caches that can pick random keys without scanning
+// every entry.
+type Sampler[K comparable] interface {
+
+	// SampleKeys returns up to n distinct keys chosen uniformly at random.
+	SampleKeys(n int) []K
+}
+
+// Lener is implemented by caches that can report the number of entries
+// without materializing all keys.
+type Lener interface {
+
+	// Len returns the number of entries, i.e. len(Keys()).
+	Len() int
+}
+
+// Ranger is implemented by caches that can iterate over their keys without
+// materializing them all.
+type Ranger[K comparable] interface {
+
+	// RangeKeys calls fn for each key in the same order as Keys until fn
+	// returns false. fn must not modify the cache.
+	RangeKeys(fn func(key K) bool)
+}
+
+// Peeker is implemented by caches whose Get has side effects (e.g. moving the
+// entry to the front in LRU order) and that can also read an entry without them.
+type Peeker[K comparable, V any] interface {
+
+	// Peek returns the value associated with the given key like Get, but
+	// without changing any internal state.
+	Peek(ctx context.Context, key K) (V, error)
+}
+
+// Container is implemented by caches that can report whether a key is present
+// without copying its value or changing any internal state.
+type Container[K comparable] interface {
+
+	// Contains reports whether the key is in the cache.
+	Contains(key K) bool
+}
+
+// Clearer is implemented by caches that can remove all entries at once.
+type Clearer interface {
+
+	// Clear removes all entries without notifying the callback set by
+	// SetOnEvicted.
+	Clear()
+}
+
+// ExtendedICache is an ICache that also reports its size, iterates over its
+// keys, checks for keys and removes all entries. Every backend shipped with
+// this module implements it, making it a convenient base for decorators;
+// consumers accepting a plain ICache should still discover the optional
+// interfaces by type assertion.
+type ExtendedICache[K comparable, V any] interface {
+	ICache[K, V]
+	Lener
+	Ranger[K]
+	Container[K]
+	Clearer
+}
+
+// Order describes the order in which keys are returned.
+type Order int
+
+const (
+	// OrderAny makes no guarantee about the order, every cache supports it.
+	OrderAny Order = iota
+
+	// OrderInsertion returns keys from the least to the most recently written.
+	OrderInsertion
+
+	// OrderRecency returns keys from the least to the most recently used,
+	// where both reads and writes count as a use.
+	OrderRecency
+)
+
+// KeyOrderer is implemented by caches that can return their keys in a
+// well-defined order.
+type KeyOrderer[K comparable] interface {
+
+	// KeysIn returns all keys in the given order, or an error wrapping
+	// cacheError.ErrUnsupportedOrder if the cache does not track that order.
+	KeysIn(order Order) ([]K, error)
+}
+
+// BackendStats is a point-in-time view of a cache's internal state.
+type BackendStats struct {
+	// Len is the number of entries, the same as Lener.Len.
+	Len int
+	// Evictions is the number of entries removed by the eviction policy,
+	// e.g. for capacity or age, since the cache was created. Entries removed
+	// by Delete or Clear are not counted.
+	Evictions uint64
+}
+
+// BackendConfig describes how a cache was configured.
+type BackendConfig struct {
+	// Policy names the eviction policy, e.g. "lru" or "fifo".
+	Policy string
+	// Capacity is the maximum number of entries, or 0 if unbounded.
+	Capacity int
+	// RejectWhenFull reports whether writing a new key to a full cache fails
+	// instead of evicting an entry.
+	RejectWhenFull bool
+	// Extra holds policy-specific settings, such as the size of the protected
+	// segment of an SLRU cache. It may be nil.
+	Extra map[string]string
+}
+
+// Inspector is implemented by caches that expose their runtime state, so
+// debugging tools can be written once against this interface instead of each
+// concrete type. Every backend shipped with this module implements it. Like
+// the other methods, Inspector methods are not safe for concurrent use unless
+// the cache says otherwise; cache.Cache.Inspect calls them under its lock.
+type Inspector[K comparable, V any] interface {
+	Lener
+	// Stats returns a snapshot of the cache's internal counters.
+	Stats() BackendStats
+	// OldestEntry returns the entry the eviction policy considers oldest,
+	// i.e. the next candidate for eviction, without changing any internal
+	// state. It returns false if the cache is empty or does not order its
+	// entries.
+	OldestEntry() (key K, value V, ok bool)
+	// Config returns the settings the cache was created with, reflecting any
+	// changes made at runtime such as Resizer.SetCapacity.
+	Config() BackendConfig
+}
+
+// Integer is the set of integer types.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is the set of floating-point types.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is the set of numeric types whose values can be changed atomically
+// with cache.Incr and cache.Decr.
+type Number interface {
+	Integer | Float
+}
+
+// Ordered is the set of types that support the < operator, such as keys
+// accepted by cache.SortedKeys. It has the same type set as cmp.Ordered, so
+// values can be compared with cmp.Compare and sorted with slices.Sort.
+type Ordered interface {
+	Number | ~string
+}
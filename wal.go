@@ -0,0 +1,409 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+const DefaultCompactionInterval = 5 * time.Minute
+
+// WAL 目录中的文件：snapshot 为最近一次压缩的快照，log 为其后的操作，
+// log.old 为压缩过程中被替换下来、尚未写入快照的操作。
+const (
+	walSnapshotFile = "snapshot"
+	walLogFile      = "log"
+	walOldLogFile   = "log.old"
+)
+
+// WALOption 配置 WithWAL 的行为。
+type WALOption func(*walConfig)
+
+type walConfig struct {
+	dir                string
+	compactionInterval time.Duration
+	sync               bool
+	onError            func(err error)
+	key                []byte
+}
+
+// WithCompactionInterval 设置把 WAL 压缩为快照的间隔，默认为 DefaultCompactionInterval，
+// 为 0 时只在创建缓存和 Close 时压缩。
+func WithCompactionInterval(d time.Duration) WALOption {
+	return func(c *walConfig) {
+		c.compactionInterval = d
+	}
+}
+
+// WithWALSync 在每次写入 WAL 之后调用 fsync，进程崩溃和断电都不会丢失已经返回的写操作，代价是写操作变慢。
+// 默认只保证进程崩溃时不丢失。
+func WithWALSync() WALOption {
+	return func(c *walConfig) {
+		c.sync = true
+	}
+}
+
+// WithWALErrorHandler 设置写入或压缩 WAL 失败时的处理函数。写入 WAL 失败不会让写操作失败。
+func WithWALErrorHandler(fn func(err error)) WALOption {
+	return func(c *walConfig) {
+		c.onError = fn
+	}
+}
+
+// WithWALEncryption 使用 AES-GCM 加密日志和快照，key 的长度为 16、24 或 32 字节，由调用方负责保管。
+// 日志中的每条记录单独加密，因此崩溃时写了一半的记录仍然会被忽略；
+// 密钥错误或文件被篡改时 NewWithConfig 返回 cacheError.ErrDecrypt。
+func WithWALEncryption(key []byte) WALOption {
+	return func(c *walConfig) {
+		c.key = key
+	}
+}
+
+// WithWAL 为缓存启用预写日志：Set、Delete、Expire 等写操作在修改缓存的同时追加到 dir 中的日志，
+// 创建缓存时先读取最近一次的快照再重放日志，使缓存在重启之间可以作为数据源使用。
+// 日志会定期压缩为快照（格式与 SaveTo 相同），避免无限增长。
+//
+// 元素的过期时间以绝对时间记录，重放时已经过期的元素会被跳过；滑动过期在读取时推迟的过期时间不会被记录。
+// 被淘汰的元素记录为删除。日志末尾因崩溃而写了一半的记录会被忽略，其他读取错误由 NewWithConfig 返回。
+// K 和 V 需要能够被 gob 编码。
+func WithWAL[K comparable, V any](dir string, opts ...WALOption) Option[K, V] {
+	return func(o *options[K, V]) {
+		cfg := &walConfig{dir: dir, compactionInterval: DefaultCompactionInterval}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		o.wal = cfg
+	}
+}
+
+type walOp int
+
+const (
+	walSet walOp = iota
+	walDelete
+	walExpire
+)
+
+// walRecord 是日志中的一条操作。walExpire 只使用 Expiration、TTL 和 Deadline，walDelete 只使用 Key。
+type walRecord[K comparable, V any] struct {
+	Op         walOp
+	Key        K
+	Value      V
+	Expiration time.Time
+	TTL        time.Duration
+	Sliding    bool
+	Deadline   time.Time
+	Meta       map[string]string
+	Cost       int64
+}
+
+// walLog 是正在写入的日志文件。每个日志文件从头到尾由同一个 gob.Encoder 写入，因此类型信息只出现一次。
+type walLog struct {
+	cfg *walConfig
+	mu  sync.Mutex
+	f   *os.File
+	enc *gob.Encoder
+}
+
+func (w *walLog) path(name string) string {
+	return filepath.Join(w.cfg.dir, name)
+}
+
+// create 创建新的日志文件替换当前的日志文件，调用方需要持有 mu。
+func (w *walLog) create() error {
+	f, err := os.OpenFile(w.path(walLogFile), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if w.cfg.key == nil {
+		w.f, w.enc = f, gob.NewEncoder(f)
+		return nil
+	}
+	// 日志不写入结束帧，重放时把缺少结束帧当作正常的结尾
+	ew, err := NewEncryptWriter(f, w.cfg.key)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.enc = f, gob.NewEncoder(ew)
+	return nil
+}
+
+// append 追加一条记录，日志已经关闭时直接返回。
+func (w *walLog) append(record any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.enc == nil {
+		return nil
+	}
+	if err := w.enc.Encode(record); err != nil {
+		return err
+	}
+	if w.cfg.sync {
+		return w.f.Sync()
+	}
+	return nil
+}
+
+// rotate 把当前的日志重命名为 log.old 并开始写入新的日志，final 为 true 时不再创建新的日志。
+// 上一次压缩失败留下的 log.old 尚未写入快照，此时不切换，下一次快照同时包含两个日志中的操作。
+func (w *walLog) rotate(final bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := os.Stat(w.path(walOldLogFile)); err == nil {
+		if final && w.f != nil {
+			err = w.f.Close()
+			w.f, w.enc = nil, nil
+		}
+		return err
+	}
+	if w.f != nil {
+		err := w.f.Close()
+		w.f, w.enc = nil, nil
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(w.path(walLogFile), w.path(walOldLogFile)); err != nil {
+			return err
+		}
+	}
+	if final {
+		return nil
+	}
+	return w.create()
+}
+
+// openWAL 读取 WithWAL 目录中的快照并重放日志，然后立即保存新的快照，之后的操作写入新的日志。
+func (c *Cache[K, V]) openWAL() error {
+	cfg := c.opts.wal
+	if err := os.MkdirAll(cfg.dir, 0o700); err != nil {
+		return fmt.Errorf("cache: open wal: %w", err)
+	}
+	c.wal = &walLog{cfg: cfg}
+	if err := c.loadWALSnapshot(); err != nil {
+		return err
+	}
+	for _, name := range []string{walOldLogFile, walLogFile} {
+		if err := c.replayWAL(c.wal.path(name)); err != nil {
+			return err
+		}
+	}
+	// 快照写入之前崩溃时旧的日志仍然存在，重放它们的结果不变
+	if err := c.writeSnapshot(c.wal.path(walSnapshotFile), c.opts.wal.key); err != nil {
+		return fmt.Errorf("cache: open wal: %w", err)
+	}
+	for _, name := range []string{walOldLogFile, walLogFile} {
+		if err := os.Remove(c.wal.path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("cache: open wal: %w", err)
+		}
+	}
+	c.wal.mu.Lock()
+	defer c.wal.mu.Unlock()
+	if err := c.wal.create(); err != nil {
+		return fmt.Errorf("cache: open wal: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache[K, V]) loadWALSnapshot() error {
+	f, err := os.Open(c.wal.path(walSnapshotFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cache: open wal snapshot: %w", err)
+	}
+	defer f.Close()
+	if err := c.loadSnapshotFrom(f, c.opts.wal.key); err != nil {
+		return fmt.Errorf("cache: load wal snapshot: %w", err)
+	}
+	return nil
+}
+
+// replayWAL 按顺序重放 path 中的操作，文件不存在时直接返回 nil，文件末尾不完整的记录会被忽略。
+func (c *Cache[K, V]) replayWAL(path string) error {
+	ctx := context.Background()
+	now := time.Now()
+	return decodeWAL(path, c.opts.wal.key, func(record walRecord[K, V]) error {
+		return c.apply(ctx, record, now)
+	})
+}
+
+// decodeWAL 按顺序对 path 中的每条记录调用 fn，文件不存在时直接返回 nil，文件末尾不完整的记录会被忽略。
+// key 不为空时先解密。R 为 walRecord 或者省略了部分字段的结构体，gob 会忽略 R 中不存在的字段。
+func decodeWAL[R any](path string, key []byte, fn func(record R) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cache: open wal: %w", err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if key != nil {
+		if r, err = NewDecryptReader(f, key); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("cache: replay %s: %w", filepath.Base(path), err)
+		}
+	}
+	dec := gob.NewDecoder(r)
+	for i := 0; ; i++ {
+		var record R
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("cache: replay %s entry %d: %w", filepath.Base(path), i, err)
+		}
+		if err := fn(record); err != nil {
+			return fmt.Errorf("cache: replay %s entry %d: %w", filepath.Base(path), i, err)
+		}
+	}
+}
+
+// apply 将一条日志记录应用到缓存，记录的过期时间早于 now 时删除该键。
+func (c *Cache[K, V]) apply(ctx context.Context, r walRecord[K, V], now time.Time) error {
+	c.mutex.Lock()
+	defer c.unlock()
+	expired := !r.Expiration.IsZero() && !r.Expiration.After(now)
+	switch {
+	case r.Op == walSet && !expired:
+		item := &Item[V]{
+			value: r.Value, expiration: r.Expiration, ttl: r.TTL, sliding: r.Sliding, deadline: r.Deadline,
+			meta: r.Meta, created: now, cost: r.Cost,
+		}
+		if err := c.cache.Set(ctx, r.Key, item); err != nil {
+			return err
+		}
+		c.track(r.Key, item)
+		return nil
+	case r.Op == walExpire && !expired:
+		item, err := c.cache.Get(ctx, r.Key)
+		if errors.Is(err, cacheError.ErrNoKey) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		item.expiration, item.ttl, item.deadline = r.Expiration, r.TTL, r.Deadline
+		c.track(r.Key, item)
+		return nil
+	case r.Op == walSet, r.Op == walExpire, r.Op == walDelete:
+		if err := c.cache.Delete(ctx, r.Key); err != nil && !errors.Is(err, cacheError.ErrNoKey) {
+			return err
+		}
+		c.untrack(r.Key)
+		return nil
+	default:
+		return fmt.Errorf("unknown wal operation %d", r.Op)
+	}
+}
+
+// compactWAL 切换到新的日志，把缓存保存为快照后丢弃切换下来的日志。
+// final 为 true 时不再创建新的日志（用于 Close）。同一时刻只有一次压缩。
+//
+// 切换日志和保存快照之间的写操作同时出现在快照和新的日志中，由于每条记录都包含键的完整状态，重放时结果不变。
+func (c *Cache[K, V]) compactWAL(final bool) error {
+	c.snapshotMu.Lock()
+	defer c.snapshotMu.Unlock()
+	if c.closed.Load() && !final {
+		return nil
+	}
+	if err := c.wal.rotate(final); err != nil {
+		return fmt.Errorf("cache: compact wal: %w", err)
+	}
+	if err := c.writeSnapshot(c.wal.path(walSnapshotFile), c.opts.wal.key); err != nil {
+		return fmt.Errorf("cache: compact wal: %w", err)
+	}
+	if err := os.Remove(c.wal.path(walOldLogFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cache: compact wal: %w", err)
+	}
+	return nil
+}
+
+// runWAL 启动定期压缩日志的协程，ctx 结束时执行最后一次压缩后退出。
+func (c *Cache[K, V]) runWAL(ctx context.Context) {
+	go func() {
+		var tick <-chan time.Time
+		if c.opts.wal.compactionInterval > 0 {
+			ticker := time.NewTicker(c.opts.wal.compactionInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-tick:
+				c.walError(c.compactWAL(false))
+			case <-ctx.Done():
+				c.walError(c.compactWAL(false))
+				return
+			}
+		}
+	}()
+}
+
+func (c *Cache[K, V]) walError(err error) {
+	if err != nil && c.opts.wal.onError != nil {
+		c.safeCall(func() { c.opts.wal.onError(err) })
+	}
+}
+
+// logSet 记录 key 被写入 item，调用方需要持有写锁。
+func (c *Cache[K, V]) logSet(key K, item *Item[V]) {
+	if c.wal == nil {
+		return
+	}
+	c.logWAL(walRecord[K, V]{
+		Op: walSet, Key: key, Value: item.value,
+		Expiration: item.expiration, TTL: item.ttl, Sliding: item.sliding, Deadline: item.deadline, Meta: item.meta, Cost: item.cost,
+	})
+}
+
+// logDelete 记录 key 被删除或淘汰，调用方需要持有写锁。
+func (c *Cache[K, V]) logDelete(key K) {
+	if c.wal == nil {
+		return
+	}
+	c.logWAL(walRecord[K, V]{Op: walDelete, Key: key})
+}
+
+// logExpire 记录 key 的过期时间被修改为 item 当前的过期时间，调用方需要持有写锁。
+func (c *Cache[K, V]) logExpire(key K, item *Item[V]) {
+	if c.wal == nil {
+		return
+	}
+	c.logWAL(walRecord[K, V]{Op: walExpire, Key: key, Expiration: item.expiration, TTL: item.ttl, Deadline: item.deadline})
+}
+
+func (c *Cache[K, V]) logWAL(record walRecord[K, V]) {
+	if err := c.wal.append(record); err != nil && c.opts.wal.onError != nil {
+		err = fmt.Errorf("cache: append wal: %w", err)
+		c.enqueue(func() { c.opts.wal.onError(err) })
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chenmingyong0423/go-generics-cache/simple"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithWAL_replay(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		// ops 在第一个缓存上执行，之后不调用 Close 直接用同一个目录创建新的缓存，模拟进程崩溃
+		ops   func(t *testing.T, c *Cache[string, int])
+		crash func(t *testing.T, dir string)
+
+		want    map[string]int
+		wantTTL map[string]time.Duration
+	}{
+		{
+			name: "set and delete",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 2))
+				require.NoError(t, c.Set(ctx, "a", 10))
+				require.NoError(t, c.Delete(ctx, "b"))
+				_, err := c.MDelete(ctx, "missing")
+				require.NoError(t, err)
+			},
+			want:    map[string]int{"a": 10},
+			wantTTL: map[string]time.Duration{"a": NoExpiration},
+		},
+		{
+			name: "expire and persist",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 2, WithExpiration(time.Hour)))
+				require.NoError(t, c.Expire(ctx, "a", 2*time.Hour))
+				_, err := c.Persist(ctx, "b")
+				require.NoError(t, err)
+			},
+			want:    map[string]int{"a": 1, "b": 2},
+			wantTTL: map[string]time.Duration{"a": 2 * time.Hour, "b": NoExpiration},
+		},
+		{
+			name: "skip expired",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "a", 1, WithExpiration(time.Millisecond)))
+				require.NoError(t, c.Set(ctx, "b", 2))
+				require.NoError(t, c.Expire(ctx, "b", time.Millisecond))
+				time.Sleep(5 * time.Millisecond)
+			},
+			want: map[string]int{},
+		},
+		{
+			name: "torn tail",
+			ops: func(t *testing.T, c *Cache[string, int]) {
+				require.NoError(t, c.Set(ctx, "a", 1))
+				require.NoError(t, c.Set(ctx, "b", 2))
+			},
+			crash: func(t *testing.T, dir string) {
+				// 最后一条记录只写入了一部分
+				path := filepath.Join(dir, walLogFile)
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				require.NoError(t, os.Truncate(path, info.Size()-2))
+			},
+			want:    map[string]int{"a": 1},
+			wantTTL: map[string]time.Duration{"a": NoExpiration},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c := NewSimpleCache[string, int](ctx, 10, time.Hour, WithWAL[string, int](dir, WithCompactionInterval(0)))
+			tc.ops(t, c)
+			if tc.crash != nil {
+				tc.crash(t, dir)
+			}
+
+			restored := NewSimpleCache[string, int](ctx, 10, time.Hour, WithWAL[string, int](dir, WithCompactionInterval(0)))
+			defer restored.Close()
+			assert.Equal(t, tc.want, restored.Snapshot(ctx))
+			for key, want := range tc.wantTTL {
+				ttl, err := restored.TTL(ctx, key)
+				require.NoError(t, err)
+				assert.InDelta(t, want, ttl, float64(time.Minute), key)
+			}
+			// 重放之后的操作写入新的日志
+			require.NoError(t, restored.Set(ctx, "new", 1))
+			again := NewSimpleCache[string, int](ctx, 10, time.Hour, WithWAL[string, int](dir, WithCompactionInterval(0)))
+			defer again.Close()
+			want := map[string]int{"new": 1}
+			for k, v := range tc.want {
+				want[k] = v
+			}
+			assert.Equal(t, want, again.Snapshot(ctx))
+		})
+	}
+}
+
+func TestWithWAL_eviction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := NewLruCache[string, int](ctx, 2, time.Hour, WithWAL[string, int](dir, WithCompactionInterval(0)))
+	require.NoError(t, c.Set(ctx, "a", 1))
+	require.NoError(t, c.Set(ctx, "b", 2))
+	require.NoError(t, c.Set(ctx, "c", 3))
+
+	// 被淘汰的元素记录为删除，容量更大的缓存也不会恢复它
+	restored := NewLruCache[string, int](ctx, 10, time.Hour, WithWAL[string, int](dir, WithCompactionInterval(0)))
+	defer restored.Close()
+	assert.Equal(t, map[string]int{"b": 2, "c": 3}, restored.Snapshot(ctx))
+}
+
+func TestWithWAL_compaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := NewSimpleCache[string, int](ctx, 10, time.Hour, WithWAL[string, int](dir, WithCompactionInterval(10*time.Millisecond)))
+	require.NoError(t, c.Set(ctx, "a", 1))
+
+	// 压缩之后快照中包含压缩前的操作
+	assert.Eventually(t, func() bool {
+		other := NewSimpleCache[string, int](ctx, 10, time.Hour)
+		f, err := os.Open(filepath.Join(dir, walSnapshotFile))
+		if err != nil {
+			return false
+		}
+		defer f.Close()
+		return other.LoadFrom(f) == nil && other.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// Close 时保存最后一次快照并删除日志
+	require.NoError(t, c.Set(ctx, "b", 2))
+	require.NoError(t, c.Close())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, walSnapshotFile, entries[0].Name())
+
+	restored := NewSimpleCache[string, int](ctx, 10, time.Hour, WithWAL[string, int](dir))
+	defer restored.Close()
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, restored.Snapshot(ctx))
+}
+
+func TestWithWAL_invalid(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt")
+	require.NoError(t, os.MkdirAll(corrupt, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(corrupt, walSnapshotFile), []byte("not a snapshot"), 0o600))
+	negative := filepath.Join(dir, "negative")
+	require.NoError(t, os.MkdirAll(negative, 0o700))
+	writeSnapshotHeader(t, filepath.Join(negative, walSnapshotFile), snapshotHeader{Version: snapshotVersion, Count: -1})
+
+	testCases := []struct {
+		name    string
+		opt     Option[string, int]
+		wantErr string
+	}{
+		{
+			name:    "empty dir",
+			opt:     WithWAL[string, int](""),
+			wantErr: "WithWAL requires a directory",
+		},
+		{
+			name:    "negative compaction interval",
+			opt:     WithWAL[string, int](dir, WithCompactionInterval(-time.Second)),
+			wantErr: "WithCompactionInterval must not be negative",
+		},
+		{
+			name:    "invalid encryption key",
+			opt:     WithWAL[string, int](dir, WithWALEncryption(make([]byte, 20))),
+			wantErr: "WithWALEncryption key must be 16, 24 or 32 bytes, got 20",
+		},
+		{
+			name:    "corrupt snapshot",
+			opt:     WithWAL[string, int](corrupt),
+			wantErr: "cache: load wal snapshot",
+		},
+		{
+			name:    "negative count",
+			opt:     WithWAL[string, int](negative),
+			wantErr: "cache: invalid snapshot entry count -1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewWithConfig(ctx, Config[string, int]{
+				Backend:  simple.NewCache[string, *Item[int]](10),
+				Interval: time.Hour,
+				Options:  []Option[string, int]{tc.opt},
+			})
+			assert.Nil(t, c)
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
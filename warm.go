@@ -0,0 +1,165 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+const DefaultWarmBatchSize = 100
+
+// WarmOption 配置 Warm 的行为。
+type WarmOption func(*warmConfig)
+
+type warmConfig struct {
+	rate      int
+	batchSize int
+	overwrite bool
+	itemOpts  []ItemOption
+}
+
+// WithWarmRate 限制 Warm 每秒写入的元素数量，避免预热占满 CPU 或拖慢数据源，默认为 0 表示不限制。
+func WithWarmRate(n int) WarmOption {
+	return func(c *warmConfig) {
+		c.rate = n
+	}
+}
+
+// WithWarmBatchSize 设置 Warm 每次加锁写入的元素数量，默认为 DefaultWarmBatchSize。
+// 较小的批次让预热期间的其他读写等待更短。
+func WithWarmBatchSize(n int) WarmOption {
+	return func(c *warmConfig) {
+		c.batchSize = n
+	}
+}
+
+// WithWarmOverwrite 让 Warm 覆盖缓存中已经存在的键，默认跳过这些键，以免用数据源中的旧值覆盖预热期间写入的新值。
+func WithWarmOverwrite() WarmOption {
+	return func(c *warmConfig) {
+		c.overwrite = true
+	}
+}
+
+// WithWarmItemOptions 设置 Warm 写入的每个元素使用的选项，例如 WithExpiration。
+func WithWarmItemOptions(opts ...ItemOption) WarmOption {
+	return func(c *warmConfig) {
+		c.itemOpts = opts
+	}
+}
+
+// Warm 将 src 产生的键值对分批写入缓存，返回写入的元素数量，适用于在接收流量之前从数据库等数据源预热缓存。
+// src 与 Go 1.23 的 iter.Seq2[K, V] 具有相同的底层类型，可以直接传入 iter.Seq2 的值。
+//
+// 与 GetOrLoad 加载的值相同，预热的值只写入缓存，不会同步到 WithStore 设置的 store。
+// ctx 结束时停止读取 src 并返回 ctx 的错误，此前写入的元素保留在缓存中。
+func (c *Cache[K, V]) Warm(ctx context.Context, src func(yield func(K, V) bool), opts ...WarmOption) (int, error) {
+	cfg := warmConfig{batchSize: DefaultWarmBatchSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.rate < 0 {
+		return 0, fmt.Errorf("cache: warm rate must not be negative, got %d", cfg.rate)
+	}
+	if cfg.batchSize <= 0 {
+		return 0, fmt.Errorf("cache: warm batch size must be positive, got %d", cfg.batchSize)
+	}
+	// 限速时每批的元素数量不超过每秒的数量，保证写入是均匀的
+	if cfg.rate > 0 {
+		cfg.batchSize = min(cfg.batchSize, cfg.rate)
+	}
+
+	var (
+		start   = time.Now()
+		batch   = make([]Entry[K, V], 0, cfg.batchSize)
+		written int
+		read    int
+		err     error
+	)
+	flush := func() bool {
+		var n int
+		n, err = c.warmBatch(ctx, batch, cfg)
+		written += n
+		read += len(batch)
+		batch = batch[:0]
+		if err != nil {
+			return false
+		}
+		if cfg.rate > 0 {
+			err = sleepCtx(ctx, time.Until(start.Add(time.Duration(read)*time.Second/time.Duration(cfg.rate))))
+		}
+		return err == nil
+	}
+	src(func(key K, value V) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		batch = append(batch, Entry[K, V]{Key: key, Value: value})
+		return len(batch) < cfg.batchSize || flush()
+	})
+	if err == nil && len(batch) > 0 {
+		flush()
+	}
+	return written, err
+}
+
+// warmBatch 在一次加锁内写入 entries，返回写入的元素数量。
+func (c *Cache[K, V]) warmBatch(ctx context.Context, entries []Entry[K, V], cfg warmConfig) (int, error) {
+	c.mutex.Lock()
+	defer c.unlock()
+	if err := c.checkClosed(); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		if !cfg.overwrite {
+			item, err := c.cache.Get(ctx, e.Key)
+			if err == nil && !item.Expired() {
+				continue
+			}
+			if err != nil && !errors.Is(err, cacheError.ErrNoKey) {
+				return n, err
+			}
+		}
+		item := c.newItem(e.Value, cfg.itemOpts...)
+		if err := c.cache.Set(ctx, e.Key, item); err != nil {
+			return n, err
+		}
+		c.track(e.Key, item)
+		c.stats.sets.Add(1)
+		c.invalidate(e.Key)
+		n++
+	}
+	return n, nil
+}
+
+// sleepCtx 等待 d 或者 ctx 结束，ctx 结束时返回 ctx 的错误。
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seq 按顺序产生 0 到 n-1 作为键，值为键的 10 倍。
+func seq(n int) func(yield func(int, int) bool) {
+	return func(yield func(int, int) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i, i*10) {
+				return
+			}
+		}
+	}
+}
+
+func TestCache_Warm(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing map[int]int
+		src      func(yield func(int, int) bool)
+		opts     []WarmOption
+
+		wantN   int
+		want    map[int]int
+		wantErr error
+	}{
+		{
+			name:  "empty source",
+			src:   seq(0),
+			want:  map[int]int{},
+			wantN: 0,
+		},
+		{
+			name:  "multiple batches",
+			src:   seq(5),
+			opts:  []WarmOption{WithWarmBatchSize(2)},
+			want:  map[int]int{0: 0, 1: 10, 2: 20, 3: 30, 4: 40},
+			wantN: 5,
+		},
+		{
+			name:     "skip existing",
+			existing: map[int]int{1: 1},
+			src:      seq(3),
+			want:     map[int]int{0: 0, 1: 1, 2: 20},
+			wantN:    2,
+		},
+		{
+			name:     "overwrite",
+			existing: map[int]int{1: 1},
+			src:      seq(3),
+			opts:     []WarmOption{WithWarmOverwrite()},
+			want:     map[int]int{0: 0, 1: 10, 2: 20},
+			wantN:    3,
+		},
+		{
+			name:    "negative rate",
+			src:     seq(3),
+			opts:    []WarmOption{WithWarmRate(-1)},
+			want:    map[int]int{},
+			wantErr: errors.New("cache: warm rate must not be negative, got -1"),
+		},
+		{
+			name:    "invalid batch size",
+			src:     seq(3),
+			opts:    []WarmOption{WithWarmBatchSize(0)},
+			want:    map[int]int{},
+			wantErr: errors.New("cache: warm batch size must be positive, got 0"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewSimpleCache[int, int](ctx, 10, time.Hour)
+			require.NoError(t, c.MSet(ctx, tc.existing))
+			n, err := c.Warm(ctx, tc.src, tc.opts...)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantN, n)
+			assert.Equal(t, tc.want, c.Snapshot(ctx))
+		})
+	}
+}
+
+func TestCache_Warm_itemOptions(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	c := NewSimpleCache[int, int](ctx, 10, time.Hour, WithStore[int, int](store, WriteThrough))
+	n, err := c.Warm(ctx, seq(2), WithWarmItemOptions(WithExpiration(time.Minute)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	ttl, err := c.TTL(ctx, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	// 预热的值不会写入 store
+	data, _ := store.snapshot()
+	assert.Empty(t, data)
+}
+
+func TestCache_Warm_rate(t *testing.T) {
+	ctx := context.Background()
+	c := NewSimpleCache[int, int](ctx, 100, time.Hour)
+	start := time.Now()
+	n, err := c.Warm(ctx, seq(30), WithWarmRate(200))
+	require.NoError(t, err)
+	assert.Equal(t, 30, n)
+	// 200 个每秒写入 30 个元素至少需要 150ms
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+}
+
+func TestCache_Warm_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewSimpleCache[int, int](context.Background(), 100, time.Hour)
+	src := func(yield func(int, int) bool) {
+		for i := 0; ; i++ {
+			if i == 3 {
+				cancel()
+			}
+			if !yield(i, i) {
+				return
+			}
+		}
+	}
+	n, err := c.Warm(ctx, src, WithWarmBatchSize(1))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 3, c.Len())
+
+	require.NoError(t, c.Close())
+	_, err = c.Warm(context.Background(), seq(1))
+	assert.Equal(t, cacheError.ErrClosed, err)
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+)
+
+// WithEarlyExpiration 启用 XFetch 风格的概率提前过期：临近过期的元素在 Get 时会以一定概率被视为已过期，
+// 从而由单个调用者提前重新计算，避免热点键真正过期时大量请求同时回源。
+//
+// 对于重新计算耗时为 delta 的元素，每次 Get 在 now - delta*beta*ln(rand()) >= expiration 时提前过期。
+// beta 越大越倾向于提前计算，1 是常用的默认值。delta 在通过加载器加载时自动记录，
+// 手动写入的元素可以通过 WithRecomputeTime 提供；没有 delta 或没有过期时间的元素不会提前过期。
+//
+// 提前过期的元素仍然有效：配置了加载器（WithLoader 或 GetOrLoad）时由当前调用者重新加载，
+// 其他并发的调用者以及加载失败时都会得到当前缓存的值；没有加载器时 Get 返回 cacheError.ErrNoKey，由调用方重新计算并写入。
+func WithEarlyExpiration[K comparable, V any](beta float64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.beta = beta
+	}
+}
+
+// WithRandSource 设置缓存内部使用的随机数源（用于概率提前过期、Sample 和清理协程的随机启动延迟），便于在测试中复现结果。
+// 后端实现了 types.RandSetter 时（例如 random 后端的随机淘汰）也使用该随机数源，并覆盖 random.WithRandSource。
+// 未设置时使用 math/rand 的全局随机数源。
+func WithRandSource[K comparable, V any](src rand.Source) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.rand = &lockedRand{r: rand.New(src)}
+	}
+}
+
+// WithRecomputeTime 记录重新计算该值的耗时，供 WithEarlyExpiration 和 WithRecomputeAwareEviction 使用。
+func WithRecomputeTime(d time.Duration) ItemOption {
+	return func(o *itemOptions) {
+		o.delta = d
+	}
+}
+
+// lockedRand 使 rand.Rand 可以被并发使用。
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
+
+func (c *Cache[K, V]) randIntn(n int) int {
+	if c.opts.rand != nil {
+		return c.opts.rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func (c *Cache[K, V]) randInt63n(n int64) int64 {
+	if c.opts.rand != nil {
+		return c.opts.rand.Int63n(n)
+	}
+	return rand.Int63n(n)
+}
+
+func (c *Cache[K, V]) randFloat64() float64 {
+	if c.opts.rand != nil {
+		return c.opts.rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// expiresEarly 判断元素是否被提前判定为过期，调用方必须持有读锁。
+func (c *Cache[K, V]) expiresEarly(item *Item[V]) bool {
+	if item.delta <= 0 || item.expiration.IsZero() {
+		return false
+	}
+	// 1 - Float64() 取值于 (0, 1]，避免 ln(0)
+	gap := -float64(item.delta) * c.opts.beta * math.Log(1-c.randFloat64())
+	return time.Now().Add(time.Duration(gap)).After(item.expiration)
+}
+
+// expireEarly 处理被提前判定为过期的 key，stale 为当前缓存的值。
+func (c *Cache[K, V]) expireEarly(ctx context.Context, key K, stale V, loader func(ctx context.Context, key K) (V, error), opts ...ItemOption) (V, error) {
+	if loader == nil {
+		var zero V
+		return zero, cacheError.ErrNoKey
+	}
+	cl, ok := c.reload(ctx, key, loader, opts...)
+	if !ok || cl.err != nil {
+		return stale, nil
+	}
+	return c.clone(cl.val), nil
+}
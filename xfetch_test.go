@@ -0,0 +1,137 @@
+// Copyright 2024 chenmingyong0423
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cacheError "github.com/chenmingyong0423/go-generics-cache/error"
+	"github.com/chenmingyong0423/go-generics-cache/random"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// constSource 总是返回同一个值的随机数源，Float64 的结果约为 v / 2^63。
+type constSource int64
+
+func (s constSource) Int63() int64 { return int64(s) }
+func (constSource) Seed(int64)     {}
+
+func TestCache_expiresEarly(t *testing.T) {
+	testCases := []struct {
+		name string
+		item *Item[int]
+		src  rand.Source
+
+		want bool
+	}{
+		{
+			name: "no expiration",
+			item: &Item[int]{delta: time.Second},
+			src:  rand.NewSource(1),
+		},
+		{
+			name: "no recompute time",
+			item: &Item[int]{expiration: time.Now().Add(time.Millisecond)},
+			src:  rand.NewSource(1),
+		},
+		{
+			name: "far from expiration",
+			item: &Item[int]{expiration: time.Now().Add(time.Hour), delta: time.Millisecond},
+			// rand() 接近 0 时 -ln(1-rand()) 接近 0
+			src: constSource(1),
+		},
+		{
+			name: "close to expiration",
+			item: &Item[int]{expiration: time.Now().Add(time.Millisecond), delta: time.Second},
+			// rand() 接近 1 时 -ln(1-rand()) 很大
+			src:  constSource(1<<63 - 1<<20),
+			want: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewSimpleCache[int, int](context.Background(), 0, 0,
+				WithEarlyExpiration[int, int](1), WithRandSource[int, int](tc.src))
+			assert.Equal(t, tc.want, c.expiresEarly(tc.item))
+		})
+	}
+}
+
+func TestWithEarlyExpiration(t *testing.T) {
+	ctx := context.Background()
+	early := WithRandSource[string, int](constSource(1<<63 - 1<<20))
+
+	t.Run("without loader", func(t *testing.T) {
+		c := NewSimpleCache[string, int](ctx, 0, 0, WithEarlyExpiration[string, int](1), early)
+		require.NoError(t, c.Set(ctx, "k", 1, WithExpiration(time.Minute), WithRecomputeTime(10*time.Second)))
+		_, err := c.Get(ctx, "k")
+		assert.Equal(t, cacheError.ErrNoKey, err)
+		// 没有记录重新计算耗时的元素不会提前过期
+		require.NoError(t, c.Set(ctx, "k", 1, WithExpiration(time.Minute)))
+		v, err := c.Get(ctx, "k")
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	})
+
+	t.Run("with loader", func(t *testing.T) {
+		var calls atomic.Int32
+		c := NewSimpleCache[string, int](ctx, 0, 0, WithEarlyExpiration[string, int](1), early,
+			WithLoader[string, int](LoaderFunc[string, int](func(ctx context.Context, key string) (int, error) {
+				time.Sleep(2 * time.Millisecond)
+				return int(calls.Add(1)), nil
+			}), 20*time.Millisecond))
+		v, err := c.Get(ctx, "k")
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+		// 加载耗时被记录，之后的 Get 会提前重新加载
+		v, err = c.Get(ctx, "k")
+		require.NoError(t, err)
+		assert.Equal(t, 2, v)
+	})
+
+	t.Run("GetOrLoad keeps stale value on error", func(t *testing.T) {
+		c := NewSimpleCache[string, int](ctx, 0, 0, WithEarlyExpiration[string, int](1), early)
+		require.NoError(t, c.Set(ctx, "k", 1, WithExpiration(time.Minute), WithRecomputeTime(10*time.Second)))
+		v, err := c.GetOrLoad(ctx, "k", func(ctx context.Context, key string) (int, error) {
+			return 0, assert.AnError
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	})
+}
+
+func TestWithRandSource_randomBackend(t *testing.T) {
+	ctx := context.Background()
+	run := func(seed int64) []int {
+		var evicted []int
+		// 后端没有设置随机源，淘汰由缓存的随机源决定
+		c := New[int, int](ctx, random.NewCache[int, *Item[int]](3), 0,
+			WithRandSource[int, int](rand.NewSource(seed)),
+			WithOnEvicted(func(key int, _ int) { evicted = append(evicted, key) }))
+		for i := 0; i < 20; i++ {
+			require.NoError(t, c.Set(ctx, i, i))
+		}
+		return evicted
+	}
+	assert.Equal(t, run(1), run(1))
+	assert.Len(t, run(1), 17)
+	assert.NotEqual(t, run(1), run(2))
+}